@@ -13,8 +13,8 @@ import (
 
 type DB interface {
 	WithTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
-	WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
-	WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
+	WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error
+	WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error
 	GetDialect() migrator.Dialect
 	GetDBType() core.DbType
 	GetSqlxSession() *session.SessionDB
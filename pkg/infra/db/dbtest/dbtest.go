@@ -23,11 +23,11 @@ func (f *FakeDB) WithTransactionalDbSession(ctx context.Context, callback sqlsto
 	return f.ExpectedError
 }
 
-func (f *FakeDB) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+func (f *FakeDB) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error {
 	return f.ExpectedError
 }
 
-func (f *FakeDB) WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+func (f *FakeDB) WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error {
 	return f.ExpectedError
 }
 
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
@@ -11,6 +12,10 @@ const DefaultBatchSize = 1000
 
 type BulkOpSettings struct {
 	BatchSize int
+	// CheckpointInterval is the number of committed batches after which ProcessInBatchesWithSettings checkpoints the
+	// write-ahead log on SQLite, to keep it from growing for the whole run. It's ignored on other databases and
+	// checkpointing is disabled if it's not positive.
+	CheckpointInterval int
 }
 
 func NativeSettingsForDialect(d migrator.Dialect) BulkOpSettings {
@@ -58,3 +63,153 @@ func InBatches(items interface{}, opts BulkOpSettings, fn func(batch interface{}
 	}
 	return nil
 }
+
+// BatchProgress reports how many batches and rows ProcessInBatches has committed, and how many times the
+// write-ahead log was checkpointed in between.
+type BatchProgress struct {
+	Batches     int
+	Rows        int64
+	Checkpoints int
+}
+
+// ProcessInBatches iterates over all rows of the bean's table in primary key order and calls fn with at most
+// batchSize rows at a time. The batch is a slice of the bean's type. Every batch is read and processed in its own
+// transaction, so if fn returns an error only the current batch is rolled back. Processing stops on the first error
+// and the progress made by the already committed batches is returned alongside it.
+// The bean's table needs a single-column primary key, which is used for keyset pagination.
+func (ss *SQLStore) ProcessInBatches(ctx context.Context, bean interface{}, batchSize int, fn func(sess *DBSession, batch interface{}) error) (BatchProgress, error) {
+	return ss.ProcessInBatchesWithSettings(ctx, bean, BulkOpSettings{BatchSize: batchSize}, fn)
+}
+
+// ProcessInBatchesWithSettings is ProcessInBatches with the batch size and checkpoint interval of opts. On SQLite, a
+// passive WAL checkpoint is run after every CheckpointInterval committed batches, so that large runs don't bloat the
+// write-ahead log. A failed checkpoint is logged and doesn't stop the processing.
+func (ss *SQLStore) ProcessInBatchesWithSettings(ctx context.Context, bean interface{}, opts BulkOpSettings, fn func(sess *DBSession, batch interface{}) error) (BatchProgress, error) {
+	var progress BatchProgress
+	opts = normalizeBulkSettings(opts)
+	checkpoint := opts.CheckpointInterval > 0 && ss.Dialect.DriverName() == migrator.SQLite
+
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return progress, fmt.Errorf("failed to get table info for %T", bean)
+	}
+	pkColumns := table.PKColumns()
+	if len(pkColumns) != 1 {
+		return progress, fmt.Errorf("processing in batches requires a single-column primary key, table %q has %d", table.Name, len(pkColumns))
+	}
+	pk := pkColumns[0]
+	beanType := reflect.Indirect(reflect.ValueOf(bean)).Type()
+
+	var lastKey interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		batch := reflect.New(reflect.SliceOf(beanType))
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			query := sess.Table(table.Name).OrderBy(dialect.Quote(pk.Name)).Limit(opts.BatchSize)
+			if lastKey != nil {
+				query = query.Where(dialect.Quote(pk.Name)+" > ?", lastKey)
+			}
+			if err := query.Find(batch.Interface()); err != nil {
+				return err
+			}
+			if batch.Elem().Len() == 0 {
+				return nil
+			}
+			return fn(sess, batch.Elem().Interface())
+		})
+		if err != nil {
+			return progress, err
+		}
+
+		rows := batch.Elem().Len()
+		if rows == 0 {
+			return progress, nil
+		}
+		progress.Batches++
+		progress.Rows += int64(rows)
+
+		key, err := pk.ValueOf(batch.Elem().Index(rows - 1).Addr().Interface())
+		if err != nil {
+			return progress, err
+		}
+		lastKey = key.Interface()
+
+		if checkpoint && progress.Batches%opts.CheckpointInterval == 0 {
+			if err := ss.checkpointWAL(ctx); err != nil {
+				sessionLogger.Warn("Failed to checkpoint the write-ahead log", "batches", progress.Batches, "error", err)
+			} else {
+				progress.Checkpoints++
+			}
+		}
+
+		if rows < opts.BatchSize {
+			return progress, nil
+		}
+	}
+}
+
+// checkpointWAL copies the committed transactions in the SQLite write-ahead log into the database without waiting
+// for readers or writers, so that the log can be reused from the start
+func (ss *SQLStore) checkpointWAL(ctx context.Context) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("PRAGMA wal_checkpoint(PASSIVE)")
+		return err
+	})
+}
+
+// InsertStream consumes beans from in and inserts them in batches of at most batchSize rows, each batch in its own
+// transaction, until in is closed. A batch is inserted with InsertMany, so its statements stay within the placeholder
+// limit of the dialect however large the batch. The beans need to be values of or pointers to the bean's type.
+// The number of inserted rows is returned. If the context is cancelled, InsertStream stops without inserting the
+// beans it has received since the last committed batch and returns the number of rows inserted so far alongside
+// the context's error.
+func (ss *SQLStore) InsertStream(ctx context.Context, bean interface{}, in <-chan interface{}, batchSize int) (int64, error) {
+	var inserted int64
+	opts := normalizeBulkSettings(BulkOpSettings{BatchSize: batchSize})
+	beanType := reflect.Indirect(reflect.ValueOf(bean)).Type()
+
+	batch := reflect.MakeSlice(reflect.SliceOf(beanType), 0, opts.BatchSize)
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		var n int64
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			var err error
+			n, err = sess.InsertMany(batch.Interface(), opts.BatchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		inserted += n
+		batch = batch.Slice(0, 0)
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return inserted, ctx.Err()
+		case item, ok := <-in:
+			if !ok {
+				return inserted, flush()
+			}
+
+			v := reflect.Indirect(reflect.ValueOf(item))
+			if !v.IsValid() || v.Type() != beanType {
+				return inserted, fmt.Errorf("expected a %s, got %T", beanType, item)
+			}
+			batch = reflect.Append(batch, v)
+
+			if batch.Len() >= opts.BatchSize {
+				if err := flush(); err != nil {
+					return inserted, err
+				}
+			}
+		}
+	}
+}
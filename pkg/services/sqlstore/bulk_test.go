@@ -2,9 +2,14 @@ package sqlstore
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
 type bulkTestItem struct {
@@ -86,6 +91,236 @@ func TestIntegrationBulkOps(t *testing.T) {
 	})
 }
 
+type batchTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Value string `xorm:"varchar(10)"`
+}
+
+func TestIntegrationProcessInBatches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(batchTestItem))
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, count int) {
+		t.Helper()
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			if _, err := sess.Exec("DELETE FROM batch_test_item"); err != nil {
+				return err
+			}
+			_, err := sess.BulkInsert(batchTestItem{}, make([]batchTestItem, count), NativeSettingsForDialect(db.GetDialect()))
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	markDone := func(sess *DBSession, batch interface{}) error {
+		for _, item := range batch.([]batchTestItem) {
+			if _, err := sess.Table("batch_test_item").ID(item.ID).Cols("value").Update(&batchTestItem{Value: "done"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	countDone := func(t *testing.T) int64 {
+		t.Helper()
+		var done int64
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			done, err = sess.Table("batch_test_item").Where("value = ?", "done").Count()
+			return err
+		})
+		require.NoError(t, err)
+		return done
+	}
+
+	t.Run("processes all rows in batches", func(t *testing.T) {
+		setup(t, 95)
+
+		var sizes []int
+		progress, err := db.ProcessInBatches(context.Background(), batchTestItem{}, 10, func(sess *DBSession, batch interface{}) error {
+			sizes = append(sizes, len(batch.([]batchTestItem)))
+			return markDone(sess, batch)
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, BatchProgress{Batches: 10, Rows: 95}, progress)
+		require.Equal(t, []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 5}, sizes)
+		require.Equal(t, int64(95), countDone(t))
+	})
+
+	t.Run("failure only rolls back the failing batch", func(t *testing.T) {
+		setup(t, 50)
+
+		calls := 0
+		progress, err := db.ProcessInBatches(context.Background(), batchTestItem{}, 10, func(sess *DBSession, batch interface{}) error {
+			calls++
+			if err := markDone(sess, batch); err != nil {
+				return err
+			}
+			if calls == 3 {
+				return errors.New("processing failed")
+			}
+			return nil
+		})
+
+		require.EqualError(t, err, "processing failed")
+		require.Equal(t, BatchProgress{Batches: 2, Rows: 20}, progress)
+		require.Equal(t, 3, calls)
+		require.Equal(t, int64(20), countDone(t))
+	})
+
+	t.Run("checkpoints the write-ahead log at the configured batch interval", func(t *testing.T) {
+		setup(t, 95)
+
+		progress, err := db.ProcessInBatchesWithSettings(context.Background(), batchTestItem{}, BulkOpSettings{BatchSize: 10, CheckpointInterval: 3}, markDone)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(95), countDone(t))
+		require.Equal(t, 10, progress.Batches)
+		if IsTestDbMySQL() || IsTestDbPostgres() {
+			require.Zero(t, progress.Checkpoints)
+		} else {
+			require.Equal(t, 3, progress.Checkpoints)
+		}
+	})
+
+	t.Run("doesn't checkpoint without a checkpoint interval", func(t *testing.T) {
+		setup(t, 30)
+
+		progress, err := db.ProcessInBatchesWithSettings(context.Background(), batchTestItem{}, BulkOpSettings{BatchSize: 10}, markDone)
+
+		require.NoError(t, err)
+		require.Equal(t, BatchProgress{Batches: 3, Rows: 30}, progress)
+	})
+
+	t.Run("rejects beans without a single-column primary key", func(t *testing.T) {
+		type noPrimaryKey struct {
+			Value string
+		}
+		_, err := db.ProcessInBatches(context.Background(), noPrimaryKey{}, 10, func(sess *DBSession, batch interface{}) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+}
+
+type streamTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Value string `xorm:"varchar(10)"`
+}
+
+func TestIntegrationInsertStream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(streamTestItem))
+	require.NoError(t, err)
+
+	reset := func(t *testing.T) {
+		t.Helper()
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			_, err := sess.Exec("DELETE FROM stream_test_item")
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	count := func(t *testing.T) int64 {
+		t.Helper()
+		var total int64
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			total, err = sess.Table("stream_test_item").Count()
+			return err
+		})
+		require.NoError(t, err)
+		return total
+	}
+
+	t.Run("inserts all streamed rows until the channel is closed", func(t *testing.T) {
+		reset(t)
+
+		in := make(chan interface{})
+		go func() {
+			defer close(in)
+			for i := 0; i < 5000; i++ {
+				if i%2 == 0 {
+					in <- streamTestItem{Value: "value"}
+				} else {
+					in <- &streamTestItem{Value: "pointer"}
+				}
+			}
+		}()
+
+		inserted, err := db.InsertStream(context.Background(), streamTestItem{}, in, 300)
+		require.NoError(t, err)
+		require.Equal(t, int64(5000), inserted)
+		require.Equal(t, int64(5000), count(t))
+	})
+
+	t.Run("stops when the context is cancelled mid-stream", func(t *testing.T) {
+		reset(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		in := make(chan interface{})
+		go func() {
+			for i := 0; i < 2250; i++ {
+				in <- streamTestItem{Value: "value"}
+			}
+			cancel()
+		}()
+
+		inserted, err := db.InsertStream(ctx, streamTestItem{}, in, 500)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int64(2000), inserted)
+		require.Equal(t, int64(2000), count(t))
+	})
+
+	t.Run("keeps the statements of a batch within the placeholder limit of the dialect", func(t *testing.T) {
+		reset(t)
+		hooks := &insertHooksDialect{Dialect: dialect}
+		dialect = hooks
+		t.Cleanup(func() { dialect = hooks.Dialect })
+
+		// more rows than fit in a single statement on every dialect
+		rows := db.GetDialect().MaxPlaceholders() + 1
+		in := make(chan interface{})
+		go func() {
+			defer close(in)
+			for i := 0; i < rows; i++ {
+				in <- streamTestItem{Value: "value"}
+			}
+		}()
+
+		inserted, err := db.InsertStream(context.Background(), streamTestItem{}, in, rows)
+		require.NoError(t, err)
+		require.Equal(t, int64(rows), inserted)
+		require.Equal(t, int64(rows), count(t))
+		require.Greater(t, hooks.pre, 1)
+	})
+
+	t.Run("rejects beans of another type", func(t *testing.T) {
+		reset(t)
+
+		in := make(chan interface{}, 2)
+		in <- streamTestItem{Value: "value"}
+		in <- batchTestItem{Value: "value"}
+		close(in)
+
+		inserted, err := db.InsertStream(context.Background(), streamTestItem{}, in, 10)
+		require.Error(t, err)
+		require.Equal(t, int64(0), inserted)
+		require.Equal(t, int64(0), count(t))
+	})
+}
+
 func assertTableCount(t *testing.T, db *SQLStore, table interface{}, expCount int64) {
 	t.Helper()
 	err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
@@ -95,3 +330,119 @@ func assertTableCount(t *testing.T, db *SQLStore, table interface{}, expCount in
 	})
 	require.NoError(t, err)
 }
+
+type insertManyTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Name  string `xorm:"varchar(10)"`
+	Value string `xorm:"varchar(10)"`
+	Extra string `xorm:"varchar(10)"`
+}
+
+// insertHooksDialect counts the calls of the insert hooks of the dialect it wraps
+type insertHooksDialect struct {
+	migrator.Dialect
+	pre, post int
+}
+
+func (d *insertHooksDialect) PreInsertId(table string, sess *xorm.Session) error {
+	d.pre++
+	return d.Dialect.PreInsertId(table, sess)
+}
+
+func (d *insertHooksDialect) PostInsertId(table string, sess *xorm.Session) error {
+	d.post++
+	return d.Dialect.PostInsertId(table, sess)
+}
+
+func TestIntegrationInsertMany(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(insertManyTestItem))
+	require.NoError(t, err)
+
+	hooks := &insertHooksDialect{Dialect: dialect}
+	dialect = hooks
+	t.Cleanup(func() { dialect = hooks.Dialect })
+
+	insertMany := func(t *testing.T, beans interface{}, chunkSize int) (int64, error) {
+		t.Helper()
+		_, err := db.engine.Exec("DELETE FROM insert_many_test_item")
+		require.NoError(t, err)
+		hooks.pre, hooks.post = 0, 0
+
+		var inserted int64
+		err = db.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			inserted, err = sess.InsertMany(beans, chunkSize)
+			return err
+		})
+		return inserted, err
+	}
+
+	count := func(t *testing.T) int64 {
+		t.Helper()
+		total, err := db.engine.Table("insert_many_test_item").Count()
+		require.NoError(t, err)
+		return total
+	}
+
+	t.Run("inserts the rows in chunks, running the insert hooks once per chunk", func(t *testing.T) {
+		items := make([]insertManyTestItem, 250)
+		for i := range items {
+			items[i].Name = fmt.Sprint(i)
+		}
+
+		inserted, err := insertMany(t, items, 100)
+		require.NoError(t, err)
+		require.Equal(t, int64(250), inserted)
+		require.Equal(t, int64(250), count(t))
+		require.Equal(t, 3, hooks.pre)
+		require.Equal(t, 3, hooks.post)
+	})
+
+	t.Run("inserts a slice of pointers", func(t *testing.T) {
+		items := []*insertManyTestItem{{Name: "a"}, {Name: "b"}}
+
+		inserted, err := insertMany(t, &items, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), inserted)
+		require.Equal(t, int64(2), count(t))
+	})
+
+	t.Run("uses the batch size of the dialect without a chunk size", func(t *testing.T) {
+		items := make([]insertManyTestItem, 25)
+
+		inserted, err := insertMany(t, items, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(25), inserted)
+		batchSize := db.GetDialect().BatchSize()
+		chunks := (25 + batchSize - 1) / batchSize
+		require.Equal(t, chunks, hooks.pre)
+	})
+
+	t.Run("keeps the chunks within the placeholder limit of the dialect", func(t *testing.T) {
+		// more rows than fit in a single statement on every dialect
+		rows := db.GetDialect().MaxPlaceholders() + 1
+		items := make([]insertManyTestItem, rows)
+
+		inserted, err := insertMany(t, items, rows)
+		require.NoError(t, err)
+		require.Equal(t, int64(rows), inserted)
+		require.Equal(t, int64(rows), count(t))
+		require.Greater(t, hooks.pre, 1)
+	})
+
+	t.Run("inserts nothing for an empty slice", func(t *testing.T) {
+		inserted, err := insertMany(t, []insertManyTestItem{}, 10)
+		require.NoError(t, err)
+		require.Zero(t, inserted)
+		require.Zero(t, hooks.pre)
+	})
+
+	t.Run("rejects beans that aren't a slice", func(t *testing.T) {
+		_, err := insertMany(t, insertManyTestItem{}, 10)
+		require.Error(t, err)
+	})
+}
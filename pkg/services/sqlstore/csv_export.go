@@ -0,0 +1,81 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// StreamQueryCSV runs the query and writes its result to w as CSV, with a header row holding the column names.
+// Rows are written as they're read from the database, so only a single row is held in memory at a time.
+// NULL values are written as empty fields and times are formatted as RFC 3339.
+// The query is run outside of any transaction stored in the context.
+func (ss *SQLStore) StreamQueryCSV(ctx context.Context, w io.Writer, query string, args ...interface{}) error {
+	for _, filter := range ss.engine.Dialect().Filters() {
+		query = filter.Do(query, ss.engine.Dialect(), nil)
+	}
+
+	rows, err := ss.engine.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to run query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			record[i] = formatCSVValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type csvExportTestItem struct {
+	ID          int64 `xorm:"pk autoincr 'id'"`
+	Name        string
+	Description *string
+	Created     time.Time
+}
+
+func TestIntegrationStreamQueryCSV(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(csvExportTestItem))
+	require.NoError(t, err)
+
+	created := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+	description := `a "quoted" description, with a comma`
+	items := []csvExportTestItem{
+		{ID: 1, Name: "plain", Created: created},
+		{ID: 2, Name: "multi\nline", Description: &description, Created: created.Add(time.Hour)},
+	}
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM csv_export_test_item"); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&items)
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("writes a header and a row per result row", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.StreamQueryCSV(context.Background(), &buf, "SELECT id, name, description, created FROM csv_export_test_item WHERE id > ? ORDER BY id", 0)
+		require.NoError(t, err)
+		require.Equal(t, "id,name,description,created\n"+
+			"1,plain,,2022-11-01T10:00:00Z\n"+
+			"2,\"multi\nline\",\"a \"\"quoted\"\" description, with a comma\",2022-11-01T11:00:00Z\n", buf.String())
+	})
+
+	t.Run("writes only the header for an empty result", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.StreamQueryCSV(context.Background(), &buf, "SELECT id, name FROM csv_export_test_item WHERE id > ?", 10)
+		require.NoError(t, err)
+		require.Equal(t, "id,name\n", buf.String())
+	})
+
+	t.Run("returns the error of an invalid query", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.StreamQueryCSV(context.Background(), &buf, "SELECT missing FROM csv_export_test_item")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,46 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"xorm.io/builder"
+)
+
+// DistinctCount returns the number of distinct values of the column among the rows of the bean's table matching the
+// conditions, which are passed the same way as to BuildSQL. NULL isn't counted as a value, like in COUNT(DISTINCT).
+// The column must be a column of the table.
+func (ss *SQLStore) DistinctCount(ctx context.Context, bean interface{}, column string, conditions ...interface{}) (int64, error) {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return 0, fmt.Errorf("could not resolve the table of %T", bean)
+	}
+	if table.GetColumn(column) == nil {
+		return 0, fmt.Errorf("table %s has no column %q", table.Name, column)
+	}
+
+	cond, err := buildCond(conditions...)
+	if err != nil {
+		return 0, err
+	}
+
+	rawSQL, args, err := builder.Select("COUNT(DISTINCT " + ss.Dialect.Quote(column) + ")").
+		From(ss.Dialect.Quote(table.Name)).
+		Where(cond).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, filter := range ss.engine.Dialect().Filters() {
+		rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table.Table)
+	}
+
+	var count int64
+	err = ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.SQL(rawSQL, args...).Get(&count)
+		return err
+	})
+
+	return count, err
+}
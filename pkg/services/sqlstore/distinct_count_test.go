@@ -0,0 +1,68 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+)
+
+type distinctCountTestItem struct {
+	ID    int64 `xorm:"pk autoincr 'id'"`
+	OrgID int64 `xorm:"org_id"`
+	Kind  *string
+}
+
+func TestIntegrationDistinctCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(distinctCountTestItem))
+	require.NoError(t, err)
+
+	kind := func(s string) *string { return &s }
+	items := []distinctCountTestItem{
+		{OrgID: 1, Kind: kind("a")},
+		{OrgID: 1, Kind: kind("a")},
+		{OrgID: 1, Kind: kind("b")},
+		{OrgID: 1, Kind: nil},
+		{OrgID: 2, Kind: kind("c")},
+		{OrgID: 2, Kind: nil},
+	}
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		_, err := sess.Insert(&items)
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("counts the distinct values of all rows, without NULL", func(t *testing.T) {
+		count, err := db.DistinctCount(context.Background(), &distinctCountTestItem{}, "kind")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+
+		count, err = db.DistinctCount(context.Background(), &distinctCountTestItem{}, "org_id")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("counts the distinct values of the rows matching the conditions", func(t *testing.T) {
+		count, err := db.DistinctCount(context.Background(), &distinctCountTestItem{}, "kind", "org_id = ?", 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+
+		count, err = db.DistinctCount(context.Background(), &distinctCountTestItem{}, "kind", builder.Eq{"org_id": 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+
+		count, err = db.DistinctCount(context.Background(), &distinctCountTestItem{}, "kind", "org_id = ?", 3)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("fails for a column the table doesn't have", func(t *testing.T) {
+		_, err := db.DistinctCount(context.Background(), &distinctCountTestItem{}, "kind) FROM user --")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,46 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// ExecTemplate executes the query after replacing its {{.name}} placeholders with the identifiers of idents quoted
+// for the dialect, e.g. "SELECT * FROM {{.table}} WHERE {{.column}} = ?" with idents {"table": "user", "column":
+// "login"}. Only identifiers go in the template, values are passed as args so that they stay parameterized.
+// A placeholder without an identifier, and identifiers that contain quotes, are an error.
+func (sess *DBSession) ExecTemplate(query string, idents map[string]string, args ...interface{}) (sql.Result, error) {
+	rawSQL, err := quoteQueryTemplate(dialect, query, idents)
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Exec(append([]interface{}{rawSQL}, args...)...)
+}
+
+// quoteQueryTemplate returns the query with its placeholders replaced by the identifiers quoted for the dialect
+func quoteQueryTemplate(d migrator.Dialect, query string, idents map[string]string) (string, error) {
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query template: %w", err)
+	}
+
+	quoted := make(map[string]string, len(idents))
+	for name, ident := range idents {
+		// the quotes aren't escaped by the dialects, so an identifier with quotes could end the quoting early
+		if ident == "" || strings.ContainsAny(ident, "`\"\x00") {
+			return "", fmt.Errorf("invalid identifier %q for %q", ident, name)
+		}
+		quoted[name] = d.Quote(ident)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, quoted); err != nil {
+		return "", fmt.Errorf("failed to fill in query template: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,84 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestQuoteQueryTemplate(t *testing.T) {
+	query := "SELECT {{.column}} FROM {{.table}} WHERE {{.column}} = ?"
+	idents := map[string]string{"table": "user", "column": "login"}
+
+	testCases := []struct {
+		desc     string
+		dialect  migrator.Dialect
+		expected string
+	}{
+		{desc: "sqlite", dialect: migrator.NewSQLite3Dialect(nil), expected: "SELECT `login` FROM `user` WHERE `login` = ?"},
+		{desc: "mysql", dialect: migrator.NewMysqlDialect(nil), expected: "SELECT `login` FROM `user` WHERE `login` = ?"},
+		{desc: "postgres", dialect: migrator.NewPostgresDialect(nil), expected: `SELECT "login" FROM "user" WHERE "login" = ?`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			rawSQL, err := quoteQueryTemplate(tc.dialect, query, idents)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rawSQL)
+		})
+	}
+
+	t.Run("fails for a placeholder without an identifier", func(t *testing.T) {
+		_, err := quoteQueryTemplate(migrator.NewSQLite3Dialect(nil), "SELECT * FROM {{.table}}", map[string]string{"column": "login"})
+		require.Error(t, err)
+	})
+
+	t.Run("fails for identifiers with quotes", func(t *testing.T) {
+		for _, ident := range []string{"user` WHERE 1=1 --", `user" WHERE 1=1 --`, ""} {
+			_, err := quoteQueryTemplate(migrator.NewSQLite3Dialect(nil), "SELECT * FROM {{.table}}", map[string]string{"table": ident})
+			require.Error(t, err, ident)
+		}
+	})
+
+	t.Run("fails for an invalid template", func(t *testing.T) {
+		_, err := quoteQueryTemplate(migrator.NewSQLite3Dialect(nil), "SELECT * FROM {{.table", map[string]string{"table": "user"})
+		require.Error(t, err)
+	})
+}
+
+type execTemplateTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Value string `xorm:"varchar(50)"`
+}
+
+func TestIntegrationExecTemplate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(execTemplateTestItem))
+	require.NoError(t, err)
+
+	idents := map[string]string{"table": "exec_template_test_item", "column": "value"}
+	// a value that would end the statement early if it weren't parameterized
+	value := "x'); DELETE FROM exec_template_test_item; --"
+
+	err = ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+		for i := 0; i < 2; i++ {
+			if _, err := sess.ExecTemplate("INSERT INTO {{.table}} ({{.column}}) VALUES (?)", idents, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var items []execTemplateTestItem
+	require.NoError(t, ss.engine.Find(&items))
+	require.Len(t, items, 2)
+	for _, item := range items {
+		require.Equal(t, value, item.Value)
+	}
+}
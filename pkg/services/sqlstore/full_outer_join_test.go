@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+type joinTestLeft struct {
+	ID  int64  `xorm:"pk autoincr 'id'"`
+	Key string `xorm:"'join_key'"`
+}
+
+type joinTestRight struct {
+	ID  int64  `xorm:"pk autoincr 'id'"`
+	Key string `xorm:"'join_key'"`
+}
+
+func TestIntegrationFullOuterJoinSQL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(joinTestLeft), new(joinTestRight))
+	require.NoError(t, err)
+
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM join_test_left"); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("DELETE FROM join_test_right"); err != nil {
+			return err
+		}
+		// "b" matches twice on the right side, "a" and "d" are only on the left side, "c" and "e" only on the right side
+		for _, key := range []string{"a", "b", "d"} {
+			if _, err := sess.Insert(&joinTestLeft{Key: key}); err != nil {
+				return err
+			}
+		}
+		for _, key := range []string{"b", "b", "c", "e"} {
+			if _, err := sess.Insert(&joinTestRight{Key: key}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	expected := []string{"-:c", "-:e", "a:-", "b:b", "b:b", "d:-"}
+
+	columns := []string{"l.join_key AS left_key", "r.join_key AS right_key"}
+	query := func(t *testing.T, rawSQL string) []string {
+		t.Helper()
+		var rows []struct {
+			LeftKey  *string
+			RightKey *string
+		}
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			return sess.SQL(rawSQL).Find(&rows)
+		})
+		require.NoError(t, err)
+
+		result := make([]string, 0, len(rows))
+		for _, row := range rows {
+			left, right := "-", "-"
+			if row.LeftKey != nil {
+				left = *row.LeftKey
+			}
+			if row.RightKey != nil {
+				right = *row.RightKey
+			}
+			result = append(result, left+":"+right)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	t.Run("the dialect's full outer join returns matching and unmatched rows of both sides", func(t *testing.T) {
+		rawSQL := db.Dialect.FullOuterJoinSQL(columns, "join_test_left l", "join_test_right r", "l.join_key = r.join_key")
+		require.Equal(t, expected, query(t, rawSQL))
+	})
+
+	t.Run("the emulated full outer join returns the same rows", func(t *testing.T) {
+		// SQLite and Postgres support RIGHT JOIN, so the statement used for MySQL can run on every database
+		rawSQL := (&migrator.MySQLDialect{}).FullOuterJoinSQL(columns, "join_test_left l", "join_test_right r", "l.join_key = r.join_key")
+		require.Equal(t, expected, query(t, rawSQL))
+	})
+
+	t.Run("can be filtered as a subquery", func(t *testing.T) {
+		rawSQL := "SELECT * FROM (" + db.Dialect.FullOuterJoinSQL(columns, "join_test_left l", "join_test_right r", "l.join_key = r.join_key") + ") merged WHERE merged.left_key IS NULL"
+		require.Equal(t, []string{"-:c", "-:e"}, query(t, rawSQL))
+	})
+}
@@ -2,8 +2,10 @@ package sqlstore
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
 // GetDBHealthQuery executes a query to check
@@ -14,3 +16,48 @@ func (ss *SQLStore) GetDBHealthQuery(ctx context.Context, query *models.GetDBHea
 		return err
 	})
 }
+
+// ReadinessStatus is the result of a database readiness check.
+type ReadinessStatus struct {
+	// Ready is true when the database is reachable and all migrations have been applied.
+	Ready bool `json:"ready"`
+	// Reachable is true when the database responded to a ping.
+	Reachable bool `json:"reachable"`
+	// PendingMigrations lists the registered migrations that aren't recorded in the migration log.
+	PendingMigrations []string `json:"pendingMigrations,omitempty"`
+	// Error describes why the database isn't ready, if the check itself failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ReadinessCheck reports whether the database can serve traffic. Unlike GetDBHealthQuery it also verifies
+// that the migration log contains every registered migration, so that a database that is still being
+// migrated (or was never migrated) is reported as not ready.
+func (ss *SQLStore) ReadinessCheck(ctx context.Context) ReadinessStatus {
+	if err := ss.engine.PingContext(ctx); err != nil {
+		return ReadinessStatus{Error: fmt.Sprintf("database is unreachable: %v", err)}
+	}
+
+	status := ReadinessStatus{Reachable: true}
+	if ss.migrations == nil {
+		status.Ready = true
+		return status
+	}
+
+	mg := migrator.NewMigrator(ss.engine, ss.Cfg)
+	ss.migrations.AddMigration(mg)
+
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to read migration log: %v", err)
+		return status
+	}
+
+	for _, id := range mg.GetMigrationIDs(true) {
+		if _, ok := logMap[id]; !ok {
+			status.PendingMigrations = append(status.PendingMigrations, id)
+		}
+	}
+
+	status.Ready = len(status.PendingMigrations) == 0
+	return status
+}
@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
 	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
 )
 
 func TestIntegrationGetDBHealthQuery(t *testing.T) {
@@ -18,3 +20,42 @@ func TestIntegrationGetDBHealthQuery(t *testing.T) {
 	err := store.GetDBHealthQuery(context.Background(), &query)
 	require.NoError(t, err)
 }
+
+func TestIntegrationReadinessCheck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := InitTestDB(t)
+
+	t.Run("ready when migrated", func(t *testing.T) {
+		status := store.ReadinessCheck(context.Background())
+		require.True(t, status.Ready)
+		require.True(t, status.Reachable)
+		require.Empty(t, status.PendingMigrations)
+		require.Empty(t, status.Error)
+	})
+
+	t.Run("not ready when migrations are pending", func(t *testing.T) {
+		engine, err := xorm.NewEngine(sqlutil.SQLite3TestDB().DriverName, "file:readiness_check_test?mode=memory&cache=shared")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = engine.Close() })
+
+		notMigrated := &SQLStore{Cfg: store.Cfg, engine: engine, migrations: store.migrations}
+		status := notMigrated.ReadinessCheck(context.Background())
+		require.False(t, status.Ready)
+		require.True(t, status.Reachable)
+		require.Contains(t, status.PendingMigrations, "create migration_log table")
+	})
+
+	t.Run("not ready when unreachable", func(t *testing.T) {
+		engine, err := xorm.NewEngine(sqlutil.SQLite3TestDB().DriverName, "file:readiness_check_closed_test?mode=memory&cache=shared")
+		require.NoError(t, err)
+		require.NoError(t, engine.Close())
+
+		unreachable := &SQLStore{Cfg: store.Cfg, engine: engine, migrations: store.migrations}
+		status := unreachable.ReadinessCheck(context.Background())
+		require.False(t, status.Ready)
+		require.False(t, status.Reachable)
+		require.NotEmpty(t, status.Error)
+	})
+}
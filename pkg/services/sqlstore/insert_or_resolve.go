@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// InsertOrResolve inserts the bean, or if a row with the same values of the conflict columns already exists, calls
+// resolve with that row and the bean and updates the row to the bean resolve returns instead, e.g. to keep the newer
+// of the two or to merge them. If resolve returns nil, the existing row is left as it is. Both beans passed to resolve
+// are pointers of the bean's type, and the bean it returns must be one as well.
+// The conflict columns need a unique index for conflicts to be detected. The insert, lookup and update are run in one
+// transaction, with the insert behind a savepoint, so that the failed insert doesn't abort the transaction on Postgres.
+func (ss *SQLStore) InsertOrResolve(ctx context.Context, bean interface{}, conflictCols []string, resolve func(existing, incoming interface{}) interface{}) error {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return fmt.Errorf("could not resolve the table of %T", bean)
+	}
+	if len(conflictCols) == 0 {
+		return fmt.Errorf("at least one conflict column is required")
+	}
+
+	conflict, err := keyCondition(ss.Dialect, table, bean, conflictCols)
+	if err != nil {
+		return err
+	}
+
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		if _, err := sess.Exec("SAVEPOINT insert_or_resolve"); err != nil {
+			return err
+		}
+		_, err := sess.Insert(bean)
+		if err == nil {
+			_, err = sess.Exec("RELEASE SAVEPOINT insert_or_resolve")
+			return err
+		}
+		if !ss.Dialect.IsUniqueConstraintViolation(err) {
+			return err
+		}
+		if _, err := sess.Exec("ROLLBACK TO SAVEPOINT insert_or_resolve"); err != nil {
+			return err
+		}
+
+		existing := reflect.New(reflect.Indirect(reflect.ValueOf(bean)).Type()).Interface()
+		found, err := sess.Table(table.Name).Where(conflict).Get(existing)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("row of table %q conflicts on another unique index than %v", table.Name, conflictCols)
+		}
+
+		resolved := resolve(existing, bean)
+		if resolved == nil {
+			return nil
+		}
+		_, err = sess.Table(table.Name).Where(conflict).AllCols().Update(resolved)
+		return err
+	})
+}
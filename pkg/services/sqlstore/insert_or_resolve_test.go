@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type insertOrResolveTestItem struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	Name    string `xorm:"unique"`
+	Version int64
+	Value   string
+}
+
+func TestIntegrationInsertOrResolve(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(insertOrResolveTestItem))
+	require.NoError(t, err)
+
+	// lastWriteWins keeps the row with the higher version
+	lastWriteWins := func(existing, incoming interface{}) interface{} {
+		if incoming.(*insertOrResolveTestItem).Version > existing.(*insertOrResolveTestItem).Version {
+			return incoming
+		}
+		return nil
+	}
+
+	getItem := func(t *testing.T, name string) insertOrResolveTestItem {
+		t.Helper()
+		var item insertOrResolveTestItem
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			found, err := sess.Where("name = ?", name).Get(&item)
+			require.True(t, found)
+			return err
+		})
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("inserts the bean if there's no conflict", func(t *testing.T) {
+		called := false
+		err := db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "new", Version: 1, Value: "a"}, []string{"name"}, func(existing, incoming interface{}) interface{} {
+			called = true
+			return incoming
+		})
+		require.NoError(t, err)
+		require.False(t, called)
+
+		item := getItem(t, "new")
+		require.Equal(t, int64(1), item.Version)
+		require.Equal(t, "a", item.Value)
+	})
+
+	t.Run("updates the row to the resolved bean on conflict", func(t *testing.T) {
+		err := db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "lww", Version: 1, Value: "a"}, []string{"name"}, lastWriteWins)
+		require.NoError(t, err)
+		inserted := getItem(t, "lww")
+
+		err = db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "lww", Version: 2, Value: "b"}, []string{"name"}, lastWriteWins)
+		require.NoError(t, err)
+		require.Equal(t, insertOrResolveTestItem{ID: inserted.ID, Name: "lww", Version: 2, Value: "b"}, getItem(t, "lww"))
+
+		// an older write loses
+		err = db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "lww", Version: 1, Value: "c"}, []string{"name"}, lastWriteWins)
+		require.NoError(t, err)
+		require.Equal(t, insertOrResolveTestItem{ID: inserted.ID, Name: "lww", Version: 2, Value: "b"}, getItem(t, "lww"))
+	})
+
+	t.Run("updates the row to a merge of both beans", func(t *testing.T) {
+		err := db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "merge", Version: 1, Value: "a"}, []string{"name"}, nil)
+		require.NoError(t, err)
+
+		var seenExisting, seenIncoming insertOrResolveTestItem
+		err = db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "merge", Version: 3, Value: "b"}, []string{"name"}, func(existing, incoming interface{}) interface{} {
+			seenExisting = *existing.(*insertOrResolveTestItem)
+			seenIncoming = *incoming.(*insertOrResolveTestItem)
+			merged := *existing.(*insertOrResolveTestItem)
+			merged.Version = seenIncoming.Version
+			merged.Value = seenExisting.Value + "+" + seenIncoming.Value
+			return &merged
+		})
+		require.NoError(t, err)
+		require.Equal(t, "a", seenExisting.Value)
+		require.Equal(t, "b", seenIncoming.Value)
+
+		item := getItem(t, "merge")
+		require.Equal(t, seenExisting.ID, item.ID)
+		require.Equal(t, int64(3), item.Version)
+		require.Equal(t, "a+b", item.Value)
+	})
+
+	t.Run("rejects unknown conflict columns", func(t *testing.T) {
+		err := db.InsertOrResolve(context.Background(), &insertOrResolveTestItem{Name: "unknown"}, []string{"missing"}, lastWriteWins)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,203 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// IndexInfo describes an index of a database table.
+type IndexInfo struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// ListIndexes returns the indexes of the table with their columns in index order, sorted by index name.
+// Indexes created implicitly by the database, e.g. for primary keys, are included.
+func (ss *SQLStore) ListIndexes(ctx context.Context, table string) ([]IndexInfo, error) {
+	var rawSQL string
+	switch ss.Dialect.DriverName() {
+	case migrator.Postgres:
+		rawSQL = `SELECT i.relname AS index_name, a.attname AS column_name, CASE WHEN ix.indisunique THEN 1 ELSE 0 END AS is_unique
+			FROM pg_class t
+			JOIN pg_index ix ON t.oid = ix.indrelid
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+			WHERE t.relkind = 'r' AND t.relname = ? AND pg_table_is_visible(t.oid)
+			ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)`
+	case migrator.MySQL:
+		rawSQL = `SELECT INDEX_NAME AS index_name, COLUMN_NAME AS column_name, 1 - NON_UNIQUE AS is_unique
+			FROM information_schema.STATISTICS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+			ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+	case migrator.SQLite:
+		rawSQL = `SELECT il.name AS index_name, ii.name AS column_name, il."unique" AS is_unique
+			FROM pragma_index_list(?) AS il, pragma_index_info(il.name) AS ii
+			ORDER BY il.name, ii.seqno`
+	default:
+		return nil, fmt.Errorf("listing indexes is not supported for database type %q", ss.Dialect.DriverName())
+	}
+
+	var rows []map[string]string
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		rows, err = sess.QueryString(rawSQL, table)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes of table %q: %w", table, err)
+	}
+
+	indexes := map[string]*IndexInfo{}
+	for _, row := range rows {
+		name := row["index_name"]
+		index, ok := indexes[name]
+		if !ok {
+			index = &IndexInfo{Name: name, Unique: row["is_unique"] == "1"}
+			indexes[name] = index
+		}
+		index.Columns = append(index.Columns, row["column_name"])
+	}
+
+	result := make([]IndexInfo, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, *index)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// DumpSchema returns the DDL statements creating the tables of the database and their indexes, each terminated by a
+// semicolon. The statements are reconstructed from the live database: from the catalogs on Postgres, with SHOW CREATE TABLE
+// on MySQL and from sqlite_master on SQLite, so their formatting differs per database. Tables are ordered by name.
+func (ss *SQLStore) DumpSchema(ctx context.Context) (string, error) {
+	var statements []string
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		switch ss.Dialect.DriverName() {
+		case migrator.Postgres:
+			statements, err = ss.dumpPostgresSchema(sess)
+		case migrator.MySQL:
+			statements, err = ss.dumpMySQLSchema(sess)
+		case migrator.SQLite:
+			statements, err = dumpSQLiteSchema(sess)
+		default:
+			err = fmt.Errorf("dumping the schema is not supported for database type %q", ss.Dialect.DriverName())
+		}
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to dump schema: %w", err)
+	}
+
+	return strings.Join(statements, ";\n") + ";\n", nil
+}
+
+func dumpSQLiteSchema(sess *DBSession) ([]string, error) {
+	rows, err := sess.QueryString(`SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY tbl_name, CASE type WHEN 'table' THEN 0 ELSE 1 END, name`)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]string, 0, len(rows))
+	for _, row := range rows {
+		statements = append(statements, row["sql"])
+	}
+	return statements, nil
+}
+
+func (ss *SQLStore) dumpMySQLSchema(sess *DBSession) ([]string, error) {
+	tables, err := sess.QueryString(`SELECT TABLE_NAME AS table_name FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME`)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]string, 0, len(tables))
+	for _, table := range tables {
+		// SHOW CREATE TABLE includes the indexes of the table
+		rows, err := sess.QueryString("SHOW CREATE TABLE " + ss.Dialect.Quote(table["table_name"]))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			statements = append(statements, row["Create Table"])
+		}
+	}
+	return statements, nil
+}
+
+func (ss *SQLStore) dumpPostgresSchema(sess *DBSession) ([]string, error) {
+	tables, err := sess.QueryString(`SELECT c.relname AS table_name FROM pg_class c
+		WHERE c.relkind = 'r' AND pg_table_is_visible(c.oid)
+		ORDER BY c.relname`)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	for _, table := range tables {
+		name := table["table_name"]
+		columns, err := sess.QueryString(`SELECT a.attname AS column_name, format_type(a.atttypid, a.atttypmod) AS column_type,
+			CASE WHEN a.attnotnull THEN 1 ELSE 0 END AS not_null, COALESCE(pg_get_expr(d.adbin, d.adrelid), '') AS column_default
+			FROM pg_attribute a
+			JOIN pg_class c ON c.oid = a.attrelid
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE c.relname = ? AND pg_table_is_visible(c.oid) AND a.attnum > 0 AND NOT a.attisdropped
+			ORDER BY a.attnum`, name)
+		if err != nil {
+			return nil, err
+		}
+
+		constraints, err := sess.QueryString(`SELECT con.conname AS constraint_name, pg_get_constraintdef(con.oid) AS definition
+			FROM pg_constraint con
+			JOIN pg_class c ON c.oid = con.conrelid
+			WHERE c.relname = ? AND pg_table_is_visible(c.oid)
+			ORDER BY con.conname`, name)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions := make([]string, 0, len(columns)+len(constraints))
+		for _, column := range columns {
+			definition := ss.Dialect.Quote(column["column_name"]) + " " + column["column_type"]
+			if column["column_default"] != "" {
+				definition += " DEFAULT " + column["column_default"]
+			}
+			if column["not_null"] == "1" {
+				definition += " NOT NULL"
+			}
+			definitions = append(definitions, definition)
+		}
+		for _, constraint := range constraints {
+			definitions = append(definitions, "CONSTRAINT "+ss.Dialect.Quote(constraint["constraint_name"])+" "+constraint["definition"])
+		}
+		statements = append(statements, "CREATE TABLE "+ss.Dialect.Quote(name)+" (\n\t"+strings.Join(definitions, ",\n\t")+"\n)")
+
+		// indexes backing constraints are created by the constraints
+		indexes, err := sess.QueryString(`SELECT pg_get_indexdef(ix.indexrelid) AS definition
+			FROM pg_index ix
+			JOIN pg_class t ON t.oid = ix.indrelid
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			WHERE t.relname = ? AND pg_table_is_visible(t.oid)
+				AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = ix.indexrelid)
+			ORDER BY i.relname`, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indexes {
+			statements = append(statements, index["definition"])
+		}
+	}
+	return statements, nil
+}
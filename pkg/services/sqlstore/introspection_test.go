@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationListIndexes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+
+	t.Run("reports the known indexes of the user table", func(t *testing.T) {
+		indexes, err := db.ListIndexes(context.Background(), "user")
+		require.NoError(t, err)
+
+		require.Contains(t, indexes, IndexInfo{Name: "UQE_user_login", Columns: []string{"login"}, Unique: true})
+		require.Contains(t, indexes, IndexInfo{Name: "UQE_user_email", Columns: []string{"email"}, Unique: true})
+		require.Contains(t, indexes, IndexInfo{Name: "IDX_user_login_email", Columns: []string{"login", "email"}, Unique: false})
+	})
+
+	t.Run("returns no indexes for an unknown table", func(t *testing.T) {
+		indexes, err := db.ListIndexes(context.Background(), "does_not_exist")
+		require.NoError(t, err)
+		require.Empty(t, indexes)
+	})
+}
+
+func TestIntegrationDumpSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+
+	schema, err := db.DumpSchema(context.Background())
+	require.NoError(t, err)
+
+	t.Run("contains the tables with their columns", func(t *testing.T) {
+		require.Contains(t, schema, "CREATE TABLE "+db.Dialect.Quote("user"))
+		require.Contains(t, schema, "CREATE TABLE "+db.Dialect.Quote("dashboard"))
+		require.Contains(t, schema, db.Dialect.Quote("login"))
+		require.Contains(t, schema, db.Dialect.Quote("is_admin"))
+	})
+
+	t.Run("contains the indexes", func(t *testing.T) {
+		require.Contains(t, schema, "UQE_user_login")
+		require.Contains(t, schema, "IDX_user_login_email")
+	})
+
+	t.Run("orders the tables by name", func(t *testing.T) {
+		require.Less(t, strings.Index(schema, "CREATE TABLE "+db.Dialect.Quote("dashboard")), strings.Index(schema, "CREATE TABLE "+db.Dialect.Quote("user")))
+	})
+}
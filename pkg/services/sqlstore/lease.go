@@ -0,0 +1,84 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+)
+
+// leaseClockSkewTolerance is how long an expired lease is still considered held by others, so that a contender whose
+// clock runs ahead of the holder's doesn't take over a lease the holder believes it still holds.
+var leaseClockSkewTolerance = 5 * time.Second
+
+type lease struct {
+	ID      int64 `xorm:"pk autoincr 'id'"`
+	Name    string
+	Holder  string
+	Version int64
+	// Expires is the expiry in unix milliseconds, according to the clock of the holder
+	Expires int64
+}
+
+// AcquireLease tries to acquire the named lease for holder for the duration of ttl. The lease is acquired if nobody
+// holds it, it's already held by holder, or the lease of its current holder has expired. Acquiring a lease that's
+// already held by holder extends it like RenewLease.
+// Expiry is compared against the local clock, so a lease is only taken over once it has been expired for longer than
+// the tolerated clock skew between holders. Holders should therefore renew their lease well before the ttl passes.
+func (ss *SQLStore) AcquireLease(ctx context.Context, name string, ttl time.Duration, holder string) (bool, error) {
+	now := TimeNow()
+	acquired := false
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		affected, err := sess.Table("lease").
+			Where("name = ? AND (holder = ? OR expires < ?)", name, holder, now.Add(-leaseClockSkewTolerance).UnixMilli()).
+			Incr("version").
+			Update(map[string]interface{}{"holder": holder, "expires": now.Add(ttl).UnixMilli()})
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			acquired = true
+			return nil
+		}
+
+		// somebody else holds the lease, or acquired it concurrently, if nothing is inserted. The conflict is skipped
+		// rather than failing, which would abort the transaction of the context on Postgres.
+		res, err := sess.Exec(ss.Dialect.InsertIgnoreSQL("lease", []string{"name", "holder", "version", "expires"}),
+			name, holder, 1, now.Add(ttl).UnixMilli())
+		if err != nil {
+			return err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		acquired = inserted > 0
+		return nil
+	})
+
+	return acquired, err
+}
+
+// RenewLease extends the named lease held by holder to expire after ttl. It returns false if holder doesn't hold the
+// lease (anymore), or if its lease has already expired, in which case the holder must not assume it held the lease
+// in the meantime and should try to acquire it again.
+func (ss *SQLStore) RenewLease(ctx context.Context, name string, ttl time.Duration, holder string) (bool, error) {
+	now := TimeNow()
+	renewed := false
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		affected, err := sess.Table("lease").
+			Where("name = ? AND holder = ? AND expires >= ?", name, holder, now.UnixMilli()).
+			Incr("version").
+			Update(map[string]interface{}{"expires": now.Add(ttl).UnixMilli()})
+		renewed = affected > 0
+		return err
+	})
+
+	return renewed, err
+}
+
+// ReleaseLease releases the named lease if it's held by holder, so that other contenders can acquire it right away.
+func (ss *SQLStore) ReleaseLease(ctx context.Context, name string, holder string) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM lease WHERE name = ? AND holder = ?", name, holder)
+		return err
+	})
+}
@@ -0,0 +1,157 @@
+package sqlstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationLease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	ctx := context.Background()
+
+	getLease := func(t *testing.T, name string) lease {
+		t.Helper()
+		var l lease
+		err := db.WithDbSession(ctx, func(sess *DBSession) error {
+			has, err := sess.Where("name = ?", name).Get(&l)
+			require.True(t, has)
+			return err
+		})
+		require.NoError(t, err)
+		return l
+	}
+
+	t.Run("only one of two contenders acquires the lease", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]bool, 2)
+		for i, holder := range []string{"node-1", "node-2"} {
+			i, holder := i, holder
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				acquired, err := db.AcquireLease(ctx, "contended", time.Minute, holder)
+				require.NoError(t, err)
+				results[i] = acquired
+			}()
+		}
+		wg.Wait()
+
+		require.ElementsMatch(t, []bool{true, false}, results)
+
+		holder := "node-1"
+		if results[1] {
+			holder = "node-2"
+		}
+		require.Equal(t, holder, getLease(t, "contended").Holder)
+	})
+
+	t.Run("the holder can acquire its lease again, others can't", func(t *testing.T) {
+		acquired, err := db.AcquireLease(ctx, "held", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, err = db.AcquireLease(ctx, "held", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, err = db.AcquireLease(ctx, "held", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.False(t, acquired)
+	})
+
+	t.Run("a lease held by others doesn't fail the transaction it's acquired in", func(t *testing.T) {
+		acquired, err := db.AcquireLease(ctx, "in-transaction", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		err = db.InTransaction(ctx, func(ctx context.Context) error {
+			acquired, err := db.AcquireLease(ctx, "in-transaction", time.Minute, "node-2")
+			require.NoError(t, err)
+			require.False(t, acquired)
+			// the transaction can go on
+			return db.WithDbSession(ctx, func(sess *DBSession) error {
+				_, err := sess.Insert(&lease{Name: "after-contention", Holder: "node-2", Version: 1, Expires: time.Now().Add(time.Minute).UnixMilli()})
+				return err
+			})
+		})
+		require.NoError(t, err)
+		require.Equal(t, "node-1", getLease(t, "in-transaction").Holder)
+		require.Equal(t, "node-2", getLease(t, "after-contention").Holder)
+	})
+
+	t.Run("renewal extends the lease of its holder only", func(t *testing.T) {
+		t.Cleanup(ResetTimeNow)
+		start := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+		MockTimeNow(start)
+
+		acquired, err := db.AcquireLease(ctx, "renewed", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		MockTimeNow(start.Add(30 * time.Second))
+		renewed, err := db.RenewLease(ctx, "renewed", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, renewed)
+		require.Equal(t, start.Add(90*time.Second).UnixMilli(), getLease(t, "renewed").Expires)
+
+		renewed, err = db.RenewLease(ctx, "renewed", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.False(t, renewed)
+
+		// the original expiry has passed, but the lease was extended
+		MockTimeNow(start.Add(80 * time.Second))
+		acquired, err = db.AcquireLease(ctx, "renewed", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.False(t, acquired)
+	})
+
+	t.Run("an expired lease is taken over only after the clock skew tolerance", func(t *testing.T) {
+		t.Cleanup(ResetTimeNow)
+		start := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+		MockTimeNow(start)
+
+		acquired, err := db.AcquireLease(ctx, "expired", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		MockTimeNow(start.Add(time.Minute + leaseClockSkewTolerance/2))
+		acquired, err = db.AcquireLease(ctx, "expired", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		// the holder can't renew an expired lease
+		renewed, err := db.RenewLease(ctx, "expired", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.False(t, renewed)
+
+		MockTimeNow(start.Add(time.Minute + 2*leaseClockSkewTolerance))
+		acquired, err = db.AcquireLease(ctx, "expired", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.True(t, acquired)
+		require.Equal(t, "node-2", getLease(t, "expired").Holder)
+	})
+
+	t.Run("a released lease can be acquired by others", func(t *testing.T) {
+		acquired, err := db.AcquireLease(ctx, "released", time.Minute, "node-1")
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		// releasing somebody else's lease has no effect
+		require.NoError(t, db.ReleaseLease(ctx, "released", "node-2"))
+		acquired, err = db.AcquireLease(ctx, "released", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		require.NoError(t, db.ReleaseLease(ctx, "released", "node-1"))
+		acquired, err = db.AcquireLease(ctx, "released", time.Minute, "node-2")
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+}
@@ -0,0 +1,84 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+var ErrListenNotifyNotSupported = errors.New("LISTEN/NOTIFY is only supported on Postgres")
+
+var listenerLogger = log.New("sqlstore.listener")
+
+const (
+	listenerMinReconnectInterval = 10 * time.Second
+	listenerMaxReconnectInterval = time.Minute
+	// listenerPingInterval is how often an idle listener checks its connection
+	listenerPingInterval = 90 * time.Second
+)
+
+// NotifyAfterCommit sends a notification with the payload on the channel to the listeners of every instance sharing
+// the database. If the context has a transaction, the notification is only sent once it's committed, and not at all if
+// it's rolled back, since Postgres delivers the notifications of a transaction on commit. Otherwise it's sent right
+// away. On other databases it does nothing, so that callers can notify unconditionally and rely on polling there.
+func (ss *SQLStore) NotifyAfterCommit(ctx context.Context, channel string, payload string) error {
+	if ss.Dialect.DriverName() != migrator.Postgres {
+		return nil
+	}
+
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("SELECT pg_notify(?, ?)", channel, payload)
+		return err
+	})
+}
+
+// Listen calls the handler with the payload of each notification sent on the channel until the context is done.
+// The notifications are received on a dedicated connection, which is reconnected if it's lost. Notifications sent
+// while it's reconnecting are lost, so listeners should resync their state after errors are logged.
+// It returns ErrListenNotifyNotSupported on databases other than Postgres.
+func (ss *SQLStore) Listen(ctx context.Context, channel string, handler func(payload string)) error {
+	if ss.Dialect.DriverName() != migrator.Postgres {
+		return ErrListenNotifyNotSupported
+	}
+
+	ctxLogger := listenerLogger.FromContext(ctx)
+	listener := pq.NewListener(ss.engine.DataSourceName(), listenerMinReconnectInterval, listenerMaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			ctxLogger.Warn("Database listener connection failed", "channel", channel, "event", event, "error", err)
+		}
+	})
+	defer func() {
+		if err := listener.Close(); err != nil {
+			ctxLogger.Warn("Failed to close database listener", "channel", channel, "error", err)
+		}
+	}()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-listener.Notify:
+			// a nil notification signals that the connection was re-established
+			if notification != nil {
+				handler(notification.Extra)
+			}
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					ctxLogger.Warn("Database listener ping failed", "channel", channel, "error", err)
+				}
+			}()
+		}
+	}
+}
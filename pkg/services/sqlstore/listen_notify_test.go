@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationListenNotify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+
+	t.Run("is not supported on other databases than Postgres", func(t *testing.T) {
+		if IsTestDbPostgres() {
+			t.Skip("skipping test, LISTEN/NOTIFY is supported on Postgres")
+		}
+
+		err := db.Listen(context.Background(), "events", func(payload string) {})
+		require.ErrorIs(t, err, ErrListenNotifyNotSupported)
+
+		require.NoError(t, db.NotifyAfterCommit(context.Background(), "events", "ignored"))
+	})
+
+	t.Run("delivers the notifications of committed transactions to the listener", func(t *testing.T) {
+		if !IsTestDbPostgres() {
+			t.Skip("skipping test, LISTEN/NOTIFY is only supported on Postgres")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		received := make(chan string, 10)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- db.Listen(ctx, "events", func(payload string) {
+				received <- payload
+			})
+		}()
+		t.Cleanup(func() {
+			cancel()
+			require.ErrorIs(t, <-errCh, context.Canceled)
+		})
+
+		// the listener doesn't signal when it's listening, so notify until it receives something
+		require.Eventually(t, func() bool {
+			require.NoError(t, db.NotifyAfterCommit(context.Background(), "events", "ready"))
+			select {
+			case <-received:
+				return true
+			case <-time.After(100 * time.Millisecond):
+				return false
+			}
+		}, 10*time.Second, 10*time.Millisecond)
+		// drain the notifications sent while waiting for the listener
+		time.Sleep(200 * time.Millisecond)
+		for len(received) > 0 {
+			<-received
+		}
+
+		errRollback := errors.New("roll back")
+		err := db.InTransaction(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, db.NotifyAfterCommit(ctx, "events", "rolled back"))
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		err = db.InTransaction(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, db.NotifyAfterCommit(ctx, "events", "committed"))
+			select {
+			case payload := <-received:
+				require.FailNow(t, "received a notification before the commit", payload)
+			case <-time.After(100 * time.Millisecond):
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		select {
+		case payload := <-received:
+			require.Equal(t, "committed", payload)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "did not receive the notification")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+var ErrFetchAndLockRequiresTransaction = errors.New("fetch and lock requires an open transaction")
+
+// FetchAndLock selects up to limit rows matching the condition into rowsSlicePtr and locks them until the
+// surrounding transaction ends. On Postgres and MySQL 8 rows that are already locked by another transaction
+// are skipped (SELECT ... FOR UPDATE SKIP LOCKED), which allows several workers to consume a queue-like table
+// concurrently without picking up the same rows. Older MySQL servers can't skip locked rows, so the rows are locked
+// with SELECT ... FOR UPDATE and concurrent fetchers wait for each other instead.
+// SQLite has no row-level locks, so the database write lock is taken instead; concurrent fetchers are
+// serialized and should mark or delete the fetched rows before committing.
+func (sess *DBSession) FetchAndLock(rowsSlicePtr interface{}, limit int, cond string, args ...interface{}) error {
+	if !sess.transactionOpen {
+		return ErrFetchAndLockRequiresTransaction
+	}
+
+	table, err := tableNameForSlice(sess, rowsSlicePtr)
+	if err != nil {
+		return err
+	}
+
+	rawSQL := "SELECT * FROM " + dialect.Quote(table)
+	if cond != "" {
+		rawSQL += " WHERE " + cond
+	}
+	if limit > 0 {
+		rawSQL += dialect.Limit(int64(limit))
+	}
+
+	switch dialect.DriverName() {
+	case migrator.SQLite:
+		// a write statement (even one not matching any rows) upgrades the transaction to hold the write lock
+		if _, err := sess.Exec("DELETE FROM " + dialect.Quote(table) + " WHERE 1 = 0"); err != nil {
+			return fmt.Errorf("failed to acquire write lock: %w", err)
+		}
+	default:
+		rawSQL += dialect.RowLockSQL()
+	}
+
+	return sess.SQL(rawSQL, args...).Find(rowsSlicePtr)
+}
+
+func tableNameForSlice(sess *DBSession, rowsSlicePtr interface{}) (string, error) {
+	t := reflect.TypeOf(rowsSlicePtr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return "", fmt.Errorf("need a pointer to a slice of beans, got %T", rowsSlicePtr)
+	}
+	t = t.Elem().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return sess.DB().Mapper.Obj2Table(t.Name()), nil
+}
@@ -0,0 +1,85 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type lockTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Value string `xorm:"varchar(10)"`
+}
+
+func TestIntegrationFetchAndLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(lockTestItem))
+	require.NoError(t, err)
+
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM lock_test_item"); err != nil {
+			return err
+		}
+		_, err := sess.BulkInsert(lockTestItem{}, make([]lockTestItem, 10), NativeSettingsForDialect(db.GetDialect()))
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("fails outside of a transaction", func(t *testing.T) {
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var items []lockTestItem
+			return sess.FetchAndLock(&items, 5, "")
+		})
+		require.ErrorIs(t, err, ErrFetchAndLockRequiresTransaction)
+	})
+
+	t.Run("fetches at most limit rows matching the condition", func(t *testing.T) {
+		var items []lockTestItem
+		err := db.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			return sess.FetchAndLock(&items, 3, "id > ?", 0)
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+	})
+
+	t.Run("concurrent fetchers do not get the same rows", func(t *testing.T) {
+		if !IsTestDbPostgres() && !IsTestDbMySQL() {
+			t.Skip("skipping test, row-level locks are only supported on Postgres and MySQL")
+		}
+
+		var first, second []lockTestItem
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		errCh := make(chan error)
+		go func() {
+			errCh <- db.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+				err := sess.FetchAndLock(&first, 5, "")
+				close(locked)
+				<-release
+				return err
+			})
+		}()
+
+		<-locked
+		err := db.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			return sess.FetchAndLock(&second, 5, "")
+		})
+		close(release)
+		require.NoError(t, err)
+		require.NoError(t, <-errCh)
+
+		require.Len(t, first, 5)
+		require.Len(t, second, 5)
+		seen := make(map[int64]bool)
+		for _, item := range first {
+			seen[item.ID] = true
+		}
+		for _, item := range second {
+			require.False(t, seen[item.ID], "row %d was fetched by both transactions", item.ID)
+		}
+	})
+}
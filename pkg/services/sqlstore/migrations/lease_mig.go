@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addLeaseMigrations(mg *migrator.Migrator) {
+	lease := migrator.Table{
+		Name: "lease",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "holder", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "expires", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"name"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create lease table", migrator.NewAddTableMigration(lease))
+
+	mg.AddMigration("add unique index lease.name", migrator.NewAddIndexMigration(lease, lease.Indices[0]))
+}
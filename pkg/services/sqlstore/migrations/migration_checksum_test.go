@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestMigrationChecksums(t *testing.T) {
+	// use a separate in-memory database, the shared one is expected to be empty by TestMigrations
+	x, err := xorm.NewEngine(sqlutil.SQLite3TestDB().DriverName, "file:migration_checksum_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+
+	newMigrator := func(sql ...string) *Migrator {
+		mg := NewMigrator(x, &setting.Cfg{})
+		addMigrationLogMigrations(mg)
+		ids := []string{"checksum test migration 1", "checksum test migration 2", "checksum test migration 3"}
+		for i, s := range sql {
+			mg.AddMigration(ids[i], NewRawSQLMigration(s))
+		}
+		return mg
+	}
+
+	mg := newMigrator("SELECT 1;", "SELECT 2;")
+	require.NoError(t, mg.Start(false, 0))
+
+	checksums, err := mg.GetMigrationChecksums(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Checksum(NewRawSQLMigration("SELECT 1;"), mg.Dialect), checksums["checksum test migration 1"])
+	require.Equal(t, Checksum(NewRawSQLMigration("SELECT 2;"), mg.Dialect), checksums["checksum test migration 2"])
+	require.Contains(t, checksums, "create migration_log table")
+
+	t.Run("unchanged migrations have no mismatches", func(t *testing.T) {
+		mg := newMigrator("SELECT 1;", "SELECT 2;")
+		mismatches, err := mg.VerifyChecksums(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, mismatches)
+
+		mg.ChecksumVerification = true
+		require.NoError(t, mg.Start(false, 0))
+	})
+
+	t.Run("new migrations are not reported and get recorded once applied", func(t *testing.T) {
+		mg := newMigrator("SELECT 1;", "SELECT 2;", "SELECT 3;")
+		mismatches, err := mg.VerifyChecksums(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, mismatches)
+
+		mg.ChecksumVerification = true
+		require.NoError(t, mg.Start(false, 0))
+
+		checksums, err := mg.GetMigrationChecksums(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, Checksum(NewRawSQLMigration("SELECT 3;"), mg.Dialect), checksums["checksum test migration 3"])
+	})
+
+	t.Run("changed migrations are reported", func(t *testing.T) {
+		mg := newMigrator("SELECT 1;", "SELECT 42;", "SELECT 3;")
+		mismatches, err := mg.VerifyChecksums(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []ChecksumMismatch{{
+			MigrationID: "checksum test migration 2",
+			Recorded:    Checksum(NewRawSQLMigration("SELECT 2;"), mg.Dialect),
+			Current:     Checksum(NewRawSQLMigration("SELECT 42;"), mg.Dialect),
+		}}, mismatches)
+
+		t.Run("and the migrator refuses to run if verification is enabled", func(t *testing.T) {
+			mg.ChecksumVerification = true
+			err := mg.Start(false, 0)
+			require.ErrorIs(t, err, ErrMigrationChecksumMismatch)
+		})
+
+		t.Run("but runs if verification is disabled", func(t *testing.T) {
+			mg.ChecksumVerification = false
+			require.NoError(t, mg.Start(false, 0))
+		})
+	})
+}
@@ -107,6 +107,8 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 
 	AddExternalAlertmanagerToDatasourceMigration(mg)
 
+	addLeaseMigrations(mg)
+
 	// TODO: This migration will be enabled later in the nested folder feature
 	// implementation process. It is on hold so we can continue working on the
 	// store implementation without impacting any grafana instances built off
@@ -129,6 +131,16 @@ func addMigrationLogMigrations(mg *Migrator) {
 	}
 
 	mg.AddMigration("create migration_log table", NewAddTableMigration(migrationLogV1))
+
+	migrationChecksumV1 := Table{
+		Name: "migration_checksum",
+		Columns: []*Column{
+			{Name: "migration_id", Type: DB_NVarchar, Length: 255, IsPrimaryKey: true},
+			{Name: "checksum", Type: DB_NVarchar, Length: 64, Nullable: false},
+		},
+	}
+
+	mg.AddMigration("create migration_checksum table", NewAddTableMigration(migrationChecksumV1))
 }
 
 func addStarMigrations(mg *Migrator) {
@@ -0,0 +1,134 @@
+package migrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+var ErrMigrationChecksumMismatch = errors.New("migration checksum mismatch")
+
+// MigrationChecksum is the checksum of the SQL of an applied migration as it was when the migration was recorded.
+type MigrationChecksum struct {
+	MigrationID string `xorm:"pk 'migration_id'"`
+	Checksum    string
+}
+
+// ChecksumMismatch describes an applied migration whose SQL has changed since it was recorded.
+type ChecksumMismatch struct {
+	MigrationID string
+	Recorded    string
+	Current     string
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the migration's SQL for the given dialect.
+func Checksum(m Migration, dialect Dialect) string {
+	sum := sha256.Sum256([]byte(m.SQL(dialect)))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetMigrationChecksums returns the recorded checksums by migration id.
+// If the migration_checksum table doesn't exist yet, an empty map is returned.
+func (mg *Migrator) GetMigrationChecksums(ctx context.Context) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	exists, err := mg.DBEngine.IsTableExist(new(MigrationChecksum))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to check table existence", err)
+	}
+	if !exists {
+		return checksums, nil
+	}
+
+	items := make([]MigrationChecksum, 0)
+	if err := mg.DBEngine.Context(ctx).Find(&items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		checksums[item.MigrationID] = item.Checksum
+	}
+
+	return checksums, nil
+}
+
+// VerifyChecksums compares the checksums of the registered migrations with the recorded ones and returns the
+// migrations whose SQL has changed since they were applied. Migrations without a recorded checksum are ignored.
+func (mg *Migrator) VerifyChecksums(ctx context.Context) ([]ChecksumMismatch, error) {
+	checksums, err := mg.GetMigrationChecksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []ChecksumMismatch
+	for _, m := range mg.migrations {
+		recorded, ok := checksums[m.Id()]
+		if !ok {
+			continue
+		}
+		if current := Checksum(m, mg.Dialect); current != recorded {
+			mismatches = append(mismatches, ChecksumMismatch{MigrationID: m.Id(), Recorded: recorded, Current: current})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// recordChecksums records the checksum of every successfully applied migration that doesn't have one yet.
+// This includes migrations that were applied before checksums were recorded.
+func (mg *Migrator) recordChecksums(ctx context.Context) error {
+	exists, err := mg.DBEngine.IsTableExist(new(MigrationChecksum))
+	if err != nil {
+		return fmt.Errorf("%v: %w", "failed to check table existence", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return err
+	}
+	checksums, err := mg.GetMigrationChecksums(ctx)
+	if err != nil {
+		return err
+	}
+
+	missing := make([]MigrationChecksum, 0)
+	for _, m := range mg.migrations {
+		if _, applied := logMap[m.Id()]; !applied {
+			continue
+		}
+		if _, recorded := checksums[m.Id()]; recorded {
+			continue
+		}
+		missing = append(missing, MigrationChecksum{MigrationID: m.Id(), Checksum: Checksum(m, mg.Dialect)})
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	mg.Logger.Debug("Recording migration checksums", "count", len(missing))
+	return mg.InTransaction(func(sess *xorm.Session) error {
+		for i := range missing {
+			if _, err := sess.Insert(&missing[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func checksumMismatchError(mismatches []ChecksumMismatch) error {
+	ids := make([]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		ids = append(ids, m.MigrationID)
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("%w: applied migrations have been modified: %s", ErrMigrationChecksumMismatch, strings.Join(ids, ", "))
+}
@@ -2,7 +2,9 @@ package migrator
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"xorm.io/xorm"
 )
@@ -10,6 +12,11 @@ import (
 var (
 	ErrLockDB        = fmt.Errorf("failed to obtain lock")
 	ErrReleaseLockDB = fmt.Errorf("failed to release lock")
+	// ErrSavepointsNotSupported is returned by the dialects of databases without savepoints
+	ErrSavepointsNotSupported = fmt.Errorf("savepoints are not supported by the database")
+	// ErrReplicationLagUnknown is returned by ReplicationLag if the database is a replica whose lag can't be told,
+	// e.g. because replication is stopped
+	ErrReplicationLagUnknown = fmt.Errorf("the replication lag of the database is unknown")
 )
 
 type Dialect interface {
@@ -27,6 +34,8 @@ type Dialect interface {
 	BooleanStr(bool) string
 	DateTimeFunc(string) string
 	BatchSize() int
+	// MaxPlaceholders is the maximum number of bound parameters a single statement can have
+	MaxPlaceholders() int
 
 	OrderBy(order string) string
 
@@ -51,12 +60,35 @@ type Dialect interface {
 	// UpsertSQL returns the upsert sql statement for a dialect
 	UpsertSQL(tableName string, keyCols, updateCols []string) string
 	UpsertMultipleSQL(tableName string, keyCols, updateCols []string, count int) (string, error)
+	// InsertIgnoreSQL returns a statement inserting a row into the table that inserts nothing, rather than failing,
+	// if the row violates a unique key
+	InsertIgnoreSQL(tableName string, cols []string) string
+	// FullOuterJoinSQL returns a statement selecting the columns from the full outer join of the left and right tables
+	FullOuterJoinSQL(columns []string, left, right, on string) string
+	// TimeBucketSQL returns an expression truncating the datetime column to the start of its time bucket, in unix seconds
+	TimeBucketSQL(column string, intervalSeconds int64) string
+	// UpdateFromValuesSQL returns a statement updating the columns of the rows with the keys to their values, and its args
+	UpdateFromValuesSQL(tableName, keyCol string, cols, types []string, keys []interface{}, values [][]interface{}) (string, []interface{})
+	// SavepointSQL returns the statements creating, releasing and rolling back to the savepoint, or an error if the
+	// database doesn't support savepoints or the name isn't valid for it
+	SavepointSQL(name string) (savepoint string, release string, rollbackTo string, err error)
+	// StatementTimeoutSQL returns the statement limiting the duration of the statements of the current transaction, or
+	// an empty string if the database can't time them out itself
+	StatementTimeoutSQL(timeout time.Duration) string
+	// ReplicationLag returns how far the database of the session lags behind the database it replicates, which is 0 if
+	// it isn't a replica, or ErrReplicationLagUnknown if it is but the lag can't be told
+	ReplicationLag(sess *xorm.Session) (time.Duration, error)
+	// TimeValue returns the value the time is stored as in a datetime column
+	TimeValue(t time.Time) interface{}
 
 	ColString(*Column) string
 	ColStringNoPk(*Column) string
 
 	Limit(limit int64) string
 	LimitOffset(limit int64, offset int64) string
+	// RowLockSQL returns the clause locking the selected rows until the end of the transaction, which skips the rows
+	// locked by other transactions if the database can
+	RowLockSQL() string
 
 	PreInsertId(table string, sess *xorm.Session) error
 	PostInsertId(table string, sess *xorm.Session) error
@@ -68,6 +100,12 @@ type Dialect interface {
 	IsUniqueConstraintViolation(err error) bool
 	ErrorMessage(err error) string
 	IsDeadlock(err error) bool
+	// IsSerializationFailure returns true if the transaction was aborted because it couldn't be serialized
+	// with concurrent transactions
+	IsSerializationFailure(err error) bool
+	// IsLockError returns true if the statement failed on a lock held by a concurrent transaction, which is safe to
+	// retry once the lock is released
+	IsLockError(err error) bool
 	Lock(LockCfg) error
 	Unlock(LockCfg) error
 }
@@ -290,6 +328,11 @@ func (b *BaseDialect) Limit(limit int64) string {
 	return fmt.Sprintf(" LIMIT %d", limit)
 }
 
+// RowLockSQL returns FOR UPDATE SKIP LOCKED
+func (b *BaseDialect) RowLockSQL() string {
+	return " FOR UPDATE SKIP LOCKED"
+}
+
 func (b *BaseDialect) LimitOffset(limit int64, offset int64) string {
 	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
 }
@@ -319,6 +362,92 @@ func (b *BaseDialect) UpsertSQL(tableName string, keyCols, updateCols []string)
 	return ""
 }
 
+// InsertIgnoreSQL returns a statement inserting a row of the columns into the table with ON CONFLICT DO NOTHING, so
+// that a row violating a unique key is skipped, and rows affected is 0, without aborting the transaction it's run in.
+func (b *BaseDialect) InsertIgnoreSQL(tableName string, cols []string) string {
+	return fmt.Sprintf("INSERT INTO %s %s ON CONFLICT DO NOTHING", b.dialect.Quote(tableName), insertColumnsSQL(b.dialect, cols))
+}
+
+// insertColumnsSQL returns the quoted columns of an insert followed by a VALUES clause with a placeholder per column
+func insertColumnsSQL(dialect Dialect, cols []string) string {
+	quoted := make([]string, 0, len(cols))
+	for _, col := range cols {
+		quoted = append(quoted, dialect.Quote(col))
+	}
+	return fmt.Sprintf("(%s) VALUES (%s)", strings.Join(quoted, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "))
+}
+
+// FullOuterJoinSQL returns a statement selecting the columns from the full outer join of the left and right tables
+// on the join condition. The tables can be aliased, e.g. "dashboard d", and the condition and columns should refer to
+// their columns through the aliases. The condition must not contain placeholders as it can be repeated in the
+// statement. To filter or order the result, use the statement as a subquery.
+func (b *BaseDialect) FullOuterJoinSQL(columns []string, left, right, on string) string {
+	return fmt.Sprintf("SELECT %s FROM %s FULL OUTER JOIN %s ON %s", strings.Join(columns, ", "), left, right, on)
+}
+
+// UpdateFromValuesSQL returns a statement updating the columns of the rows of the table whose key column is one of the
+// keys to the values at the same index, and its args. Each value is a slice with an element per column. The new value
+// of each column is picked with a CASE on the key column, so that all rows are updated by a single statement.
+// The types of the key column followed by those of the columns are only needed by dialects which cast the values.
+func (b *BaseDialect) UpdateFromValuesSQL(tableName, keyCol string, cols, types []string, keys []interface{}, values [][]interface{}) (string, []interface{}) {
+	quotedKey := b.dialect.Quote(keyCol)
+	args := make([]interface{}, 0, len(keys)*(2*len(cols)+1))
+
+	sets := make([]string, 0, len(cols))
+	for i, col := range cols {
+		set := strings.Builder{}
+		set.WriteString(fmt.Sprintf("%s = CASE %s", b.dialect.Quote(col), quotedKey))
+		for j, key := range keys {
+			set.WriteString(" WHEN ? THEN ?")
+			args = append(args, key, values[j][i])
+		}
+		set.WriteString(" END")
+		sets = append(sets, set.String())
+	}
+
+	placeholders := make([]string, 0, len(keys))
+	for _, key := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, key)
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
+		b.dialect.Quote(tableName), strings.Join(sets, ", "), quotedKey, strings.Join(placeholders, ", ")), args
+}
+
+// savepointNamePattern matches the savepoint names that are identifiers on every database
+var savepointNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SavepointSQL returns ErrSavepointsNotSupported, dialects of databases with savepoints override it
+func (b *BaseDialect) SavepointSQL(name string) (string, string, string, error) {
+	return "", "", "", ErrSavepointsNotSupported
+}
+
+// standardSavepointSQL returns the SQL standard savepoint statements, if the name is an identifier of at most
+// maxLength characters. Names can't be bound as parameters, so they're validated rather than escaped.
+func standardSavepointSQL(d Dialect, name string, maxLength int) (string, string, string, error) {
+	if !savepointNamePattern.MatchString(name) {
+		return "", "", "", fmt.Errorf("invalid savepoint name %q: it must start with a letter or an underscore, followed by letters, digits or underscores", name)
+	}
+	if maxLength > 0 && len(name) > maxLength {
+		return "", "", "", fmt.Errorf("invalid savepoint name %q: it must not be longer than %d characters", name, maxLength)
+	}
+
+	quoted := d.Quote(name)
+	return "SAVEPOINT " + quoted, "RELEASE SAVEPOINT " + quoted, "ROLLBACK TO SAVEPOINT " + quoted, nil
+}
+
+// StatementTimeoutSQL returns an empty string, dialects of databases that can time out the statements of a transaction
+// override it
+func (b *BaseDialect) StatementTimeoutSQL(timeout time.Duration) string {
+	return ""
+}
+
+// ReplicationLag returns 0, dialects of databases that can be replicas override it
+func (b *BaseDialect) ReplicationLag(sess *xorm.Session) (time.Duration, error) {
+	return 0, nil
+}
+
 func (b *BaseDialect) Lock(_ LockCfg) error {
 	return nil
 }
@@ -330,3 +459,10 @@ func (b *BaseDialect) Unlock(_ LockCfg) error {
 func (b *BaseDialect) OrderBy(order string) string {
 	return order
 }
+
+// TimeValue returns the value the time is stored as in a datetime column: the time in UTC, truncated to whole seconds
+// since datetime columns don't keep fractional seconds on every database. The columns don't keep the time zone either,
+// so times in other zones would be stored as if they were in UTC.
+func (b *BaseDialect) TimeValue(t time.Time) interface{} {
+	return t.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,24 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullOuterJoinSQL(t *testing.T) {
+	columns := []string{"l.id AS left_id", "r.id AS right_id"}
+
+	for _, db := range []Dialect{&PostgresDialect{}, &SQLite3{}} {
+		require.Equal(t,
+			"SELECT l.id AS left_id, r.id AS right_id FROM left_table l FULL OUTER JOIN right_table r ON l.key = r.key",
+			db.FullOuterJoinSQL(columns, "left_table l", "right_table r", "l.key = r.key"))
+	}
+
+	db := &MySQLDialect{}
+	require.Equal(t,
+		"SELECT l.id AS left_id, r.id AS right_id FROM left_table l LEFT JOIN right_table r ON l.key = r.key "+
+			"UNION ALL SELECT l.id AS left_id, r.id AS right_id FROM left_table l RIGHT JOIN right_table r ON l.key = r.key "+
+			"WHERE NOT EXISTS (SELECT 1 FROM left_table l WHERE l.key = r.key)",
+		db.FullOuterJoinSQL(columns, "left_table l", "right_table r", "l.key = r.key"))
+}
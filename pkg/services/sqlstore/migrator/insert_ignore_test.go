@@ -0,0 +1,18 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertIgnoreSQL(t *testing.T) {
+	postgres := NewPostgresDialect(nil)
+	require.Equal(t, `INSERT INTO "lease" ("name", "holder") VALUES (?, ?) ON CONFLICT DO NOTHING`, postgres.InsertIgnoreSQL("lease", []string{"name", "holder"}))
+
+	sqlite := NewSQLite3Dialect(nil)
+	require.Equal(t, "INSERT INTO `lease` (`name`, `holder`) VALUES (?, ?) ON CONFLICT DO NOTHING", sqlite.InsertIgnoreSQL("lease", []string{"name", "holder"}))
+
+	mysql := NewMysqlDialect(nil)
+	require.Equal(t, "INSERT IGNORE INTO `lease` (`name`, `holder`) VALUES (?, ?)", mysql.InsertIgnoreSQL("lease", []string{"name", "holder"}))
+}
@@ -1,6 +1,7 @@
 package migrator
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -27,6 +28,8 @@ type Migrator struct {
 	Logger       log.Logger
 	Cfg          *setting.Cfg
 	isLocked     atomic.Bool
+	// ChecksumVerification makes the migrator refuse to run if an applied migration has been modified.
+	ChecksumVerification bool
 }
 
 type MigrationLog struct {
@@ -133,6 +136,16 @@ func (mg *Migrator) run() (err error) {
 		return err
 	}
 
+	if mg.ChecksumVerification {
+		mismatches, err := mg.VerifyChecksums(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(mismatches) > 0 {
+			return checksumMismatchError(mismatches)
+		}
+	}
+
 	migrationsPerformed := 0
 	migrationsSkipped := 0
 	start := time.Now()
@@ -181,6 +194,10 @@ func (mg *Migrator) run() (err error) {
 
 	mg.Logger.Info("migrations completed", "performed", migrationsPerformed, "skipped", migrationsSkipped, "duration", time.Since(start))
 
+	if err := mg.recordChecksums(context.Background()); err != nil {
+		return fmt.Errorf("%v: %w", "failed to record migration checksums", err)
+	}
+
 	// Make sure migrations are synced
 	return mg.DBEngine.Sync2()
 }
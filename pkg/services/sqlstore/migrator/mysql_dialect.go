@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/VividCortex/mysqlerr"
 	"github.com/go-sql-driver/mysql"
@@ -15,6 +17,9 @@ import (
 
 type MySQLDialect struct {
 	BaseDialect
+	// skipLocked is whether the server supports SKIP LOCKED, which is checked once it's needed
+	skipLocked     bool
+	skipLockedOnce sync.Once
 }
 
 func NewMysqlDialect(engine *xorm.Engine) Dialect {
@@ -48,6 +53,105 @@ func (db *MySQLDialect) BatchSize() int {
 	return 1000
 }
 
+func (db *MySQLDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+// RowLockSQL returns FOR UPDATE SKIP LOCKED if the server supports it, which MySQL does since 8.0.1 and MariaDB since
+// 10.6, or FOR UPDATE otherwise, in which case the rows locked by other transactions are waited for rather than
+// skipped. The version of the server is read once, and if it can't be read the rows are locked with FOR UPDATE.
+func (db *MySQLDialect) RowLockSQL() string {
+	db.skipLockedOnce.Do(func() {
+		if db.engine == nil {
+			return
+		}
+		var version string
+		if _, err := db.engine.SQL("SELECT VERSION()").Get(&version); err != nil {
+			return
+		}
+		db.skipLocked = mysqlSupportsSkipLocked(version)
+	})
+
+	if db.skipLocked {
+		return " FOR UPDATE SKIP LOCKED"
+	}
+	return " FOR UPDATE"
+}
+
+// mysqlSupportsSkipLocked returns true if the server of the version, e.g. 8.0.32 or 10.6.12-MariaDB, supports
+// SKIP LOCKED
+func mysqlSupportsSkipLocked(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 3 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	// the patch version may be followed by a suffix, e.g. -log or -MariaDB
+	digits := strings.IndexFunc(parts[2], func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(parts[2])
+	}
+	patch, err := strconv.Atoi(parts[2][:digits])
+	if err != nil {
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return major > 10 || (major == 10 && minor >= 6)
+	}
+	return major > 8 || (major == 8 && (minor > 0 || patch >= 1))
+}
+
+// SavepointSQL returns the savepoint statements, for names of at most 64 characters which is the limit of identifiers
+func (db *MySQLDialect) SavepointSQL(name string) (string, string, string, error) {
+	return standardSavepointSQL(db, name, 64)
+}
+
+// StatementTimeoutSQL returns an empty string, since max_execution_time can only be set for the session, which would
+// outlive the transaction on the pooled connection, and only applies to SELECT statements. The driver closes the
+// connection of a statement whose context is done instead.
+func (db *MySQLDialect) StatementTimeoutSQL(timeout time.Duration) string {
+	return ""
+}
+
+// ReplicationLag returns the lag in the replica status of a replica, which is unknown if the replica isn't replicating.
+// A database without replica status isn't a replica. Servers that predate SHOW REPLICA STATUS, i.e. MySQL before
+// 8.0.22 and MariaDB before 10.5.1, are asked with SHOW SLAVE STATUS instead.
+func (db *MySQLDialect) ReplicationLag(sess *xorm.Session) (time.Duration, error) {
+	status, err := sess.QueryString("SHOW REPLICA STATUS")
+	if db.isThisError(err, mysqlerr.ER_PARSE_ERROR) {
+		status, err = sess.QueryString("SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(status) == 0 {
+		return 0, nil
+	}
+	return mysqlReplicationLag(status[0])
+}
+
+// mysqlReplicationLag returns the lag in the replica status, which is Seconds_Behind_Source on MySQL 8.0.22 and later,
+// and Seconds_Behind_Master before that and on MariaDB
+func mysqlReplicationLag(status map[string]string) (time.Duration, error) {
+	behind, ok := status["Seconds_Behind_Source"]
+	if !ok {
+		behind = status["Seconds_Behind_Master"]
+	}
+	seconds, err := strconv.ParseInt(behind, 10, 64)
+	if err != nil {
+		return 0, ErrReplicationLagUnknown
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
 func (db *MySQLDialect) SQLType(c *Column) string {
 	var res string
 	switch c.Type {
@@ -171,7 +275,7 @@ func (db *MySQLDialect) TruncateDBTables() error {
 
 	for _, table := range tables {
 		switch table.Name {
-		case "migration_log":
+		case "migration_log", "migration_checksum":
 			continue
 		case "dashboard_acl":
 			// keep default dashboard permissions
@@ -218,6 +322,30 @@ func (db *MySQLDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, mysqlerr.ER_LOCK_DEADLOCK)
 }
 
+// IsSerializationFailure returns false since InnoDB reports serialization conflicts as deadlocks
+func (db *MySQLDialect) IsSerializationFailure(err error) bool {
+	return false
+}
+
+// IsLockError returns true for deadlocks and lock wait timeouts
+func (db *MySQLDialect) IsLockError(err error) bool {
+	return db.isThisError(err, mysqlerr.ER_LOCK_DEADLOCK) || db.isThisError(err, mysqlerr.ER_LOCK_WAIT_TIMEOUT)
+}
+
+// FullOuterJoinSQL emulates a full outer join, which MySQL doesn't support, by appending the rows of the right table
+// without a match in the left table to the result of the left join
+func (db *MySQLDialect) FullOuterJoinSQL(columns []string, left, right, on string) string {
+	cols := strings.Join(columns, ", ")
+	return fmt.Sprintf("SELECT %s FROM %s LEFT JOIN %s ON %s UNION ALL SELECT %s FROM %s RIGHT JOIN %s ON %s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+		cols, left, right, on, cols, left, right, on, left, on)
+}
+
+// InsertIgnoreSQL returns a statement inserting a row of the columns into the table with INSERT IGNORE, since MySQL
+// has no ON CONFLICT DO NOTHING
+func (db *MySQLDialect) InsertIgnoreSQL(tableName string, cols []string) string {
+	return fmt.Sprintf("INSERT IGNORE INTO %s %s", db.Quote(tableName), insertColumnsSQL(db, cols))
+}
+
 // UpsertSQL returns the upsert sql statement for MySQL dialect
 func (db *MySQLDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	q, _ := db.UpsertMultipleSQL(tableName, keyCols, updateCols, 1)
@@ -323,3 +451,9 @@ func (db *MySQLDialect) getLockName() (string, error) {
 
 	return s, nil
 }
+
+// TimeBucketSQL returns an expression truncating the datetime column to the start of its time bucket, in unix seconds.
+// TIMESTAMPDIFF is used instead of UNIX_TIMESTAMP since the latter interprets the value in the session time zone.
+func (db *MySQLDialect) TimeBucketSQL(column string, intervalSeconds int64) string {
+	return fmt.Sprintf("(TIMESTAMPDIFF(SECOND, '1970-01-01 00:00:00', %s) DIV %d) * %d", column, intervalSeconds, intervalSeconds)
+}
@@ -1,11 +1,13 @@
 package migrator
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/lib/pq"
@@ -49,6 +51,43 @@ func (db *PostgresDialect) BatchSize() int {
 	return 1000
 }
 
+func (db *PostgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+// SavepointSQL returns the savepoint statements, for names of at most 63 characters since longer identifiers are
+// truncated, which could make distinct savepoints collide
+func (db *PostgresDialect) SavepointSQL(name string) (string, string, string, error) {
+	return standardSavepointSQL(db, name, 63)
+}
+
+// StatementTimeoutSQL returns the statement setting the statement_timeout of the transaction, which is reset when it
+// ends, in milliseconds and at least 1 since 0 disables the timeout
+func (db *PostgresDialect) StatementTimeoutSQL(timeout time.Duration) string {
+	ms := timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)
+}
+
+// ReplicationLag returns the time since the last transaction replayed by a standby, unless it has replayed all the WAL
+// it received, in which case it's caught up. The lag is unknown if the standby hasn't replayed any transaction yet.
+func (db *PostgresDialect) ReplicationLag(sess *xorm.Session) (time.Duration, error) {
+	var lag sql.NullFloat64
+	_, err := sess.SQL(`SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END`).Get(&lag)
+	if err != nil {
+		return 0, err
+	}
+	if !lag.Valid {
+		return 0, ErrReplicationLagUnknown
+	}
+	return time.Duration(lag.Float64 * float64(time.Second)), nil
+}
+
 func (db *PostgresDialect) Default(col *Column) string {
 	if col.Type == DB_Bool {
 		if col.Default == "0" {
@@ -160,7 +199,7 @@ func (db *PostgresDialect) TruncateDBTables() error {
 		switch table.Name {
 		case "":
 			continue
-		case "migration_log":
+		case "migration_log", "migration_checksum":
 			continue
 		case "dashboard_acl":
 			// keep default dashboard permissions
@@ -214,6 +253,15 @@ func (db *PostgresDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, "40P01")
 }
 
+func (db *PostgresDialect) IsSerializationFailure(err error) bool {
+	return db.isThisError(err, "40001")
+}
+
+// IsLockError returns true for deadlocks and serialization failures
+func (db *PostgresDialect) IsLockError(err error) bool {
+	return db.IsDeadlock(err) || db.IsSerializationFailure(err)
+}
+
 func (db *PostgresDialect) PostInsertId(table string, sess *xorm.Session) error {
 	if table != "org" {
 		return nil
@@ -380,3 +428,43 @@ func (db *PostgresDialect) getLockKey() (string, error) {
 	}
 	return key, nil
 }
+
+// UpdateFromValuesSQL returns a statement updating the columns of the rows of the table whose key column is one of the
+// keys to the values at the same index, and its args, by joining the table with a VALUES list of the keys and values.
+// The placeholders of the first row are cast to the types of the columns, since Postgres would otherwise resolve the
+// columns of the list as text, and the other rows take on these types.
+func (db *PostgresDialect) UpdateFromValuesSQL(tableName, keyCol string, cols, types []string, keys []interface{}, values [][]interface{}) (string, []interface{}) {
+	args := make([]interface{}, 0, len(keys)*(len(cols)+1))
+
+	rows := make([]string, 0, len(keys))
+	for i, key := range keys {
+		placeholders := make([]string, 0, len(cols)+1)
+		for j := 0; j <= len(cols); j++ {
+			if i == 0 {
+				placeholders = append(placeholders, fmt.Sprintf("CAST(? AS %s)", types[j]))
+			} else {
+				placeholders = append(placeholders, "?")
+			}
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+		args = append(append(args, key), values[i]...)
+	}
+
+	quotedCols := make([]string, 0, len(cols)+1)
+	quotedCols = append(quotedCols, db.Quote(keyCol))
+	sets := make([]string, 0, len(cols))
+	for _, col := range cols {
+		quotedCols = append(quotedCols, db.Quote(col))
+		sets = append(sets, fmt.Sprintf("%s = v.%s", db.Quote(col), db.Quote(col)))
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s FROM (VALUES %s) AS v(%s) WHERE %s.%s = v.%s",
+		db.Quote(tableName), strings.Join(sets, ", "), strings.Join(rows, ", "), strings.Join(quotedCols, ", "),
+		db.Quote(tableName), db.Quote(keyCol), db.Quote(keyCol)), args
+}
+
+// TimeBucketSQL returns an expression truncating the datetime column to the start of its time bucket, in unix seconds.
+// The buckets are aligned to the unix epoch.
+func (db *PostgresDialect) TimeBucketSQL(column string, intervalSeconds int64) string {
+	return fmt.Sprintf("CAST(FLOOR(EXTRACT(EPOCH FROM %s) / %d) * %d AS BIGINT)", column, intervalSeconds, intervalSeconds)
+}
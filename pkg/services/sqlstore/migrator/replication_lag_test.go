@@ -0,0 +1,30 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMysqlReplicationLag(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		status map[string]string
+		lag    time.Duration
+		err    error
+	}{
+		{desc: "MySQL 8.0.22 and later", status: map[string]string{"Seconds_Behind_Source": "3"}, lag: 3 * time.Second},
+		{desc: "MySQL before 8.0.22 and MariaDB", status: map[string]string{"Seconds_Behind_Master": "5"}, lag: 5 * time.Second},
+		{desc: "replica that isn't replicating", status: map[string]string{"Seconds_Behind_Source": ""}, err: ErrReplicationLagUnknown},
+		{desc: "status without a lag", status: map[string]string{}, err: ErrReplicationLagUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			lag, err := mysqlReplicationLag(tc.status)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.lag, lag)
+		})
+	}
+}
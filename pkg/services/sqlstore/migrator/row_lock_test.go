@@ -0,0 +1,39 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowLockSQL(t *testing.T) {
+	require.Equal(t, " FOR UPDATE SKIP LOCKED", NewPostgresDialect(nil).RowLockSQL())
+	require.Empty(t, NewSQLite3Dialect(nil).RowLockSQL())
+	// without a server whose version can be read the rows are locked without skipping
+	require.Equal(t, " FOR UPDATE", NewMysqlDialect(nil).RowLockSQL())
+}
+
+func TestMysqlSupportsSkipLocked(t *testing.T) {
+	testCases := []struct {
+		version    string
+		skipLocked bool
+	}{
+		{version: "8.0.32", skipLocked: true},
+		{version: "8.0.1", skipLocked: true},
+		{version: "8.0.0-dmr", skipLocked: false},
+		{version: "8.1.0-commercial", skipLocked: true},
+		{version: "9.0.1", skipLocked: true},
+		{version: "5.7.40-log", skipLocked: false},
+		{version: "5.6.51", skipLocked: false},
+		{version: "10.6.12-MariaDB", skipLocked: true},
+		{version: "11.0.2-MariaDB-1:11.0.2+maria~ubu2204", skipLocked: true},
+		{version: "10.5.19-MariaDB-log", skipLocked: false},
+		{version: "unknown", skipLocked: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			require.Equal(t, tc.skipLocked, mysqlSupportsSkipLocked(tc.version))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavepointSQL(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		dialect    Dialect
+		savepoint  string
+		release    string
+		rollbackTo string
+	}{
+		{desc: "sqlite", dialect: NewSQLite3Dialect(nil), savepoint: "SAVEPOINT `sp_1`", release: "RELEASE SAVEPOINT `sp_1`", rollbackTo: "ROLLBACK TO SAVEPOINT `sp_1`"},
+		{desc: "mysql", dialect: NewMysqlDialect(nil), savepoint: "SAVEPOINT `sp_1`", release: "RELEASE SAVEPOINT `sp_1`", rollbackTo: "ROLLBACK TO SAVEPOINT `sp_1`"},
+		{desc: "postgres", dialect: NewPostgresDialect(nil), savepoint: `SAVEPOINT "sp_1"`, release: `RELEASE SAVEPOINT "sp_1"`, rollbackTo: `ROLLBACK TO SAVEPOINT "sp_1"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			savepoint, release, rollbackTo, err := tc.dialect.SavepointSQL("sp_1")
+			require.NoError(t, err)
+			require.Equal(t, tc.savepoint, savepoint)
+			require.Equal(t, tc.release, release)
+			require.Equal(t, tc.rollbackTo, rollbackTo)
+
+			for _, name := range []string{"", "1sp", "sp-1", "sp 1", "sp`; DROP TABLE user; --"} {
+				_, _, _, err := tc.dialect.SavepointSQL(name)
+				require.Error(t, err, name)
+			}
+		})
+	}
+
+	t.Run("limits the length of names to that of identifiers", func(t *testing.T) {
+		name := "sp_" + strings.Repeat("x", 61)
+
+		_, _, _, err := NewMysqlDialect(nil).SavepointSQL(name)
+		require.NoError(t, err)
+		_, _, _, err = NewPostgresDialect(nil).SavepointSQL(name)
+		require.Error(t, err)
+		_, _, _, err = NewMysqlDialect(nil).SavepointSQL(name + "x")
+		require.Error(t, err)
+		_, _, _, err = NewSQLite3Dialect(nil).SavepointSQL(name + "x")
+		require.NoError(t, err)
+	})
+
+	t.Run("isn't supported by default", func(t *testing.T) {
+		_, _, _, err := (&BaseDialect{}).SavepointSQL("sp_1")
+		require.ErrorIs(t, err, ErrSavepointsNotSupported)
+	})
+}
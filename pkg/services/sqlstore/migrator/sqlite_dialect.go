@@ -4,11 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mattn/go-sqlite3"
 	"xorm.io/xorm"
 )
 
+// sqliteDateTimeFormat is the format xorm writes datetime columns in on SQLite, which stores them as text
+const sqliteDateTimeFormat = "2006-01-02 15:04:05"
+
 type SQLite3 struct {
 	BaseDialect
 }
@@ -46,6 +50,23 @@ func (db *SQLite3) BatchSize() int {
 	return 10
 }
 
+// RowLockSQL returns an empty string, since SQLite has no row locks and locks the whole database instead
+func (db *SQLite3) RowLockSQL() string {
+	return ""
+}
+
+func (db *SQLite3) MaxPlaceholders() int {
+	// SQLITE_MAX_VARIABLE_NUMBER defaults to 999 before SQLite 3.32.0
+	return 999
+}
+
+// SavepointSQL returns the savepoint statements. SQLite doesn't limit the length of identifiers, but the names are
+// still restricted to identifiers, and savepoints must only be created in a transaction, since outside of one a
+// SAVEPOINT starts a transaction that RELEASE commits.
+func (db *SQLite3) SavepointSQL(name string) (string, string, string, error) {
+	return standardSavepointSQL(db, name, 0)
+}
+
 func (db *SQLite3) DateTimeFunc(value string) string {
 	return "datetime(" + value + ")"
 }
@@ -106,7 +127,7 @@ func (db *SQLite3) TruncateDBTables() error {
 
 	for _, table := range tables {
 		switch table.Name {
-		case "migration_log":
+		case "migration_log", "migration_checksum":
 			continue
 		case "dashboard_acl":
 			// keep default dashboard permissions
@@ -155,6 +176,16 @@ func (db *SQLite3) IsDeadlock(err error) bool {
 	return false // No deadlock
 }
 
+func (db *SQLite3) IsSerializationFailure(err error) bool {
+	return false // Transactions are serialized by the database lock
+}
+
+// IsLockError returns true if the database or a table was locked by another connection
+func (db *SQLite3) IsLockError(err error) bool {
+	var driverErr sqlite3.Error
+	return errors.As(err, &driverErr) && (driverErr.Code == sqlite3.ErrLocked || driverErr.Code == sqlite3.ErrBusy)
+}
+
 // UpsertSQL returns the upsert sql statement for SQLite dialect
 func (db *SQLite3) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	str, _ := db.UpsertMultipleSQL(tableName, keyCols, updateCols, 1)
@@ -210,3 +241,15 @@ func (db *SQLite3) UpsertMultipleSQL(tableName string, keyCols, updateCols []str
 	)
 	return s, nil
 }
+
+// TimeBucketSQL returns an expression truncating the datetime column to the start of its time bucket, in unix seconds.
+func (db *SQLite3) TimeBucketSQL(column string, intervalSeconds int64) string {
+	return fmt.Sprintf("(CAST(strftime('%%s', %s) AS INTEGER) / %d) * %d", column, intervalSeconds, intervalSeconds)
+}
+
+// TimeValue returns the value the time is stored as in a datetime column, which is text in the format xorm writes on
+// SQLite. Datetime columns are compared as text, so times have to be formatted the same way and in the same zone to
+// compare as the times they represent.
+func (db *SQLite3) TimeValue(t time.Time) interface{} {
+	return t.UTC().Format(sqliteDateTimeFormat)
+}
@@ -0,0 +1,18 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTimeoutSQL(t *testing.T) {
+	require.Equal(t, "SET LOCAL statement_timeout = 1500", NewPostgresDialect(nil).StatementTimeoutSQL(1500*time.Millisecond))
+	// a timeout of 0 would disable it
+	require.Equal(t, "SET LOCAL statement_timeout = 1", NewPostgresDialect(nil).StatementTimeoutSQL(time.Microsecond))
+
+	// the statements are cancelled through their context instead
+	require.Empty(t, NewMysqlDialect(nil).StatementTimeoutSQL(time.Second))
+	require.Empty(t, NewSQLite3Dialect(nil).StatementTimeoutSQL(time.Second))
+}
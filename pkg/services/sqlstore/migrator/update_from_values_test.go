@@ -0,0 +1,31 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateFromValuesSQL(t *testing.T) {
+	cols := []string{"name", "score"}
+	types := []string{"BIGINT", "VARCHAR(255)", "INTEGER"}
+	keys := []interface{}{int64(1), int64(2)}
+	values := [][]interface{}{{"a", 10}, {"b", 20}}
+
+	for _, db := range []Dialect{NewSQLite3Dialect(nil), NewMysqlDialect(nil)} {
+		sql, args := db.UpdateFromValuesSQL("item", "id", cols, types, keys, values)
+		require.Equal(t,
+			"UPDATE `item` SET `name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END, `score` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?, ?)",
+			sql)
+		require.Equal(t, []interface{}{int64(1), "a", int64(2), "b", int64(1), 10, int64(2), 20, int64(1), int64(2)}, args)
+	}
+
+	db := NewPostgresDialect(nil)
+	sql, args := db.UpdateFromValuesSQL("item", "id", cols, types, keys, values)
+	require.Equal(t,
+		`UPDATE "item" SET "name" = v."name", "score" = v."score" `+
+			`FROM (VALUES (CAST(? AS BIGINT), CAST(? AS VARCHAR(255)), CAST(? AS INTEGER)), (?, ?, ?)) AS v("id", "name", "score") `+
+			`WHERE "item"."id" = v."id"`,
+		sql)
+	require.Equal(t, []interface{}{int64(1), "a", 10, int64(2), "b", 20}, args)
+}
@@ -88,11 +88,11 @@ func (m *SQLStoreMock) CreateTeam(name string, email string, orgID int64) (model
 	}, nil
 }
 
-func (m *SQLStoreMock) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+func (m *SQLStoreMock) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error {
 	return m.ExpectedError
 }
 
-func (m *SQLStoreMock) WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+func (m *SQLStoreMock) WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc, opts ...sqlstore.SessionOption) error {
 	return m.ExpectedError
 }
 
@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"xorm.io/builder"
+	"xorm.io/core"
+)
+
+// MoveRows moves the rows of the bean's table matching the conditions to the destination table, e.g. to archive
+// them, and returns the number of moved rows. The conditions are passed the same way as to BuildSQL. The rows are
+// copied and deleted in chunks of the dialect's batch size, so that the number of args per statement stays within the
+// database's limits, but all chunks are moved in the same transaction, so either all rows are moved or none are.
+// The destination table needs the columns of the bean's table, which needs a single-column primary key.
+func (ss *SQLStore) MoveRows(ctx context.Context, srcBean interface{}, dstTable string, conditions ...interface{}) (int64, error) {
+	table := ss.engine.TableInfo(srcBean)
+	if !table.IsValid() {
+		return 0, fmt.Errorf("could not resolve the table of %T", srcBean)
+	}
+	pkColumns := table.PKColumns()
+	if len(pkColumns) != 1 {
+		return 0, fmt.Errorf("moving rows requires a single-column primary key, table %q has %d", table.Name, len(pkColumns))
+	}
+	pk := pkColumns[0]
+	beanType := reflect.Indirect(reflect.ValueOf(srcBean)).Type()
+
+	cond, err := buildCond(conditions...)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := make([]string, 0, len(table.ColumnsSeq()))
+	for _, column := range table.ColumnsSeq() {
+		columns = append(columns, ss.Dialect.Quote(column))
+	}
+	batchSize := normalizeBulkSettings(NativeSettingsForDialect(ss.Dialect)).BatchSize
+
+	var moved int64
+	err = ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		for {
+			// the moved rows are deleted, so the next chunk is always at the start of the matching rows
+			chunk := reflect.New(reflect.SliceOf(beanType))
+			err := sess.Table(table.Name).Cols(pk.Name).Where(cond).OrderBy(ss.Dialect.Quote(pk.Name)).Limit(batchSize).Find(chunk.Interface())
+			if err != nil {
+				return err
+			}
+			rows := chunk.Elem().Len()
+			if rows == 0 {
+				return nil
+			}
+
+			keys := make([]interface{}, 0, rows)
+			for i := 0; i < rows; i++ {
+				key, err := pk.ValueOf(chunk.Elem().Index(i).Addr().Interface())
+				if err != nil {
+					return err
+				}
+				keys = append(keys, key.Interface())
+			}
+			inChunk := builder.In(ss.Dialect.Quote(pk.Name), keys...)
+
+			selectSQL, args, err := builder.Select(columns...).From(ss.Dialect.Quote(table.Name)).Where(inChunk).ToSQL()
+			if err != nil {
+				return err
+			}
+			inserted, err := ss.execMoveRowsSQL(sess, table.Table, fmt.Sprintf("INSERT INTO %s (%s) %s",
+				ss.Dialect.Quote(dstTable), strings.Join(columns, ", "), selectSQL), args)
+			if err != nil {
+				return err
+			}
+
+			deleteSQL, args, err := builder.Delete(inChunk).From(ss.Dialect.Quote(table.Name)).ToSQL()
+			if err != nil {
+				return err
+			}
+			deleted, err := ss.execMoveRowsSQL(sess, table.Table, deleteSQL, args)
+			if err != nil {
+				return err
+			}
+
+			if inserted != deleted {
+				return fmt.Errorf("moved %d rows to %s but deleted %d from %s", inserted, dstTable, deleted, table.Name)
+			}
+			moved += deleted
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+func (ss *SQLStore) execMoveRowsSQL(sess *DBSession, table *core.Table, rawSQL string, args []interface{}) (int64, error) {
+	for _, filter := range ss.engine.Dialect().Filters() {
+		rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table)
+	}
+
+	res, err := sess.Exec(append([]interface{}{rawSQL}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
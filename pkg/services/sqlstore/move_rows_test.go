@@ -0,0 +1,127 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type moveTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Value string `xorm:"varchar(10)"`
+}
+
+type moveTestItemArchive struct {
+	ID    int64  `xorm:"pk 'id'"`
+	Value string `xorm:"varchar(10)"`
+}
+
+func TestIntegrationMoveRows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(moveTestItem), new(moveTestItemArchive))
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, old int, current int) {
+		t.Helper()
+		// interleave the old and current rows, so that the chunks to move aren't contiguous
+		items := make([]moveTestItem, 0, old+current)
+		for i := 0; i < old || i < current; i++ {
+			if i < old {
+				items = append(items, moveTestItem{Value: "old"})
+			}
+			if i < current {
+				items = append(items, moveTestItem{Value: "current"})
+			}
+		}
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			if _, err := sess.Exec("DELETE FROM move_test_item"); err != nil {
+				return err
+			}
+			if _, err := sess.Exec("DELETE FROM move_test_item_archive"); err != nil {
+				return err
+			}
+			_, err := sess.BulkInsert(moveTestItem{}, items, NativeSettingsForDialect(db.GetDialect()))
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	find := func(t *testing.T) ([]moveTestItem, []moveTestItemArchive) {
+		t.Helper()
+		var src []moveTestItem
+		var dst []moveTestItemArchive
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			if err := sess.Asc("id").Find(&src); err != nil {
+				return err
+			}
+			return sess.Asc("id").Find(&dst)
+		})
+		require.NoError(t, err)
+		return src, dst
+	}
+
+	t.Run("moves the matching rows to the destination in chunks", func(t *testing.T) {
+		setup(t, 55, 45)
+		before, _ := find(t)
+
+		moved, err := db.MoveRows(context.Background(), moveTestItem{}, "move_test_item_archive", "value = ?", "old")
+		require.NoError(t, err)
+		require.Equal(t, int64(55), moved)
+
+		src, dst := find(t)
+		require.Len(t, src, 45)
+		for _, item := range src {
+			require.Equal(t, "current", item.Value)
+		}
+		require.Len(t, dst, 55)
+		expected := []moveTestItemArchive{}
+		for _, item := range before {
+			if item.Value == "old" {
+				expected = append(expected, moveTestItemArchive(item))
+			}
+		}
+		require.Equal(t, expected, dst)
+	})
+
+	t.Run("moves nothing if no rows match", func(t *testing.T) {
+		setup(t, 0, 10)
+
+		moved, err := db.MoveRows(context.Background(), moveTestItem{}, "move_test_item_archive", map[string]interface{}{"value": "old"})
+		require.NoError(t, err)
+		require.Zero(t, moved)
+
+		src, dst := find(t)
+		require.Len(t, src, 10)
+		require.Empty(t, dst)
+	})
+
+	t.Run("moves no rows at all if a chunk fails", func(t *testing.T) {
+		setup(t, 30, 0)
+		before, _ := find(t)
+		// a row of the last chunk is already archived, so copying that chunk fails on the primary key
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			_, err := sess.Insert(&moveTestItemArchive{ID: before[len(before)-1].ID, Value: "archived"})
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = db.MoveRows(context.Background(), moveTestItem{}, "move_test_item_archive", "value = ?", "old")
+		require.Error(t, err)
+
+		src, dst := find(t)
+		require.Equal(t, before, src)
+		require.Equal(t, []moveTestItemArchive{{ID: before[len(before)-1].ID, Value: "archived"}}, dst)
+	})
+
+	t.Run("rejects beans without a single-column primary key", func(t *testing.T) {
+		type noPrimaryKey struct {
+			Value string
+		}
+		_, err := db.MoveRows(context.Background(), noPrimaryKey{}, "move_test_item_archive")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// nullScanner is a scan destination which stores NULL as a default value instead of failing
+type nullScanner struct {
+	dest interface{}
+	// def is the value NULL is stored as, the zero value of the destination's type if it's nil
+	def interface{}
+}
+
+// NullAsZero returns a scan destination storing the value in dest, which stores NULL as the zero value of the type
+// dest points to rather than failing. It supports pointers to strings, bools, ints, floats, times and byte slices.
+func NullAsZero(dest interface{}) sql.Scanner {
+	return &nullScanner{dest: dest}
+}
+
+// NullAsDefault returns a scan destination like NullAsZero, which stores NULL as def instead of the zero value.
+// def must be of the type dest points to.
+func NullAsDefault(dest interface{}, def interface{}) sql.Scanner {
+	return &nullScanner{dest: dest, def: def}
+}
+
+// rowScanner is the scanning part of *sql.Rows and *sql.Row
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanNullAsZero scans the current row into dest like rows.Scan, but stores NULLs as the zero values of the
+// destinations' types, so that nullable columns can be scanned without sql.Null* types. Destinations that are
+// already scanners, e.g. those returned by NullAsDefault, are used as they are.
+func ScanNullAsZero(rows rowScanner, dest ...interface{}) error {
+	scanArgs := make([]interface{}, len(dest))
+	for i, d := range dest {
+		if scanner, ok := d.(sql.Scanner); ok {
+			scanArgs[i] = scanner
+			continue
+		}
+		scanArgs[i] = NullAsZero(d)
+	}
+	return rows.Scan(scanArgs...)
+}
+
+func (n *nullScanner) Scan(value interface{}) error {
+	if value == nil {
+		return n.storeNull()
+	}
+
+	switch d := n.dest.(type) {
+	case *string:
+		var v sql.NullString
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.String
+	case *bool:
+		var v sql.NullBool
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Bool
+	case *int:
+		var v sql.NullInt64
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = int(v.Int64)
+	case *int32:
+		var v sql.NullInt32
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Int32
+	case *int64:
+		var v sql.NullInt64
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Int64
+	case *float64:
+		var v sql.NullFloat64
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Float64
+	case *time.Time:
+		// SQLite returns datetimes stored as text by raw SQL as text
+		t, err := LoadTime(value)
+		if err != nil {
+			return err
+		}
+		*d = t
+	case *[]byte:
+		switch v := value.(type) {
+		case []byte:
+			*d = append([]byte(nil), v...)
+		case string:
+			*d = []byte(v)
+		default:
+			return fmt.Errorf("can't scan %T into %T", value, n.dest)
+		}
+	default:
+		return fmt.Errorf("unsupported scan destination %T", n.dest)
+	}
+	return nil
+}
+
+func (n *nullScanner) storeNull() error {
+	dest := reflect.ValueOf(n.dest)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", n.dest)
+	}
+
+	if n.def == nil {
+		dest.Elem().Set(reflect.Zero(dest.Elem().Type()))
+		return nil
+	}
+
+	def := reflect.ValueOf(n.def)
+	if !def.Type().AssignableTo(dest.Elem().Type()) {
+		return fmt.Errorf("default %T can't be stored in %T", n.def, n.dest)
+	}
+	dest.Elem().Set(def)
+	return nil
+}
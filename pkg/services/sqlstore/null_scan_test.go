@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nullScanTestItem struct {
+	ID      int64 `xorm:"pk autoincr 'id'"`
+	Count   int64
+	Name    string
+	Created time.Time
+	Active  bool
+}
+
+func TestIntegrationScanNullAsZero(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(nullScanTestItem))
+	require.NoError(t, err)
+
+	created := time.Date(2022, 11, 1, 10, 30, 0, 0, time.UTC)
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM null_scan_test_item"); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&nullScanTestItem{ID: 1, Count: 3, Name: "set", Created: created, Active: true}); err != nil {
+			return err
+		}
+		_, err := sess.Exec("INSERT INTO null_scan_test_item (id) VALUES (2)")
+		return err
+	})
+	require.NoError(t, err)
+
+	query := func(t *testing.T, id int64, dest ...interface{}) {
+		t.Helper()
+		rows, err := db.engine.DB().QueryContext(context.Background(), fmt.Sprintf("SELECT count, name, created, active FROM null_scan_test_item WHERE id = %d", id))
+		require.NoError(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+		require.True(t, rows.Next())
+		require.NoError(t, ScanNullAsZero(rows, dest...))
+		require.NoError(t, rows.Err())
+	}
+
+	t.Run("scans values", func(t *testing.T) {
+		var item nullScanTestItem
+		query(t, 1, &item.Count, &item.Name, &item.Created, &item.Active)
+		require.Equal(t, nullScanTestItem{Count: 3, Name: "set", Created: created, Active: true}, item)
+	})
+
+	t.Run("scans NULLs as zero values", func(t *testing.T) {
+		item := nullScanTestItem{Count: 1, Name: "stale", Created: created, Active: true}
+		query(t, 2, &item.Count, &item.Name, &item.Created, &item.Active)
+		require.Equal(t, nullScanTestItem{}, item)
+	})
+
+	t.Run("scans NULLs as defaults", func(t *testing.T) {
+		var item nullScanTestItem
+		query(t, 2, NullAsDefault(&item.Count, int64(-1)), NullAsDefault(&item.Name, "unknown"), NullAsDefault(&item.Created, created), NullAsDefault(&item.Active, true))
+		require.Equal(t, nullScanTestItem{Count: -1, Name: "unknown", Created: created, Active: true}, item)
+	})
+
+	t.Run("fails for defaults of another type", func(t *testing.T) {
+		var count int64
+		var name string
+		var active bool
+		rows, err := db.engine.DB().QueryContext(context.Background(), "SELECT count, name, active FROM null_scan_test_item WHERE id = 2")
+		require.NoError(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+		require.True(t, rows.Next())
+		require.Error(t, ScanNullAsZero(rows, NullAsDefault(&count, "none"), &name, &active))
+	})
+}
@@ -0,0 +1,89 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// FindOrphans finds the rows of a child table whose foreign key column refers to a row of the parent bean's table
+// that no longer exists, e.g. the permissions of a deleted dashboard left behind by a failed cleanup. The orphans are
+// stored in the slice orphans points to, like with Find, whose element type is the bean of the child table. Rows whose
+// foreign key is NULL don't refer to a parent, so they aren't orphans. The child table may be the parent table, e.g.
+// to find the rows of a tree whose parent row was deleted.
+func (ss *SQLStore) FindOrphans(ctx context.Context, orphans interface{}, parentBean interface{}, fkCol, parentKeyCol string) error {
+	sliceValue := reflect.Indirect(reflect.ValueOf(orphans))
+	if sliceValue.Kind() != reflect.Slice {
+		return fmt.Errorf("orphans must be a pointer to a slice, got %T", orphans)
+	}
+	childBean := reflect.New(sliceValue.Type().Elem()).Interface()
+	if sliceValue.Type().Elem().Kind() == reflect.Ptr {
+		childBean = reflect.New(sliceValue.Type().Elem().Elem()).Interface()
+	}
+
+	childTable, where, err := ss.orphansCondition(childBean, parentBean, fkCol, parentKeyCol)
+	if err != nil {
+		return err
+	}
+
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.Table(childTable).Where(where).Find(orphans)
+	})
+}
+
+// PurgeOrphans deletes the rows of the child bean's table that FindOrphans finds in one transaction, and returns the
+// number of deleted rows.
+func (ss *SQLStore) PurgeOrphans(ctx context.Context, childBean interface{}, parentBean interface{}, fkCol, parentKeyCol string) (int64, error) {
+	childTable, where, err := ss.orphansCondition(childBean, parentBean, fkCol, parentKeyCol)
+	if err != nil {
+		return 0, err
+	}
+
+	// the foreign keys of the orphans are selected through a derived table, since MySQL doesn't allow a subquery of a
+	// DELETE to select from the table it deletes from, which it does if the child table is the parent table
+	quotedChild, quotedFK := ss.Dialect.Quote(childTable), ss.Dialect.Quote(fkCol)
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE %s IN (SELECT fk FROM (SELECT %s.%s AS fk FROM %s WHERE %s) orphan_keys)",
+		quotedChild, quotedFK, quotedChild, quotedFK, quotedChild, where)
+
+	var purged int64
+	err = ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		res, err := sess.Exec(deleteSQL)
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// orphansCondition returns the name of the child bean's table, and the condition matching its rows whose foreign key
+// column refers to a row of the parent bean's table that doesn't exist
+func (ss *SQLStore) orphansCondition(childBean interface{}, parentBean interface{}, fkCol, parentKeyCol string) (string, string, error) {
+	childTable := ss.engine.TableInfo(childBean)
+	if !childTable.IsValid() {
+		return "", "", fmt.Errorf("could not resolve the table of %T", childBean)
+	}
+	if childTable.GetColumn(fkCol) == nil {
+		return "", "", fmt.Errorf("table %q has no column %q", childTable.Name, fkCol)
+	}
+	parentTable := ss.engine.TableInfo(parentBean)
+	if !parentTable.IsValid() {
+		return "", "", fmt.Errorf("could not resolve the table of %T", parentBean)
+	}
+	if parentTable.GetColumn(parentKeyCol) == nil {
+		return "", "", fmt.Errorf("table %q has no column %q", parentTable.Name, parentKeyCol)
+	}
+
+	// the parent table is aliased, so that the child column is resolved against the child table even if it's the
+	// parent table
+	quotedChild, quotedFK := ss.Dialect.Quote(childTable.Name), ss.Dialect.Quote(fkCol)
+	where := fmt.Sprintf("%s.%s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s parent_row WHERE parent_row.%s = %s.%s)",
+		quotedChild, quotedFK, ss.Dialect.Quote(parentTable.Name), ss.Dialect.Quote(parentKeyCol), quotedChild, quotedFK)
+
+	return childTable.Name, where, nil
+}
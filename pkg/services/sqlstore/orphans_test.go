@@ -0,0 +1,153 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orphansTestParent struct {
+	ID   int64 `xorm:"pk autoincr 'id'"`
+	Name string
+}
+
+type orphansTestChild struct {
+	ID       int64  `xorm:"pk autoincr 'id'"`
+	ParentID *int64 `xorm:"'parent_id'"`
+}
+
+type orphansTestNode struct {
+	ID       int64 `xorm:"pk autoincr 'id'"`
+	ParentID int64 `xorm:"'parent_id'"`
+}
+
+func TestIntegrationOrphans(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(orphansTestParent), new(orphansTestChild), new(orphansTestNode))
+	require.NoError(t, err)
+
+	// setup inserts two parents with two children each and deletes the second parent, which orphans its children. A
+	// child without a parent isn't an orphan.
+	setup := func(t *testing.T) (kept []int64, orphaned []int64) {
+		t.Helper()
+		for _, table := range []string{"orphans_test_parent", "orphans_test_child"} {
+			_, err := ss.engine.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+		parents := []*orphansTestParent{{Name: "kept"}, {Name: "deleted"}}
+		for _, parent := range parents {
+			_, err := ss.engine.Insert(parent)
+			require.NoError(t, err)
+		}
+		children := []*orphansTestChild{{ParentID: &parents[0].ID}, {ParentID: &parents[0].ID}, {ParentID: &parents[1].ID}, {ParentID: &parents[1].ID}, {}}
+		for _, child := range children {
+			_, err := ss.engine.Insert(child)
+			require.NoError(t, err)
+		}
+		_, err := ss.engine.ID(parents[1].ID).Delete(&orphansTestParent{})
+		require.NoError(t, err)
+		return []int64{children[0].ID, children[1].ID, children[4].ID}, []int64{children[2].ID, children[3].ID}
+	}
+	childIDs := func(t *testing.T) []int64 {
+		t.Helper()
+		var children []orphansTestChild
+		require.NoError(t, ss.engine.Asc("id").Find(&children))
+		ids := make([]int64, 0, len(children))
+		for _, child := range children {
+			ids = append(ids, child.ID)
+		}
+		return ids
+	}
+
+	t.Run("finds the children whose parent doesn't exist", func(t *testing.T) {
+		_, orphaned := setup(t)
+
+		var orphans []*orphansTestChild
+		err := ss.FindOrphans(context.Background(), &orphans, &orphansTestParent{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Len(t, orphans, 2)
+		require.ElementsMatch(t, orphaned, []int64{orphans[0].ID, orphans[1].ID})
+	})
+
+	t.Run("finds no orphans if every parent exists", func(t *testing.T) {
+		setup(t)
+		_, err := ss.engine.Exec("DELETE FROM orphans_test_child WHERE parent_id NOT IN (SELECT id FROM orphans_test_parent)")
+		require.NoError(t, err)
+
+		var orphans []orphansTestChild
+		err = ss.FindOrphans(context.Background(), &orphans, &orphansTestParent{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Empty(t, orphans)
+	})
+
+	t.Run("purges the children whose parent doesn't exist", func(t *testing.T) {
+		kept, _ := setup(t)
+
+		purged, err := ss.PurgeOrphans(context.Background(), &orphansTestChild{}, &orphansTestParent{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), purged)
+		require.Equal(t, kept, childIDs(t))
+
+		purged, err = ss.PurgeOrphans(context.Background(), &orphansTestChild{}, &orphansTestParent{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Equal(t, int64(0), purged)
+	})
+
+	t.Run("purges within the transaction in the context", func(t *testing.T) {
+		setup(t)
+		errRollback := errors.New("rollback")
+
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			purged, err := ss.PurgeOrphans(ctx, &orphansTestChild{}, &orphansTestParent{}, "parent_id", "id")
+			require.NoError(t, err)
+			require.Equal(t, int64(2), purged)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		require.Len(t, childIDs(t), 5)
+	})
+
+	t.Run("finds and purges the orphans of a table referring to itself", func(t *testing.T) {
+		_, err := ss.engine.Exec("DELETE FROM orphans_test_node")
+		require.NoError(t, err)
+		root := &orphansTestNode{}
+		_, err = ss.engine.Insert(root)
+		require.NoError(t, err)
+		branch := &orphansTestNode{ParentID: root.ID}
+		_, err = ss.engine.Insert(branch)
+		require.NoError(t, err)
+		leaf := &orphansTestNode{ParentID: branch.ID}
+		_, err = ss.engine.Insert(leaf)
+		require.NoError(t, err)
+		_, err = ss.engine.ID(root.ID).Delete(&orphansTestNode{})
+		require.NoError(t, err)
+
+		var orphans []orphansTestNode
+		err = ss.FindOrphans(context.Background(), &orphans, &orphansTestNode{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Equal(t, []orphansTestNode{*branch}, orphans)
+
+		purged, err := ss.PurgeOrphans(context.Background(), &orphansTestNode{}, &orphansTestNode{}, "parent_id", "id")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), purged)
+	})
+
+	t.Run("fails if a column doesn't exist", func(t *testing.T) {
+		var orphans []orphansTestChild
+		err := ss.FindOrphans(context.Background(), &orphans, &orphansTestParent{}, "owner_id", "id")
+		require.ErrorContains(t, err, `has no column "owner_id"`)
+
+		_, err = ss.PurgeOrphans(context.Background(), &orphansTestChild{}, &orphansTestParent{}, "parent_id", "uid")
+		require.ErrorContains(t, err, `has no column "uid"`)
+	})
+
+	t.Run("fails if orphans isn't a pointer to a slice", func(t *testing.T) {
+		err := ss.FindOrphans(context.Background(), &orphansTestChild{}, &orphansTestParent{}, "parent_id", "id")
+		require.ErrorContains(t, err, "must be a pointer to a slice")
+	})
+}
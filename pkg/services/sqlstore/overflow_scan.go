@@ -0,0 +1,171 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OverflowError is returned when a value of the database doesn't fit the integer or float type it's scanned into,
+// e.g. a SUM beyond the range of an int32
+type OverflowError struct {
+	// Value is the value of the database as text
+	Value string
+	// Type is the type it doesn't fit
+	Type reflect.Type
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("value %s overflows %s", e.Value, e.Type)
+}
+
+// overflowScanner is a scan destination which fails with an OverflowError rather than storing a value that doesn't
+// fit its type
+type overflowScanner struct {
+	dest interface{}
+}
+
+// CheckedScan returns a scan destination storing the value in dest, which fails with an OverflowError if the value
+// doesn't fit the type dest points to, rather than failing with a driver-specific error or storing a truncated value.
+// It supports pointers to ints, uints and floats of any size. Values are accepted as integers, floats or numeric text,
+// since some databases return aggregates like SUM as decimals, but a value with a fraction can't be scanned into an
+// integer. NULL is stored as 0, like by NullAsZero, since aggregates of no rows are NULL.
+func CheckedScan(dest interface{}) sql.Scanner {
+	return &overflowScanner{dest: dest}
+}
+
+// QueryScalar runs a query returning a single row with a single numeric column, e.g. a COUNT or SUM, and scans the
+// value into dest like CheckedScan does, so a value that doesn't fit dest fails with an OverflowError.
+func (ss *SQLStore) QueryScalar(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		rows, err := sess.QueryInterface(append([]interface{}{query}, args...)...)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return sql.ErrNoRows
+		}
+		if len(rows) > 1 {
+			return fmt.Errorf("query returned %d rows rather than one", len(rows))
+		}
+		if len(rows[0]) != 1 {
+			return fmt.Errorf("query returned %d columns rather than one", len(rows[0]))
+		}
+		for _, value := range rows[0] {
+			return CheckedScan(dest).Scan(value)
+		}
+		return nil
+	})
+}
+
+func (o *overflowScanner) Scan(value interface{}) error {
+	dest := reflect.ValueOf(o.dest)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", o.dest)
+	}
+	elem := dest.Elem()
+	if value == nil {
+		elem.Set(reflect.Zero(elem.Type()))
+		return nil
+	}
+
+	text, err := numericText(value)
+	if err != nil {
+		return err
+	}
+	overflow := &OverflowError{Value: text, Type: elem.Type()}
+
+	switch elem.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(text, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return overflow
+		}
+		if err != nil {
+			v, err = parseIntegralFloat(text, overflow)
+			if err != nil {
+				return err
+			}
+		}
+		if elem.OverflowInt(v) {
+			return overflow
+		}
+		elem.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if strings.HasPrefix(text, "-") {
+			if v, err := strconv.ParseFloat(text, 64); err == nil && v == 0 {
+				elem.SetUint(0)
+				return nil
+			}
+			return overflow
+		}
+		v, err := strconv.ParseUint(text, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return overflow
+		}
+		if err != nil {
+			var f int64
+			if f, err = parseIntegralFloat(text, overflow); err != nil {
+				return err
+			}
+			v = uint64(f)
+		}
+		if elem.OverflowUint(v) {
+			return overflow
+		}
+		elem.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(text, elem.Type().Bits())
+		if errors.Is(err, strconv.ErrRange) && !math.IsInf(v, 0) {
+			// ParseFloat reports values too small to be represented as ErrRange too, which are stored as 0 instead
+			err = nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return overflow
+		}
+		if err != nil {
+			return fmt.Errorf("can't scan %q into %T: %w", text, o.dest, err)
+		}
+		elem.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported scan destination %T", o.dest)
+	}
+	return nil
+}
+
+// numericText returns a numeric value of the database as text, so that it's parsed the same way whichever type the
+// driver returns it as
+func numericText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []byte:
+		return strings.TrimSpace(string(v)), nil
+	case string:
+		return strings.TrimSpace(v), nil
+	}
+	return "", fmt.Errorf("can't scan %T as a number", value)
+}
+
+// parseIntegralFloat parses numeric text that isn't an integer literal, e.g. a decimal like 42.000, into an int64.
+// Values beyond the range of an int64 fail with the overflow error.
+func parseIntegralFloat(text string, overflow *OverflowError) (int64, error) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("can't scan %q as a number: %w", text, err)
+	}
+	if math.IsInf(f, 0) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, overflow
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("can't scan %q into an integer, it has a fraction", text)
+	}
+	return int64(f), nil
+}
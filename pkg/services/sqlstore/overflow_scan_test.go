@@ -0,0 +1,157 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type overflowScanTestRow struct {
+	ID    int64 `xorm:"pk autoincr 'id'"`
+	Value int64
+}
+
+func TestIntegrationQueryScalar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	require.NoError(t, ss.engine.Sync(new(overflowScanTestRow)))
+	for _, value := range []int64{3000000000, 3000000000} {
+		_, err := ss.engine.Insert(&overflowScanTestRow{Value: value})
+		require.NoError(t, err)
+	}
+	ctx := context.Background()
+	sumSQL := "SELECT SUM(value) FROM overflow_scan_test_row"
+
+	t.Run("should scan an aggregate beyond the range of an int32 into an int64", func(t *testing.T) {
+		var sum int64
+		require.NoError(t, ss.QueryScalar(ctx, &sum, sumSQL))
+		require.Equal(t, int64(6000000000), sum)
+	})
+
+	t.Run("should fail with an overflow error for an aggregate beyond the range of the target", func(t *testing.T) {
+		var sum int32
+		err := ss.QueryScalar(ctx, &sum, sumSQL)
+		var overflow *OverflowError
+		require.True(t, errors.As(err, &overflow), "expected an OverflowError, got %v", err)
+		require.Equal(t, reflect.TypeOf(int32(0)), overflow.Type)
+		require.Zero(t, sum)
+
+		var usum uint32
+		err = ss.QueryScalar(ctx, &usum, sumSQL)
+		require.True(t, errors.As(err, &overflow), "expected an OverflowError, got %v", err)
+	})
+
+	t.Run("should fail with an overflow error for a negative value scanned into a uint", func(t *testing.T) {
+		var value uint64
+		err := ss.QueryScalar(ctx, &value, "SELECT -SUM(value) FROM overflow_scan_test_row")
+		var overflow *OverflowError
+		require.True(t, errors.As(err, &overflow), "expected an OverflowError, got %v", err)
+	})
+
+	t.Run("should fail with an overflow error for a value beyond the range of an int64", func(t *testing.T) {
+		var value int64
+		err := ss.QueryScalar(ctx, &value, "SELECT '99999999999999999999'")
+		var overflow *OverflowError
+		require.True(t, errors.As(err, &overflow), "expected an OverflowError, got %v", err)
+		require.Equal(t, "99999999999999999999", overflow.Value)
+	})
+
+	t.Run("should fail with an overflow error for a float beyond the range of a float32", func(t *testing.T) {
+		var small float32
+		err := ss.QueryScalar(ctx, &small, "SELECT 1e300")
+		var overflow *OverflowError
+		require.True(t, errors.As(err, &overflow), "expected an OverflowError, got %v", err)
+
+		var large float64
+		require.NoError(t, ss.QueryScalar(ctx, &large, "SELECT 1e300"))
+		require.Equal(t, 1e300, large)
+	})
+
+	t.Run("should scan the NULL of an aggregate of no rows as 0", func(t *testing.T) {
+		sum := int64(1)
+		require.NoError(t, ss.QueryScalar(ctx, &sum, sumSQL+" WHERE id < ?", 0))
+		require.Zero(t, sum)
+	})
+
+	t.Run("should fail if the query doesn't return a single value", func(t *testing.T) {
+		var value int64
+		err := ss.QueryScalar(ctx, &value, "SELECT value FROM overflow_scan_test_row WHERE id < ?", 0)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = ss.QueryScalar(ctx, &value, "SELECT value FROM overflow_scan_test_row")
+		require.EqualError(t, err, "query returned 2 rows rather than one")
+
+		err = ss.QueryScalar(ctx, &value, "SELECT id, value FROM overflow_scan_test_row WHERE id = (SELECT MIN(id) FROM overflow_scan_test_row)")
+		require.EqualError(t, err, "query returned 2 columns rather than one")
+	})
+
+	t.Run("should fail to scan a value with a fraction into an integer", func(t *testing.T) {
+		var value int64
+		err := ss.QueryScalar(ctx, &value, "SELECT 2.5")
+		require.Error(t, err)
+		var overflow *OverflowError
+		require.False(t, errors.As(err, &overflow))
+	})
+
+	t.Run("should run in the transaction of the context", func(t *testing.T) {
+		_, err := ss.engine.Exec("DELETE FROM overflow_scan_test_row")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			for _, value := range []int64{3000000000, 3000000000} {
+				_, err := ss.engine.Insert(&overflowScanTestRow{Value: value})
+				require.NoError(t, err)
+			}
+		})
+
+		var count int64
+		err = ss.InTransaction(ctx, func(ctx context.Context) error {
+			return ss.WithDbSession(ctx, func(sess *DBSession) error {
+				if _, err := sess.Insert(&overflowScanTestRow{Value: math.MaxInt32}); err != nil {
+					return err
+				}
+				return ss.QueryScalar(ctx, &count, "SELECT COUNT(*) FROM overflow_scan_test_row")
+			})
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+	})
+}
+
+func TestCheckedScan(t *testing.T) {
+	t.Run("should accept the types drivers return numbers as", func(t *testing.T) {
+		for _, value := range []interface{}{int64(42), float64(42), []byte("42.000"), " 42 "} {
+			var dest int16
+			require.NoError(t, CheckedScan(&dest).Scan(value), "value %#v", value)
+			require.Equal(t, int16(42), dest)
+		}
+	})
+
+	t.Run("should store the largest and smallest values of the target", func(t *testing.T) {
+		var i8 int8
+		require.NoError(t, CheckedScan(&i8).Scan(int64(math.MinInt8)))
+		require.Equal(t, int8(math.MinInt8), i8)
+		var u64 uint64
+		require.NoError(t, CheckedScan(&u64).Scan([]byte("18446744073709551615")))
+		require.Equal(t, uint64(math.MaxUint64), u64)
+
+		var overflow *OverflowError
+		require.True(t, errors.As(CheckedScan(&i8).Scan(int64(math.MaxInt8+1)), &overflow))
+		require.True(t, errors.As(CheckedScan(&u64).Scan([]byte("18446744073709551616")), &overflow))
+	})
+
+	t.Run("should fail for an unsupported target or value", func(t *testing.T) {
+		var s string
+		require.EqualError(t, CheckedScan(&s).Scan(int64(1)), "unsupported scan destination *string")
+		var i int
+		require.EqualError(t, CheckedScan(i).Scan(int64(1)), "scan destination must be a non-nil pointer, got int")
+		require.Error(t, CheckedScan(&i).Scan(true))
+		require.Error(t, CheckedScan(&i).Scan([]byte("abc")))
+	})
+}
@@ -0,0 +1,60 @@
+package sqlstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// maxParallelReads limits the number of reads ParallelReads runs concurrently, so that a single request can't take up
+// all connections of the pool
+const maxParallelReads = 4
+
+// ParallelReads runs the independent reads concurrently, at most maxParallelReads at a time, and waits for all of them.
+// Each read gets a new session of its own, even if the context has a transaction, since a session can't be shared
+// between goroutines, so the reads don't see uncommitted changes of the transaction. The sessions are on the read
+// replica like those of WithReadOnlyDbSession, so the reads must not write, and are timed separately. The errors of
+// all failed reads are returned together, in the order of the reads.
+func (ss *SQLStore) ParallelReads(ctx context.Context, fns ...func(sess *DBSession) error) error {
+	errs := make([]error, len(fns))
+	limit := make(chan struct{}, maxParallelReads)
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		i, fn := i, fn
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			errs[i] = ss.withParallelReadSession(ctx, fn)
+		}()
+	}
+	wg.Wait()
+
+	var result *multierror.Error
+	for _, err := range errs {
+		if err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// withParallelReadSession calls the callback with a new session on the engine of the reads of the context, with a timer
+// of its own since the timer of the context isn't meant to be shared between goroutines. Unlike WithNewDbSession it
+// doesn't count as a write of the context.
+func (ss *SQLStore) withParallelReadSession(ctx context.Context, callback DBTransactionFunc) error {
+	release, err := ss.sessions.acquire()
+	if err != nil {
+		return err
+	}
+	timer, done := newSessionTimer(ctx)
+	defer done()
+	engine := ss.readOnlyEngine(ctx)
+	sess := &DBSession{Session: engine.NewSession().Context(ctx), timer: timer, engine: engine, release: release}
+	defer sess.Close()
+	return ss.retryOnLocks(ctx, callback, sess, timer, ss.dbCfg.QueryRetries)
+}
@@ -0,0 +1,151 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestIntegrationParallelReads(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+
+	t.Run("runs the reads concurrently", func(t *testing.T) {
+		// every read waits until all of them have started, which only happens if they run concurrently
+		var started sync.WaitGroup
+		started.Add(maxParallelReads)
+		allStarted := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(allStarted)
+		}()
+
+		read := func(sess *DBSession) error {
+			started.Done()
+			select {
+			case <-allStarted:
+			case <-time.After(5 * time.Second):
+				return errors.New("reads didn't run concurrently")
+			}
+			_, err := sess.Exec("SELECT 1")
+			return err
+		}
+		fns := make([]func(*DBSession) error, maxParallelReads)
+		for i := range fns {
+			fns[i] = read
+		}
+
+		require.NoError(t, db.ParallelReads(context.Background(), fns...))
+	})
+
+	t.Run("gives each read a session of its own", func(t *testing.T) {
+		var mu sync.Mutex
+		sessions := map[*DBSession]struct{}{}
+		read := func(sess *DBSession) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sessions[sess] = struct{}{}
+			return nil
+		}
+
+		var outer *DBSession
+		err := db.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+			outer = sess
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+			return db.ParallelReads(ctx, read, read, read)
+		})
+		require.NoError(t, err)
+
+		require.Len(t, sessions, 3)
+		require.NotContains(t, sessions, outer)
+	})
+
+	t.Run("gives each read a timer of its own", func(t *testing.T) {
+		var mu sync.Mutex
+		timers := map[*sessionTimer]struct{}{}
+		read := func(sess *DBSession) error {
+			mu.Lock()
+			defer mu.Unlock()
+			timers[sess.timer] = struct{}{}
+			return nil
+		}
+
+		var timings []SessionTiming
+		ctx := WithSessionTimingCallback(context.Background(), func(timing SessionTiming) {
+			mu.Lock()
+			defer mu.Unlock()
+			timings = append(timings, timing)
+		})
+		err := db.WithDbSession(ctx, func(outer *DBSession) error {
+			ctx := context.WithValue(ctx, ContextSessionKey{}, outer)
+			if err := db.ParallelReads(ctx, read, read, read); err != nil {
+				return err
+			}
+			require.NotContains(t, timers, outer.timer)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, timers, 3)
+		// the reads and the outer session
+		require.Len(t, timings, 4)
+	})
+
+	t.Run("runs the reads on the replica", func(t *testing.T) {
+		replica, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "replica.db")+"?mode=rwc")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = replica.Close() })
+		// the test store is shared between tests
+		db.readEngine = replica
+		t.Cleanup(func() { db.readEngine = nil })
+
+		var mu sync.Mutex
+		var engines []*xorm.Engine
+		read := func(sess *DBSession) error {
+			mu.Lock()
+			defer mu.Unlock()
+			engines = append(engines, sess.engine)
+			return nil
+		}
+
+		require.NoError(t, db.ParallelReads(context.Background(), read, read))
+		require.Equal(t, []*xorm.Engine{replica, replica}, engines)
+
+		// unless the context has written
+		engines = nil
+		ctx := ReadYourWrites(context.Background())
+		require.NoError(t, db.WithDbSession(ctx, func(sess *DBSession) error { return nil }))
+		require.NoError(t, db.ParallelReads(ctx, read, read))
+		require.Equal(t, []*xorm.Engine{db.engine, db.engine}, engines)
+	})
+
+	t.Run("returns the errors of all failed reads", func(t *testing.T) {
+		errFirst := errors.New("first")
+		errThird := errors.New("third")
+		ran := make([]bool, 3)
+
+		err := db.ParallelReads(context.Background(),
+			func(sess *DBSession) error { ran[0] = true; return errFirst },
+			func(sess *DBSession) error { ran[1] = true; return nil },
+			func(sess *DBSession) error { ran[2] = true; return errThird },
+		)
+		require.Error(t, err)
+		require.ErrorIs(t, err, errFirst)
+		require.ErrorIs(t, err, errThird)
+		require.Equal(t, []bool{true, true, true}, ran)
+	})
+
+	t.Run("returns no error without reads", func(t *testing.T) {
+		require.NoError(t, db.ParallelReads(context.Background()))
+	})
+}
@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// rollupAliasPattern matches the names of the summary columns the aggregates of a rollup are stored in
+var rollupAliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RollupInto aggregates the rows of the bean's table by the group columns and upserts the aggregates into the summary
+// table, so that dashboards can query the small summary rather than the table itself. Each aggregate is an expression
+// with the summary column it's stored in as alias, e.g. "SUM(duration) AS total_duration". The summary table needs
+// the group columns, with a unique key on them, and the columns of the aggregates.
+//
+// With a zero since every group is rolled up. Otherwise only the groups with rows whose timeColumn is at or after since
+// are, but from all of their rows, so the summary is the same as after a full rollup whatever the aggregates are,
+// while the other groups are left as they are. Rolling up incrementally is cheap when one of the group columns is a
+// time bucket, since only the latest buckets have new rows. The watermark to pass as since next time is the time the
+// rollup started, less however late rows may be inserted. Rows of a group with a NULL group column aren't rolled up,
+// since they can't be told apart in the unique key of the summary.
+//
+// The rollup is a single statement run in a transaction, or in the transaction of the context if it has one.
+func (ss *SQLStore) RollupInto(ctx context.Context, bean interface{}, timeColumn string, summaryTable string, groupByCols []string, aggExprs []string, since time.Time) error {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return fmt.Errorf("could not resolve the table of %T", bean)
+	}
+	if len(groupByCols) == 0 {
+		return fmt.Errorf("a rollup needs at least one group column")
+	}
+	if len(aggExprs) == 0 {
+		return fmt.Errorf("a rollup needs at least one aggregate")
+	}
+	for _, column := range append([]string{timeColumn}, groupByCols...) {
+		if table.GetColumn(column) == nil {
+			return fmt.Errorf("table %s has no column %q", table.Name, column)
+		}
+	}
+
+	groups := make([]string, 0, len(groupByCols))
+	for _, column := range groupByCols {
+		groups = append(groups, ss.Dialect.Quote(column))
+	}
+	aggregates := make([]string, 0, len(aggExprs))
+	aliases := make([]string, 0, len(aggExprs))
+	for _, aggExpr := range aggExprs {
+		expr, alias, err := splitRollupAlias(aggExpr)
+		if err != nil {
+			return err
+		}
+		aggregates = append(aggregates, expr+" AS "+ss.Dialect.Quote(alias))
+		aliases = append(aliases, ss.Dialect.Quote(alias))
+	}
+
+	upsert, err := ss.rollupUpsertSQL(groups, aliases)
+	if err != nil {
+		return err
+	}
+
+	source := ss.Dialect.Quote(table.Name)
+	groupList := strings.Join(groups, ", ")
+	// SQLite can't parse an upsert after a SELECT without WHERE
+	where := "WHERE 1 = 1"
+	var args []interface{}
+	if !since.IsZero() {
+		if len(groups) > 1 {
+			groupList = "(" + groupList + ")"
+		}
+		where = fmt.Sprintf("WHERE %s IN (SELECT %s FROM %s WHERE %s >= ?)", groupList, strings.Join(groups, ", "), source, ss.Dialect.Quote(timeColumn))
+		args = append(args, ss.StoreTime(since))
+	}
+
+	rawSQL := fmt.Sprintf("INSERT INTO %s (%s, %s) SELECT %s, %s FROM %s %s GROUP BY %s %s",
+		ss.Dialect.Quote(summaryTable), strings.Join(groups, ", "), strings.Join(aliases, ", "),
+		strings.Join(groups, ", "), strings.Join(aggregates, ", "), source, where, strings.Join(groups, ", "), upsert)
+	for _, filter := range ss.engine.Dialect().Filters() {
+		rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table.Table)
+	}
+
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec(append([]interface{}{rawSQL}, args...)...)
+		return err
+	})
+}
+
+// splitRollupAlias splits an aggregate of a rollup into its expression and the summary column it's stored in, which
+// follows its last AS
+func splitRollupAlias(aggExpr string) (string, string, error) {
+	i := strings.LastIndex(strings.ToUpper(aggExpr), " AS ")
+	if i < 0 {
+		return "", "", fmt.Errorf("aggregate %q has no alias naming its summary column", aggExpr)
+	}
+	expr, alias := strings.TrimSpace(aggExpr[:i]), strings.TrimSpace(aggExpr[i+len(" AS "):])
+	if expr == "" || !rollupAliasPattern.MatchString(alias) {
+		return "", "", fmt.Errorf("aggregate %q must be an expression followed by AS and the name of its summary column", aggExpr)
+	}
+	return expr, alias, nil
+}
+
+// rollupUpsertSQL returns the clause updating the aggregates of the summary rows that already exist
+func (ss *SQLStore) rollupUpsertSQL(groups []string, aliases []string) (string, error) {
+	set := make([]string, 0, len(aliases))
+	switch ss.Dialect.DriverName() {
+	case migrator.SQLite, migrator.Postgres:
+		for _, alias := range aliases {
+			set = append(set, fmt.Sprintf("%s = excluded.%s", alias, alias))
+		}
+		return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(groups, ", "), strings.Join(set, ", ")), nil
+	case migrator.MySQL:
+		for _, alias := range aliases {
+			set = append(set, fmt.Sprintf("%s = VALUES(%s)", alias, alias))
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", "), nil
+	}
+	return "", fmt.Errorf("rollups are not supported for database type %q", ss.Dialect.DriverName())
+}
@@ -0,0 +1,170 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rollupTestEvent struct {
+	ID       int64     `xorm:"pk autoincr 'id'"`
+	Kind     string    `xorm:"'kind'"`
+	Day      string    `xorm:"'day'"`
+	Duration int64     `xorm:"'duration'"`
+	Created  time.Time `xorm:"'created'"`
+}
+
+type rollupTestSummary struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	Kind          string `xorm:"unique(kind_day) 'kind'"`
+	Day           string `xorm:"unique(kind_day) 'day'"`
+	Events        int64  `xorm:"'events'"`
+	TotalDuration int64  `xorm:"'total_duration'"`
+}
+
+type rollupTestKindSummary struct {
+	Kind   string `xorm:"pk 'kind'"`
+	Events int64  `xorm:"'events'"`
+}
+
+func TestIntegrationRollupInto(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(rollupTestEvent), new(rollupTestSummary), new(rollupTestKindSummary))
+	require.NoError(t, err)
+
+	aggExprs := []string{"COUNT(*) AS events", "SUM(duration) as total_duration"}
+	groupByCols := []string{"kind", "day"}
+	start := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	reset := func(t *testing.T) {
+		t.Helper()
+		for _, table := range []string{"rollup_test_event", "rollup_test_summary"} {
+			_, err := ss.engine.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+	}
+	insert := func(t *testing.T, events ...rollupTestEvent) {
+		t.Helper()
+		for i := range events {
+			_, err := ss.engine.Insert(&events[i])
+			require.NoError(t, err)
+		}
+	}
+	summary := func(t *testing.T) map[string]rollupTestSummary {
+		t.Helper()
+		var rows []rollupTestSummary
+		require.NoError(t, ss.engine.Find(&rows))
+		byGroup := map[string]rollupTestSummary{}
+		for _, row := range rows {
+			byGroup[row.Kind+"/"+row.Day] = rollupTestSummary{Kind: row.Kind, Day: row.Day, Events: row.Events, TotalDuration: row.TotalDuration}
+		}
+		return byGroup
+	}
+
+	t.Run("rolls up every group without a watermark", func(t *testing.T) {
+		reset(t)
+		insert(t,
+			rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 10, Created: start},
+			rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 20, Created: start.Add(time.Hour)},
+			rollupTestEvent{Kind: "query", Day: "2022-11-01", Duration: 5, Created: start},
+			rollupTestEvent{Kind: "login", Day: "2022-11-02", Duration: 7, Created: start.Add(24 * time.Hour)},
+		)
+
+		err := ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, aggExprs, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, map[string]rollupTestSummary{
+			"login/2022-11-01": {Kind: "login", Day: "2022-11-01", Events: 2, TotalDuration: 30},
+			"query/2022-11-01": {Kind: "query", Day: "2022-11-01", Events: 1, TotalDuration: 5},
+			"login/2022-11-02": {Kind: "login", Day: "2022-11-02", Events: 1, TotalDuration: 7},
+		}, summary(t))
+	})
+
+	t.Run("rolls up the groups with rows since the watermark from all of their rows", func(t *testing.T) {
+		reset(t)
+		insert(t,
+			rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 10, Created: start},
+			rollupTestEvent{Kind: "query", Day: "2022-11-01", Duration: 5, Created: start},
+		)
+		err := ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, aggExprs, time.Time{})
+		require.NoError(t, err)
+
+		// the summary of a group without new rows is left as it is, which the marker tells
+		_, err = ss.engine.Exec("UPDATE rollup_test_summary SET events = 100 WHERE kind = ?", "query")
+		require.NoError(t, err)
+		watermark := start.Add(time.Hour)
+		insert(t,
+			rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 20, Created: watermark},
+			rollupTestEvent{Kind: "login", Day: "2022-11-02", Duration: 7, Created: watermark.Add(time.Hour)},
+		)
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, aggExprs, watermark)
+		require.NoError(t, err)
+		require.Equal(t, map[string]rollupTestSummary{
+			"login/2022-11-01": {Kind: "login", Day: "2022-11-01", Events: 2, TotalDuration: 30},
+			"query/2022-11-01": {Kind: "query", Day: "2022-11-01", Events: 100, TotalDuration: 5},
+			"login/2022-11-02": {Kind: "login", Day: "2022-11-02", Events: 1, TotalDuration: 7},
+		}, summary(t))
+
+		// nothing is rolled up without rows since the watermark
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, aggExprs, watermark.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, summary(t), 3)
+	})
+
+	t.Run("rolls up incrementally by a single group column", func(t *testing.T) {
+		reset(t)
+		_, err := ss.engine.Exec("DELETE FROM rollup_test_kind_summary")
+		require.NoError(t, err)
+		insert(t,
+			rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 10, Created: start},
+			rollupTestEvent{Kind: "login", Day: "2022-11-02", Duration: 20, Created: start.Add(24 * time.Hour)},
+			rollupTestEvent{Kind: "query", Day: "2022-11-01", Duration: 5, Created: start},
+		)
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_kind_summary", []string{"kind"}, []string{"COUNT(*) AS events"}, start.Add(time.Hour))
+		require.NoError(t, err)
+		var rows []rollupTestKindSummary
+		require.NoError(t, ss.engine.Find(&rows))
+		require.Equal(t, []rollupTestKindSummary{{Kind: "login", Events: 2}}, rows)
+	})
+
+	t.Run("rolls up within the transaction in the context", func(t *testing.T) {
+		reset(t)
+		insert(t, rollupTestEvent{Kind: "login", Day: "2022-11-01", Duration: 10, Created: start})
+		errRollback := errors.New("rollback")
+
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			err := ss.RollupInto(ctx, &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, aggExprs, time.Time{})
+			require.NoError(t, err)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		require.Empty(t, summary(t))
+	})
+
+	t.Run("fails on invalid arguments", func(t *testing.T) {
+		err := ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", []string{"kind", "region"}, aggExprs, time.Time{})
+		require.ErrorContains(t, err, `has no column "region"`)
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "updated", "rollup_test_summary", groupByCols, aggExprs, time.Time{})
+		require.ErrorContains(t, err, `has no column "updated"`)
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", nil, aggExprs, time.Time{})
+		require.ErrorContains(t, err, "at least one group column")
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, nil, time.Time{})
+		require.ErrorContains(t, err, "at least one aggregate")
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, []string{"COUNT(*)"}, time.Time{})
+		require.ErrorContains(t, err, "has no alias")
+
+		err = ss.RollupInto(context.Background(), &rollupTestEvent{}, "created", "rollup_test_summary", groupByCols, []string{"COUNT(*) AS events; DROP TABLE x"}, time.Time{})
+		require.ErrorContains(t, err, "followed by AS and the name of its summary column")
+	})
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// TableSpec describes a table the database is expected to have.
+type TableSpec struct {
+	Name    string
+	Columns []ColumnSpec
+}
+
+// ColumnSpec describes a column a table is expected to have. Type is one of the migrator column types, e.g.
+// migrator.DB_BigInt, and is compared to the live column as the type it's mapped to by the dialect.
+type ColumnSpec struct {
+	Name   string
+	Type   string
+	Length int
+}
+
+// SchemaMismatchError is returned by AssertSchema if the live schema doesn't match the expected one.
+type SchemaMismatchError struct {
+	// Diffs describes every difference, one per missing table, missing column or mismatched column type
+	Diffs []string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return "database schema doesn't match the expected schema:\n\t" + strings.Join(e.Diffs, "\n\t")
+}
+
+// schemaTypeSynonyms maps type names to the name of the same type used by the dialects, since the names the databases
+// report don't always match the ones the tables were created with, e.g. Postgres reports SERIAL columns as integers
+var schemaTypeSynonyms = map[string]string{
+	"INT":       migrator.DB_Integer,
+	"SERIAL":    migrator.DB_Integer,
+	"BIGSERIAL": migrator.DB_BigInt,
+	"BOOLEAN":   migrator.DB_Bool,
+	"TIMESTAMP": migrator.DB_DateTime,
+}
+
+// AssertSchema checks that the live schema has the expected tables with the expected columns and column types, so that
+// services can fail fast at startup on a database that has drifted from the schema they expect. Tables and columns that
+// aren't expected are ignored, and column lengths aren't compared, since not all databases report them.
+// If the schema doesn't match, a *SchemaMismatchError describing every difference is returned.
+func (ss *SQLStore) AssertSchema(ctx context.Context, expected []TableSpec) error {
+	var diffs []string
+	for _, table := range expected {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		exists, err := ss.engine.IsTableExist(table.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check if table %q exists: %w", table.Name, err)
+		}
+		if !exists {
+			diffs = append(diffs, fmt.Sprintf("table %q is missing", table.Name))
+			continue
+		}
+
+		_, columns, err := ss.engine.Dialect().GetColumns(table.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get the columns of table %q: %w", table.Name, err)
+		}
+
+		for _, spec := range table.Columns {
+			column, ok := columns[spec.Name]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("column %q of table %q is missing", spec.Name, table.Name))
+				continue
+			}
+
+			expectedType := ss.Dialect.SQLType(&migrator.Column{Name: spec.Name, Type: spec.Type, Length: spec.Length})
+			if normalizeSchemaType(expectedType) != normalizeSchemaType(column.SQLType.Name) {
+				diffs = append(diffs, fmt.Sprintf("column %q of table %q has type %s, expected %s", spec.Name, table.Name, column.SQLType.Name, expectedType))
+			}
+		}
+	}
+
+	if len(diffs) > 0 {
+		return &SchemaMismatchError{Diffs: diffs}
+	}
+	return nil
+}
+
+// normalizeSchemaType strips the length and modifiers from the type and maps it to the name the dialects use
+func normalizeSchemaType(sqlType string) string {
+	name := strings.ToUpper(strings.TrimSpace(sqlType))
+	if i := strings.IndexAny(name, "( "); i >= 0 {
+		name = name[:i]
+	}
+	if synonym, ok := schemaTypeSynonyms[name]; ok {
+		return synonym
+	}
+	return name
+}
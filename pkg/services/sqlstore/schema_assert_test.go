@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestIntegrationAssertSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+
+	userTable := TableSpec{
+		Name: "user",
+		Columns: []ColumnSpec{
+			{Name: "id", Type: migrator.DB_BigInt},
+			{Name: "version", Type: migrator.DB_Int},
+			{Name: "login", Type: migrator.DB_NVarchar, Length: 190},
+			{Name: "is_admin", Type: migrator.DB_Bool},
+			{Name: "created", Type: migrator.DB_DateTime},
+		},
+	}
+
+	t.Run("returns no error if the schema matches", func(t *testing.T) {
+		require.NoError(t, db.AssertSchema(context.Background(), []TableSpec{userTable}))
+	})
+
+	t.Run("reports a mismatched column type", func(t *testing.T) {
+		mismatched := TableSpec{
+			Name: "user",
+			Columns: []ColumnSpec{
+				{Name: "login", Type: migrator.DB_NVarchar, Length: 190},
+				{Name: "created", Type: migrator.DB_Blob},
+			},
+		}
+
+		err := db.AssertSchema(context.Background(), []TableSpec{mismatched})
+		var mismatchErr *SchemaMismatchError
+		require.ErrorAs(t, err, &mismatchErr)
+		require.Len(t, mismatchErr.Diffs, 1)
+		require.Contains(t, mismatchErr.Diffs[0], `column "created" of table "user" has type`)
+		require.Contains(t, err.Error(), mismatchErr.Diffs[0])
+	})
+
+	t.Run("reports missing tables and columns", func(t *testing.T) {
+		err := db.AssertSchema(context.Background(), []TableSpec{
+			{Name: "user", Columns: []ColumnSpec{{Name: "does_not_exist", Type: migrator.DB_Text}}},
+			{Name: "does_not_exist"},
+		})
+		var mismatchErr *SchemaMismatchError
+		require.ErrorAs(t, err, &mismatchErr)
+		require.Equal(t, []string{
+			`column "does_not_exist" of table "user" is missing`,
+			`table "does_not_exist" is missing`,
+		}, mismatchErr.Diffs)
+	})
+}
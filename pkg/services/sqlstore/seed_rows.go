@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// SeedRows inserts each of the beans unless a row of its table with the same values of the key columns already exists,
+// and returns the number of inserted rows, so that default rows can be seeded every time an instance starts. The beans
+// can be of different tables, which all need the key columns. They're inserted in one transaction, each behind a
+// savepoint, and a bean whose insert fails on a unique index is taken to have been seeded concurrently by another
+// instance rather than failing the seeding. The key columns therefore need a unique index for concurrent seeding to
+// be race-safe.
+func (ss *SQLStore) SeedRows(ctx context.Context, beans []interface{}, keyCols []string) (int, error) {
+	if len(keyCols) == 0 {
+		return 0, fmt.Errorf("at least one key column is required")
+	}
+
+	tables := make([]string, len(beans))
+	conditions := make([]builder.Eq, len(beans))
+	for i, bean := range beans {
+		table := ss.engine.TableInfo(bean)
+		if !table.IsValid() {
+			return 0, fmt.Errorf("could not resolve the table of %T", bean)
+		}
+		cond, err := keyCondition(ss.Dialect, table, bean, keyCols)
+		if err != nil {
+			return 0, err
+		}
+		tables[i], conditions[i] = table.Name, cond
+	}
+
+	created := 0
+	err := ss.InTransaction(ctx, func(ctx context.Context) error {
+		// the transaction is run again if it's retried
+		created = 0
+		for i, bean := range beans {
+			err := ss.WithSavepoint(ctx, "seed_rows", func(sess *DBSession) error {
+				exists, err := sess.Table(tables[i]).Where(conditions[i]).Exist()
+				if err != nil || exists {
+					return err
+				}
+				if _, err := sess.Insert(bean); err != nil {
+					return err
+				}
+				created++
+				return nil
+			})
+			if err != nil && !ss.Dialect.IsUniqueConstraintViolation(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// keyCondition returns the condition matching the rows of the table with the same values of the key columns as the bean
+func keyCondition(d migrator.Dialect, table *xorm.Table, bean interface{}, keyCols []string) (builder.Eq, error) {
+	cond := builder.Eq{}
+	for _, name := range keyCols {
+		column := table.GetColumn(name)
+		if column == nil {
+			return nil, fmt.Errorf("table %q has no column %q", table.Name, name)
+		}
+		value, err := column.ValueOf(bean)
+		if err != nil {
+			return nil, err
+		}
+		cond[d.Quote(column.Name)] = value.Interface()
+	}
+	return cond, nil
+}
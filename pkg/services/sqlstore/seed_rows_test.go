@@ -0,0 +1,119 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type seedRowsTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Name  string `xorm:"unique"`
+	Value string
+}
+
+func TestIntegrationSeedRows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(seedRowsTestItem))
+	require.NoError(t, err)
+
+	reset := func(t *testing.T) {
+		t.Helper()
+		_, err := ss.engine.Exec("DELETE FROM seed_rows_test_item")
+		require.NoError(t, err)
+	}
+	seeds := func() []interface{} {
+		return []interface{}{
+			&seedRowsTestItem{Name: "org", Value: "default"},
+			&seedRowsTestItem{Name: "admin", Value: "default"},
+			&seedRowsTestItem{Name: "settings", Value: "default"},
+		}
+	}
+	items := func(t *testing.T) map[string]string {
+		t.Helper()
+		var items []seedRowsTestItem
+		require.NoError(t, ss.engine.Find(&items))
+		values := make(map[string]string, len(items))
+		for _, item := range items {
+			values[item.Name] = item.Value
+		}
+		return values
+	}
+
+	t.Run("is idempotent", func(t *testing.T) {
+		reset(t)
+
+		created, err := ss.SeedRows(context.Background(), seeds(), []string{"name"})
+		require.NoError(t, err)
+		require.Equal(t, 3, created)
+
+		created, err = ss.SeedRows(context.Background(), seeds(), []string{"name"})
+		require.NoError(t, err)
+		require.Equal(t, 0, created)
+		require.Equal(t, map[string]string{"org": "default", "admin": "default", "settings": "default"}, items(t))
+	})
+
+	t.Run("only inserts the missing rows and leaves the existing ones as they are", func(t *testing.T) {
+		reset(t)
+		_, err := ss.engine.Insert(&seedRowsTestItem{Name: "admin", Value: "changed"})
+		require.NoError(t, err)
+
+		created, err := ss.SeedRows(context.Background(), seeds(), []string{"name"})
+		require.NoError(t, err)
+		require.Equal(t, 2, created)
+		require.Equal(t, map[string]string{"org": "default", "admin": "changed", "settings": "default"}, items(t))
+	})
+
+	t.Run("seeds each row once across concurrent instances", func(t *testing.T) {
+		reset(t)
+
+		const instances = 5
+		var wg sync.WaitGroup
+		created := make([]int, instances)
+		errs := make([]error, instances)
+		for i := 0; i < instances; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				created[i], errs[i] = ss.SeedRows(context.Background(), seeds(), []string{"name"})
+			}(i)
+		}
+		wg.Wait()
+
+		total := 0
+		for i := 0; i < instances; i++ {
+			require.NoError(t, errs[i])
+			total += created[i]
+		}
+		require.Equal(t, 3, total)
+		require.Len(t, items(t), 3)
+	})
+
+	t.Run("is rolled back with the transaction in the context", func(t *testing.T) {
+		reset(t)
+		errRollback := errors.New("roll back")
+
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			created, err := ss.SeedRows(ctx, seeds(), []string{"name"})
+			require.NoError(t, err)
+			require.Equal(t, 3, created)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		require.Empty(t, items(t))
+	})
+
+	t.Run("fails for an unknown key column", func(t *testing.T) {
+		_, err := ss.SeedRows(context.Background(), seeds(), []string{"unknown"})
+		require.Error(t, err)
+
+		_, err = ss.SeedRows(context.Background(), seeds(), nil)
+		require.Error(t, err)
+	})
+}
@@ -3,24 +3,31 @@ package sqlstore
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"xorm.io/xorm"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	"github.com/grafana/grafana/pkg/util/retryer"
-	"github.com/mattn/go-sqlite3"
 )
 
-var sessionLogger = log.New("sqlstore.session")
+var sessionLogger log.Logger = log.New("sqlstore.session")
 var ErrMaximumRetriesReached = errutil.NewBase(errutil.StatusInternal, "sqlstore.max-retries-reached")
 
 type DBSession struct {
 	*xorm.Session
 	transactionOpen bool
 	events          []interface{}
+	timer           *sessionTimer
+	// engine is the engine the session was started on
+	engine *xorm.Engine
+	// release unregisters the session from the sessions of the store once it's closed
+	release func()
 }
 
 type DBTransactionFunc func(sess *DBSession) error
@@ -33,7 +40,18 @@ func (sess *DBSession) PublishAfterCommit(msg interface{}) {
 	sess.events = append(sess.events, msg)
 }
 
-func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DBSession, bool, error) {
+// Close closes the session and unregisters it from the sessions of the store, so that Shutdown stops waiting for it
+func (sess *DBSession) Close() {
+	sess.Session.Close()
+	if sess.release != nil {
+		sess.release()
+	}
+}
+
+// startSessionOrUseExisting returns the session in the context, or starts a new one that is registered with the
+// sessions, which fails with ErrShuttingDown once the store is shutting down. A reused session isn't registered again,
+// since it was registered by the scope that started it.
+func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, sessions *sessionTracker, beginTran bool) (*DBSession, bool, error) {
 	value := ctx.Value(ContextSessionKey{})
 	var sess *DBSession
 	sess, ok := value.(*DBSession)
@@ -45,10 +63,19 @@ func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, beginTr
 		return sess, false, nil
 	}
 
-	newSess := &DBSession{Session: engine.NewSession(), transactionOpen: beginTran}
+	release, err := sessions.acquire()
+	if err != nil {
+		return nil, false, err
+	}
+	newSess := &DBSession{Session: engine.NewSession(), transactionOpen: beginTran, engine: engine, release: release}
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
+			newSess.Close()
+			return nil, false, err
+		}
+		if err := setStatementTimeout(ctx, newSess); err != nil {
+			newSess.Close()
 			return nil, false, err
 		}
 	}
@@ -58,79 +85,362 @@ func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, beginTr
 	return newSess, true, nil
 }
 
+// setStatementTimeout limits the statements of the transaction to the time left until the deadline of the context, if
+// it has one, on databases that can time them out themselves. Statements are run with the context, so on the other
+// databases, and outside of transactions where statements may run on any connection of the pool, the driver cancels
+// them once the deadline has passed.
+func setStatementTimeout(ctx context.Context, sess *DBSession) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+
+	if rawSQL := dialect.StatementTimeoutSQL(timeout); rawSQL != "" {
+		if _, err := sess.Exec(rawSQL); err != nil {
+			return fmt.Errorf("failed to set the statement timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+// withContextError returns the error of a statement, wrapping the error of the context if the statement failed after
+// the context was done, e.g. on a statement timeout, so that errors.Is(err, context.DeadlineExceeded) holds
+func withContextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", err, ctx.Err())
+}
+
 // WithDbSession calls the callback with the session in the context (if exists).
 // Otherwise it creates a new one that is closed upon completion.
 // A session is stored in the context if sqlstore.InTransaction() has been been previously called with the same context (and it's not committed/rolledback yet).
-// In case of sqlite3.ErrLocked or sqlite3.ErrBusy failure it will be retried at most five times before giving up.
-func (ss *SQLStore) WithDbSession(ctx context.Context, callback DBTransactionFunc) error {
-	return ss.withDbSession(ctx, ss.engine, callback)
+// In case of a lock failure, e.g. sqlite3.ErrBusy or a deadlock, it will be retried at most QueryRetries times before giving up,
+// unless the retries are overridden with MaxRetries. The session of a transaction isn't retried, since the transaction
+// has to be re-run as a whole.
+func (ss *SQLStore) WithDbSession(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error {
+	markWritten(ctx)
+	return ss.withDbSession(ctx, ss.engine, callback, opts...)
 }
 
-// WithNewDbSession calls the callback with a new session that is closed upon completion.
-// In case of sqlite3.ErrLocked or sqlite3.ErrBusy failure it will be retried at most five times before giving up.
-func (ss *SQLStore) WithNewDbSession(ctx context.Context, callback DBTransactionFunc) error {
-	sess := &DBSession{Session: ss.engine.NewSession(), transactionOpen: false}
+// WithReadOnlyDbSession calls the callback with the session in the context (if exists), so that the reads of a
+// transaction see its writes. Otherwise it creates a new one on the read replica, or on the database if no replica is
+// configured or the context has written with ReadYourWrites, that is closed upon completion. The callback must not
+// write, and may not see the latest writes when running on the replica.
+// In case of a lock failure, e.g. sqlite3.ErrBusy or a deadlock, it will be retried at most QueryRetries times before giving up,
+// unless the retries are overridden with MaxRetries.
+func (ss *SQLStore) WithReadOnlyDbSession(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error {
+	return ss.withDbSession(ctx, ss.readOnlyEngine(ctx), callback, opts...)
+}
+
+// readOnlyEngine returns the engine of the new sessions of the reads of the context, which is the read replica unless
+// no replica is configured or the context has written with ReadYourWrites
+func (ss *SQLStore) readOnlyEngine(ctx context.Context) *xorm.Engine {
+	if ss.readEngine != nil && !writtenIn(ctx) {
+		return ss.readEngine
+	}
+	return ss.engine
+}
+
+// WithBoundedStalenessRead calls the callback with a session like WithReadOnlyDbSession, but on the database rather
+// than the replica if the replica lags behind it by more than maxStaleness, or if its lag can't be told, so that the
+// callback never reads data older than that. The lag is checked on every call, which costs a query on the replica,
+// unless the context has written with ReadYourWrites, in which case the database is used without checking it.
+func (ss *SQLStore) WithBoundedStalenessRead(ctx context.Context, maxStaleness time.Duration, callback DBTransactionFunc, opts ...SessionOption) error {
+	engine := ss.engine
+	if _, inSession := ctx.Value(ContextSessionKey{}).(*DBSession); !inSession && ss.readEngine != nil && !writtenIn(ctx) && ss.replicaWithin(ctx, maxStaleness) {
+		engine = ss.readEngine
+	}
+	return ss.withDbSession(ctx, engine, callback, opts...)
+}
+
+// replicaWithin returns true if the replica lags behind the database by at most maxStaleness
+func (ss *SQLStore) replicaWithin(ctx context.Context, maxStaleness time.Duration) bool {
+	sess := ss.readEngine.NewSession().Context(ctx)
 	defer sess.Close()
-	retry := 0
-	return retryer.Retry(ss.retryOnLocks(ctx, callback, sess, retry), ss.dbCfg.QueryRetries, time.Millisecond*time.Duration(10), time.Second)
+
+	ctxLogger := sessionLogger.FromContext(ctx)
+	lag, err := ss.Dialect.ReplicationLag(sess)
+	if err != nil {
+		ctxLogger.Warn("Failed to tell the lag of the replica, reading from the database", "error", err)
+		return false
+	}
+	if lag > maxStaleness {
+		ctxLogger.Debug("Replica lags behind, reading from the database", "lag", lag, "maxStaleness", maxStaleness)
+		return false
+	}
+	return true
 }
 
-func (ss *SQLStore) retryOnLocks(ctx context.Context, callback DBTransactionFunc, sess *DBSession, retry int) func() (retryer.RetrySignal, error) {
-	return func() (retryer.RetrySignal, error) {
-		retry++
+// readYourWritesKey is the context key of the writeTracker of ReadYourWrites
+type readYourWritesKey struct{}
 
-		err := callback(sess)
+// writeTracker records whether a session on the database has been run in a context, which is shared by the contexts
+// derived from it
+type writeTracker struct {
+	written atomic.Bool
+}
 
-		ctxLogger := tsclogger.FromContext(ctx)
+// ReadYourWrites returns a context in which reads stop going to the read replica once something may have been
+// written in it, e.g. for the duration of a request, so that the request reads its own writes however far the replica
+// lags behind. From then on WithReadOnlyDbSession and WithBoundedStalenessRead use the database, as do the contexts
+// derived from it. The statements of a session can't be told apart, so every session on the database counts as a
+// write, i.e. those of WithDbSession, WithNewDbSession and transactions, while those of WithReadOnlyDbSession and
+// ParallelReads don't.
+// Without it reads go to the replica regardless of what was written.
+func ReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(readYourWritesKey{}).(*writeTracker); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, readYourWritesKey{}, &writeTracker{})
+}
 
-		var sqlError sqlite3.Error
-		if errors.As(err, &sqlError) && (sqlError.Code == sqlite3.ErrLocked || sqlError.Code == sqlite3.ErrBusy) {
-			ctxLogger.Info("Database locked, sleeping then retrying", "error", err, "retry", retry, "code", sqlError.Code)
-			// retryer immediately returns the error (if there is one) without checking the response
-			// therefore we only have to send it if we have reached the maximum retries
-			if retry == ss.dbCfg.QueryRetries {
-				return retryer.FuncError, ErrMaximumRetriesReached.Errorf("retry %d: %w", retry, err)
-			}
-			return retryer.FuncFailure, nil
+// markWritten records that a session on the database has been run in the context, if it tracks its writes
+func markWritten(ctx context.Context) {
+	if tracker, ok := ctx.Value(readYourWritesKey{}).(*writeTracker); ok {
+		tracker.written.Store(true)
+	}
+}
+
+// writtenIn returns true if the context tracks its writes and a session on the database has been run in it
+func writtenIn(ctx context.Context) bool {
+	tracker, ok := ctx.Value(readYourWritesKey{}).(*writeTracker)
+	return ok && tracker.written.Load()
+}
+
+// WithNewDbSession calls the callback with a new session that is closed upon completion.
+// In case of a lock failure, e.g. sqlite3.ErrBusy or a deadlock, it will be retried at most QueryRetries times before giving up,
+// unless the retries are overridden with MaxRetries.
+func (ss *SQLStore) WithNewDbSession(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error {
+	markWritten(ctx)
+	release, err := ss.sessions.acquire()
+	if err != nil {
+		return err
+	}
+	_, timer, done := startSessionTimer(ctx)
+	defer done()
+	sess := &DBSession{Session: ss.engine.NewSession().Context(ctx), transactionOpen: false, timer: timer, engine: ss.engine, release: release}
+	defer sess.Close()
+	return ss.retryOnLocks(ctx, callback, sess, timer, ss.queryRetries(opts))
+}
+
+// retryOnLocks calls the callback until it doesn't fail on a lock, e.g. with sqlite3.ErrLocked or a MySQL deadlock, at
+// most queryRetries times. The delay between the attempts grows exponentially from QueryRetryMinDelay up to
+// QueryRetryMaxDelay, with jitter so that sessions waiting on the same lock don't retry in lockstep. The retries are
+// counted in the session retry metrics, labeled by the operation of the context. Once the context is done no further
+// attempt is made, and the returned error wraps the context's error.
+func (ss *SQLStore) retryOnLocks(ctx context.Context, callback DBTransactionFunc, sess *DBSession, timer *sessionTimer, queryRetries int) error {
+	ctxLogger := tsclogger.FromContext(ctx)
+	for retry := 1; ; retry++ {
+		// a cancelled request shouldn't hold on to the connection for the rest of the retries
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("attempt %d: %w", retry, err)
 		}
+		err := withContextError(ctx, timer.run(func() error { return callback(sess) }))
 
-		if err != nil {
-			return retryer.FuncError, err
+		if err == nil {
+			sessionRetries.succeeded(ctx, retry-1)
+			return nil
+		}
+		if !isRetriableLockError(err, ss.Dialect) {
+			return err
+		}
+		if retry >= queryRetries {
+			sessionRetries.exhaustedRetries(ctx)
+			return ErrMaximumRetriesReached.Errorf("retry %d: %w", retry, err)
 		}
 
-		return retryer.FuncComplete, nil
+		delay := ss.queryRetryDelay(retry)
+		ctxLogger.Info("Database locked, sleeping then retrying", "error", err, "retry", retry, "delay", delay)
+		sessionRetries.retried(ctx)
+		// the time from a failed attempt to the next one is lock wait
+		failedAt := time.Now()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("retry %d: %w", retry, ctx.Err())
+		case <-time.After(delay):
+		}
+		timer.waited(time.Since(failedAt))
+	}
+}
+
+// SessionOption overrides the configuration of the database for a single session.
+type SessionOption func(*sessionOptions)
+
+type sessionOptions struct {
+	// maxRetries overrides QueryRetries if it's set
+	maxRetries *int
+}
+
+// MaxRetries overrides the number of times a session is retried on lock failures, rather than QueryRetries, without
+// changing the configuration. With 0 the callback is run exactly once.
+func MaxRetries(n int) SessionOption {
+	return func(o *sessionOptions) {
+		o.maxRetries = &n
 	}
 }
 
-func (ss *SQLStore) withDbSession(ctx context.Context, engine *xorm.Engine, callback DBTransactionFunc) error {
-	sess, isNew, err := startSessionOrUseExisting(ctx, engine, false)
+// queryRetries returns the number of attempts retryOnLocks makes, which is QueryRetries unless it's overridden
+func (ss *SQLStore) queryRetries(opts []SessionOption) int {
+	var o sessionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxRetries == nil {
+		return ss.dbCfg.QueryRetries
+	}
+	// the first attempt isn't a retry
+	return *o.maxRetries + 1
+}
+
+// isRetriableLockError returns true if the error is one of the lock errors the dialect declares safe to retry, e.g.
+// sqlite3.ErrBusy on SQLite, deadlocks and lock wait timeouts on MySQL and deadlocks and serialization failures on
+// Postgres. Errors of other drivers than the dialect's never are.
+func isRetriableLockError(err error, dialect migrator.Dialect) bool {
+	return err != nil && dialect.IsLockError(err)
+}
+
+// queryRetryDelay returns the delay after the failed attempt. The delay is QueryRetryMinDelay doubled for every
+// attempt, capped at QueryRetryMaxDelay, of which the second half is randomized, and never less than the min delay.
+func (ss *SQLStore) queryRetryDelay(retry int) time.Duration {
+	minDelay, maxDelay := ss.dbCfg.QueryRetryMinDelay, ss.dbCfg.QueryRetryMaxDelay
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+
+	backoff := maxDelay
+	// shifting by more than this could overflow
+	if retry < 32 {
+		if d := minDelay << uint(retry); d > 0 && d < maxDelay {
+			backoff = d
+		}
+	}
+	delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff-backoff/2)+1))
+	if delay < minDelay {
+		return minDelay
+	}
+	return delay
+}
+
+func (ss *SQLStore) withDbSession(ctx context.Context, engine *xorm.Engine, callback DBTransactionFunc, opts ...SessionOption) error {
+	_, timer, done := startSessionTimer(ctx)
+	defer done()
+	sess, isNew, err := startSessionOrUseExisting(ctx, engine, ss.sessions, false)
 	if err != nil {
 		return err
 	}
 	if isNew {
+		sess.timer = timer
 		defer sess.Close()
 	}
-	retry := 0
-	return retryer.Retry(ss.retryOnLocks(ctx, callback, sess, retry), ss.dbCfg.QueryRetries, time.Millisecond*time.Duration(10), time.Second)
+	if ss.dbCfg.LogSlowQueries {
+		defer logSlowSession(ctx, sess, ss.dbCfg.SlowQueryThreshold, time.Now())
+	}
+	if !isNew {
+		// on a lock error the database has already aborted or rolled back the transaction of the outer scope, so
+		// re-running the callback alone would run part of the unit of work outside of it. The error is returned for the
+		// outer scope to re-run the whole transaction instead, e.g. with RetryableTransaction.
+		return withContextError(ctx, timer.run(func() error { return callback(sess) }))
+	}
+	return ss.retryOnLocks(ctx, callback, sess, timer, ss.queryRetries(opts))
+}
+
+// logSlowSession logs a warning if the session took at least the threshold since it started, including all its
+// attempts, along with the last SQL it ran if the engine logs SQL
+func logSlowSession(ctx context.Context, sess *DBSession, threshold time.Duration, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < threshold {
+		return
+	}
+
+	logCtx := []interface{}{"elapsed", elapsed, "threshold", threshold}
+	if sess.engine != nil && sess.engine.Logger().IsShowSQL() {
+		if lastSQL, args := sess.LastSQL(); lastSQL != "" {
+			logCtx = append(logCtx, "lastSQL", lastSQL, "args", args)
+		}
+	}
+	sessionLogger.FromContext(ctx).Warn("Slow database session", logCtx...)
 }
 
+// InsertId inserts the bean, running the dialect's insert hooks around it, e.g. to allow inserting explicit ids on
+// MSSQL or to reset the sequence of the table on Postgres, and returns the id of the inserted row. If the hook after
+// the insert fails, the row has been inserted nonetheless, so its id is returned along with the error, which tells
+// that the row was inserted. In a transaction it's up to the caller whether to roll the row back.
 func (sess *DBSession) InsertId(bean interface{}) (int64, error) {
 	table := sess.DB().Mapper.Obj2Table(getTypeName(bean))
 
 	if err := dialect.PreInsertId(table, sess.Session); err != nil {
-		return 0, err
+		return 0, fmt.Errorf("preparing to insert into %s failed, nothing was inserted: %w", table, err)
 	}
 	id, err := sess.Session.InsertOne(bean)
 	if err != nil {
 		return 0, err
 	}
 	if err := dialect.PostInsertId(table, sess.Session); err != nil {
-		return 0, err
+		return id, fmt.Errorf("inserted row %d into %s, but finishing the insert failed: %w", id, table, err)
 	}
 
 	return id, nil
 }
 
+// InsertMany inserts the slice of beans with multi-row inserts of at most chunkSize rows, running the dialect's
+// insert hooks around each of them, and returns the number of inserted rows. The chunks are inserted in the session,
+// so they're only inserted all or none if it's a transaction. If chunkSize isn't positive the batch size of the
+// dialect is used, and a chunk never has more rows than fit in the placeholder limit of the dialect.
+func (sess *DBSession) InsertMany(beans interface{}, chunkSize int) (int64, error) {
+	slice := reflect.Indirect(reflect.ValueOf(beans))
+	if slice.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("need a slice of beans to insert, got %T", beans)
+	}
+	if slice.Len() == 0 {
+		return 0, nil
+	}
+
+	beanType := slice.Type().Elem()
+	for beanType.Kind() == reflect.Ptr {
+		beanType = beanType.Elem()
+	}
+	table := sess.DB().Mapper.Obj2Table(beanType.Name())
+
+	if chunkSize < 1 {
+		chunkSize = dialect.BatchSize()
+	}
+	// every column of every row is a placeholder
+	if sess.engine != nil {
+		if columns := len(sess.engine.TableInfo(reflect.New(beanType).Interface()).Columns()); columns > 0 {
+			if maxRows := dialect.MaxPlaceholders() / columns; chunkSize > maxRows && maxRows > 0 {
+				chunkSize = maxRows
+			}
+		}
+	}
+
+	var inserted int64
+	for start := 0; start < slice.Len(); start += chunkSize {
+		end := start + chunkSize
+		if end > slice.Len() {
+			end = slice.Len()
+		}
+
+		if err := dialect.PreInsertId(table, sess.Session); err != nil {
+			return inserted, err
+		}
+		n, err := sess.Session.InsertMulti(slice.Slice(start, end).Interface())
+		if err != nil {
+			return inserted, err
+		}
+		inserted += n
+		if err := dialect.PostInsertId(table, sess.Session); err != nil {
+			return inserted, err
+		}
+	}
+
+	return inserted, nil
+}
+
 func getTypeName(bean interface{}) (res string) {
 	t := reflect.TypeOf(bean)
 	for t.Kind() == reflect.Ptr {
@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unknownSessionOperation is the operation label of sessions whose context doesn't name their operation
+const unknownSessionOperation = "unknown"
+
+// sessionRetryMetrics counts the retries of sessions that failed on a lock, by the operation of the session
+type sessionRetryMetrics struct {
+	retries           *prometheus.CounterVec
+	exhausted         *prometheus.CounterVec
+	retriesPerSuccess *prometheus.HistogramVec
+}
+
+var sessionRetries = newSessionRetryMetrics()
+
+func init() {
+	prometheus.MustRegister(sessionRetries.retries, sessionRetries.exhausted, sessionRetries.retriesPerSuccess)
+}
+
+func newSessionRetryMetrics() *sessionRetryMetrics {
+	return &sessionRetryMetrics{
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Name:      "database_session_retries_total",
+			Help:      "Total number of times a session was retried because it failed on a lock",
+		}, []string{"operation"}),
+		exhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Name:      "database_session_retries_exhausted_total",
+			Help:      "Total number of sessions that failed on a lock after reaching the maximum number of retries",
+		}, []string{"operation"}),
+		retriesPerSuccess: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "grafana",
+			Name:      "database_session_retries_per_success",
+			Help:      "Number of times successful sessions were retried because they failed on a lock",
+			Buckets:   []float64{0, 1, 2, 3, 5, 10},
+		}, []string{"operation"}),
+	}
+}
+
+type sessionOperationKey struct{}
+
+// WithSessionOperation returns a context that labels the retry metrics of sessions started with it, e.g. by
+// WithDbSession, with the operation. Operations should be a small fixed set of names, e.g. "GetDashboard", since
+// every operation is a time series of its own.
+func WithSessionOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, sessionOperationKey{}, operation)
+}
+
+// sessionOperation returns the operation of the context, or unknownSessionOperation if it doesn't have one
+func sessionOperation(ctx context.Context) string {
+	if operation, ok := ctx.Value(sessionOperationKey{}).(string); ok && operation != "" {
+		return operation
+	}
+	return unknownSessionOperation
+}
+
+// retried counts a retry of a session after it failed on a lock
+func (m *sessionRetryMetrics) retried(ctx context.Context) {
+	m.retries.WithLabelValues(sessionOperation(ctx)).Inc()
+}
+
+// exhaustedRetries counts a session that failed on a lock after its last retry
+func (m *sessionRetryMetrics) exhaustedRetries(ctx context.Context) {
+	m.exhausted.WithLabelValues(sessionOperation(ctx)).Inc()
+}
+
+// succeeded records the number of times a successful session was retried
+func (m *sessionRetryMetrics) succeeded(ctx context.Context, retries int) {
+	m.retriesPerSuccess.WithLabelValues(sessionOperation(ctx)).Observe(float64(retries))
+}
@@ -0,0 +1,72 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationSessionRetryMetrics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := InitTestDB(t)
+	// the test store is shared between tests
+	dbCfg := store.dbCfg
+	t.Cleanup(func() { store.dbCfg = dbCfg })
+	store.dbCfg.QueryRetries = 3
+
+	// lockedCallback fails with a database locked error the first failures times
+	lockedCallback := func(failures int) DBTransactionFunc {
+		attempts := 0
+		return func(sess *DBSession) error {
+			attempts++
+			if attempts <= failures {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			return nil
+		}
+	}
+
+	t.Run("counts the retries of successful sessions by operation", func(t *testing.T) {
+		ctx := WithSessionOperation(context.Background(), "test-retried")
+		require.NoError(t, store.WithDbSession(ctx, lockedCallback(2)))
+		require.NoError(t, store.WithDbSession(ctx, lockedCallback(0)))
+
+		require.Equal(t, 2.0, testutil.ToFloat64(sessionRetries.retries.WithLabelValues("test-retried")))
+		require.Zero(t, testutil.ToFloat64(sessionRetries.exhausted.WithLabelValues("test-retried")))
+		require.NoError(t, testutil.CollectAndCompare(sessionRetries.retriesPerSuccess.WithLabelValues("test-retried").(prometheus.Histogram), strings.NewReader(`
+			# HELP grafana_database_session_retries_per_success Number of times successful sessions were retried because they failed on a lock
+			# TYPE grafana_database_session_retries_per_success histogram
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="0"} 1
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="1"} 1
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="2"} 2
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="3"} 2
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="5"} 2
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="10"} 2
+			grafana_database_session_retries_per_success_bucket{operation="test-retried",le="+Inf"} 2
+			grafana_database_session_retries_per_success_sum{operation="test-retried"} 2
+			grafana_database_session_retries_per_success_count{operation="test-retried"} 2
+		`), "grafana_database_session_retries_per_success"))
+	})
+
+	t.Run("counts sessions that exhausted their retries", func(t *testing.T) {
+		ctx := WithSessionOperation(context.Background(), "test-exhausted")
+		err := store.WithNewDbSession(ctx, lockedCallback(5))
+		require.ErrorIs(t, err, ErrMaximumRetriesReached)
+
+		require.Equal(t, 2.0, testutil.ToFloat64(sessionRetries.retries.WithLabelValues("test-exhausted")))
+		require.Equal(t, 1.0, testutil.ToFloat64(sessionRetries.exhausted.WithLabelValues("test-exhausted")))
+	})
+
+	t.Run("labels sessions without an operation as unknown", func(t *testing.T) {
+		before := testutil.ToFloat64(sessionRetries.retries.WithLabelValues(unknownSessionOperation))
+		require.NoError(t, store.WithDbSession(context.Background(), lockedCallback(1)))
+		require.Equal(t, before+1, testutil.ToFloat64(sessionRetries.retries.WithLabelValues(unknownSessionOperation)))
+	})
+}
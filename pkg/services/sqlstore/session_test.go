@@ -4,17 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
 	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
 func TestRetryingOnFailures(t *testing.T) {
 	store := InitTestDB(t)
 	store.dbCfg.QueryRetries = 5
 
-	funcToTest := map[string]func(ctx context.Context, callback DBTransactionFunc) error{
+	funcToTest := map[string]func(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error{
 		"WithDbSession":    store.WithDbSession,
 		"WithNewDbSession": store.WithNewDbSession,
 	}
@@ -61,8 +70,52 @@ func TestRetryingOnFailures(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, store.dbCfg.QueryRetries, i)
 		})
+
+		t.Run(fmt.Sprintf("%s should run the callback exactly once with MaxRetries(0)", name), func(t *testing.T) {
+			i := 0
+			callback := func(sess *DBSession) error {
+				i++
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			err := f(context.Background(), callback, MaxRetries(0))
+			require.ErrorIs(t, err, ErrMaximumRetriesReached)
+			require.Equal(t, 1, i)
+			require.Equal(t, 5, store.dbCfg.QueryRetries)
+		})
+
+		t.Run(fmt.Sprintf("%s should retry more often than QueryRetries with MaxRetries", name), func(t *testing.T) {
+			i := 0
+			callback := func(sess *DBSession) error {
+				i++
+				if i < 8 {
+					return sqlite3.Error{Code: sqlite3.ErrBusy}
+				}
+				return nil
+			}
+			err := f(context.Background(), callback, MaxRetries(7))
+			require.NoError(t, err)
+			require.Equal(t, 8, i)
+			require.Equal(t, 5, store.dbCfg.QueryRetries)
+		})
 	}
 
+	t.Run("WithDbSession should leave the retries to the transaction whose session it reuses", func(t *testing.T) {
+		transactions, sessions := 0, 0
+		err := store.InTransaction(context.Background(), func(ctx context.Context) error {
+			transactions++
+			return store.WithDbSession(ctx, func(sess *DBSession) error {
+				sessions++
+				if sessions == 1 {
+					return sqlite3.Error{Code: sqlite3.ErrBusy}
+				}
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, transactions)
+		require.Equal(t, 2, sessions)
+	})
+
 	// Check SQL query
 	sess := store.GetSqlxSession()
 	rows, err := sess.Query(context.Background(), `SELECT "hello",2.3,4`)
@@ -79,3 +132,625 @@ func TestRetryingOnFailures(t *testing.T) {
 	require.Equal(t, int64(4), val3)
 	require.False(t, rows.Next()) // no more rows
 }
+
+func TestQueryRetryDelay(t *testing.T) {
+	store := InitTestDB(t)
+
+	t.Run("defaults to between 10ms and 1s", func(t *testing.T) {
+		require.Equal(t, 10*time.Millisecond, store.dbCfg.QueryRetryMinDelay)
+		require.Equal(t, time.Second, store.dbCfg.QueryRetryMaxDelay)
+	})
+
+	t.Run("grows exponentially up to the max delay", func(t *testing.T) {
+		ss := &SQLStore{dbCfg: DatabaseConfig{QueryRetryMinDelay: 10 * time.Millisecond, QueryRetryMaxDelay: 100 * time.Millisecond}}
+		for retry, bounds := range map[int][2]time.Duration{
+			1:   {10 * time.Millisecond, 20 * time.Millisecond},
+			2:   {20 * time.Millisecond, 40 * time.Millisecond},
+			3:   {40 * time.Millisecond, 80 * time.Millisecond},
+			4:   {50 * time.Millisecond, 100 * time.Millisecond},
+			100: {50 * time.Millisecond, 100 * time.Millisecond},
+		} {
+			for i := 0; i < 50; i++ {
+				delay := ss.queryRetryDelay(retry)
+				require.GreaterOrEqual(t, delay, bounds[0])
+				require.LessOrEqual(t, delay, bounds[1])
+			}
+		}
+	})
+
+	t.Run("is never less than the min delay", func(t *testing.T) {
+		ss := &SQLStore{dbCfg: DatabaseConfig{QueryRetryMinDelay: 10 * time.Millisecond, QueryRetryMaxDelay: 15 * time.Millisecond}}
+		for i := 0; i < 50; i++ {
+			require.GreaterOrEqual(t, ss.queryRetryDelay(5), 10*time.Millisecond)
+		}
+	})
+
+	t.Run("stops retrying when the context is cancelled", func(t *testing.T) {
+		ss := InitTestDB(t)
+		// the test store is shared between tests
+		dbCfg := ss.dbCfg
+		t.Cleanup(func() { ss.dbCfg = dbCfg })
+		ss.dbCfg.QueryRetries = 5
+		ss.dbCfg.QueryRetryMinDelay = time.Minute
+		ss.dbCfg.QueryRetryMaxDelay = time.Minute
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		i := 0
+		err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+			i++
+			return sqlite3.Error{Code: sqlite3.ErrLocked}
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 1, i)
+	})
+
+	t.Run("doesn't make another attempt once the context is cancelled", func(t *testing.T) {
+		ss := InitTestDB(t)
+		// the test store is shared between tests
+		dbCfg := ss.dbCfg
+		t.Cleanup(func() { ss.dbCfg = dbCfg })
+		ss.dbCfg.QueryRetries = 100
+		ss.dbCfg.QueryRetryMinDelay = time.Millisecond
+		ss.dbCfg.QueryRetryMaxDelay = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		i := 0
+		start := time.Now()
+		err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+			i++
+			if i == 3 {
+				cancel()
+			}
+			return sqlite3.Error{Code: sqlite3.ErrLocked}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, ErrMaximumRetriesReached)
+		require.Equal(t, 3, i)
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestIsRetriableLockError(t *testing.T) {
+	sqliteDialect := migrator.NewSQLite3Dialect(nil)
+	mysqlDialect := migrator.NewMysqlDialect(nil)
+	postgresDialect := migrator.NewPostgresDialect(nil)
+
+	testCases := []struct {
+		desc      string
+		err       error
+		dialect   migrator.Dialect
+		retriable bool
+	}{
+		{desc: "sqlite locked", err: sqlite3.Error{Code: sqlite3.ErrLocked}, dialect: sqliteDialect, retriable: true},
+		{desc: "sqlite busy", err: sqlite3.Error{Code: sqlite3.ErrBusy}, dialect: sqliteDialect, retriable: true},
+		{desc: "sqlite constraint", err: sqlite3.Error{Code: sqlite3.ErrConstraint}, dialect: sqliteDialect, retriable: false},
+		{desc: "mysql deadlock", err: &mysql.MySQLError{Number: 1213}, dialect: mysqlDialect, retriable: true},
+		{desc: "mysql lock wait timeout", err: &mysql.MySQLError{Number: 1205}, dialect: mysqlDialect, retriable: true},
+		{desc: "mysql duplicate entry", err: &mysql.MySQLError{Number: 1062}, dialect: mysqlDialect, retriable: false},
+		{desc: "postgres deadlock", err: &pq.Error{Code: "40P01"}, dialect: postgresDialect, retriable: true},
+		{desc: "postgres serialization failure", err: &pq.Error{Code: "40001"}, dialect: postgresDialect, retriable: true},
+		{desc: "postgres unique violation", err: &pq.Error{Code: "23505"}, dialect: postgresDialect, retriable: false},
+		{desc: "wrapped mysql deadlock", err: fmt.Errorf("query failed: %w", &mysql.MySQLError{Number: 1213}), dialect: mysqlDialect, retriable: true},
+		{desc: "mysql deadlock on sqlite", err: &mysql.MySQLError{Number: 1213}, dialect: sqliteDialect, retriable: false},
+		{desc: "postgres deadlock on mysql", err: &pq.Error{Code: "40P01"}, dialect: mysqlDialect, retriable: false},
+		{desc: "sqlite busy on postgres", err: sqlite3.Error{Code: sqlite3.ErrBusy}, dialect: postgresDialect, retriable: false},
+		{desc: "no error", err: nil, dialect: sqliteDialect, retriable: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.retriable, isRetriableLockError(tc.err, tc.dialect))
+		})
+	}
+}
+
+func TestIntegrationWithReadOnlyDbSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	// the replica is told apart from the database by a table only it has
+	isReplica := func(sess *DBSession) bool {
+		exists, err := sess.IsTableExist("replica_marker")
+		require.NoError(t, err)
+		return exists
+	}
+
+	t.Run("uses the database without a replica", func(t *testing.T) {
+		err := ss.WithReadOnlyDbSession(context.Background(), func(sess *DBSession) error {
+			require.False(t, isReplica(sess))
+			_, err := sess.Exec("SELECT 1")
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	replica, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "replica.db")+"?mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = replica.Close() })
+	_, err = replica.Exec("CREATE TABLE replica_marker (id INTEGER)")
+	require.NoError(t, err)
+	// the test store is shared between tests
+	ss.readEngine = replica
+	t.Cleanup(func() { ss.readEngine = nil })
+
+	t.Run("uses the replica if there is one", func(t *testing.T) {
+		err := ss.WithReadOnlyDbSession(context.Background(), func(sess *DBSession) error {
+			require.True(t, isReplica(sess))
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+			require.False(t, isReplica(sess))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("reuses the session of a transaction rather than the replica", func(t *testing.T) {
+		err := ss.WithTransactionalDbSession(context.Background(), func(outer *DBSession) error {
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, outer)
+			return ss.WithReadOnlyDbSession(ctx, func(sess *DBSession) error {
+				require.Same(t, outer, sess)
+				require.False(t, isReplica(sess))
+				return nil
+			})
+		})
+		require.NoError(t, err)
+	})
+}
+
+// laggingDialect is a dialect whose replication lag is set by the test
+type laggingDialect struct {
+	migrator.Dialect
+	lag   time.Duration
+	err   error
+	calls *int
+}
+
+func (d laggingDialect) ReplicationLag(sess *xorm.Session) (time.Duration, error) {
+	*d.calls++
+	return d.lag, d.err
+}
+
+func TestIntegrationWithBoundedStalenessRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	isReplica := func(sess *DBSession) bool {
+		exists, err := sess.IsTableExist("replica_marker")
+		require.NoError(t, err)
+		return exists
+	}
+
+	t.Run("tells a database that isn't a replica has no lag", func(t *testing.T) {
+		sess := ss.engine.NewSession()
+		defer sess.Close()
+		lag, err := ss.Dialect.ReplicationLag(sess)
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(0), lag)
+	})
+
+	t.Run("uses the database without a replica", func(t *testing.T) {
+		err := ss.WithBoundedStalenessRead(context.Background(), time.Second, func(sess *DBSession) error {
+			require.False(t, isReplica(sess))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	replica, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "replica.db")+"?mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = replica.Close() })
+	_, err = replica.Exec("CREATE TABLE replica_marker (id INTEGER)")
+	require.NoError(t, err)
+	// the test store is shared between tests
+	origDialect := ss.Dialect
+	ss.readEngine = replica
+	t.Cleanup(func() {
+		ss.readEngine = nil
+		ss.Dialect = origDialect
+	})
+
+	testCases := []struct {
+		desc      string
+		lag       time.Duration
+		err       error
+		onReplica bool
+	}{
+		{desc: "uses the replica if it's caught up", lag: 0, onReplica: true},
+		{desc: "uses the replica if it lags by at most the staleness", lag: time.Second, onReplica: true},
+		{desc: "falls back to the database if the replica lags by more than the staleness", lag: time.Minute, onReplica: false},
+		{desc: "falls back to the database if the lag of the replica is unknown", err: migrator.ErrReplicationLagUnknown, onReplica: false},
+		{desc: "falls back to the database if the lag of the replica can't be queried", err: errors.New("connection refused"), onReplica: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls := 0
+			ss.Dialect = laggingDialect{Dialect: origDialect, lag: tc.lag, err: tc.err, calls: &calls}
+
+			err := ss.WithBoundedStalenessRead(context.Background(), time.Second, func(sess *DBSession) error {
+				require.Equal(t, tc.onReplica, isReplica(sess))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 1, calls)
+		})
+	}
+
+	t.Run("reuses the session of a transaction without checking the lag", func(t *testing.T) {
+		calls := 0
+		ss.Dialect = laggingDialect{Dialect: origDialect, calls: &calls}
+
+		err := ss.WithTransactionalDbSession(context.Background(), func(outer *DBSession) error {
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, outer)
+			return ss.WithBoundedStalenessRead(ctx, time.Second, func(sess *DBSession) error {
+				require.Same(t, outer, sess)
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, calls)
+	})
+}
+
+func TestIntegrationReadYourWrites(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	isReplica := func(sess *DBSession) bool {
+		exists, err := sess.IsTableExist("replica_marker")
+		require.NoError(t, err)
+		return exists
+	}
+	readsReplica := func(t *testing.T, ctx context.Context) bool {
+		t.Helper()
+		onReplica := false
+		err := ss.WithReadOnlyDbSession(ctx, func(sess *DBSession) error {
+			onReplica = isReplica(sess)
+			return nil
+		})
+		require.NoError(t, err)
+		return onReplica
+	}
+
+	replica, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "replica.db")+"?mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = replica.Close() })
+	_, err = replica.Exec("CREATE TABLE replica_marker (id INTEGER)")
+	require.NoError(t, err)
+	// the test store is shared between tests
+	origDialect := ss.Dialect
+	ss.readEngine = replica
+	t.Cleanup(func() {
+		ss.readEngine = nil
+		ss.Dialect = origDialect
+	})
+
+	write := func(sess *DBSession) error {
+		_, err := sess.Exec("SELECT 1")
+		return err
+	}
+	writes := map[string]func(ctx context.Context) error{
+		"WithDbSession":              func(ctx context.Context) error { return ss.WithDbSession(ctx, write) },
+		"WithNewDbSession":           func(ctx context.Context) error { return ss.WithNewDbSession(ctx, write) },
+		"WithTransactionalDbSession": func(ctx context.Context) error { return ss.WithTransactionalDbSession(ctx, write) },
+		"InTransaction": func(ctx context.Context) error {
+			return ss.InTransaction(ctx, func(ctx context.Context) error { return ss.WithDbSession(ctx, write) })
+		},
+	}
+	for name, f := range writes {
+		t.Run(fmt.Sprintf("reads from the database after a write with %s", name), func(t *testing.T) {
+			ctx := ReadYourWrites(context.Background())
+			require.True(t, readsReplica(t, ctx))
+
+			require.NoError(t, f(ctx))
+			require.False(t, readsReplica(t, ctx))
+			// contexts derived from it, e.g. by the handlers of the request, read from the database too
+			derived, cancel := context.WithCancel(ctx)
+			defer cancel()
+			require.False(t, readsReplica(t, derived))
+			require.False(t, readsReplica(t, ReadYourWrites(ctx)))
+		})
+	}
+
+	t.Run("reads from the replica after a write without tracking the writes", func(t *testing.T) {
+		ctx := context.Background()
+		require.NoError(t, ss.WithDbSession(ctx, write))
+		require.True(t, readsReplica(t, ctx))
+	})
+
+	t.Run("doesn't count reads as writes", func(t *testing.T) {
+		ctx := ReadYourWrites(context.Background())
+		require.True(t, readsReplica(t, ctx))
+		require.True(t, readsReplica(t, ctx))
+	})
+
+	t.Run("doesn't count parallel reads as writes", func(t *testing.T) {
+		ctx := ReadYourWrites(context.Background())
+		require.NoError(t, ss.ParallelReads(ctx, write, write))
+		require.True(t, readsReplica(t, ctx))
+	})
+
+	t.Run("doesn't pin the reads of another context", func(t *testing.T) {
+		written := ReadYourWrites(context.Background())
+		require.NoError(t, ss.WithDbSession(written, write))
+
+		require.False(t, readsReplica(t, written))
+		require.True(t, readsReplica(t, ReadYourWrites(context.Background())))
+	})
+
+	t.Run("reads from the database with bounded staleness after a write without checking the lag", func(t *testing.T) {
+		calls := 0
+		ss.Dialect = laggingDialect{Dialect: origDialect, calls: &calls}
+		t.Cleanup(func() { ss.Dialect = origDialect })
+
+		ctx := ReadYourWrites(context.Background())
+		require.NoError(t, ss.WithDbSession(ctx, write))
+		err := ss.WithBoundedStalenessRead(ctx, time.Minute, func(sess *DBSession) error {
+			require.False(t, isReplica(sess))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, calls)
+	})
+}
+
+func TestIntegrationStatementDeadline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	// slowQuery runs for far longer than the deadlines of the tests
+	var slowQuery string
+	switch ss.Dialect.DriverName() {
+	case migrator.Postgres:
+		slowQuery = "SELECT pg_sleep(30)"
+	case migrator.MySQL:
+		slowQuery = "SELECT SLEEP(30)"
+	default:
+		slowQuery = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 10000000000) SELECT count(*) FROM c"
+	}
+	runSlowQuery := func(sess *DBSession) error {
+		_, err := sess.QueryString(slowQuery)
+		return err
+	}
+
+	funcToTest := map[string]func(ctx context.Context, callback DBTransactionFunc) error{
+		"WithDbSession":              func(ctx context.Context, callback DBTransactionFunc) error { return ss.WithDbSession(ctx, callback) },
+		"WithNewDbSession":           func(ctx context.Context, callback DBTransactionFunc) error { return ss.WithNewDbSession(ctx, callback) },
+		"WithTransactionalDbSession": ss.WithTransactionalDbSession,
+	}
+
+	for name, f := range funcToTest {
+		t.Run(fmt.Sprintf("%s times out a query running past the deadline of the context", name), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := f(ctx, runSlowQuery)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			require.Less(t, time.Since(start), 10*time.Second)
+		})
+
+		t.Run(fmt.Sprintf("%s runs queries as before without a deadline", name), func(t *testing.T) {
+			err := f(context.Background(), func(sess *DBSession) error {
+				_, err := sess.QueryString("SELECT 1")
+				return err
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("fails to start a transaction once the deadline has passed", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		called := false
+		err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			called = true
+			return nil
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.False(t, called)
+	})
+}
+
+// contextFakeLogger is a fake logger that records the logs of the loggers created with FromContext too
+type contextFakeLogger struct {
+	*logtest.Fake
+}
+
+func (l contextFakeLogger) FromContext(_ context.Context) log.Logger {
+	return l
+}
+
+func TestIntegrationLogSlowSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := InitTestDB(t)
+
+	logger := contextFakeLogger{&logtest.Fake{}}
+	origLogger, origCfg := sessionLogger, store.dbCfg
+	sessionLogger = logger
+	t.Cleanup(func() {
+		sessionLogger = origLogger
+		store.dbCfg = origCfg
+	})
+
+	slowCallback := func(sess *DBSession) error {
+		if _, err := sess.QueryString("SELECT 1"); err != nil {
+			return err
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+
+	t.Run("doesn't log slow sessions by default", func(t *testing.T) {
+		*logger.Fake = logtest.Fake{}
+		store.dbCfg.LogSlowQueries = false
+
+		require.NoError(t, store.WithDbSession(context.Background(), slowCallback))
+		require.Equal(t, 0, logger.WarnLogs.Calls)
+	})
+
+	t.Run("doesn't log sessions faster than the threshold", func(t *testing.T) {
+		*logger.Fake = logtest.Fake{}
+		store.dbCfg.LogSlowQueries = true
+		store.dbCfg.SlowQueryThreshold = time.Minute
+
+		require.NoError(t, store.WithDbSession(context.Background(), slowCallback))
+		require.Equal(t, 0, logger.WarnLogs.Calls)
+	})
+
+	t.Run("logs sessions slower than the threshold without the SQL if it's not logged", func(t *testing.T) {
+		*logger.Fake = logtest.Fake{}
+		store.dbCfg.LogSlowQueries = true
+		store.dbCfg.SlowQueryThreshold = 10 * time.Millisecond
+
+		require.NoError(t, store.WithDbSession(context.Background(), slowCallback))
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.Equal(t, "Slow database session", logger.WarnLogs.Message)
+		require.Equal(t, "elapsed", logger.WarnLogs.Ctx[0])
+		require.GreaterOrEqual(t, logger.WarnLogs.Ctx[1], 20*time.Millisecond)
+		require.NotContains(t, logger.WarnLogs.Ctx, "lastSQL")
+	})
+
+	t.Run("includes the last SQL if it's logged", func(t *testing.T) {
+		*logger.Fake = logtest.Fake{}
+		store.dbCfg.LogSlowQueries = true
+		store.dbCfg.SlowQueryThreshold = 10 * time.Millisecond
+		origEngineLogger := store.engine.Logger()
+		store.engine.SetLogger(NewXormLogger(log.LvlInfo, log.NewNopLogger()))
+		t.Cleanup(func() { store.engine.SetLogger(origEngineLogger) })
+
+		require.NoError(t, store.WithDbSession(context.Background(), slowCallback))
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.Contains(t, logger.WarnLogs.Ctx, "lastSQL")
+		require.Contains(t, logger.WarnLogs.Ctx, "SELECT 1")
+	})
+
+	t.Run("reports the total time of all the retries", func(t *testing.T) {
+		*logger.Fake = logtest.Fake{}
+		store.dbCfg.LogSlowQueries = true
+		store.dbCfg.SlowQueryThreshold = 50 * time.Millisecond
+		store.dbCfg.QueryRetries = 5
+		store.dbCfg.QueryRetryMinDelay = time.Millisecond
+		store.dbCfg.QueryRetryMaxDelay = time.Millisecond
+
+		i := 0
+		err := store.WithDbSession(context.Background(), func(sess *DBSession) error {
+			i++
+			// no single attempt is slower than the threshold
+			time.Sleep(20 * time.Millisecond)
+			if i < 4 {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 4, i)
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.GreaterOrEqual(t, logger.WarnLogs.Ctx[1], 80*time.Millisecond)
+	})
+}
+
+type insertIdTestItem struct {
+	ID   int64  `xorm:"pk autoincr 'id'"`
+	Name string `xorm:"varchar(10)"`
+}
+
+// failingInsertHooksDialect fails the insert hooks of the dialect it wraps with the given errors
+type failingInsertHooksDialect struct {
+	migrator.Dialect
+	preErr, postErr error
+}
+
+func (d *failingInsertHooksDialect) PreInsertId(table string, sess *xorm.Session) error {
+	if d.preErr != nil {
+		return d.preErr
+	}
+	return d.Dialect.PreInsertId(table, sess)
+}
+
+func (d *failingInsertHooksDialect) PostInsertId(table string, sess *xorm.Session) error {
+	if d.postErr != nil {
+		return d.postErr
+	}
+	return d.Dialect.PostInsertId(table, sess)
+}
+
+func TestIntegrationInsertId(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(insertIdTestItem))
+	require.NoError(t, err)
+
+	hooks := &failingInsertHooksDialect{Dialect: dialect}
+	dialect = hooks
+	t.Cleanup(func() { dialect = hooks.Dialect })
+
+	insertId := func(t *testing.T, item *insertIdTestItem) (int64, error) {
+		t.Helper()
+		var id int64
+		err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			id, err = sess.InsertId(item)
+			return err
+		})
+		return id, err
+	}
+	exists := func(t *testing.T, id int64) bool {
+		t.Helper()
+		found, err := ss.engine.ID(id).Exist(&insertIdTestItem{})
+		require.NoError(t, err)
+		return found
+	}
+
+	t.Run("returns the id of the inserted row", func(t *testing.T) {
+		hooks.preErr, hooks.postErr = nil, nil
+
+		id, err := insertId(t, &insertIdTestItem{Name: "ok"})
+		require.NoError(t, err)
+		require.NotZero(t, id)
+		require.True(t, exists(t, id))
+	})
+
+	t.Run("returns the id of the inserted row if the hook after the insert fails", func(t *testing.T) {
+		errSequence := errors.New("resetting the sequence failed")
+		hooks.preErr, hooks.postErr = nil, errSequence
+
+		id, err := insertId(t, &insertIdTestItem{Name: "post"})
+		require.ErrorIs(t, err, errSequence)
+		require.NotZero(t, id)
+		require.ErrorContains(t, err, fmt.Sprintf("inserted row %d into insert_id_test_item, but finishing the insert failed", id))
+		require.True(t, exists(t, id))
+	})
+
+	t.Run("inserts nothing if the hook before the insert fails", func(t *testing.T) {
+		errIdentityInsert := errors.New("enabling identity insert failed")
+		hooks.preErr, hooks.postErr = errIdentityInsert, nil
+		count, err := ss.engine.Count(&insertIdTestItem{})
+		require.NoError(t, err)
+
+		id, err := insertId(t, &insertIdTestItem{Name: "pre"})
+		require.ErrorIs(t, err, errIdentityInsert)
+		require.ErrorContains(t, err, "preparing to insert into insert_id_test_item failed, nothing was inserted")
+		require.Zero(t, id)
+		after, err := ss.engine.Count(&insertIdTestItem{})
+		require.NoError(t, err)
+		require.Equal(t, count, after)
+	})
+}
@@ -0,0 +1,118 @@
+package sqlstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var databaseLockWaitCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "database_lock_wait_seconds_total",
+	Help:      "Total time spent waiting before retrying sessions that failed because the database was locked or a transaction conflicted",
+})
+
+func init() {
+	prometheus.MustRegister(databaseLockWaitCounter)
+}
+
+// SessionTiming is the time a session spent, split into waiting on locks and running queries.
+type SessionTiming struct {
+	// LockWait is the time spent waiting before retrying after the database was locked or a transaction conflicted
+	LockWait time.Duration
+	// Query is the time spent running the callback of the session, across all attempts
+	Query time.Duration
+	// Retries is the number of times the callback was retried
+	Retries int
+}
+
+type sessionTimingCallbackKey struct{}
+
+type sessionTimerKey struct{}
+
+// WithSessionTimingCallback returns a context that makes sessions started with it, e.g. by WithDbSession or
+// WithTransactionalDbSession, call fn with their timing once they're done. Sessions nested in another session are
+// part of the outer session's timing and aren't reported separately.
+func WithSessionTimingCallback(ctx context.Context, fn func(SessionTiming)) context.Context {
+	return context.WithValue(ctx, sessionTimingCallbackKey{}, fn)
+}
+
+type sessionTimer struct {
+	mu     sync.Mutex
+	timing SessionTiming
+	// depth is the number of callbacks currently running, only the outermost one is timed
+	depth int
+}
+
+// startSessionTimer returns the timer of the session in the context, or starts a new one. The returned function
+// reports the timing of a new timer and must be called once the session is done.
+func startSessionTimer(ctx context.Context) (context.Context, *sessionTimer, func()) {
+	if timer, ok := ctx.Value(sessionTimerKey{}).(*sessionTimer); ok {
+		return ctx, timer, func() {}
+	}
+	if sess, ok := ctx.Value(ContextSessionKey{}).(*DBSession); ok && sess.timer != nil {
+		return context.WithValue(ctx, sessionTimerKey{}, sess.timer), sess.timer, func() {}
+	}
+
+	timer, done := newSessionTimer(ctx)
+	return context.WithValue(ctx, sessionTimerKey{}, timer), timer, done
+}
+
+// newSessionTimer starts a new timer regardless of the timer in the context, e.g. for sessions running concurrently
+// with it. The returned function reports its timing and must be called once the session is done.
+func newSessionTimer(ctx context.Context) (*sessionTimer, func()) {
+	timer := &sessionTimer{}
+	return timer, func() {
+		timing := timer.get()
+		if timing.LockWait > 0 {
+			databaseLockWaitCounter.Add(timing.LockWait.Seconds())
+			sessionLogger.FromContext(ctx).Debug("Database session waited on locks", "lockWait", timing.LockWait, "queryTime", timing.Query, "retries", timing.Retries)
+		}
+		if fn, ok := ctx.Value(sessionTimingCallbackKey{}).(func(SessionTiming)); ok {
+			fn(timing)
+		}
+	}
+}
+
+// run calls fn and adds the time it took, minus the time it waited on locks, to the query time
+func (t *sessionTimer) run(fn func() error) error {
+	t.mu.Lock()
+	t.depth++
+	lockWait := t.timing.LockWait
+	t.mu.Unlock()
+
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.depth--
+	if t.depth == 0 {
+		t.timing.Query += elapsed - (t.timing.LockWait - lockWait)
+	}
+	return err
+}
+
+// waited adds d to the lock wait time, and counts a retry
+func (t *sessionTimer) waited(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timing.LockWait += d
+	t.timing.Retries++
+}
+
+// sleep sleeps for d before a retry and adds it to the lock wait time
+func (t *sessionTimer) sleep(d time.Duration) {
+	start := time.Now()
+	time.Sleep(d)
+	t.waited(time.Since(start))
+}
+
+func (t *sessionTimer) get() SessionTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timing
+}
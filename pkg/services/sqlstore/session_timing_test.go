@@ -0,0 +1,108 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationSessionTiming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := InitTestDB(t)
+	store.dbCfg.QueryRetries = 5
+
+	// lockedCallback fails with a database locked error the first failures times, each attempt taking queryTime
+	lockedCallback := func(failures int, queryTime time.Duration) (DBTransactionFunc, *int) {
+		attempts := 0
+		return func(sess *DBSession) error {
+			attempts++
+			time.Sleep(queryTime)
+			if attempts <= failures {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			return nil
+		}, &attempts
+	}
+
+	timingCtx := func() (context.Context, *[]SessionTiming) {
+		var timings []SessionTiming
+		return WithSessionTimingCallback(context.Background(), func(timing SessionTiming) {
+			timings = append(timings, timing)
+		}), &timings
+	}
+
+	funcToTest := map[string]func(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error{
+		"WithDbSession":    store.WithDbSession,
+		"WithNewDbSession": store.WithNewDbSession,
+	}
+
+	for name, f := range funcToTest {
+		t.Run(fmt.Sprintf("%s accumulates the lock wait across retries", name), func(t *testing.T) {
+			ctx, timings := timingCtx()
+			callback, attempts := lockedCallback(3, 5*time.Millisecond)
+
+			require.NoError(t, f(ctx, callback))
+			require.Equal(t, 4, *attempts)
+			require.Len(t, *timings, 1)
+
+			timing := (*timings)[0]
+			require.Equal(t, 3, timing.Retries)
+			// the retries back off at least 10ms, 20ms and 40ms
+			require.GreaterOrEqual(t, timing.LockWait, 70*time.Millisecond)
+			require.GreaterOrEqual(t, timing.Query, 20*time.Millisecond)
+			require.Less(t, timing.Query, timing.LockWait)
+		})
+
+		t.Run(fmt.Sprintf("%s reports no lock wait without retries", name), func(t *testing.T) {
+			ctx, timings := timingCtx()
+			callback, _ := lockedCallback(0, 5*time.Millisecond)
+
+			require.NoError(t, f(ctx, callback))
+			require.Len(t, *timings, 1)
+			require.Zero(t, (*timings)[0].Retries)
+			require.Zero(t, (*timings)[0].LockWait)
+			require.GreaterOrEqual(t, (*timings)[0].Query, 5*time.Millisecond)
+		})
+	}
+
+	t.Run("WithTransactionalDbSession accumulates the lock wait across retries", func(t *testing.T) {
+		ctx, timings := timingCtx()
+		callback, attempts := lockedCallback(2, time.Millisecond)
+
+		require.NoError(t, store.WithTransactionalDbSession(ctx, callback))
+		require.Equal(t, 3, *attempts)
+		require.Len(t, *timings, 1)
+
+		timing := (*timings)[0]
+		require.Equal(t, 2, timing.Retries)
+		require.GreaterOrEqual(t, timing.LockWait, 20*time.Millisecond)
+		require.GreaterOrEqual(t, timing.Query, 3*time.Millisecond)
+		require.Less(t, timing.Query, timing.LockWait)
+	})
+
+	t.Run("nested sessions are part of the outer session's timing", func(t *testing.T) {
+		ctx, timings := timingCtx()
+		callback, attempts := lockedCallback(2, time.Millisecond)
+
+		err := store.InTransaction(ctx, func(ctx context.Context) error {
+			return store.WithDbSession(ctx, callback)
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, *attempts)
+		require.Len(t, *timings, 1)
+
+		timing := (*timings)[0]
+		require.Equal(t, 2, timing.Retries)
+		// the transaction is retried rather than the nested session, sleeping 10ms before each retry, which isn't part
+		// of the query time of the outer session
+		require.GreaterOrEqual(t, timing.LockWait, 20*time.Millisecond)
+		require.GreaterOrEqual(t, timing.Query, 3*time.Millisecond)
+		require.Less(t, timing.Query, timing.LockWait)
+	})
+}
@@ -0,0 +1,119 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrShuttingDown is returned when a session is requested once the store has begun to shut down
+var ErrShuttingDown = errors.New("sqlstore is shutting down")
+
+// sessionTracker counts the sessions started by the store that haven't been closed yet, so that shutting down can
+// wait for them. A nil tracker tracks nothing, which is the case for stores that weren't created by newSQLStore.
+type sessionTracker struct {
+	mu           sync.Mutex
+	active       int
+	shuttingDown bool
+	// drained is closed once the store is shutting down and no session is active anymore
+	drained chan struct{}
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{drained: make(chan struct{})}
+}
+
+// acquire registers a session that is about to be started and returns the func to call once it's closed, which may
+// be called more than once. It fails with ErrShuttingDown once the store has begun to shut down.
+func (t *sessionTracker) acquire() (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	t.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.active--
+			if t.shuttingDown && t.active == 0 {
+				close(t.drained)
+			}
+		})
+	}, nil
+}
+
+// beginShutdown stops sessions from being acquired, and returns true if it's the first call
+func (t *sessionTracker) beginShutdown() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shuttingDown {
+		return false
+	}
+	t.shuttingDown = true
+	if t.active == 0 {
+		close(t.drained)
+	}
+	return true
+}
+
+// activeSessions returns the number of sessions that haven't been closed yet
+func (t *sessionTracker) activeSessions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.active
+}
+
+// Shutdown stops the store from starting new sessions, which fail with ErrShuttingDown from then on, waits for the
+// sessions in progress to be closed and then closes the database, and the replica if one is configured. A session
+// reusing the session of an outer transaction isn't a session of its own, so it's neither counted nor refused while
+// the transaction is in progress. If the context is done before every session has been closed, the database is closed
+// regardless and the error of the context is returned, so the sessions still in progress fail. Calling it again once
+// the store is shutting down only waits for the sessions to be closed.
+func (ss *SQLStore) Shutdown(ctx context.Context) error {
+	if ss.sessions == nil {
+		return ss.closeEngines()
+	}
+
+	first := ss.sessions.beginShutdown()
+
+	var waitErr error
+	select {
+	case <-ss.sessions.drained:
+	case <-ctx.Done():
+		waitErr = fmt.Errorf("%d database sessions still in progress: %w", ss.sessions.activeSessions(), ctx.Err())
+		ss.log.Warn("Closing the database with sessions still in progress", "error", waitErr)
+	}
+
+	if !first {
+		return waitErr
+	}
+	if err := ss.closeEngines(); err != nil {
+		if waitErr != nil {
+			return fmt.Errorf("%s: %w", waitErr, err)
+		}
+		return err
+	}
+	return waitErr
+}
+
+// closeEngines closes the database and the replica, if one is configured
+func (ss *SQLStore) closeEngines() error {
+	if ss.readEngine != nil && ss.readEngine != ss.engine {
+		if err := ss.readEngine.Close(); err != nil {
+			return fmt.Errorf("failed to close the database replica: %w", err)
+		}
+	}
+	if err := ss.engine.Close(); err != nil {
+		return fmt.Errorf("failed to close the database: %w", err)
+	}
+	return nil
+}
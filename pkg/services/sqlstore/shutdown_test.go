@@ -0,0 +1,170 @@
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestIntegrationShutdown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	// the test store is shared between tests, so every test shuts down a store of its own
+	newStore := func(t *testing.T) *SQLStore {
+		t.Helper()
+		engine, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "shutdown.db")+"?mode=rwc")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = engine.Close() })
+		return &SQLStore{Cfg: ss.Cfg, engine: engine, Dialect: ss.Dialect, dbCfg: ss.dbCfg, bus: ss.bus, log: log.New("sqlstore"), sessions: newSessionTracker()}
+	}
+	query := func(sess *DBSession) error {
+		_, err := sess.Exec("SELECT 1")
+		return err
+	}
+	// inFlight starts a session that is held open until the returned func is called, which waits for it to be closed
+	inFlight := func(t *testing.T, store *SQLStore) func() {
+		t.Helper()
+		started, finish, closed := make(chan struct{}), make(chan struct{}), make(chan error)
+		go func() {
+			closed <- store.WithDbSession(context.Background(), func(sess *DBSession) error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		<-started
+		return func() {
+			close(finish)
+			require.NoError(t, <-closed)
+		}
+	}
+
+	t.Run("closes the database if no session is in progress", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.WithDbSession(context.Background(), query))
+
+		require.NoError(t, store.Shutdown(context.Background()))
+		require.Error(t, store.engine.Ping())
+	})
+
+	t.Run("fails new sessions once shutting down", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Shutdown(context.Background()))
+
+		require.ErrorIs(t, store.WithDbSession(context.Background(), query), ErrShuttingDown)
+		require.ErrorIs(t, store.WithNewDbSession(context.Background(), query), ErrShuttingDown)
+		require.ErrorIs(t, store.WithTransactionalDbSession(context.Background(), query), ErrShuttingDown)
+		require.ErrorIs(t, store.InTransaction(context.Background(), func(ctx context.Context) error { return nil }), ErrShuttingDown)
+	})
+
+	t.Run("waits for the sessions in progress", func(t *testing.T) {
+		store := newStore(t)
+		finish := inFlight(t, store)
+
+		shutdown := make(chan error)
+		go func() { shutdown <- store.Shutdown(context.Background()) }()
+		select {
+		case err := <-shutdown:
+			t.Fatalf("shut down with a session in progress: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.ErrorIs(t, store.WithDbSession(context.Background(), query), ErrShuttingDown)
+
+		finish()
+		require.NoError(t, <-shutdown)
+		require.Equal(t, 0, store.sessions.activeSessions())
+		require.Error(t, store.engine.Ping())
+	})
+
+	t.Run("closes the database once the context is done", func(t *testing.T) {
+		store := newStore(t)
+		finish := inFlight(t, store)
+		defer finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := store.Shutdown(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "1 database sessions still in progress")
+		require.Error(t, store.engine.Ping())
+	})
+
+	t.Run("counts the sessions reusing a transaction once and lets them go on while shutting down", func(t *testing.T) {
+		store := newStore(t)
+		shutdown := make(chan error)
+
+		err := store.InTransaction(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, store.WithDbSession(ctx, func(sess *DBSession) error {
+				require.Equal(t, 1, store.sessions.activeSessions())
+				return query(sess)
+			}))
+
+			go func() { shutdown <- store.Shutdown(context.Background()) }()
+			require.Eventually(t, func() bool {
+				return store.WithNewDbSession(context.Background(), query) != nil
+			}, time.Second, 10*time.Millisecond)
+
+			return store.WithTransactionalDbSession(ctx, query)
+		})
+		require.NoError(t, err)
+		require.NoError(t, <-shutdown)
+	})
+
+	t.Run("waits again if called more than once", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Shutdown(context.Background()))
+		require.NoError(t, store.Shutdown(context.Background()))
+	})
+}
+
+func TestSessionTracker(t *testing.T) {
+	t.Run("releasing a session more than once counts it once", func(t *testing.T) {
+		tracker := newSessionTracker()
+		release, err := tracker.acquire()
+		require.NoError(t, err)
+		other, err := tracker.acquire()
+		require.NoError(t, err)
+
+		release()
+		release()
+		require.Equal(t, 1, tracker.activeSessions())
+		other()
+		require.Equal(t, 0, tracker.activeSessions())
+	})
+
+	t.Run("is drained once the last session is released while shutting down", func(t *testing.T) {
+		tracker := newSessionTracker()
+		release, err := tracker.acquire()
+		require.NoError(t, err)
+
+		require.True(t, tracker.beginShutdown())
+		require.False(t, tracker.beginShutdown())
+		_, err = tracker.acquire()
+		require.ErrorIs(t, err, ErrShuttingDown)
+		select {
+		case <-tracker.drained:
+			t.Fatal("drained with a session in progress")
+		default:
+		}
+
+		release()
+		<-tracker.drained
+	})
+
+	t.Run("a nil tracker tracks nothing", func(t *testing.T) {
+		var tracker *sessionTracker
+		release, err := tracker.acquire()
+		require.NoError(t, err)
+		release()
+	})
+}
@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"fmt"
+
+	"xorm.io/builder"
+)
+
+// BuildSQL renders the SELECT statement xorm executes for finding beans of the given type with the given conditions,
+// without running it. The conditions are passed the same way as to (*xorm.Session).Where: either a query string followed
+// by its arguments, a builder.Cond or a map of column names to values. No conditions select all rows.
+// The statement is rendered for the dialect of the store, including its quoting and placeholders, which is useful
+// for verifying how a query is constructed.
+func (ss *SQLStore) BuildSQL(bean interface{}, conditions ...interface{}) (string, []interface{}, error) {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return "", nil, fmt.Errorf("could not resolve the table of %T", bean)
+	}
+
+	cond, err := buildCond(conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := make([]string, 0, len(table.ColumnsSeq()))
+	for _, col := range table.ColumnsSeq() {
+		columns = append(columns, ss.Dialect.Quote(col))
+	}
+
+	rawSQL, args, err := builder.Select(columns...).From(ss.Dialect.Quote(table.Name)).Where(cond).ToSQL()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, filter := range ss.engine.Dialect().Filters() {
+		rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table.Table)
+	}
+
+	return rawSQL, args, nil
+}
+
+func buildCond(conditions ...interface{}) (builder.Cond, error) {
+	if len(conditions) == 0 {
+		return builder.NewCond(), nil
+	}
+
+	switch query := conditions[0].(type) {
+	case string:
+		return builder.Expr(query, conditions[1:]...), nil
+	case map[string]interface{}:
+		if len(conditions) > 1 {
+			return nil, fmt.Errorf("unexpected arguments for a %T condition", query)
+		}
+		return builder.Eq(query), nil
+	case builder.Cond:
+		if len(conditions) > 1 {
+			return nil, fmt.Errorf("unexpected arguments for a %T condition", query)
+		}
+		return query, nil
+	default:
+		return nil, fmt.Errorf("unsupported condition type %T", query)
+	}
+}
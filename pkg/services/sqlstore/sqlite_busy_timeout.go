@@ -0,0 +1,64 @@
+package sqlstore
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"xorm.io/core"
+	"xorm.io/xorm"
+)
+
+// sqliteBusyTimeout returns the busy_timeout of a connection of the engine, which is how long SQLite waits for a lock
+// before failing with sqlite3.ErrBusy.
+func sqliteBusyTimeout(engine *xorm.Engine) (time.Duration, error) {
+	var ms int64
+	if _, err := engine.SQL("PRAGMA busy_timeout").Get(&ms); err != nil {
+		return 0, fmt.Errorf("%v: %w", "failed to read SQLite busy_timeout", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// checkSQLiteBusyTimeout warns if the busy_timeout of a SQLite engine is lower than MinBusyTimeout, since sessions
+// then fail with sqlite3.ErrBusy under contention more often than the query retries absorb. With RaiseBusyTimeout the
+// engine is reopened with MinBusyTimeout as its busy_timeout instead, since the pragma only applies to the connection
+// it's run on. The returned engine is the one to use, and the engine it was checked on is left open for the caller to
+// close, since it may not be the caller's to close.
+func (ss *SQLStore) checkSQLiteBusyTimeout(engine *xorm.Engine) (*xorm.Engine, error) {
+	minTimeout := ss.dbCfg.MinBusyTimeout
+	if engine.Dialect().DBType() != core.SQLITE || minTimeout <= 0 {
+		return engine, nil
+	}
+
+	timeout, err := sqliteBusyTimeout(engine)
+	if err != nil {
+		return nil, err
+	}
+	if timeout >= minTimeout {
+		return engine, nil
+	}
+	if !ss.dbCfg.RaiseBusyTimeout {
+		ss.log.Warn("SQLite busy_timeout is lower than recommended, which makes queries fail on locks under contention. Set _busy_timeout in the url query parameters of [database] or enable raise_busy_timeout",
+			"busyTimeout", timeout, "recommended", minTimeout)
+		return engine, nil
+	}
+
+	raised, err := xorm.NewEngine(engine.DriverName(), withSQLiteBusyTimeout(engine.DataSourceName(), minTimeout))
+	if err != nil {
+		return nil, err
+	}
+	ss.log.Info("Raised SQLite busy_timeout", "from", timeout, "to", minTimeout)
+	return raised, nil
+}
+
+// withSQLiteBusyTimeout returns the connection string with the busy timeout of the driver replaced by timeout.
+func withSQLiteBusyTimeout(connectionString string, timeout time.Duration) string {
+	base, query, _ := strings.Cut(connectionString, "?")
+	// the params that fail to parse are dropped, the others are kept
+	params, _ := url.ParseQuery(query)
+	// the driver reads _timeout too, and only the first of either
+	params.Del("_timeout")
+	params.Set("_busy_timeout", fmt.Sprint(timeout.Milliseconds()))
+	return base + "?" + params.Encode()
+}
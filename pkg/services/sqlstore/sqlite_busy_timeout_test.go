@@ -0,0 +1,87 @@
+package sqlstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func TestCheckSQLiteBusyTimeout(t *testing.T) {
+	newEngine := func(t *testing.T, busyTimeout string) *xorm.Engine {
+		t.Helper()
+		engine, err := xorm.NewEngine(migrator.SQLite, "file:"+filepath.Join(t.TempDir(), "grafana.db")+"?mode=rwc&_busy_timeout="+busyTimeout)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = engine.Close() })
+		return engine
+	}
+
+	t.Run("warns if the busy timeout is lower than the minimum", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		ss := &SQLStore{log: logger, dbCfg: DatabaseConfig{MinBusyTimeout: time.Second}}
+		engine := newEngine(t, "100")
+
+		checked, err := ss.checkSQLiteBusyTimeout(engine)
+		require.NoError(t, err)
+		require.Same(t, engine, checked)
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.Contains(t, logger.WarnLogs.Message, "busy_timeout is lower than recommended")
+		require.Equal(t, []interface{}{"busyTimeout", 100 * time.Millisecond, "recommended", time.Second}, logger.WarnLogs.Ctx)
+	})
+
+	t.Run("doesn't warn if the busy timeout reaches the minimum", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		ss := &SQLStore{log: logger, dbCfg: DatabaseConfig{MinBusyTimeout: time.Second}}
+		engine := newEngine(t, "1000")
+
+		checked, err := ss.checkSQLiteBusyTimeout(engine)
+		require.NoError(t, err)
+		require.Same(t, engine, checked)
+		require.Zero(t, logger.WarnLogs.Calls)
+	})
+
+	t.Run("doesn't check without a minimum", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		ss := &SQLStore{log: logger}
+
+		_, err := ss.checkSQLiteBusyTimeout(newEngine(t, "100"))
+		require.NoError(t, err)
+		require.Zero(t, logger.WarnLogs.Calls)
+	})
+
+	t.Run("raises the busy timeout to the minimum if enabled", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		ss := &SQLStore{log: logger, dbCfg: DatabaseConfig{MinBusyTimeout: 2 * time.Second, RaiseBusyTimeout: true}}
+
+		engine := newEngine(t, "100")
+		raised, err := ss.checkSQLiteBusyTimeout(engine)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = raised.Close() })
+		require.NotSame(t, engine, raised)
+		require.Zero(t, logger.WarnLogs.Calls)
+		require.Equal(t, 1, logger.InfoLogs.Calls)
+
+		// the engine it was checked on isn't closed, since it's the caller's
+		require.NoError(t, engine.Ping())
+
+		// every connection of the pool gets the raised busy timeout
+		raised.SetMaxIdleConns(0)
+		for i := 0; i < 3; i++ {
+			timeout, err := sqliteBusyTimeout(raised)
+			require.NoError(t, err)
+			require.Equal(t, 2*time.Second, timeout)
+		}
+	})
+}
+
+func TestWithSQLiteBusyTimeout(t *testing.T) {
+	require.Equal(t, "file:/tmp/grafana.db?_busy_timeout=5000&cache=private&mode=rwc",
+		withSQLiteBusyTimeout("file:/tmp/grafana.db?cache=private&mode=rwc&_timeout=100", 5*time.Second))
+	require.Equal(t, "file:/tmp/grafana.db?_busy_timeout=5000",
+		withSQLiteBusyTimeout("file:/tmp/grafana.db", 5*time.Second))
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/ini.v1"
 	"xorm.io/core"
 	"xorm.io/xorm"
 
@@ -53,11 +54,18 @@ type SQLStore struct {
 	bus                         bus.Bus
 	dbCfg                       DatabaseConfig
 	engine                      *xorm.Engine
+	readEngine                  *xorm.Engine
 	log                         log.Logger
 	Dialect                     migrator.Dialect
 	skipEnsureDefaultOrgAndUser bool
 	migrations                  registry.DatabaseMigrator
 	tracer                      tracing.Tracer
+	openTransactions            *transactionRegistry
+	sessions                    *sessionTracker
+	afterCommitMu               sync.RWMutex
+	afterCommitHandlers         []AfterCommitHandler
+	writeLimitsMu               sync.RWMutex
+	writeLimits                 map[string]*writeLimiter
 }
 
 func ProvideService(cfg *setting.Cfg, cacheService *localcache.CacheService, migrations registry.DatabaseMigrator, bus bus.Bus, tracer tracing.Tracer) (*SQLStore, error) {
@@ -104,6 +112,8 @@ func newSQLStore(cfg *setting.Cfg, cacheService *localcache.CacheService, engine
 		migrations:                  migrations,
 		bus:                         bus,
 		tracer:                      tracer,
+		openTransactions:            newTransactionRegistry(),
+		sessions:                    newSessionTracker(),
 	}
 	for _, opt := range opts {
 		if !opt.EnsureDefaultOrgAndUser {
@@ -114,6 +124,9 @@ func newSQLStore(cfg *setting.Cfg, cacheService *localcache.CacheService, engine
 	if err := ss.initEngine(engine); err != nil {
 		return nil, fmt.Errorf("%v: %w", "failed to connect to database", err)
 	}
+	if err := ss.initReadEngine(); err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to connect to database replica", err)
+	}
 
 	ss.Dialect = migrator.NewDialect(ss.engine)
 
@@ -142,11 +155,20 @@ func (ss *SQLStore) Migrate(isDatabaseLockingEnabled bool) error {
 	}
 
 	migrator := migrator.NewMigrator(ss.engine, ss.Cfg)
+	migrator.ChecksumVerification = ss.dbCfg.VerifyMigrationChecksums
 	ss.migrations.AddMigration(migrator)
 
 	return migrator.Start(isDatabaseLockingEnabled, ss.dbCfg.MigrationLockAttemptTimeout)
 }
 
+// VerifyMigrationChecksums returns the applied migrations whose SQL has changed since they were recorded.
+func (ss *SQLStore) VerifyMigrationChecksums(ctx context.Context) ([]migrator.ChecksumMismatch, error) {
+	migrator := migrator.NewMigrator(ss.engine, ss.Cfg)
+	ss.migrations.AddMigration(migrator)
+
+	return migrator.VerifyChecksums(ctx)
+}
+
 // Sync syncs changes to the database.
 func (ss *SQLStore) Sync() error {
 	return ss.engine.Sync2()
@@ -233,13 +255,13 @@ func (ss *SQLStore) ensureMainOrgAndAdminUser() error {
 	return err
 }
 
-func (ss *SQLStore) buildExtraConnectionString(sep rune) string {
-	if ss.dbCfg.UrlQueryParams == nil {
+func buildExtraConnectionString(cfg *DatabaseConfig, sep rune) string {
+	if cfg.UrlQueryParams == nil {
 		return ""
 	}
 
 	var sb strings.Builder
-	for key, values := range ss.dbCfg.UrlQueryParams {
+	for key, values := range cfg.UrlQueryParams {
 		for _, value := range values {
 			sb.WriteRune(sep)
 			sb.WriteString(key)
@@ -255,36 +277,42 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 		return "", err
 	}
 
-	cnnstr := ss.dbCfg.ConnectionString
+	return ss.connectionString(&ss.dbCfg, "custom")
+}
+
+// connectionString returns the connection string of the database config. tlsConfigName is the name the TLS config of
+// MySQL is registered under, which has to be unique for every database.
+func (ss *SQLStore) connectionString(cfg *DatabaseConfig, tlsConfigName string) (string, error) {
+	cnnstr := cfg.ConnectionString
 
 	// special case used by integration tests
 	if cnnstr != "" {
 		return cnnstr, nil
 	}
 
-	switch ss.dbCfg.Type {
+	switch cfg.Type {
 	case migrator.MySQL:
 		protocol := "tcp"
-		if strings.HasPrefix(ss.dbCfg.Host, "/") {
+		if strings.HasPrefix(cfg.Host, "/") {
 			protocol = "unix"
 		}
 
 		cnnstr = fmt.Sprintf("%s:%s@%s(%s)/%s?collation=utf8mb4_unicode_ci&allowNativePasswords=true",
-			ss.dbCfg.User, ss.dbCfg.Pwd, protocol, ss.dbCfg.Host, ss.dbCfg.Name)
+			cfg.User, cfg.Pwd, protocol, cfg.Host, cfg.Name)
 
-		if ss.dbCfg.SslMode == "true" || ss.dbCfg.SslMode == "skip-verify" {
-			tlsCert, err := makeCert(ss.dbCfg)
+		if cfg.SslMode == "true" || cfg.SslMode == "skip-verify" {
+			tlsCert, err := makeCert(*cfg)
 			if err != nil {
 				return "", err
 			}
-			if err := mysql.RegisterTLSConfig("custom", tlsCert); err != nil {
+			if err := mysql.RegisterTLSConfig(tlsConfigName, tlsCert); err != nil {
 				return "", err
 			}
 
-			cnnstr += "&tls=custom"
+			cnnstr += "&tls=" + tlsConfigName
 		}
 
-		if isolation := ss.dbCfg.IsolationLevel; isolation != "" {
+		if isolation := cfg.IsolationLevel; isolation != "" {
 			val := url.QueryEscape(fmt.Sprintf("'%s'", isolation))
 			cnnstr += fmt.Sprintf("&tx_isolation=%s", val)
 		}
@@ -297,42 +325,42 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 			cnnstr += "&parseTime=true"
 		}
 
-		cnnstr += ss.buildExtraConnectionString('&')
+		cnnstr += buildExtraConnectionString(cfg, '&')
 	case migrator.Postgres:
-		addr, err := util.SplitHostPortDefault(ss.dbCfg.Host, "127.0.0.1", "5432")
+		addr, err := util.SplitHostPortDefault(cfg.Host, "127.0.0.1", "5432")
 		if err != nil {
-			return "", fmt.Errorf("invalid host specifier '%s': %w", ss.dbCfg.Host, err)
+			return "", fmt.Errorf("invalid host specifier '%s': %w", cfg.Host, err)
 		}
 
-		if ss.dbCfg.Pwd == "" {
-			ss.dbCfg.Pwd = "''"
+		if cfg.Pwd == "" {
+			cfg.Pwd = "''"
 		}
-		if ss.dbCfg.User == "" {
-			ss.dbCfg.User = "''"
+		if cfg.User == "" {
+			cfg.User = "''"
 		}
 		cnnstr = fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=%s sslcert=%s sslkey=%s sslrootcert=%s",
-			ss.dbCfg.User, ss.dbCfg.Pwd, addr.Host, addr.Port, ss.dbCfg.Name, ss.dbCfg.SslMode, ss.dbCfg.ClientCertPath,
-			ss.dbCfg.ClientKeyPath, ss.dbCfg.CaCertPath)
+			cfg.User, cfg.Pwd, addr.Host, addr.Port, cfg.Name, cfg.SslMode, cfg.ClientCertPath,
+			cfg.ClientKeyPath, cfg.CaCertPath)
 
-		cnnstr += ss.buildExtraConnectionString(' ')
+		cnnstr += buildExtraConnectionString(cfg, ' ')
 	case migrator.SQLite:
 		// special case for tests
-		if !filepath.IsAbs(ss.dbCfg.Path) {
-			ss.dbCfg.Path = filepath.Join(ss.Cfg.DataPath, ss.dbCfg.Path)
+		if !filepath.IsAbs(cfg.Path) {
+			cfg.Path = filepath.Join(ss.Cfg.DataPath, cfg.Path)
 		}
-		if err := os.MkdirAll(path.Dir(ss.dbCfg.Path), os.ModePerm); err != nil {
+		if err := os.MkdirAll(path.Dir(cfg.Path), os.ModePerm); err != nil {
 			return "", err
 		}
 
-		cnnstr = fmt.Sprintf("file:%s?cache=%s&mode=rwc", ss.dbCfg.Path, ss.dbCfg.CacheMode)
+		cnnstr = fmt.Sprintf("file:%s?cache=%s&mode=rwc", cfg.Path, cfg.CacheMode)
 
-		if ss.dbCfg.WALEnabled {
+		if cfg.WALEnabled {
 			cnnstr += "&_journal_mode=WAL"
 		}
 
-		cnnstr += ss.buildExtraConnectionString('&')
+		cnnstr += buildExtraConnectionString(cfg, '&')
 	default:
-		return "", fmt.Errorf("unknown database type: %s", ss.dbCfg.Type)
+		return "", fmt.Errorf("unknown database type: %s", cfg.Type)
 	}
 
 	return cnnstr, nil
@@ -384,7 +412,8 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 			}
 		}
 	}
-	if engine == nil {
+	opened := engine == nil
+	if opened {
 		var err error
 		engine, err = xorm.NewEngine(ss.dbCfg.Type, connectionString)
 		if err != nil {
@@ -392,9 +421,56 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		}
 	}
 
-	engine.SetMaxOpenConns(ss.dbCfg.MaxOpenConn)
-	engine.SetMaxIdleConns(ss.dbCfg.MaxIdleConn)
-	engine.SetConnMaxLifetime(time.Second * time.Duration(ss.dbCfg.ConnMaxLifetime))
+	checked, err := ss.checkSQLiteBusyTimeout(engine)
+	if err != nil {
+		if opened {
+			_ = engine.Close()
+		}
+		return err
+	}
+	// an engine that was passed in is closed by whoever opened it
+	if checked != engine && opened {
+		if err := engine.Close(); err != nil {
+			ss.log.Warn("Failed to close the database engine", "error", err)
+		}
+	}
+	engine = checked
+
+	ss.configureEngine(engine, &ss.dbCfg)
+	ss.engine = engine
+	return nil
+}
+
+// initReadEngine initializes ss.readEngine if a replica is configured, after ss.engine has been initialized.
+func (ss *SQLStore) initReadEngine() error {
+	replica := ss.dbCfg.Replica
+	if replica == nil || ss.readEngine != nil {
+		return nil
+	}
+
+	connectionString, err := ss.connectionString(replica, "custom-replica")
+	if err != nil {
+		return err
+	}
+	// the replica is of the same type as the database, which may have been wrapped with hooks already
+	replica.Type = ss.dbCfg.Type
+
+	sqlog.Info("Connecting to DB replica", "dbtype", replica.Type)
+	engine, err := xorm.NewEngine(replica.Type, connectionString)
+	if err != nil {
+		return err
+	}
+
+	ss.configureEngine(engine, replica)
+	ss.readEngine = engine
+	return nil
+}
+
+// configureEngine applies the connection pool settings of the database config and the SQL logging to the engine.
+func (ss *SQLStore) configureEngine(engine *xorm.Engine, cfg *DatabaseConfig) {
+	engine.SetMaxOpenConns(cfg.MaxOpenConn)
+	engine.SetMaxIdleConns(cfg.MaxIdleConn)
+	engine.SetConnMaxLifetime(time.Second * time.Duration(cfg.ConnMaxLifetime))
 
 	// configure sql logging
 	debugSQL := ss.Cfg.Raw.Section("database").Key("log_queries").MustBool(false)
@@ -406,65 +482,106 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		engine.ShowSQL(true)
 		engine.ShowExecTime(true)
 	}
-
-	ss.engine = engine
-	return nil
 }
 
 // readConfig initializes the SQLStore from its configuration.
 func (ss *SQLStore) readConfig() error {
-	sec := ss.Cfg.Raw.Section("database")
+	dbCfg, err := readDatabaseConfig(ss.Cfg.Raw.Section("database"))
+	if err != nil {
+		return err
+	}
+
+	// the keys of [database] apply to [database.replica] as well, unless it sets them itself
+	if sec, err := ss.Cfg.Raw.GetSection("database.replica"); err == nil && isReplicaConfigured(sec) {
+		replica, err := readDatabaseConfig(sec)
+		if err != nil {
+			return fmt.Errorf("%v: %w", "invalid database replica config", err)
+		}
+		if replica.Type != dbCfg.Type {
+			return fmt.Errorf("database replica must be of the same type as the database, %q, got %q", dbCfg.Type, replica.Type)
+		}
+		dbCfg.Replica = &replica
+	}
+
+	ss.dbCfg = dbCfg
+	return nil
+}
+
+// isReplicaConfigured returns true if the section itself sets where to connect to.
+func isReplicaConfigured(sec *ini.Section) bool {
+	// Keys only returns the keys of the section itself, unlike Key
+	for _, key := range sec.Keys() {
+		switch key.Name() {
+		case "url", "host", "connection_string", "path":
+			if key.String() != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readDatabaseConfig reads the database config from the section.
+func readDatabaseConfig(sec *ini.Section) (DatabaseConfig, error) {
+	var dbCfg DatabaseConfig
 
 	cfgURL := sec.Key("url").String()
 	if len(cfgURL) != 0 {
 		dbURL, err := url.Parse(cfgURL)
 		if err != nil {
-			return err
+			return dbCfg, err
 		}
-		ss.dbCfg.Type = dbURL.Scheme
-		ss.dbCfg.Host = dbURL.Host
+		dbCfg.Type = dbURL.Scheme
+		dbCfg.Host = dbURL.Host
 
 		pathSplit := strings.Split(dbURL.Path, "/")
 		if len(pathSplit) > 1 {
-			ss.dbCfg.Name = pathSplit[1]
+			dbCfg.Name = pathSplit[1]
 		}
 
 		userInfo := dbURL.User
 		if userInfo != nil {
-			ss.dbCfg.User = userInfo.Username()
-			ss.dbCfg.Pwd, _ = userInfo.Password()
+			dbCfg.User = userInfo.Username()
+			dbCfg.Pwd, _ = userInfo.Password()
 		}
 
-		ss.dbCfg.UrlQueryParams = dbURL.Query()
+		dbCfg.UrlQueryParams = dbURL.Query()
 	} else {
-		ss.dbCfg.Type = sec.Key("type").String()
-		ss.dbCfg.Host = sec.Key("host").String()
-		ss.dbCfg.Name = sec.Key("name").String()
-		ss.dbCfg.User = sec.Key("user").String()
-		ss.dbCfg.ConnectionString = sec.Key("connection_string").String()
-		ss.dbCfg.Pwd = sec.Key("password").String()
-	}
-
-	ss.dbCfg.MaxOpenConn = sec.Key("max_open_conn").MustInt(0)
-	ss.dbCfg.MaxIdleConn = sec.Key("max_idle_conn").MustInt(2)
-	ss.dbCfg.ConnMaxLifetime = sec.Key("conn_max_lifetime").MustInt(14400)
-
-	ss.dbCfg.SslMode = sec.Key("ssl_mode").String()
-	ss.dbCfg.CaCertPath = sec.Key("ca_cert_path").String()
-	ss.dbCfg.ClientKeyPath = sec.Key("client_key_path").String()
-	ss.dbCfg.ClientCertPath = sec.Key("client_cert_path").String()
-	ss.dbCfg.ServerCertName = sec.Key("server_cert_name").String()
-	ss.dbCfg.Path = sec.Key("path").MustString("data/grafana.db")
-	ss.dbCfg.IsolationLevel = sec.Key("isolation_level").String()
-
-	ss.dbCfg.CacheMode = sec.Key("cache_mode").MustString("private")
-	ss.dbCfg.WALEnabled = sec.Key("wal").MustBool(false)
-	ss.dbCfg.SkipMigrations = sec.Key("skip_migrations").MustBool()
-	ss.dbCfg.MigrationLockAttemptTimeout = sec.Key("locking_attempt_timeout_sec").MustInt()
-
-	ss.dbCfg.QueryRetries = sec.Key("query_retries").MustInt()
-	ss.dbCfg.TransactionRetries = sec.Key("transaction_retries").MustInt(5)
-	return nil
+		dbCfg.Type = sec.Key("type").String()
+		dbCfg.Host = sec.Key("host").String()
+		dbCfg.Name = sec.Key("name").String()
+		dbCfg.User = sec.Key("user").String()
+		dbCfg.ConnectionString = sec.Key("connection_string").String()
+		dbCfg.Pwd = sec.Key("password").String()
+	}
+
+	dbCfg.MaxOpenConn = sec.Key("max_open_conn").MustInt(0)
+	dbCfg.MaxIdleConn = sec.Key("max_idle_conn").MustInt(2)
+	dbCfg.ConnMaxLifetime = sec.Key("conn_max_lifetime").MustInt(14400)
+
+	dbCfg.SslMode = sec.Key("ssl_mode").String()
+	dbCfg.CaCertPath = sec.Key("ca_cert_path").String()
+	dbCfg.ClientKeyPath = sec.Key("client_key_path").String()
+	dbCfg.ClientCertPath = sec.Key("client_cert_path").String()
+	dbCfg.ServerCertName = sec.Key("server_cert_name").String()
+	dbCfg.Path = sec.Key("path").MustString("data/grafana.db")
+	dbCfg.IsolationLevel = sec.Key("isolation_level").String()
+
+	dbCfg.CacheMode = sec.Key("cache_mode").MustString("private")
+	dbCfg.WALEnabled = sec.Key("wal").MustBool(false)
+	dbCfg.SkipMigrations = sec.Key("skip_migrations").MustBool()
+	dbCfg.MigrationLockAttemptTimeout = sec.Key("locking_attempt_timeout_sec").MustInt()
+	dbCfg.VerifyMigrationChecksums = sec.Key("verify_migration_checksums").MustBool(false)
+
+	dbCfg.QueryRetries = sec.Key("query_retries").MustInt()
+	dbCfg.QueryRetryMinDelay = sec.Key("query_retry_min_delay").MustDuration(10 * time.Millisecond)
+	dbCfg.QueryRetryMaxDelay = sec.Key("query_retry_max_delay").MustDuration(time.Second)
+	dbCfg.LogSlowQueries = sec.Key("log_slow_queries").MustBool(false)
+	dbCfg.SlowQueryThreshold = sec.Key("slow_query_threshold").MustDuration(time.Second)
+	dbCfg.TransactionRetries = sec.Key("transaction_retries").MustInt(5)
+	dbCfg.MinBusyTimeout = sec.Key("min_busy_timeout").MustDuration(time.Second)
+	dbCfg.RaiseBusyTimeout = sec.Key("raise_busy_timeout").MustBool(false)
+	return dbCfg, nil
 }
 
 // ITestDB is an interface of arguments for testing db
@@ -687,8 +804,16 @@ type DatabaseConfig struct {
 	UrlQueryParams              map[string][]string
 	SkipMigrations              bool
 	MigrationLockAttemptTimeout int
-	// SQLite only
-	QueryRetries int
+	VerifyMigrationChecksums    bool
+	QueryRetries                int
+	QueryRetryMinDelay          time.Duration
+	QueryRetryMaxDelay          time.Duration
+	LogSlowQueries              bool
+	SlowQueryThreshold          time.Duration
 	// SQLite only
 	TransactionRetries int
+	MinBusyTimeout     time.Duration
+	RaiseBusyTimeout   bool
+	// Replica is the read replica of the database, nil if none is configured
+	Replica *DatabaseConfig
 }
@@ -21,8 +21,8 @@ type Store interface {
 	GetSystemStats(ctx context.Context, query *models.GetSystemStatsQuery) error
 	CreateUser(ctx context.Context, cmd user.CreateUserCommand) (*user.User, error)
 	GetSignedInUser(ctx context.Context, query *models.GetSignedInUserQuery) error
-	WithDbSession(ctx context.Context, callback DBTransactionFunc) error
-	WithNewDbSession(ctx context.Context, callback DBTransactionFunc) error
+	WithDbSession(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error
+	WithNewDbSession(ctx context.Context, callback DBTransactionFunc, opts ...SessionOption) error
 	WithTransactionalDbSession(ctx context.Context, callback DBTransactionFunc) error
 	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	Migrate(bool) error
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// SwapOrder swaps the values of the order column of the two rows of the bean's table with the primary keys idA and
+// idB, e.g. to move a dashboard up in its folder. The rows are updated in one transaction, in which the first row
+// is parked on a value below every other value of the column before the other row takes its value, so that a unique
+// index on the column, including one spanning other columns too, is never violated in between. The order column
+// must be an integer, and the table needs a single-column primary key.
+func (ss *SQLStore) SwapOrder(ctx context.Context, bean interface{}, idA, idB int64, orderCol string) error {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return fmt.Errorf("could not resolve the table of %T", bean)
+	}
+	pkColumns := table.PKColumns()
+	if len(pkColumns) != 1 {
+		return fmt.Errorf("swapping the order of rows requires a single-column primary key, table %q has %d", table.Name, len(pkColumns))
+	}
+	column := table.GetColumn(orderCol)
+	if column == nil {
+		return fmt.Errorf("table %q has no column %q", table.Name, orderCol)
+	}
+
+	quotedTable, quotedPK, quotedCol := ss.Dialect.Quote(table.Name), ss.Dialect.Quote(pkColumns[0].Name), ss.Dialect.Quote(column.Name)
+	selectSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", quotedCol, quotedTable, quotedPK)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", quotedTable, quotedCol, quotedPK)
+
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		orders := make([]int64, 2)
+		for i, id := range []int64{idA, idB} {
+			exists, err := sess.SQL(selectSQL, id).Get(&orders[i])
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("table %q has no row with %s %d", table.Name, pkColumns[0].Name, id)
+			}
+		}
+		if idA == idB || orders[0] == orders[1] {
+			return nil
+		}
+
+		var minOrder int64
+		if _, err := sess.SQL(fmt.Sprintf("SELECT MIN(%s) FROM %s", quotedCol, quotedTable)).Get(&minOrder); err != nil {
+			return err
+		}
+		if minOrder == math.MinInt64 {
+			return fmt.Errorf("no value of column %q of table %q is free to swap the order with", column.Name, table.Name)
+		}
+
+		for _, update := range []struct {
+			id    int64
+			order int64
+		}{{idA, minOrder - 1}, {idB, orders[0]}, {idA, orders[1]}} {
+			if _, err := sess.Exec(updateSQL, update.order, update.id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
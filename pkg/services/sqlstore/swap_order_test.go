@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type swapOrderTestItem struct {
+	ID        int64 `xorm:"pk autoincr 'id'"`
+	FolderID  int64 `xorm:"unique(folder_order) 'folder_id'"`
+	SortOrder int64 `xorm:"unique(folder_order)"`
+}
+
+func TestIntegrationSwapOrder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(swapOrderTestItem))
+	require.NoError(t, err)
+
+	// setup inserts three items in folder 1 and one in folder 2, ordered 1, 2, 3 and 1
+	setup := func(t *testing.T) []*swapOrderTestItem {
+		t.Helper()
+		_, err := ss.engine.Exec("DELETE FROM swap_order_test_item")
+		require.NoError(t, err)
+		items := []*swapOrderTestItem{{FolderID: 1, SortOrder: 1}, {FolderID: 1, SortOrder: 2}, {FolderID: 1, SortOrder: 3}, {FolderID: 2, SortOrder: 1}}
+		for _, item := range items {
+			_, err := ss.engine.Insert(item)
+			require.NoError(t, err)
+		}
+		return items
+	}
+	orders := func(t *testing.T, items []*swapOrderTestItem) []int64 {
+		t.Helper()
+		orders := make([]int64, 0, len(items))
+		for _, item := range items {
+			got := swapOrderTestItem{}
+			exists, err := ss.engine.ID(item.ID).Get(&got)
+			require.NoError(t, err)
+			require.True(t, exists)
+			orders = append(orders, got.SortOrder)
+		}
+		return orders
+	}
+
+	t.Run("swaps the order of two rows despite the unique index", func(t *testing.T) {
+		items := setup(t)
+
+		// updating the rows one after the other violates the unique index
+		_, err := ss.engine.Exec("UPDATE swap_order_test_item SET sort_order = ? WHERE id = ?", 2, items[0].ID)
+		require.Error(t, err)
+		require.True(t, ss.Dialect.IsUniqueConstraintViolation(err))
+
+		err = ss.SwapOrder(context.Background(), &swapOrderTestItem{}, items[0].ID, items[1].ID, "sort_order")
+		require.NoError(t, err)
+		require.Equal(t, []int64{2, 1, 3, 1}, orders(t, items))
+
+		err = ss.SwapOrder(context.Background(), &swapOrderTestItem{}, items[2].ID, items[0].ID, "sort_order")
+		require.NoError(t, err)
+		require.Equal(t, []int64{3, 1, 2, 1}, orders(t, items))
+	})
+
+	t.Run("swaps within the transaction in the context", func(t *testing.T) {
+		items := setup(t)
+
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			if err := ss.SwapOrder(ctx, &swapOrderTestItem{}, items[0].ID, items[1].ID, "sort_order"); err != nil {
+				return err
+			}
+			return ss.SwapOrder(ctx, &swapOrderTestItem{}, items[1].ID, items[2].ID, "sort_order")
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int64{2, 3, 1, 1}, orders(t, items))
+	})
+
+	t.Run("leaves the rows as they are when swapping a row with itself", func(t *testing.T) {
+		items := setup(t)
+
+		err := ss.SwapOrder(context.Background(), &swapOrderTestItem{}, items[1].ID, items[1].ID, "sort_order")
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 2, 3, 1}, orders(t, items))
+	})
+
+	t.Run("fails without changes if a row doesn't exist", func(t *testing.T) {
+		items := setup(t)
+
+		err := ss.SwapOrder(context.Background(), &swapOrderTestItem{}, items[0].ID, items[3].ID+100, "sort_order")
+		require.ErrorContains(t, err, "has no row with id")
+		require.Equal(t, []int64{1, 2, 3, 1}, orders(t, items))
+	})
+
+	t.Run("fails if the order column doesn't exist", func(t *testing.T) {
+		items := setup(t)
+
+		err := ss.SwapOrder(context.Background(), &swapOrderTestItem{}, items[0].ID, items[1].ID, "position")
+		require.ErrorContains(t, err, `has no column "position"`)
+	})
+}
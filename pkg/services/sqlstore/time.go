@@ -1,6 +1,14 @@
 package sqlstore
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"xorm.io/builder"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
 
 // TimeNow makes it possible to test usage of time
 var TimeNow = time.Now
@@ -14,3 +22,70 @@ func MockTimeNow(constTime time.Time) {
 func ResetTimeNow() {
 	TimeNow = time.Now
 }
+
+// timeFormats are the formats times stored as text are parsed with, which are those SQLite and its driver write.
+// Times without a zone are in UTC.
+var timeFormats = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// TimeColumnType returns the type of datetime columns on the database, e.g. for columns of raw CREATE TABLE statements
+func (ss *SQLStore) TimeColumnType() string {
+	return ss.Dialect.SQLType(&migrator.Column{Type: migrator.DB_DateTime})
+}
+
+// StoreTime returns the value to store the time as in a datetime column, or to compare datetime columns with in raw
+// SQL. The time is normalized to UTC and whole seconds, and on SQLite formatted the way xorm writes datetime columns,
+// so that it compares correctly with the times xorm stores.
+func (ss *SQLStore) StoreTime(t time.Time) interface{} {
+	return ss.Dialect.TimeValue(t)
+}
+
+// LoadTime converts the value of a datetime column, as returned by the driver, to a time in UTC. Depending on the
+// database and the way the value was stored it can be a time, text or unix seconds.
+func LoadTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case []byte:
+		return parseTime(string(v))
+	case string:
+		return parseTime(v)
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case nil:
+		return time.Time{}, fmt.Errorf("can't load a time from NULL")
+	}
+	return time.Time{}, fmt.Errorf("can't load a time from %T", value)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, format := range timeFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("can't parse %q as a time", value)
+}
+
+// TimeRangeCond returns the condition that the datetime column is in the range from (inclusive) to (exclusive), to
+// pass to the helpers taking conditions or to Where. A zero from or to leaves the range open on that side.
+func (ss *SQLStore) TimeRangeCond(column string, from, to time.Time) builder.Cond {
+	cond := builder.NewCond()
+	if !from.IsZero() {
+		cond = cond.And(builder.Gte{column: ss.StoreTime(from)})
+	}
+	if !to.IsZero() {
+		cond = cond.And(builder.Lt{column: ss.StoreTime(to)})
+	}
+	return cond
+}
@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"xorm.io/builder"
+)
+
+// TimeBucket is a row of a time bucketed query: the aggregated value of the rows whose time falls into the bucket
+// starting at Time.
+type TimeBucket struct {
+	Time  time.Time
+	Value float64
+}
+
+type timeBucketRow struct {
+	Bucket int64   `xorm:"bucket"`
+	Value  float64 `xorm:"value"`
+}
+
+// BuildTimeBucketSQL renders a statement grouping the rows of the bean's table into buckets of the given interval
+// by the datetime column timeColumn. Each row of the result has the start of the bucket in unix seconds as bucket
+// and the aggregation, e.g. "SUM(duration)", over the rows of the bucket as value. An empty aggregation counts the rows.
+// The buckets are aligned to the unix epoch, so the same rows are bucketed identically on every database.
+// The conditions are passed the same way as to BuildSQL. Only buckets containing rows are returned.
+func (ss *SQLStore) BuildTimeBucketSQL(bean interface{}, timeColumn string, interval time.Duration, aggregation string, conditions ...interface{}) (string, []interface{}, error) {
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return "", nil, fmt.Errorf("could not resolve the table of %T", bean)
+	}
+
+	if interval < time.Second || interval%time.Second != 0 {
+		return "", nil, fmt.Errorf("interval must be a positive number of seconds, got %s", interval)
+	}
+
+	if aggregation == "" {
+		aggregation = "COUNT(*)"
+	}
+
+	cond, err := buildCond(conditions...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	bucket := ss.Dialect.TimeBucketSQL(ss.Dialect.Quote(timeColumn), int64(interval/time.Second))
+	rawSQL, args, err := builder.Select(bucket+" AS bucket", aggregation+" AS value").
+		From(ss.Dialect.Quote(table.Name)).
+		Where(cond).
+		GroupBy("bucket").
+		OrderBy("bucket").
+		ToSQL()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, filter := range ss.engine.Dialect().Filters() {
+		rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table.Table)
+	}
+
+	return rawSQL, args, nil
+}
+
+// GetTimeBuckets runs the statement built by BuildTimeBucketSQL and returns the buckets in ascending order.
+func (ss *SQLStore) GetTimeBuckets(ctx context.Context, bean interface{}, timeColumn string, interval time.Duration, aggregation string, conditions ...interface{}) ([]TimeBucket, error) {
+	rawSQL, args, err := ss.BuildTimeBucketSQL(bean, timeColumn, interval, aggregation, conditions...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []timeBucketRow
+	err = ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.SQL(rawSQL, args...).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]TimeBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, TimeBucket{Time: time.Unix(row.Bucket, 0).UTC(), Value: row.Value})
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,153 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+type timeTestItem struct {
+	ID      int64 `xorm:"pk autoincr 'id'"`
+	Name    string
+	Created time.Time
+}
+
+func TestStoreTime(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	local := time.Date(2022, 11, 1, 11, 30, 15, 500000000, berlin)
+	expected := map[string]interface{}{
+		migrator.SQLite:   "2022-11-01 10:30:15",
+		migrator.Postgres: time.Date(2022, 11, 1, 10, 30, 15, 0, time.UTC),
+		migrator.MySQL:    time.Date(2022, 11, 1, 10, 30, 15, 0, time.UTC),
+	}
+
+	for _, dialect := range []migrator.Dialect{migrator.NewSQLite3Dialect(nil), migrator.NewPostgresDialect(nil), migrator.NewMysqlDialect(nil)} {
+		ss := &SQLStore{Dialect: dialect}
+		driver := dialect.DriverName()
+
+		t.Run(driver+" normalizes times to UTC and whole seconds", func(t *testing.T) {
+			require.Equal(t, expected[driver], ss.StoreTime(local))
+		})
+
+		t.Run(driver+" loads the stored time in UTC", func(t *testing.T) {
+			loaded, err := LoadTime(ss.StoreTime(local))
+			require.NoError(t, err)
+			require.Equal(t, time.UTC, loaded.Location())
+			require.True(t, loaded.Equal(local.Truncate(time.Second)))
+		})
+
+		t.Run(driver+" renders a time range condition", func(t *testing.T) {
+			rawSQL, args, err := builder.ToSQL(ss.TimeRangeCond("created", local, local.Add(time.Hour)))
+			require.NoError(t, err)
+			require.Equal(t, "created>=? AND created<?", rawSQL)
+			require.Equal(t, []interface{}{ss.StoreTime(local), ss.StoreTime(local.Add(time.Hour))}, args)
+		})
+	}
+
+	t.Run("TimeColumnType returns the datetime type of the dialect", func(t *testing.T) {
+		require.Equal(t, "DATETIME", (&SQLStore{Dialect: migrator.NewSQLite3Dialect(nil)}).TimeColumnType())
+		require.Equal(t, "TIMESTAMP", (&SQLStore{Dialect: migrator.NewPostgresDialect(nil)}).TimeColumnType())
+		require.Equal(t, "DATETIME", (&SQLStore{Dialect: migrator.NewMysqlDialect(nil)}).TimeColumnType())
+	})
+}
+
+func TestLoadTime(t *testing.T) {
+	expected := time.Date(2022, 11, 1, 10, 30, 15, 0, time.UTC)
+	values := []interface{}{
+		"2022-11-01 10:30:15",
+		[]byte("2022-11-01 10:30:15"),
+		"2022-11-01T10:30:15Z",
+		"2022-11-01 11:30:15+01:00",
+		"2022-11-01T05:30:15.000-05:00",
+		int64(1667298615),
+		"1667298615",
+		time.Date(2022, 11, 1, 11, 30, 15, 0, time.FixedZone("CET", 3600)),
+	}
+
+	for _, value := range values {
+		loaded, err := LoadTime(value)
+		require.NoError(t, err, "%v", value)
+		require.Equal(t, expected, loaded, "%v", value)
+	}
+
+	_, err := LoadTime("yesterday")
+	require.Error(t, err)
+	_, err = LoadTime(nil)
+	require.Error(t, err)
+	_, err = LoadTime(1.5)
+	require.Error(t, err)
+}
+
+func TestIntegrationStoreTime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(timeTestItem))
+	require.NoError(t, err)
+
+	start := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+	// the same instant as start+30m, in another zone
+	halfPast := start.Add(30 * time.Minute).In(time.FixedZone("EST", -5*3600))
+	err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM time_test_item"); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&timeTestItem{Name: "start", Created: start}); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("INSERT INTO time_test_item (name, created) VALUES (?, ?)", "half past", db.StoreTime(halfPast)); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&timeTestItem{Name: "an hour later", Created: start.Add(time.Hour)})
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("round-trips times stored in raw SQL", func(t *testing.T) {
+		var values []map[string]interface{}
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			values, err = sess.QueryInterface("SELECT created FROM time_test_item WHERE name = ?", "half past")
+			return err
+		})
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+
+		loaded, err := LoadTime(values[0]["created"])
+		require.NoError(t, err)
+		require.Equal(t, start.Add(30*time.Minute), loaded)
+	})
+
+	t.Run("times stored in raw SQL are loaded by xorm", func(t *testing.T) {
+		var item timeTestItem
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			_, err := sess.Where("name = ?", "half past").Get(&item)
+			return err
+		})
+		require.NoError(t, err)
+		require.True(t, item.Created.Equal(halfPast))
+	})
+
+	t.Run("filters rows by a time range", func(t *testing.T) {
+		var items []timeTestItem
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			return sess.Where(db.TimeRangeCond("created", halfPast, start.Add(time.Hour))).Find(&items)
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, "half past", items[0].Name)
+
+		var count int64
+		err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			count, err = sess.Where(db.TimeRangeCond("created", start, time.Time{})).Count(&timeTestItem{})
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+}
@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// openTransaction is a transaction started by the SQLStore that hasn't been committed or rolled back yet
+type openTransaction struct {
+	name    string
+	started time.Time
+	warned  bool
+}
+
+// transactionRegistry keeps track of the open transactions, so that long-running ones can be found. A nil registry
+// tracks nothing, which is the case for stores that weren't created by newSQLStore.
+type transactionRegistry struct {
+	mu     sync.Mutex
+	nextID int64
+	open   map[int64]*openTransaction
+}
+
+func newTransactionRegistry() *transactionRegistry {
+	return &transactionRegistry{open: map[int64]*openTransaction{}}
+}
+
+// track registers a transaction that was just started and returns the func to call once it's closed
+func (r *transactionRegistry) track(ctx context.Context) func() {
+	if r == nil {
+		return func() {}
+	}
+
+	name, _ := TransactionNameFromContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.nextID
+	r.open[id] = &openTransaction{name: name, started: time.Now()}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.open, id)
+	}
+}
+
+// warnLongRunning logs a warning for each transaction that has been open for longer than the threshold. Every
+// transaction is only warned about once, however long it stays open.
+func (r *transactionRegistry) warnLongRunning(logger log.Logger, threshold time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tx := range r.open {
+		if tx.warned {
+			continue
+		}
+		if openFor := time.Since(tx.started); openFor > threshold {
+			tx.warned = true
+			logger.Warn("Transaction has been open for a long time", "transaction", tx.name, "openFor", openFor, "threshold", threshold)
+		}
+	}
+}
+
+// MonitorLongTransactions checks the open transactions of the store every interval and logs a warning for each one
+// that has been open for longer than the threshold, including the name of transactions started by
+// WithNamedTransaction. Long-running transactions hold their locks for as long as they're open, so this helps to find
+// the code that blocks others. It blocks until the context is done, so it's meant to be run in a goroutine.
+func (ss *SQLStore) MonitorLongTransactions(ctx context.Context, interval time.Duration, threshold time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ss.openTransactions.warnLongRunning(tsclogger, threshold)
+		}
+	}
+}
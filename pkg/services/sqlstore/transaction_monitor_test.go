@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestIntegrationMonitorLongTransactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+
+	countOpen := func() int {
+		ss.openTransactions.mu.Lock()
+		defer ss.openTransactions.mu.Unlock()
+		return len(ss.openTransactions.open)
+	}
+
+	t.Run("warns once about a transaction open for longer than the threshold", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		errs := make(chan error)
+		go func() {
+			errs <- ss.WithNamedTransaction(context.Background(), "slow import", func(sess *DBSession) error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+		require.Equal(t, 1, countOpen())
+
+		logger := &logtest.Fake{}
+		ss.openTransactions.warnLongRunning(logger, time.Hour)
+		require.Zero(t, logger.WarnLogs.Calls)
+
+		time.Sleep(20 * time.Millisecond)
+		ss.openTransactions.warnLongRunning(logger, 10*time.Millisecond)
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.Equal(t, "Transaction has been open for a long time", logger.WarnLogs.Message)
+		require.Equal(t, []interface{}{"transaction", "slow import"}, logger.WarnLogs.Ctx[:2])
+
+		ss.openTransactions.warnLongRunning(logger, 10*time.Millisecond)
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+
+		close(release)
+		require.NoError(t, <-errs)
+		require.Zero(t, countOpen())
+	})
+
+	t.Run("doesn't track sessions without a transaction", func(t *testing.T) {
+		err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+			require.Zero(t, countOpen())
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("stops monitoring when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			ss.MonitorLongTransactions(ctx, time.Millisecond, time.Hour)
+			close(done)
+		}()
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("monitor didn't stop")
+		}
+	})
+}
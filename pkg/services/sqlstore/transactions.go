@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"xorm.io/xorm"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -15,11 +18,176 @@ import (
 
 var tsclogger = log.New("sqlstore.transactions")
 
+type transactionNameKey struct{}
+
+var registerTransactionLogProvider sync.Once
+
+// TransactionNameFromContext returns the name of the transaction started by WithNamedTransaction, if any.
+func TransactionNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(transactionNameKey{}).(string)
+	return name, ok
+}
+
+func transactionLogContext(ctx context.Context) ([]interface{}, bool) {
+	name, ok := TransactionNameFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return []interface{}{"transaction", name}, true
+}
+
+// AfterCommitHandler is called with the events published with PublishAfterCommit in a transaction, once the
+// transaction has been committed.
+type AfterCommitHandler func(ctx context.Context, events []interface{})
+
+// RegisterAfterCommitHandler registers the handler to be called after every committed transaction that published
+// events, after they have been published on the bus. The events of a transaction include those published in the
+// scopes that reused its session, and the handlers are never called for a transaction that was rolled back.
+func (ss *SQLStore) RegisterAfterCommitHandler(fn AfterCommitHandler) {
+	ss.afterCommitMu.Lock()
+	defer ss.afterCommitMu.Unlock()
+	ss.afterCommitHandlers = append(ss.afterCommitHandlers, fn)
+}
+
+func (ss *SQLStore) runAfterCommitHandlers(ctx context.Context, events []interface{}) {
+	ss.afterCommitMu.RLock()
+	handlers := ss.afterCommitHandlers
+	ss.afterCommitMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, events)
+	}
+}
+
 // WithTransactionalDbSession calls the callback with a session within a transaction.
 func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback DBTransactionFunc) error {
 	return ss.inTransactionWithRetryCtx(ctx, ss.engine, ss.bus, callback, 0)
 }
 
+// WithNamedTransaction calls the callback with a session within a transaction, like WithTransactionalDbSession.
+// The name is added to the span of the transaction and to the log lines of loggers created with FromContext,
+// so that the transaction can be correlated with traces and logs, as well as to the error returned when retrying
+// the transaction has been exhausted.
+func (ss *SQLStore) WithNamedTransaction(ctx context.Context, name string, callback DBTransactionFunc) error {
+	registerTransactionLogProvider.Do(func() {
+		log.RegisterContextualLogProvider(transactionLogContext)
+	})
+
+	ctx, span := ss.tracer.Start(context.WithValue(ctx, transactionNameKey{}, name), "database transaction")
+	span.SetAttributes("transaction", name, attribute.Key("transaction").String(name))
+	defer span.End()
+
+	err := ss.inTransactionWithRetryCtx(ctx, ss.engine, ss.bus, callback, 0)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// RetryableTransaction calls the callback with a session within a transaction, like WithTransactionalDbSession.
+// If the transaction fails with a deadlock or a serialization failure, it is rolled back and the callback is called
+// again from scratch in a new transaction, up to TransactionRetries times, so that everything it read is read again.
+// The attempts are spaced out like the retries on lock errors, between QueryRetryMinDelay and QueryRetryMaxDelay.
+// The callback therefore must not have side effects outside of the transaction.
+// Unlike the retries on SQLite's database locked errors, which apply to every transaction, this is only safe for
+// callbacks that don't depend on state from before the transaction. If the context already has a transaction,
+// the callback is run in it without retries, since only the outer scope can roll the whole transaction back.
+func (ss *SQLStore) RetryableTransaction(ctx context.Context, callback DBTransactionFunc) error {
+	if _, ok := ctx.Value(ContextSessionKey{}).(*DBSession); ok {
+		return ss.WithTransactionalDbSession(ctx, callback)
+	}
+
+	timerCtx, timer, done := startSessionTimer(ctx)
+	defer done()
+	ctxLogger := tsclogger.FromContext(ctx)
+	for retry := 0; ; retry++ {
+		err := ss.WithTransactionalDbSession(timerCtx, callback)
+		if err == nil || !ss.isRetriableTransactionError(err) {
+			return err
+		}
+		if retry >= ss.dbCfg.TransactionRetries {
+			return fmt.Errorf("transaction failed after %d retries: %w", retry, err)
+		}
+
+		// the attempts are counted from 1, like those of retryOnLocks
+		delay := ss.queryRetryDelay(retry + 1)
+		ctxLogger.Info("Transaction could not be serialized, retrying", "error", err, "retry", retry, "delay", delay)
+		waitStart := time.Now()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		timer.waited(time.Since(waitStart))
+	}
+}
+
+// PanicSafeTransaction calls the callback with a session within a transaction, like WithTransactionalDbSession.
+// If the callback panics, the transaction is rolled back explicitly before the panic is propagated to the caller,
+// rather than relying on the session being closed to roll it back, so that a failing rollback is logged instead of
+// silently leaving the transaction open. If the context already has a transaction, the panic is propagated without
+// rolling back, since only the outer scope can roll the whole transaction back.
+func (ss *SQLStore) PanicSafeTransaction(ctx context.Context, callback DBTransactionFunc) error {
+	_, inOuterTransaction := ctx.Value(ContextSessionKey{}).(*DBSession)
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		defer func() {
+			if r := recover(); r != nil {
+				if !inOuterTransaction {
+					if err := sess.Rollback(); err != nil {
+						tsclogger.FromContext(ctx).Error("Failed to roll back transaction after panic", "error", err, "panic", r)
+					}
+				}
+				panic(r)
+			}
+		}()
+
+		return callback(sess)
+	})
+}
+
+// WithSavepoint calls the callback within a savepoint of the transaction in the context, so that if the callback fails
+// only its changes are rolled back, along with the events it published, and the outer transaction can go on. If the
+// context doesn't have a transaction, the callback is called within a new transaction. The name must be a valid
+// savepoint name for the dialect, and an error is returned if the dialect doesn't support savepoints.
+func (ss *SQLStore) WithSavepoint(ctx context.Context, name string, callback DBTransactionFunc) error {
+	savepoint, release, rollbackTo, err := ss.Dialect.SavepointSQL(name)
+	if err != nil {
+		return err
+	}
+
+	sess, ok := ctx.Value(ContextSessionKey{}).(*DBSession)
+	if !ok || !sess.transactionOpen {
+		return ss.WithTransactionalDbSession(ctx, callback)
+	}
+
+	if _, err := sess.Exec(savepoint); err != nil {
+		return fmt.Errorf("creating savepoint %q failed: %w", name, err)
+	}
+	events := len(sess.events)
+
+	if err := callback(sess); err != nil {
+		if _, rollErr := sess.Exec(rollbackTo); rollErr != nil {
+			return fmt.Errorf("rolling back to savepoint %q due to error failed: %s: %w", name, rollErr, err)
+		}
+		sess.events = sess.events[:events]
+		// rolling back to a savepoint keeps it, so it's released to not pile up in the transaction
+		if _, relErr := sess.Exec(release); relErr != nil {
+			return fmt.Errorf("releasing savepoint %q due to error failed: %s: %w", name, relErr, err)
+		}
+		return err
+	}
+
+	if _, err := sess.Exec(release); err != nil {
+		return fmt.Errorf("releasing savepoint %q failed: %w", name, err)
+	}
+	return nil
+}
+
+func (ss *SQLStore) isRetriableTransactionError(err error) bool {
+	return ss.Dialect.IsDeadlock(err) || ss.Dialect.IsSerializationFailure(err)
+}
+
 // InTransaction starts a transaction and calls the fn
 // It stores the session in the context
 func (ss *SQLStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
@@ -34,7 +202,10 @@ func (ss *SQLStore) inTransactionWithRetry(ctx context.Context, fn func(ctx cont
 }
 
 func (ss *SQLStore) inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, bus bus.Bus, callback DBTransactionFunc, retry int) error {
-	sess, isNew, err := startSessionOrUseExisting(ctx, engine, true)
+	markWritten(ctx)
+	timerCtx, timer, done := startSessionTimer(ctx)
+	defer done()
+	sess, isNew, err := startSessionOrUseExisting(ctx, engine, ss.sessions, true)
 	if err != nil {
 		return err
 	}
@@ -45,10 +216,12 @@ func (ss *SQLStore) inTransactionWithRetryCtx(ctx context.Context, engine *xorm.
 	}
 
 	if isNew { // if this call initiated the session, it should be responsible for closing it.
+		sess.timer = timer
 		defer sess.Close()
+		defer ss.openTransactions.track(ctx)()
 	}
 
-	err = callback(sess)
+	err = withContextError(ctx, timer.run(func() error { return callback(sess) }))
 
 	ctxLogger := tsclogger.FromContext(ctx)
 
@@ -60,14 +233,20 @@ func (ss *SQLStore) inTransactionWithRetryCtx(ctx context.Context, engine *xorm.
 
 	// special handling of database locked errors for sqlite, then we can retry 5 times
 	var sqlError sqlite3.Error
-	if errors.As(err, &sqlError) && retry < ss.dbCfg.TransactionRetries && (sqlError.Code == sqlite3.ErrLocked || sqlError.Code == sqlite3.ErrBusy) {
-		if rollErr := sess.Rollback(); rollErr != nil {
-			return fmt.Errorf("rolling back transaction due to error failed: %s: %w", rollErr, err)
+	if errors.As(err, &sqlError) && (sqlError.Code == sqlite3.ErrLocked || sqlError.Code == sqlite3.ErrBusy) {
+		if retry < ss.dbCfg.TransactionRetries {
+			if rollErr := sess.Rollback(); rollErr != nil {
+				return fmt.Errorf("rolling back transaction due to error failed: %s: %w", rollErr, err)
+			}
+
+			timer.sleep(time.Millisecond * time.Duration(10))
+			ctxLogger.Info("Database locked, sleeping then retrying", "error", err, "retry", retry, "code", sqlError.Code)
+			return ss.inTransactionWithRetryCtx(timerCtx, engine, bus, callback, retry+1)
 		}
 
-		time.Sleep(time.Millisecond * time.Duration(10))
-		ctxLogger.Info("Database locked, sleeping then retrying", "error", err, "retry", retry, "code", sqlError.Code)
-		return ss.inTransactionWithRetryCtx(ctx, engine, bus, callback, retry+1)
+		if name, ok := TransactionNameFromContext(ctx); ok {
+			err = fmt.Errorf("transaction %q failed after %d retries: %w", name, retry, err)
+		}
 	}
 
 	if err != nil {
@@ -86,6 +265,7 @@ func (ss *SQLStore) inTransactionWithRetryCtx(ctx context.Context, engine *xorm.
 				ctxLogger.Error("Failed to publish event after commit.", "error", err)
 			}
 		}
+		ss.runAfterCommitHandlers(ctx, sess.events)
 	}
 
 	return nil
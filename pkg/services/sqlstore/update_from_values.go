@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ValuesRow is a row updated by UpdateFromValues, identified by the value of its key column. Cols are the new values
+// of the row by column name.
+type ValuesRow struct {
+	Key  interface{}
+	Cols map[string]interface{}
+}
+
+// UpdateFromValues updates every row of the bean's table whose key column matches the key of one of the rows to the
+// values of that row, so that many rows can be set to distinct values without a round trip per row. On Postgres the
+// table is joined with the values in an UPDATE ... FROM (VALUES ...), elsewhere each column is set with a CASE on the
+// key column. The rows must set the same columns and have unique keys. Rows are updated in statements of as many rows
+// as fit the limit of the database on the number of args, all in the same transaction. The number of updated rows is returned.
+func (ss *SQLStore) UpdateFromValues(ctx context.Context, bean interface{}, keyCol string, rows []ValuesRow) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	table := ss.engine.TableInfo(bean)
+	if !table.IsValid() {
+		return 0, fmt.Errorf("could not resolve the table of %T", bean)
+	}
+
+	cols := make([]string, 0, len(rows[0].Cols))
+	for col := range rows[0].Cols {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	if len(cols) == 0 {
+		return 0, fmt.Errorf("no columns to update")
+	}
+
+	types := make([]string, 0, len(cols)+1)
+	for _, name := range append([]string{keyCol}, cols...) {
+		col := table.GetColumn(name)
+		if col == nil {
+			return 0, fmt.Errorf("table %s has no column %q", table.Name, name)
+		}
+		// the type is only used to cast values, which can't be cast to the serial type of an autoincrement column
+		c := *col
+		c.IsAutoIncrement = false
+		types = append(types, ss.engine.Dialect().SqlType(&c))
+	}
+
+	keys := make([]interface{}, 0, len(rows))
+	values := make([][]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if len(row.Cols) != len(cols) {
+			return 0, fmt.Errorf("row with key %v sets %d columns, expected %d", row.Key, len(row.Cols), len(cols))
+		}
+		rowValues := make([]interface{}, 0, len(cols))
+		for _, col := range cols {
+			value, ok := row.Cols[col]
+			if !ok {
+				return 0, fmt.Errorf("row with key %v doesn't set column %q", row.Key, col)
+			}
+			rowValues = append(rowValues, value)
+		}
+		keys = append(keys, row.Key)
+		values = append(values, rowValues)
+	}
+
+	rowsPerStatement := ss.Dialect.MaxPlaceholders() / (2*len(cols) + 1)
+	if rowsPerStatement < 1 {
+		return 0, fmt.Errorf("can't update %d columns in a single statement", len(cols))
+	}
+
+	var updated int64
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		for start := 0; start < len(keys); start += rowsPerStatement {
+			end := start + rowsPerStatement
+			if end > len(keys) {
+				end = len(keys)
+			}
+
+			rawSQL, args := ss.Dialect.UpdateFromValuesSQL(table.Name, keyCol, cols, types, keys[start:end], values[start:end])
+			for _, filter := range ss.engine.Dialect().Filters() {
+				rawSQL = filter.Do(rawSQL, ss.engine.Dialect(), table.Table)
+			}
+
+			res, err := sess.Exec(append([]interface{}{rawSQL}, args...)...)
+			if err != nil {
+				return err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			updated += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
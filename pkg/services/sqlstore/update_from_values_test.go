@@ -0,0 +1,138 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+type updateValuesTestItem struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Name  string `xorm:"varchar(20)"`
+	Score int64
+}
+
+// placeholderLimitDialect is a dialect whose limit on the number of placeholders is set by the test
+type placeholderLimitDialect struct {
+	migrator.Dialect
+	maxPlaceholders int
+}
+
+func (d placeholderLimitDialect) MaxPlaceholders() int {
+	return d.maxPlaceholders
+}
+
+func TestIntegrationUpdateFromValues(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	db := InitTestDB(t)
+	err := db.engine.Sync(new(updateValuesTestItem))
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, count int) []updateValuesTestItem {
+		t.Helper()
+		items := make([]updateValuesTestItem, count)
+		for i := range items {
+			items[i] = updateValuesTestItem{Name: "initial"}
+		}
+		err := db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			if _, err := sess.Exec("DELETE FROM update_values_test_item"); err != nil {
+				return err
+			}
+			if _, err := sess.BulkInsert(updateValuesTestItem{}, items, NativeSettingsForDialect(db.GetDialect())); err != nil {
+				return err
+			}
+			items = items[:0]
+			return sess.Table("update_values_test_item").Asc("id").Find(&items)
+		})
+		require.NoError(t, err)
+		return items
+	}
+
+	t.Run("updates every row to its own values", func(t *testing.T) {
+		items := setup(t, 1000)
+		require.Len(t, items, 1000)
+
+		rows := make([]ValuesRow, 0, len(items))
+		for i, item := range items {
+			// leave every tenth row untouched
+			if i%10 == 0 {
+				continue
+			}
+			rows = append(rows, ValuesRow{Key: item.ID, Cols: map[string]interface{}{"name": fmt.Sprintf("item-%d", item.ID), "score": item.ID * 2}})
+		}
+
+		updated, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", rows)
+		require.NoError(t, err)
+		require.Equal(t, int64(900), updated)
+
+		var result []updateValuesTestItem
+		err = db.WithDbSession(context.Background(), func(sess *DBSession) error {
+			return sess.Table("update_values_test_item").Asc("id").Find(&result)
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1000)
+		for i, item := range result {
+			if i%10 == 0 {
+				require.Equal(t, updateValuesTestItem{ID: item.ID, Name: "initial"}, item)
+				continue
+			}
+			require.Equal(t, updateValuesTestItem{ID: item.ID, Name: fmt.Sprintf("item-%d", item.ID), Score: item.ID * 2}, item)
+		}
+	})
+
+	t.Run("splits the rows by the placeholder limit of the dialect", func(t *testing.T) {
+		items := setup(t, 5)
+		// the test store is shared between tests
+		origDialect := db.Dialect
+		t.Cleanup(func() { db.Dialect = origDialect })
+
+		rows := make([]ValuesRow, 0, len(items))
+		for _, item := range items {
+			rows = append(rows, ValuesRow{Key: item.ID, Cols: map[string]interface{}{"score": item.ID}})
+		}
+
+		// a row setting a single column takes up to three args
+		db.Dialect = placeholderLimitDialect{Dialect: origDialect, maxPlaceholders: 2}
+		_, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", rows)
+		require.ErrorContains(t, err, "can't update 1 columns in a single statement")
+
+		db.Dialect = placeholderLimitDialect{Dialect: origDialect, maxPlaceholders: 6}
+		updated, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", rows)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), updated)
+	})
+
+	t.Run("ignores keys without a row", func(t *testing.T) {
+		items := setup(t, 2)
+
+		updated, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", []ValuesRow{
+			{Key: items[0].ID, Cols: map[string]interface{}{"score": 5}},
+			{Key: items[1].ID + 100, Cols: map[string]interface{}{"score": 7}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), updated)
+	})
+
+	t.Run("rejects rows setting different columns", func(t *testing.T) {
+		items := setup(t, 2)
+
+		_, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", []ValuesRow{
+			{Key: items[0].ID, Cols: map[string]interface{}{"score": 5}},
+			{Key: items[1].ID, Cols: map[string]interface{}{"name": "b"}},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects unknown columns", func(t *testing.T) {
+		_, err := db.UpdateFromValues(context.Background(), updateValuesTestItem{}, "id", []ValuesRow{
+			{Key: 1, Cols: map[string]interface{}{"missing": 5}},
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// ErrWriteRateLimited is returned by WithRateLimitedWrite if a write to a table exceeds its write rate limit
+var ErrWriteRateLimited = errutil.NewBase(errutil.StatusTooManyRequests, "sqlstore.write-rate-limited")
+
+// WriteRateLimit limits the rate of the writes to a table. PerSecond writes are allowed per second on average, with
+// bursts of up to Burst writes, which is at least one. A write exceeding the rate waits for its turn for at most
+// MaxWait, and is rejected if it would have to wait any longer, so with a zero MaxWait it's rejected right away.
+type WriteRateLimit struct {
+	PerSecond float64
+	Burst     int
+	MaxWait   time.Duration
+}
+
+type writeLimiter struct {
+	limiter *rate.Limiter
+	maxWait time.Duration
+}
+
+// SetWriteRateLimit limits the rate of the writes to the table made with WithRateLimitedWrite, e.g. to keep a runaway
+// loop of a misbehaving plugin from flooding a table. A limit with a PerSecond that isn't positive removes the limit of
+// the table. Tables without a limit can be written to at any rate.
+func (ss *SQLStore) SetWriteRateLimit(table string, limit WriteRateLimit) {
+	ss.writeLimitsMu.Lock()
+	defer ss.writeLimitsMu.Unlock()
+
+	if limit.PerSecond <= 0 {
+		delete(ss.writeLimits, table)
+		return
+	}
+	if ss.writeLimits == nil {
+		ss.writeLimits = map[string]*writeLimiter{}
+	}
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	ss.writeLimits[table] = &writeLimiter{limiter: rate.NewLimiter(rate.Limit(limit.PerSecond), burst), maxWait: limit.MaxWait}
+}
+
+// WithRateLimitedWrite calls the callback with a session like WithDbSession, once the write to the table is within
+// the write rate limit of the table, if it has one. A write exceeding the limit waits for its turn for at most the
+// MaxWait of the limit, and otherwise fails with ErrWriteRateLimited without calling the callback. The statements of
+// a session can't be told apart, so only the writes made with it are limited, while reads and the writes made with
+// the other sessions aren't.
+func (ss *SQLStore) WithRateLimitedWrite(ctx context.Context, table string, callback DBTransactionFunc, opts ...SessionOption) error {
+	if err := ss.waitForWrite(ctx, table); err != nil {
+		return err
+	}
+	return ss.WithDbSession(ctx, callback, opts...)
+}
+
+// waitForWrite waits until a write to the table is within its write rate limit, if it has one
+func (ss *SQLStore) waitForWrite(ctx context.Context, table string) error {
+	ss.writeLimitsMu.RLock()
+	limit, ok := ss.writeLimits[table]
+	ss.writeLimitsMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	reservation := limit.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > limit.maxWait {
+		// the write isn't made, so it doesn't count towards the rate
+		reservation.Cancel()
+		return ErrWriteRateLimited.Errorf("writes to %s exceed the limit of %v per second", table, limit.limiter.Limit())
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
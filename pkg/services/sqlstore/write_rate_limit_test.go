@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type writeRateLimitTestItem struct {
+	ID   int64  `xorm:"pk autoincr 'id'"`
+	Name string `xorm:"varchar(10)"`
+}
+
+func TestIntegrationWriteRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ss := InitTestDB(t)
+	err := ss.engine.Sync(new(writeRateLimitTestItem))
+	require.NoError(t, err)
+
+	const table = "write_rate_limit_test_item"
+	// the test store is shared between tests
+	setLimit := func(t *testing.T, limit WriteRateLimit) {
+		t.Helper()
+		_, err := ss.engine.Exec("DELETE FROM " + table)
+		require.NoError(t, err)
+		ss.SetWriteRateLimit(table, limit)
+		t.Cleanup(func() { ss.SetWriteRateLimit(table, WriteRateLimit{}) })
+	}
+	write := func(ctx context.Context) error {
+		return ss.WithRateLimitedWrite(ctx, table, func(sess *DBSession) error {
+			_, err := sess.Insert(&writeRateLimitTestItem{Name: "item"})
+			return err
+		})
+	}
+	count := func(t *testing.T) int64 {
+		t.Helper()
+		total, err := ss.engine.Table(table).Count()
+		require.NoError(t, err)
+		return total
+	}
+
+	t.Run("rejects the writes exceeding the burst without a max wait", func(t *testing.T) {
+		setLimit(t, WriteRateLimit{PerSecond: 0.1, Burst: 3})
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, write(context.Background()))
+		}
+		err := write(context.Background())
+		require.ErrorIs(t, err, ErrWriteRateLimited)
+		require.ErrorContains(t, err, "writes to write_rate_limit_test_item exceed the limit")
+		require.Equal(t, int64(3), count(t))
+	})
+
+	t.Run("delays the writes exceeding the rate for at most the max wait", func(t *testing.T) {
+		setLimit(t, WriteRateLimit{PerSecond: 20, Burst: 1, MaxWait: time.Second})
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			require.NoError(t, write(context.Background()))
+		}
+		// the first write is within the burst, each of the others waits for 50ms
+		require.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+		require.Equal(t, int64(5), count(t))
+	})
+
+	t.Run("stops waiting once the context is done", func(t *testing.T) {
+		setLimit(t, WriteRateLimit{PerSecond: 0.1, Burst: 1, MaxWait: time.Minute})
+		require.NoError(t, write(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, write(ctx), context.DeadlineExceeded)
+		require.Equal(t, int64(1), count(t))
+	})
+
+	t.Run("doesn't limit reads or the tables without a limit", func(t *testing.T) {
+		setLimit(t, WriteRateLimit{PerSecond: 0.1, Burst: 1})
+		require.NoError(t, write(context.Background()))
+		require.ErrorIs(t, write(context.Background()), ErrWriteRateLimited)
+
+		for i := 0; i < 10; i++ {
+			err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+				_, err := sess.Table(table).Count()
+				return err
+			})
+			require.NoError(t, err)
+			err = ss.WithReadOnlyDbSession(context.Background(), func(sess *DBSession) error {
+				_, err := sess.Table(table).Count()
+				return err
+			})
+			require.NoError(t, err)
+			err = ss.WithRateLimitedWrite(context.Background(), "other_table", func(sess *DBSession) error { return nil })
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("lifts the limit of a table", func(t *testing.T) {
+		setLimit(t, WriteRateLimit{PerSecond: 0.1, Burst: 1})
+		require.NoError(t, write(context.Background()))
+		require.ErrorIs(t, write(context.Background()), ErrWriteRateLimited)
+
+		ss.SetWriteRateLimit(table, WriteRateLimit{})
+		require.NoError(t, write(context.Background()))
+		require.Equal(t, int64(2), count(t))
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -54,6 +55,9 @@ const (
 // zoneInfo names environment variable for setting the path to look for the timezone database in go
 const zoneInfo = "ZONEINFO"
 
+// awsSTSRegionalEndpointsEnvVarKeyName names environment variable for choosing between regional and global STS endpoints in the AWS SDK
+const awsSTSRegionalEndpointsEnvVarKeyName = "AWS_STS_REGIONAL_ENDPOINTS"
+
 var (
 	// App settings.
 	Env              = Dev
@@ -314,6 +318,18 @@ type Cfg struct {
 	AWSAllowedAuthProviders []string
 	AWSAssumeRoleEnabled    bool
 	AWSListMetricsPageLimit int
+	AWSSTSRegionalEndpoints bool
+	// Timeouts of AWS operations, a zero value disables the timeout
+	AWSTimeout               time.Duration
+	AWSListMetricsTimeout    time.Duration
+	AWSGetMetricDataTimeout  time.Duration
+	AWSDescribeAlarmsTimeout time.Duration
+	AWSLogsTimeout           time.Duration
+	// Retry policy of AWS operations, a zero max attempts keeps the SDK's default policy
+	AWSMaxAttempts    int
+	AWSRetryBaseDelay time.Duration
+	AWSRetryMaxDelay  time.Duration
+	AWSRetryJitter    float64
 
 	// Azure Cloud settings
 	Azure *azsettings.AzureSettings
@@ -1166,6 +1182,25 @@ func (cfg *Cfg) handleAWSConfig() {
 		}
 	}
 	cfg.AWSListMetricsPageLimit = awsPluginSec.Key("list_metrics_page_limit").MustInt(500)
+	stsRegionalEndpointsKey := awsPluginSec.Key("sts_regional_endpoints")
+	// AWS_STS_REGIONAL_ENDPOINTS set in the environment of Grafana is kept unless the setting is configured
+	configureSTSRegionalEndpoints := stsRegionalEndpointsKey.String() != "" || os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName) == ""
+	if configureSTSRegionalEndpoints {
+		cfg.AWSSTSRegionalEndpoints = stsRegionalEndpointsKey.MustBool(true)
+	} else {
+		cfg.AWSSTSRegionalEndpoints = os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName) != "legacy"
+	}
+	// operations without their own timeout use the global one
+	cfg.AWSTimeout = awsPluginSec.Key("timeout").MustDuration(0)
+	cfg.AWSListMetricsTimeout = awsPluginSec.Key("list_metrics_timeout").MustDuration(cfg.AWSTimeout)
+	cfg.AWSGetMetricDataTimeout = awsPluginSec.Key("get_metric_data_timeout").MustDuration(cfg.AWSTimeout)
+	cfg.AWSDescribeAlarmsTimeout = awsPluginSec.Key("describe_alarms_timeout").MustDuration(cfg.AWSTimeout)
+	cfg.AWSLogsTimeout = awsPluginSec.Key("logs_timeout").MustDuration(cfg.AWSTimeout)
+	cfg.AWSMaxAttempts = awsPluginSec.Key("max_attempts").MustInt(0)
+	cfg.AWSRetryBaseDelay = awsPluginSec.Key("retry_base_delay").MustDuration(100 * time.Millisecond)
+	cfg.AWSRetryMaxDelay = awsPluginSec.Key("retry_max_delay").MustDuration(20 * time.Second)
+	// the jitter is the fraction of each delay that is randomized
+	cfg.AWSRetryJitter = math.Min(math.Max(awsPluginSec.Key("retry_jitter").MustFloat64(0.5), 0), 1)
 	// Also set environment variables that can be used by core plugins
 	err := os.Setenv(awsds.AssumeRoleEnabledEnvVarKeyName, strconv.FormatBool(cfg.AWSAssumeRoleEnabled))
 	if err != nil {
@@ -1176,6 +1211,18 @@ func (cfg *Cfg) handleAWSConfig() {
 	if err != nil {
 		cfg.Logger.Error(fmt.Sprintf("could not set environment variable '%s'", awsds.AllowedAuthProvidersEnvVarKeyName), err)
 	}
+
+	// The AWS SDK reads this when creating sessions, including the ones used to obtain credentials when assuming a role
+	if configureSTSRegionalEndpoints {
+		stsRegionalEndpoints := "legacy"
+		if cfg.AWSSTSRegionalEndpoints {
+			stsRegionalEndpoints = "regional"
+		}
+		err = os.Setenv(awsSTSRegionalEndpointsEnvVarKeyName, stsRegionalEndpoints)
+		if err != nil {
+			cfg.Logger.Error(fmt.Sprintf("could not set environment variable '%s'", awsSTSRegionalEndpointsEnvVarKeyName), err)
+		}
+	}
 }
 
 func (cfg *Cfg) readSessionConfig() {
@@ -12,6 +12,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -744,3 +747,136 @@ func TestAlertingEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestAWSSTSRegionalEndpoints(t *testing.T) {
+	newSTSEndpoint := func(t *testing.T) string {
+		t.Helper()
+		sess, err := session.NewSession(&aws.Config{Region: aws.String("eu-west-1")})
+		require.NoError(t, err)
+		return sts.New(sess).Endpoint
+	}
+
+	t.Run("regional STS endpoints are used by default", func(t *testing.T) {
+		t.Setenv(awsSTSRegionalEndpointsEnvVarKeyName, "")
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		cfg.handleAWSConfig()
+
+		assert.True(t, cfg.AWSSTSRegionalEndpoints)
+		assert.Equal(t, "regional", os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName))
+		assert.Equal(t, "https://sts.eu-west-1.amazonaws.com", newSTSEndpoint(t))
+	})
+
+	t.Run("the global STS endpoint is used when regional endpoints are disabled", func(t *testing.T) {
+		t.Setenv(awsSTSRegionalEndpointsEnvVarKeyName, "")
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("aws")
+		require.NoError(t, err)
+		_, err = sec.NewKey("sts_regional_endpoints", "false")
+		require.NoError(t, err)
+		cfg.handleAWSConfig()
+
+		assert.False(t, cfg.AWSSTSRegionalEndpoints)
+		assert.Equal(t, "legacy", os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName))
+		assert.Equal(t, "https://sts.amazonaws.com", newSTSEndpoint(t))
+	})
+
+	t.Run("the environment variable is kept if the setting isn't configured", func(t *testing.T) {
+		t.Setenv(awsSTSRegionalEndpointsEnvVarKeyName, "legacy")
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("aws")
+		require.NoError(t, err)
+		_, err = sec.NewKey("sts_regional_endpoints", "")
+		require.NoError(t, err)
+		cfg.handleAWSConfig()
+
+		assert.False(t, cfg.AWSSTSRegionalEndpoints)
+		assert.Equal(t, "legacy", os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName))
+		assert.Equal(t, "https://sts.amazonaws.com", newSTSEndpoint(t))
+	})
+
+	t.Run("the setting overrides the environment variable if it's configured", func(t *testing.T) {
+		t.Setenv(awsSTSRegionalEndpointsEnvVarKeyName, "legacy")
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("aws")
+		require.NoError(t, err)
+		_, err = sec.NewKey("sts_regional_endpoints", "true")
+		require.NoError(t, err)
+		cfg.handleAWSConfig()
+
+		assert.True(t, cfg.AWSSTSRegionalEndpoints)
+		assert.Equal(t, "regional", os.Getenv(awsSTSRegionalEndpointsEnvVarKeyName))
+		assert.Equal(t, "https://sts.eu-west-1.amazonaws.com", newSTSEndpoint(t))
+	})
+}
+
+func TestAWSOperationTimeouts(t *testing.T) {
+	t.Run("timeouts are disabled by default", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		cfg.handleAWSConfig()
+
+		assert.Zero(t, cfg.AWSTimeout)
+		assert.Zero(t, cfg.AWSListMetricsTimeout)
+		assert.Zero(t, cfg.AWSGetMetricDataTimeout)
+		assert.Zero(t, cfg.AWSDescribeAlarmsTimeout)
+		assert.Zero(t, cfg.AWSLogsTimeout)
+	})
+
+	t.Run("operations without a timeout use the global timeout", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("aws")
+		require.NoError(t, err)
+		_, err = sec.NewKey("timeout", "30s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("list_metrics_timeout", "2m")
+		require.NoError(t, err)
+		_, err = sec.NewKey("logs_timeout", "")
+		require.NoError(t, err)
+		cfg.handleAWSConfig()
+
+		assert.Equal(t, 30*time.Second, cfg.AWSTimeout)
+		assert.Equal(t, 2*time.Minute, cfg.AWSListMetricsTimeout)
+		assert.Equal(t, 30*time.Second, cfg.AWSGetMetricDataTimeout)
+		assert.Equal(t, 30*time.Second, cfg.AWSDescribeAlarmsTimeout)
+		assert.Equal(t, 30*time.Second, cfg.AWSLogsTimeout)
+	})
+}
+
+func TestAWSRetryPolicy(t *testing.T) {
+	t.Run("the SDK's policy is kept by default", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		cfg.handleAWSConfig()
+
+		assert.Zero(t, cfg.AWSMaxAttempts)
+		assert.Equal(t, 100*time.Millisecond, cfg.AWSRetryBaseDelay)
+		assert.Equal(t, 20*time.Second, cfg.AWSRetryMaxDelay)
+		assert.Equal(t, 0.5, cfg.AWSRetryJitter)
+	})
+
+	t.Run("the policy is read from the aws section", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("aws")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_attempts", "5")
+		require.NoError(t, err)
+		_, err = sec.NewKey("retry_base_delay", "1s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("retry_max_delay", "1m")
+		require.NoError(t, err)
+		_, err = sec.NewKey("retry_jitter", "2")
+		require.NoError(t, err)
+		cfg.handleAWSConfig()
+
+		assert.Equal(t, 5, cfg.AWSMaxAttempts)
+		assert.Equal(t, time.Second, cfg.AWSRetryBaseDelay)
+		assert.Equal(t, time.Minute, cfg.AWSRetryMaxDelay)
+		assert.Equal(t, 1.0, cfg.AWSRetryJitter)
+	})
+}
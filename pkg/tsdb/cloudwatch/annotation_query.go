@@ -1,6 +1,7 @@
 package cloudwatch
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -8,8 +9,11 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/clients"
 )
 
 type annotationEvent struct {
@@ -19,7 +23,7 @@ type annotationEvent struct {
 	Text  string
 }
 
-func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginContext, model DataQueryJson, query backend.DataQuery) (*backend.QueryDataResponse, error) {
+func (e *cloudWatchExecutor) executeAnnotationQuery(ctx context.Context, pluginCtx backend.PluginContext, model DataQueryJson, query backend.DataQuery) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
 	statistic := ""
 
@@ -55,7 +59,7 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 			ActionPrefix:    aws.String(actionPrefix),
 			AlarmNamePrefix: aws.String(alarmNamePrefix),
 		}
-		resp, err := cli.DescribeAlarms(params)
+		resp, err := e.describeAlarms(ctx, cli, params)
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarms", err)
 		}
@@ -85,7 +89,7 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 			Statistic:  aws.String(statistic),
 			Period:     aws.Int64(period),
 		}
-		resp, err := cli.DescribeAlarmsForMetric(params)
+		resp, err := e.describeAlarmsForMetric(ctx, cli, params)
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarmsForMetric", err)
 		}
@@ -102,7 +106,7 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 			EndDate:    aws.Time(query.TimeRange.To),
 			MaxRecords: aws.Int64(100),
 		}
-		resp, err := cli.DescribeAlarmHistory(params)
+		resp, err := e.describeAlarmHistory(ctx, cli, params)
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarmHistory", err)
 		}
@@ -123,6 +127,26 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 	return result, err
 }
 
+// describeAlarms, describeAlarmsForMetric and describeAlarmHistory derive a child context for each call,
+// so that the describe alarms timeout applies per call rather than to the whole annotation query.
+func (e *cloudWatchExecutor) describeAlarms(ctx context.Context, cli cloudwatchiface.CloudWatchAPI, params *cloudwatch.DescribeAlarmsInput) (*cloudwatch.DescribeAlarmsOutput, error) {
+	ctx, cancel := clients.WithTimeout(ctx, e.cfg.AWSDescribeAlarmsTimeout)
+	defer cancel()
+	return cli.DescribeAlarmsWithContext(ctx, params)
+}
+
+func (e *cloudWatchExecutor) describeAlarmsForMetric(ctx context.Context, cli cloudwatchiface.CloudWatchAPI, params *cloudwatch.DescribeAlarmsForMetricInput) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
+	ctx, cancel := clients.WithTimeout(ctx, e.cfg.AWSDescribeAlarmsTimeout)
+	defer cancel()
+	return cli.DescribeAlarmsForMetricWithContext(ctx, params)
+}
+
+func (e *cloudWatchExecutor) describeAlarmHistory(ctx context.Context, cli cloudwatchiface.CloudWatchAPI, params *cloudwatch.DescribeAlarmHistoryInput) (*cloudwatch.DescribeAlarmHistoryOutput, error) {
+	ctx, cancel := clients.WithTimeout(ctx, e.cfg.AWSDescribeAlarmsTimeout)
+	defer cancel()
+	return cli.DescribeAlarmHistoryWithContext(ctx, params)
+}
+
 func transformAnnotationToTable(annotations []*annotationEvent, query backend.DataQuery) *data.Frame {
 	frame := data.NewFrame(query.RefID,
 		data.NewField("time", nil, []time.Time{}),
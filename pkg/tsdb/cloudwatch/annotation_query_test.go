@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -98,4 +99,37 @@ func TestQuery_AnnotationQuery(t *testing.T) {
 			AlarmNamePrefix: aws.String("some_alarm_name_prefix"),
 		}, client.calls.describeAlarms[0])
 	})
+
+	t.Run("DescribeAlarmsForMetric is called with the describe alarms timeout", func(t *testing.T) {
+		client = fakeCWAnnotationsClient{describeAlarmsForMetricOutput: &cloudwatch.DescribeAlarmsForMetricOutput{}}
+		im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return DataSource{Settings: models.CloudWatchSettings{}}, nil
+		})
+		cfg := newTestConfig()
+		cfg.AWSDescribeAlarmsTimeout = 2 * time.Minute
+
+		executor := newExecutor(im, cfg, &fakeSessionCache{}, featuremgmt.WithFeatures())
+		_, err := executor.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+			},
+			Queries: []backend.DataQuery{
+				{
+					JSON: json.RawMessage(`{
+						"type":    "annotationQuery",
+						"region":    "us-east-1",
+						"namespace": "custom",
+						"metricName": "CPUUtilization",
+						"statistic": "Average"
+					}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, client.calls.contexts, 1)
+		deadline, ok := client.calls.contexts[0].Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), deadline, 5*time.Second)
+	})
 }
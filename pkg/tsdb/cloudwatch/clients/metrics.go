@@ -1,13 +1,26 @@
 package clients
 
 import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 )
 
+// WithTimeout returns a child context of ctx that is canceled after the timeout, unless the timeout is zero.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 type metricsClient struct {
 	models.CloudWatchMetricsAPIProvider
 	config *setting.Cfg
@@ -17,20 +30,96 @@ func NewMetricsClient(api models.CloudWatchMetricsAPIProvider, config *setting.C
 	return &metricsClient{CloudWatchMetricsAPIProvider: api, config: config}
 }
 
-func (l *metricsClient) ListMetricsWithPageLimit(params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
+func (l *metricsClient) ListMetricsWithPageLimit(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
+	cloudWatchMetrics, _, err := l.ListMetricsWithMaxPages(ctx, params, l.config.AWSListMetricsPageLimit)
+	return cloudWatchMetrics, err
+}
+
+// ListMetricsWithMaxPages lists at most maxPages pages of metrics. The returned bool is true if there were more
+// pages left, i.e. the listed metrics are incomplete.
+func (l *metricsClient) ListMetricsWithMaxPages(ctx context.Context, params *cloudwatch.ListMetricsInput, maxPages int) ([]*cloudwatch.Metric, bool, error) {
 	var cloudWatchMetrics []*cloudwatch.Metric
-	pageNum := 0
-	err := l.ListMetricsPages(params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
-		pageNum++
-		metrics.MAwsCloudWatchListMetrics.Inc()
+	truncated, err := l.listMetricsPages(ctx, params, maxPages, func(page *cloudwatch.ListMetricsOutput) {
 		metrics, err := awsutil.ValuesAtPath(page, "Metrics")
 		if err == nil {
 			for _, metric := range metrics {
 				cloudWatchMetrics = append(cloudWatchMetrics, metric.(*cloudwatch.Metric))
 			}
 		}
-		return !lastPage && pageNum < l.config.AWSListMetricsPageLimit
+	})
+
+	return cloudWatchMetrics, truncated, err
+}
+
+// ListMetricsPage lists a single page of metrics, starting at the NextToken of the input, and returns the token of
+// the next page. The token is empty if it was the last page.
+func (l *metricsClient) ListMetricsPage(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, string, error) {
+	var cloudWatchMetrics []*cloudwatch.Metric
+	nextToken := ""
+	_, err := l.listMetricsPages(ctx, params, 1, func(page *cloudwatch.ListMetricsOutput) {
+		cloudWatchMetrics = append(cloudWatchMetrics, page.Metrics...)
+		nextToken = aws.StringValue(page.NextToken)
+	})
+
+	return cloudWatchMetrics, nextToken, err
+}
+
+// ListMetricsWithAccounts lists metrics like ListMetricsWithPageLimit, but also returns the owning account of each
+// metric. ListMetrics only returns the owning accounts if IncludeLinkedAccounts is set on the input.
+func (l *metricsClient) ListMetricsWithAccounts(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	var cloudWatchMetrics []resources.MetricResponse
+	_, err := l.listMetricsPages(ctx, params, l.config.AWSListMetricsPageLimit, func(page *cloudwatch.ListMetricsOutput) {
+		for i, metric := range page.Metrics {
+			metricResponse := resources.MetricResponse{Metric: metric}
+			// owning accounts are returned in the same order as the metrics
+			if i < len(page.OwningAccounts) {
+				metricResponse.AccountId = page.OwningAccounts[i]
+			}
+			cloudWatchMetrics = append(cloudWatchMetrics, metricResponse)
+		}
 	})
 
 	return cloudWatchMetrics, err
 }
+
+// GetMetricData returns the results of all pages of GetMetricData. The results of a query that spans several pages are
+// returned once per page. The get metric data timeout applies to all pages together.
+func (l *metricsClient) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput) ([]*cloudwatch.MetricDataResult, error) {
+	ctx, cancel := WithTimeout(ctx, l.config.AWSGetMetricDataTimeout)
+	defer cancel()
+
+	var results []*cloudwatch.MetricDataResult
+	input := *params
+	for {
+		output, err := l.GetMetricDataWithContext(ctx, &input)
+		if err != nil {
+			return nil, err
+		}
+		metrics.MAwsCloudWatchGetMetricData.Add(float64(len(input.MetricDataQueries)))
+		results = append(results, output.MetricDataResults...)
+
+		if aws.StringValue(output.NextToken) == "" {
+			return results, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// listMetricsPages calls fn for each page of metrics until the page limit is reached, and returns whether pages were
+// left when it stopped. The list metrics timeout applies to all pages together.
+func (l *metricsClient) listMetricsPages(ctx context.Context, params *cloudwatch.ListMetricsInput, pageLimit int, fn func(page *cloudwatch.ListMetricsOutput)) (bool, error) {
+	ctx, cancel := WithTimeout(ctx, l.config.AWSListMetricsTimeout)
+	defer cancel()
+
+	pageNum := 0
+	truncated := false
+	err := l.ListMetricsPagesWithContext(ctx, params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		pageNum++
+		metrics.MAwsCloudWatchListMetrics.Inc()
+		fn(page)
+		truncated = !lastPage && pageNum >= pageLimit
+		return !lastPage && pageNum < pageLimit
+	})
+
+	return truncated, err
+}
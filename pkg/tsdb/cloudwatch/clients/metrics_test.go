@@ -1,7 +1,9 @@
 package clients
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
@@ -29,7 +31,7 @@ func TestMetricsClient(t *testing.T) {
 		pageLimit := 3
 		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics, MetricsPerPage: 2}
 		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: pageLimit})
-		response, err := client.ListMetricsWithPageLimit(&cloudwatch.ListMetricsInput{})
+		response, err := client.ListMetricsWithPageLimit(context.Background(), &cloudwatch.ListMetricsInput{})
 		require.NoError(t, err)
 
 		expectedMetrics := fakeApi.MetricsPerPage * pageLimit
@@ -41,9 +43,132 @@ func TestMetricsClient(t *testing.T) {
 		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics}
 		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: pageLimit})
 
-		response, err := client.ListMetricsWithPageLimit(&cloudwatch.ListMetricsInput{})
+		response, err := client.ListMetricsWithPageLimit(context.Background(), &cloudwatch.ListMetricsInput{})
 		require.NoError(t, err)
 
 		assert.Equal(t, len(metrics), len(response))
 	})
+
+	t.Run("List Metrics with max pages reports whether metrics were left", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics, MetricsPerPage: 2}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 100})
+
+		response, truncated, err := client.ListMetricsWithMaxPages(context.Background(), &cloudwatch.ListMetricsInput{}, 2)
+		require.NoError(t, err)
+		assert.Len(t, response, 4)
+		assert.True(t, truncated)
+
+		response, truncated, err = client.ListMetricsWithMaxPages(context.Background(), &cloudwatch.ListMetricsInput{}, 5)
+		require.NoError(t, err)
+		assert.Len(t, response, len(metrics))
+		assert.False(t, truncated)
+	})
+
+	t.Run("List Metrics page returns a single page and the token of the next one", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics, MetricsPerPage: 4}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 100})
+
+		response, nextToken, err := client.ListMetricsPage(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, metrics[:4], response)
+		require.NotEmpty(t, nextToken)
+
+		response, nextToken, err = client.ListMetricsPage(context.Background(), &cloudwatch.ListMetricsInput{NextToken: aws.String(nextToken)})
+		require.NoError(t, err)
+		assert.Equal(t, metrics[4:8], response)
+		require.NotEmpty(t, nextToken)
+
+		response, nextToken, err = client.ListMetricsPage(context.Background(), &cloudwatch.ListMetricsInput{NextToken: aws.String(nextToken)})
+		require.NoError(t, err)
+		assert.Equal(t, metrics[8:], response)
+		assert.Empty(t, nextToken)
+	})
+
+	t.Run("Get Metric Data returns the results of all pages", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{MetricDataOutputs: []*cloudwatch.GetMetricDataOutput{
+			{MetricDataResults: []*cloudwatch.MetricDataResult{{Id: aws.String("m0")}}, NextToken: aws.String("page-2")},
+			{MetricDataResults: []*cloudwatch.MetricDataResult{{Id: aws.String("m1")}}},
+		}}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{})
+
+		response, err := client.GetMetricData(context.Background(), &cloudwatch.GetMetricDataInput{})
+		require.NoError(t, err)
+		require.Len(t, response, 2)
+		assert.Equal(t, "m0", *response[0].Id)
+		assert.Equal(t, "m1", *response[1].Id)
+		require.Len(t, fakeApi.MetricDataInputs, 2)
+		assert.Nil(t, fakeApi.MetricDataInputs[0].NextToken)
+		assert.Equal(t, "page-2", *fakeApi.MetricDataInputs[1].NextToken)
+	})
+
+	t.Run("Get Metric Data applies the get metric data timeout to the context of the caller", func(t *testing.T) {
+		type callerKey struct{}
+		fakeApi := &mocks.FakeMetricsAPI{MetricDataOutputs: []*cloudwatch.GetMetricDataOutput{{}}}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSGetMetricDataTimeout: time.Minute})
+		ctx := context.WithValue(context.Background(), callerKey{}, "caller")
+
+		_, err := client.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "caller", fakeApi.Context.Value(callerKey{}))
+		deadline, ok := fakeApi.Context.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("List Metrics with accounts pairs each metric with its owning account", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{
+			Metrics:        metrics[:3],
+			OwningAccounts: []*string{aws.String("111111111111"), aws.String("222222222222"), aws.String("111111111111")},
+			MetricsPerPage: 2,
+		}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 10})
+
+		response, err := client.ListMetricsWithAccounts(context.Background(), &cloudwatch.ListMetricsInput{IncludeLinkedAccounts: aws.Bool(true)})
+		require.NoError(t, err)
+
+		require.Len(t, response, 3)
+		assert.Equal(t, "Test_MetricName1", *response[0].MetricName)
+		assert.Equal(t, "111111111111", *response[0].AccountId)
+		assert.Equal(t, "222222222222", *response[1].AccountId)
+		assert.Equal(t, "Test_MetricName3", *response[2].MetricName)
+		assert.Equal(t, "111111111111", *response[2].AccountId)
+	})
+
+	t.Run("List Metrics applies the list metrics timeout", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 1, AWSListMetricsTimeout: time.Minute})
+
+		_, err := client.ListMetricsWithPageLimit(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+
+		deadline, ok := fakeApi.Context.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("List Metrics derives its context from the context of the caller", func(t *testing.T) {
+		type callerKey struct{}
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 1, AWSListMetricsTimeout: time.Minute})
+		ctx := context.WithValue(context.Background(), callerKey{}, "caller")
+
+		_, _, err := client.ListMetricsPage(ctx, &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "caller", fakeApi.Context.Value(callerKey{}))
+		_, ok := fakeApi.Context.Deadline()
+		assert.True(t, ok)
+	})
+
+	t.Run("List Metrics has no deadline without a timeout", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: metrics}
+		client := NewMetricsClient(fakeApi, &setting.Cfg{AWSListMetricsPageLimit: 1})
+
+		_, err := client.ListMetricsWithPageLimit(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+
+		_, ok := fakeApi.Context.Deadline()
+		assert.False(t, ok)
+	})
 }
@@ -2,6 +2,8 @@ package cloudwatch
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
@@ -18,8 +21,10 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/oam"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -107,6 +112,14 @@ func newExecutor(im instancemgmt.InstanceManager, cfg *setting.Cfg, sessions Ses
 	return e
 }
 
+// cursorSigningKey derives the key the cursors of the list metrics pagination are signed with from the secret key, so
+// that the secret key itself isn't used for anything but what it was configured for
+func cursorSigningKey(secretKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte("cloudwatch-list-metrics-cursor"))
+	return mac.Sum(nil)
+}
+
 func (e *cloudWatchExecutor) getRequestContext(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
 	r := region
 	instance, err := e.getInstance(pluginCtx)
@@ -121,9 +134,16 @@ func (e *cloudWatchExecutor) getRequestContext(pluginCtx backend.PluginContext,
 	if err != nil {
 		return models.RequestContext{}, err
 	}
+	sess = withRetryPolicy(sess, e.cfg)
 	return models.RequestContext{
-		MetricsClientProvider: clients.NewMetricsClient(NewMetricsAPI(sess), e.cfg),
-		Settings:              instance.Settings,
+		MetricsClientProvider:      clients.NewMetricsClient(NewMetricsAPI(sess), e.cfg),
+		OAMAPIProvider:             NewOAMAPI(sess),
+		ResourceTaggingAPIProvider: newRGTAClient(sess),
+		AlarmsAPIProvider:          NewAlarmsAPI(sess),
+		InsightRulesAPIProvider:    NewInsightRulesAPI(sess),
+		STSAPIProvider:             NewSTSAPI(sess),
+		Settings:                   instance.Settings,
+		CursorSigningKey:           cursorSigningKey(e.cfg.SecretKey),
 	}, nil
 }
 
@@ -160,7 +180,26 @@ func (e *cloudWatchExecutor) CallResource(ctx context.Context, req *backend.Call
 	return e.resourceHandler.CallResource(ctx, req, sender)
 }
 
-func (e *cloudWatchExecutor) checkHealthMetrics(pluginCtx backend.PluginContext) error {
+// checkHealthProfile checks that the profile exists in the shared credentials file if shared credentials are used.
+// Otherwise requests would only fail once credentials are needed, with a less helpful error.
+func (e *cloudWatchExecutor) checkHealthProfile(pluginCtx backend.PluginContext) error {
+	instance, err := e.getInstance(pluginCtx)
+	if err != nil {
+		return err
+	}
+
+	if instance.Settings.AuthType != awsds.AuthTypeSharedCreds {
+		return nil
+	}
+
+	if _, err := credentials.NewSharedCredentials("", instance.Settings.Profile).Get(); err != nil {
+		return fmt.Errorf("unable to load profile %q from the shared credentials file: %w", instance.Settings.Profile, err)
+	}
+
+	return nil
+}
+
+func (e *cloudWatchExecutor) checkHealthMetrics(ctx context.Context, pluginCtx backend.PluginContext) error {
 	namespace := "AWS/Billing"
 	metric := "EstimatedCharges"
 	params := &cloudwatch.ListMetricsInput{
@@ -173,7 +212,7 @@ func (e *cloudWatchExecutor) checkHealthMetrics(pluginCtx backend.PluginContext)
 		return err
 	}
 	metricClient := clients.NewMetricsClient(NewMetricsAPI(session), e.cfg)
-	_, err = metricClient.ListMetricsWithPageLimit(params)
+	_, err = metricClient.ListMetricsWithPageLimit(ctx, params)
 	return err
 }
 
@@ -191,7 +230,14 @@ func (e *cloudWatchExecutor) CheckHealth(ctx context.Context, req *backend.Check
 	metricsTest := "Successfully queried the CloudWatch metrics API."
 	logsTest := "Successfully queried the CloudWatch logs API."
 
-	err := e.checkHealthMetrics(req.PluginContext)
+	if err := e.checkHealthProfile(req.PluginContext); err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("CloudWatch credentials check failed: %s", err.Error()),
+		}, nil
+	}
+
+	err := e.checkHealthMetrics(ctx, req.PluginContext)
 	if err != nil {
 		status = backend.HealthStatusError
 		metricsTest = fmt.Sprintf("CloudWatch metrics query failed: %s", err.Error())
@@ -334,7 +380,7 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 	var result *backend.QueryDataResponse
 	switch model.QueryType {
 	case annotationQuery:
-		result, err = e.executeAnnotationQuery(req.PluginContext, model, q)
+		result, err = e.executeAnnotationQuery(ctx, req.PluginContext, model, q)
 	case logAction:
 		result, err = e.executeLogActions(ctx, logger, req)
 	case timeSeriesQuery:
@@ -423,6 +469,34 @@ var NewMetricsAPI = func(sess *session.Session) models.CloudWatchMetricsAPIProvi
 	return cloudwatch.New(sess)
 }
 
+// NewOAMAPI is a CloudWatch Observability Access Manager api factory.
+//
+// Stubbable by tests.
+var NewOAMAPI = func(sess *session.Session) models.OAMAPIProvider {
+	return oam.New(sess)
+}
+
+// NewAlarmsAPI is a CloudWatch alarms api factory.
+//
+// Stubbable by tests.
+var NewAlarmsAPI = func(sess *session.Session) models.AlarmsAPIProvider {
+	return cloudwatch.New(sess)
+}
+
+// NewInsightRulesAPI is a CloudWatch Contributor Insights api factory.
+//
+// Stubbable by tests.
+var NewInsightRulesAPI = func(sess *session.Session) models.InsightRulesAPIProvider {
+	return cloudwatch.New(sess)
+}
+
+// NewSTSAPI is an AWS Security Token Service api factory.
+//
+// Stubbable by tests.
+var NewSTSAPI = func(sess *session.Session) models.STSAPIProvider {
+	return sts.New(sess)
+}
+
 // NewCWClient is a CloudWatch client factory.
 //
 // Stubbable by tests.
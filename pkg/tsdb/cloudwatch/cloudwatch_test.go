@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -189,7 +193,115 @@ func Test_CheckHealth(t *testing.T) {
 			Message: "1. CloudWatch metrics query failed: some sessions error\n2. CloudWatch logs query failed: some sessions error",
 		}, resp)
 	})
+
+	t.Run("fails if the shared credentials profile doesn't exist", func(t *testing.T) {
+		credentialsFile := filepath.Join(t.TempDir(), "credentials")
+		err := os.WriteFile(credentialsFile, []byte("[other]\naws_access_key_id = AKID\naws_secret_access_key = SECRET\n"), 0600)
+		require.NoError(t, err)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+		client = fakeCheckHealthClient{}
+		im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return DataSource{Settings: models.CloudWatchSettings{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{AuthType: awsds.AuthTypeSharedCreds, Profile: "missing"},
+			}}, nil
+		})
+		executor := newExecutor(im, newTestConfig(), &fakeSessionCache{}, featuremgmt.WithFeatures())
+
+		resp, err := executor.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusError, resp.Status)
+		assert.Contains(t, resp.Message, `CloudWatch credentials check failed: unable to load profile "missing" from the shared credentials file`)
+	})
+
+	t.Run("successfully queries metrics and logs if the shared credentials profile exists", func(t *testing.T) {
+		credentialsFile := filepath.Join(t.TempDir(), "credentials")
+		err := os.WriteFile(credentialsFile, []byte("[my-profile]\naws_access_key_id = AKID\naws_secret_access_key = SECRET\n"), 0600)
+		require.NoError(t, err)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+		client = fakeCheckHealthClient{}
+		im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return DataSource{Settings: models.CloudWatchSettings{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{AuthType: awsds.AuthTypeSharedCreds, Profile: "my-profile"},
+			}}, nil
+		})
+		executor := newExecutor(im, newTestConfig(), &fakeSessionCache{}, featuremgmt.WithFeatures())
+
+		resp, err := executor.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusOk, resp.Status)
+	})
 }
+
+func Test_getRequestContext_selects_profile(t *testing.T) {
+	origNewMetricsAPI := NewMetricsAPI
+	origNewOAMAPI := NewOAMAPI
+	origNewRGTAClient := newRGTAClient
+	origNewAlarmsAPI := NewAlarmsAPI
+	origNewInsightRulesAPI := NewInsightRulesAPI
+	origNewSTSAPI := NewSTSAPI
+	t.Cleanup(func() {
+		NewMetricsAPI = origNewMetricsAPI
+		NewOAMAPI = origNewOAMAPI
+		newRGTAClient = origNewRGTAClient
+		NewAlarmsAPI = origNewAlarmsAPI
+		NewInsightRulesAPI = origNewInsightRulesAPI
+		NewSTSAPI = origNewSTSAPI
+	})
+	NewMetricsAPI = func(sess *session.Session) models.CloudWatchMetricsAPIProvider {
+		return fakeCheckHealthClient{}
+	}
+	NewOAMAPI = func(sess *session.Session) models.OAMAPIProvider {
+		return &mocks.FakeOAMClient{}
+	}
+	newRGTAClient = func(client.ConfigProvider) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+		return fakeRGTAClient{}
+	}
+	NewAlarmsAPI = func(sess *session.Session) models.AlarmsAPIProvider {
+		return &mocks.FakeAlarmsClient{}
+	}
+	NewInsightRulesAPI = func(sess *session.Session) models.InsightRulesAPIProvider {
+		return &mocks.FakeInsightRulesClient{}
+	}
+	NewSTSAPI = func(sess *session.Session) models.STSAPIProvider {
+		return &mocks.FakeSTSClient{}
+	}
+
+	var sessionConfig awsds.SessionConfig
+	sessionCache := &fakeSessionCache{getSession: func(c awsds.SessionConfig) (*session.Session, error) {
+		sessionConfig = c
+		return &session.Session{Config: &aws.Config{}}, nil
+	}}
+	im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		return DataSource{Settings: models.CloudWatchSettings{
+			AWSDatasourceSettings: awsds.AWSDatasourceSettings{AuthType: awsds.AuthTypeSharedCreds, Profile: "my-profile", Region: "us-east-1"},
+		}}, nil
+	})
+	executor := newExecutor(im, newTestConfig(), sessionCache, featuremgmt.WithFeatures())
+
+	_, err := executor.getRequestContext(backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}, "default")
+	require.NoError(t, err)
+
+	assert.Equal(t, awsds.AuthTypeSharedCreds, sessionConfig.Settings.AuthType)
+	assert.Equal(t, "my-profile", sessionConfig.Settings.Profile)
+	assert.Equal(t, "us-east-1", sessionConfig.Settings.Region)
+}
+func Test_cursorSigningKey(t *testing.T) {
+	key := cursorSigningKey("secret")
+
+	assert.Len(t, key, 32)
+	assert.NotEqual(t, []byte("secret"), key)
+	assert.Equal(t, key, cursorSigningKey("secret"))
+	assert.NotEqual(t, key, cursorSigningKey("other secret"))
+}
+
 func Test_executeLogAlertQuery(t *testing.T) {
 	origNewCWClient := NewCWClient
 	t.Cleanup(func() {
@@ -539,13 +651,38 @@ func TestQuery_ResourceRequest_DescribeLogGroups(t *testing.T) {
 func Test_CloudWatch_CallResource_Integration_Test(t *testing.T) {
 	sender := &mockedCallResourceResponseSenderForOauth{}
 	origNewMetricsAPI := NewMetricsAPI
+	origNewOAMAPI := NewOAMAPI
+	origNewRGTAClient := newRGTAClient
+	origNewAlarmsAPI := NewAlarmsAPI
+	origNewInsightRulesAPI := NewInsightRulesAPI
+	origNewSTSAPI := NewSTSAPI
 	t.Cleanup(func() {
 		NewMetricsAPI = origNewMetricsAPI
+		NewOAMAPI = origNewOAMAPI
+		newRGTAClient = origNewRGTAClient
+		NewAlarmsAPI = origNewAlarmsAPI
+		NewInsightRulesAPI = origNewInsightRulesAPI
+		NewSTSAPI = origNewSTSAPI
 	})
 	var api mocks.FakeMetricsAPI
 	NewMetricsAPI = func(sess *session.Session) models.CloudWatchMetricsAPIProvider {
 		return &api
 	}
+	NewOAMAPI = func(sess *session.Session) models.OAMAPIProvider {
+		return &mocks.FakeOAMClient{}
+	}
+	newRGTAClient = func(client.ConfigProvider) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+		return fakeRGTAClient{}
+	}
+	NewAlarmsAPI = func(sess *session.Session) models.AlarmsAPIProvider {
+		return &mocks.FakeAlarmsClient{}
+	}
+	NewInsightRulesAPI = func(sess *session.Session) models.InsightRulesAPIProvider {
+		return &mocks.FakeInsightRulesClient{}
+	}
+	NewSTSAPI = func(sess *session.Session) models.STSAPIProvider {
+		return &mocks.FakeSTSClient{}
+	}
 	im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		return DataSource{Settings: models.CloudWatchSettings{}}, nil
 	})
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/clients"
 )
 
 const (
@@ -126,6 +127,9 @@ func (e *cloudWatchExecutor) executeLogAction(ctx context.Context, logger log.Lo
 		return nil, err
 	}
 
+	ctx, cancel := clients.WithTimeout(ctx, e.cfg.AWSLogsTimeout)
+	defer cancel()
+
 	var data *data.Frame = nil
 	switch model.SubType {
 	case "GetLogGroupFields":
@@ -107,6 +107,60 @@ func TestQuery_GetLogEvents(t *testing.T) {
 	}
 }
 
+func TestQuery_LogActionTimeout(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(sess *session.Session) cloudwatchlogsiface.CloudWatchLogsAPI {
+		return &cli
+	}
+
+	im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		return DataSource{Settings: models.CloudWatchSettings{}}, nil
+	})
+	query := backend.DataQuery{
+		RefID:     "A",
+		TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+		JSON:      json.RawMessage(`{"type": "logAction", "subtype": "GetLogEvents", "logGroupName": "foo", "logStreamName": "bar"}`),
+	}
+
+	t.Run("applies the logs timeout", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		cfg := newTestConfig()
+		cfg.AWSLogsTimeout = time.Minute
+
+		executor := newExecutor(im, cfg, &fakeSessionCache{}, featuremgmt.WithFeatures())
+		_, err := executor.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries:       []backend.DataQuery{query},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, cli.calls.contexts, 1)
+		deadline, ok := cli.calls.contexts[0].Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("has no deadline without a timeout", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+
+		executor := newExecutor(im, newTestConfig(), &fakeSessionCache{}, featuremgmt.WithFeatures())
+		_, err := executor.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries:       []backend.DataQuery{query},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, cli.calls.contexts, 1)
+		_, ok := cli.calls.contexts[0].Deadline()
+		assert.False(t, ok)
+	})
+}
+
 func TestQuery_GetLogGroupFields(t *testing.T) {
 	origNewCWLogsClient := NewCWLogsClient
 	t.Cleanup(func() {
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+type AccountsServiceMock struct {
+	mock.Mock
+}
+
+func (a *AccountsServiceMock) GetAccountLabels() (map[string]string, error) {
+	args := a.Called()
+
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (a *AccountsServiceMock) IsMonitoringAccount() (bool, error) {
+	args := a.Called()
+
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeAlarmsClient struct {
+	mock.Mock
+}
+
+func (a *FakeAlarmsClient) DescribeAlarmsForMetric(input *cloudwatch.DescribeAlarmsForMetricInput) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*cloudwatch.DescribeAlarmsForMetricOutput), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type AlarmsServiceMock struct {
+	mock.Mock
+}
+
+func (a *AlarmsServiceMock) AddAlarmFlags(metrics []resources.TaggedMetric) error {
+	args := a.Called(metrics)
+
+	return args.Error(0)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type CallerIdentityServiceMock struct {
+	mock.Mock
+}
+
+func (c *CallerIdentityServiceMock) GetCallerIdentity() (resources.CallerIdentity, error) {
+	args := c.Called()
+
+	return args.Get(0).(resources.CallerIdentity), args.Error(1)
+}
@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
@@ -12,19 +15,58 @@ type FakeMetricsAPI struct {
 	cloudwatchiface.CloudWatchAPI
 
 	Metrics        []*cloudwatch.Metric
+	OwningAccounts []*string
 	MetricsPerPage int
+
+	// Context is the context of the last ListMetricsPagesWithContext or GetMetricDataWithContext call
+	Context aws.Context
+
+	// MetricDataOutputs are returned by successive GetMetricDataWithContext calls
+	MetricDataOutputs []*cloudwatch.GetMetricDataOutput
+	// MetricDataInputs are the inputs of the GetMetricDataWithContext calls
+	MetricDataInputs []*cloudwatch.GetMetricDataInput
 }
 
-func (c *FakeMetricsAPI) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+func (c *FakeMetricsAPI) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	c.Context = ctx
+	copied := *input
+	c.MetricDataInputs = append(c.MetricDataInputs, &copied)
+	if len(c.MetricDataInputs) > len(c.MetricDataOutputs) {
+		return nil, fmt.Errorf("unexpected GetMetricData call")
+	}
+	return c.MetricDataOutputs[len(c.MetricDataInputs)-1], nil
+}
+
+func (c *FakeMetricsAPI) ListMetricsPagesWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
+	c.Context = ctx
+
 	if c.MetricsPerPage == 0 {
 		c.MetricsPerPage = 1000
 	}
 	chunks := chunkSlice(c.Metrics, c.MetricsPerPage)
 
-	for i, metrics := range chunks {
-		response := fn(&cloudwatch.ListMetricsOutput{
+	// the next token of a page is the index of the next page
+	firstPage := 0
+	if input.NextToken != nil {
+		var err error
+		if firstPage, err = strconv.Atoi(*input.NextToken); err != nil {
+			return fmt.Errorf("invalid next token %q", *input.NextToken)
+		}
+	}
+
+	for i := firstPage; i < len(chunks); i++ {
+		metrics := chunks[i]
+		output := &cloudwatch.ListMetricsOutput{
 			Metrics: metrics,
-		}, i+1 == len(chunks))
+		}
+		if i+1 < len(chunks) {
+			output.NextToken = aws.String(strconv.Itoa(i + 1))
+		}
+		if len(c.OwningAccounts) > 0 {
+			start := i * c.MetricsPerPage
+			output.OwningAccounts = c.OwningAccounts[start : start+len(metrics)]
+		}
+		response := fn(output, i+1 == len(chunks))
 		if !response {
 			break
 		}
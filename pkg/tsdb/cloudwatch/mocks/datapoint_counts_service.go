@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type DatapointCountsServiceMock struct {
+	mock.Mock
+}
+
+func (d *DatapointCountsServiceMock) FilterByMinDatapoints(ctx context.Context, metrics []resources.TaggedMetric, minDatapoints int) ([]resources.TaggedMetric, error) {
+	args := d.Called(ctx, metrics, minDatapoints)
+
+	return args.Get(0).([]resources.TaggedMetric), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type DimensionAutocompleteServiceMock struct {
+	mock.Mock
+}
+
+func (d *DimensionAutocompleteServiceMock) GetDimensionAutocomplete(ctx context.Context, namespace string) (map[string][]string, error) {
+	args := d.Called(ctx, namespace)
+
+	return args.Get(0).(map[string][]string), args.Error(1)
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeInsightRulesClient struct {
+	mock.Mock
+}
+
+func (i *FakeInsightRulesClient) DescribeInsightRules(input *cloudwatch.DescribeInsightRulesInput) (*cloudwatch.DescribeInsightRulesOutput, error) {
+	args := i.Called(input)
+	return args.Get(0).(*cloudwatch.DescribeInsightRulesOutput), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type InsightRulesServiceMock struct {
+	mock.Mock
+}
+
+func (i *InsightRulesServiceMock) AddInsightRuleFlags(metrics []resources.TaggedMetric) error {
+	args := i.Called(metrics)
+
+	return args.Error(0)
+}
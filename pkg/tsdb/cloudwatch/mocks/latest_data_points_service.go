@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type LatestDataPointsServiceMock struct {
+	mock.Mock
+}
+
+func (l *LatestDataPointsServiceMock) AddLatestDataPoints(ctx context.Context, metrics []resources.TaggedMetric) error {
+	args := l.Called(ctx, metrics)
+
+	return args.Error(0)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 	"github.com/stretchr/testify/mock"
 )
@@ -9,26 +11,92 @@ type ListMetricsServiceMock struct {
 	mock.Mock
 }
 
-func (a *ListMetricsServiceMock) GetDimensionKeysByDimensionFilter(r resources.DimensionKeysRequest) ([]string, error) {
-	args := a.Called(r)
+func (a *ListMetricsServiceMock) GetDimensionKeysByDimensionFilter(ctx context.Context, r resources.DimensionKeysRequest) ([]string, error) {
+	args := a.Called(ctx, r)
 
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (a *ListMetricsServiceMock) GetDimensionValuesByDimensionFilter(r resources.DimensionValuesRequest) ([]string, error) {
-	args := a.Called(r)
+func (a *ListMetricsServiceMock) GetDimensionValuesByDimensionFilter(ctx context.Context, r resources.DimensionValuesRequest) ([]string, error) {
+	args := a.Called(ctx, r)
 
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (a *ListMetricsServiceMock) GetDimensionKeysByNamespace(namespace string) ([]string, error) {
-	args := a.Called(namespace)
+func (a *ListMetricsServiceMock) GetDimensionKeysByNamespace(ctx context.Context, namespace string) ([]string, error) {
+	args := a.Called(ctx, namespace)
 
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (a *ListMetricsServiceMock) GetMetricsByNamespace(namespace string) ([]resources.Metric, error) {
-	args := a.Called(namespace)
+func (a *ListMetricsServiceMock) GetMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Get(0).([]resources.Metric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) RefreshMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Get(0).([]resources.Metric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetDimensionedMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Get(0).([]resources.Metric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsByNamespacePage(ctx context.Context, namespace string, requireDimensions bool, limit int, nextToken string) ([]resources.Metric, string, error) {
+	args := a.Called(ctx, namespace, requireDimensions, limit, nextToken)
+
+	return args.Get(0).([]resources.Metric), args.String(1), args.Error(2)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsByNamespaceGroupedByAccount(ctx context.Context, namespace string) (map[string][]resources.Metric, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Get(0).(map[string][]resources.Metric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsByNamespaceOfAccount(ctx context.Context, namespace string, accountId string) ([]resources.Metric, error) {
+	args := a.Called(ctx, namespace, accountId)
 
 	return args.Get(0).([]resources.Metric), args.Error(1)
 }
+
+func (a *ListMetricsServiceMock) GetDimensionValuesByDimensionKeys(ctx context.Context, r resources.BulkDimensionValuesRequest) (map[string][]string, error) {
+	args := a.Called(ctx, r)
+
+	return args.Get(0).(map[string][]string), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsByMetricStream(ctx context.Context, streamName string) ([]resources.Metric, error) {
+	args := a.Called(ctx, streamName)
+
+	return args.Get(0).([]resources.Metric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetMetricCountByNamespace(ctx context.Context, namespace string) (int, bool, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsWithDimensionsByNamespace(ctx context.Context, namespace string, nextToken string) ([]resources.TaggedMetric, string, error) {
+	args := a.Called(ctx, namespace, nextToken)
+
+	return args.Get(0).([]resources.TaggedMetric), args.String(1), args.Error(2)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsWithAllDimensionsByNamespace(ctx context.Context, namespace string) ([]resources.TaggedMetric, error) {
+	args := a.Called(ctx, namespace)
+
+	return args.Get(0).([]resources.TaggedMetric), args.Error(1)
+}
+
+func (a *ListMetricsServiceMock) GetMetricsWithDimensionsByNamespaceUpTo(ctx context.Context, namespace string, maxResults int) ([]resources.TaggedMetric, bool, error) {
+	args := a.Called(ctx, namespace, maxResults)
+
+	return args.Get(0).([]resources.TaggedMetric), args.Bool(1), args.Error(2)
+}
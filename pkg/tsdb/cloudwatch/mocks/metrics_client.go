@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,7 +12,32 @@ type FakeMetricsClient struct {
 	mock.Mock
 }
 
-func (m *FakeMetricsClient) ListMetricsWithPageLimit(params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
-	args := m.Called(params)
+func (m *FakeMetricsClient) ListMetricsWithPageLimit(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
+	args := m.Called(ctx, params)
 	return args.Get(0).([]*cloudwatch.Metric), args.Error(1)
 }
+
+func (m *FakeMetricsClient) ListMetricsWithAccounts(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]resources.MetricResponse), args.Error(1)
+}
+
+func (m *FakeMetricsClient) ListMetricsWithMaxPages(ctx context.Context, params *cloudwatch.ListMetricsInput, maxPages int) ([]*cloudwatch.Metric, bool, error) {
+	args := m.Called(ctx, params, maxPages)
+	return args.Get(0).([]*cloudwatch.Metric), args.Bool(1), args.Error(2)
+}
+
+func (m *FakeMetricsClient) ListMetricsPage(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, string, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]*cloudwatch.Metric), args.String(1), args.Error(2)
+}
+
+func (m *FakeMetricsClient) GetMetricStream(params *cloudwatch.GetMetricStreamInput) (*cloudwatch.GetMetricStreamOutput, error) {
+	args := m.Called(params)
+	return args.Get(0).(*cloudwatch.GetMetricStreamOutput), args.Error(1)
+}
+
+func (m *FakeMetricsClient) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput) ([]*cloudwatch.MetricDataResult, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]*cloudwatch.MetricDataResult), args.Error(1)
+}
@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type PeriodsServiceMock struct {
+	mock.Mock
+}
+
+func (p *PeriodsServiceMock) InferPeriods(ctx context.Context, metrics []resources.TaggedMetric) error {
+	args := p.Called(ctx, metrics)
+
+	return args.Error(0)
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeResourceTaggingClient struct {
+	mock.Mock
+}
+
+func (r *FakeResourceTaggingClient) GetResources(input *resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	args := r.Called(input)
+	return args.Get(0).(*resourcegroupstaggingapi.GetResourcesOutput), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/mock"
+)
+
+type ResourceTagsServiceMock struct {
+	mock.Mock
+}
+
+func (r *ResourceTagsServiceMock) AddResourceTags(metrics []resources.TaggedMetric) error {
+	args := r.Called(metrics)
+
+	return args.Error(0)
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeSTSClient struct {
+	mock.Mock
+}
+
+func (s *FakeSTSClient) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	args := s.Called(input)
+	return args.Get(0).(*sts.GetCallerIdentityOutput), args.Error(1)
+}
@@ -1,21 +1,112 @@
 package models
 
 import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 )
 
 type ListMetricsProvider interface {
-	GetDimensionKeysByDimensionFilter(resources.DimensionKeysRequest) ([]string, error)
-	GetDimensionKeysByNamespace(string) ([]string, error)
-	GetDimensionValuesByDimensionFilter(resources.DimensionValuesRequest) ([]string, error)
-	GetMetricsByNamespace(namespace string) ([]resources.Metric, error)
+	GetDimensionKeysByDimensionFilter(ctx context.Context, r resources.DimensionKeysRequest) ([]string, error)
+	GetDimensionKeysByNamespace(ctx context.Context, namespace string) ([]string, error)
+	GetDimensionValuesByDimensionFilter(ctx context.Context, r resources.DimensionValuesRequest) ([]string, error)
+	GetDimensionValuesByDimensionKeys(ctx context.Context, r resources.BulkDimensionValuesRequest) (map[string][]string, error)
+	GetMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error)
+	RefreshMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error)
+	GetDimensionedMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error)
+	GetMetricsByNamespacePage(ctx context.Context, namespace string, requireDimensions bool, limit int, nextToken string) ([]resources.Metric, string, error)
+	GetMetricsByNamespaceGroupedByAccount(ctx context.Context, namespace string) (map[string][]resources.Metric, error)
+	GetMetricsByNamespaceOfAccount(ctx context.Context, namespace string, accountId string) ([]resources.Metric, error)
+	GetMetricsByMetricStream(ctx context.Context, streamName string) ([]resources.Metric, error)
+	GetMetricCountByNamespace(ctx context.Context, namespace string) (int, bool, error)
+	GetMetricsWithDimensionsByNamespace(ctx context.Context, namespace string, nextToken string) ([]resources.TaggedMetric, string, error)
+	GetMetricsWithAllDimensionsByNamespace(ctx context.Context, namespace string) ([]resources.TaggedMetric, error)
+	GetMetricsWithDimensionsByNamespaceUpTo(ctx context.Context, namespace string, maxResults int) ([]resources.TaggedMetric, bool, error)
 }
 
 type MetricsClientProvider interface {
-	ListMetricsWithPageLimit(params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error)
+	ListMetricsWithPageLimit(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error)
+	ListMetricsWithAccounts(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error)
+	ListMetricsWithMaxPages(ctx context.Context, params *cloudwatch.ListMetricsInput, maxPages int) ([]*cloudwatch.Metric, bool, error)
+	ListMetricsPage(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, string, error)
+	GetMetricStream(params *cloudwatch.GetMetricStreamInput) (*cloudwatch.GetMetricStreamOutput, error)
+	GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput) ([]*cloudwatch.MetricDataResult, error)
 }
 
 type CloudWatchMetricsAPIProvider interface {
-	ListMetricsPages(*cloudwatch.ListMetricsInput, func(*cloudwatch.ListMetricsOutput, bool) bool) error
+	ListMetricsPagesWithContext(aws.Context, *cloudwatch.ListMetricsInput, func(*cloudwatch.ListMetricsOutput, bool) bool, ...request.Option) error
+	GetMetricStream(*cloudwatch.GetMetricStreamInput) (*cloudwatch.GetMetricStreamOutput, error)
+	GetMetricDataWithContext(aws.Context, *cloudwatch.GetMetricDataInput, ...request.Option) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+type AccountsProvider interface {
+	GetAccountLabels() (map[string]string, error)
+	IsMonitoringAccount() (bool, error)
+}
+
+type ResourceTagsProvider interface {
+	AddResourceTags(metrics []resources.TaggedMetric) error
+}
+
+type LatestDataPointsProvider interface {
+	AddLatestDataPoints(ctx context.Context, metrics []resources.TaggedMetric) error
+}
+
+type DimensionAutocompleteProvider interface {
+	GetDimensionAutocomplete(ctx context.Context, namespace string) (map[string][]string, error)
+}
+
+type PeriodsProvider interface {
+	InferPeriods(ctx context.Context, metrics []resources.TaggedMetric) error
+}
+
+type DatapointCountsProvider interface {
+	FilterByMinDatapoints(ctx context.Context, metrics []resources.TaggedMetric, minDatapoints int) ([]resources.TaggedMetric, error)
+}
+
+type AlarmsProvider interface {
+	AddAlarmFlags(metrics []resources.TaggedMetric) error
+}
+
+type InsightRulesProvider interface {
+	AddInsightRuleFlags(metrics []resources.TaggedMetric) error
+}
+
+type CallerIdentityProvider interface {
+	GetCallerIdentity() (resources.CallerIdentity, error)
+}
+
+type MetricsHealthProvider interface {
+	CheckListMetrics(ctx context.Context) error
+}
+
+type TestQueryProvider interface {
+	RunTestQuery(ctx context.Context, r resources.TestQueryRequest) (resources.TestQueryResult, error)
+}
+
+type AlarmsAPIProvider interface {
+	DescribeAlarmsForMetric(*cloudwatch.DescribeAlarmsForMetricInput) (*cloudwatch.DescribeAlarmsForMetricOutput, error)
+}
+
+type InsightRulesAPIProvider interface {
+	DescribeInsightRules(*cloudwatch.DescribeInsightRulesInput) (*cloudwatch.DescribeInsightRulesOutput, error)
+}
+
+type STSAPIProvider interface {
+	GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+}
+
+type ResourceTaggingAPIProvider interface {
+	GetResources(*resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error)
+}
+
+type OAMAPIProvider interface {
+	ListSinks(*oam.ListSinksInput) (*oam.ListSinksOutput, error)
+	ListAttachedLinks(*oam.ListAttachedLinksInput) (*oam.ListAttachedLinksOutput, error)
 }
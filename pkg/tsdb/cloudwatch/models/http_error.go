@@ -1,11 +1,23 @@
 package models
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
 
 type HttpError struct {
 	Message    string
 	Error      string
 	StatusCode int
+	// AWSErrorCode and AWSErrorMessage are the code and message of the AWS error the request failed with, if any, so
+	// that the frontend can tell e.g. throttling from missing permissions
+	AWSErrorCode    string `json:",omitempty"`
+	AWSErrorMessage string `json:",omitempty"`
+	// Body, if set, is the body of the response instead of the error, for routes whose errors have a shape of their own
+	Body []byte `json:"-"`
 }
 
 func NewHttpError(message string, statusCode int, err error) *HttpError {
@@ -20,3 +32,32 @@ func NewHttpError(message string, statusCode int, err error) *HttpError {
 
 	return httpError
 }
+
+// NewAWSHttpError returns the error of a request that failed calling AWS. If err wraps an AWS error, its code is
+// mapped to the status of the response and embedded together with its message, otherwise the status is 500.
+func NewAWSHttpError(message string, err error) *HttpError {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return NewHttpError(message, http.StatusInternalServerError, err)
+	}
+
+	httpError := NewHttpError(message, AWSErrorStatusCode(awsErr.Code()), err)
+	httpError.AWSErrorCode = awsErr.Code()
+	httpError.AWSErrorMessage = awsErr.Message()
+	return httpError
+}
+
+// AWSErrorStatusCode returns the status of the response to a request that failed with the AWS error code: 429 if the
+// request was throttled, 403 if access was denied, 400 if the parameters were invalid and 500 otherwise
+func AWSErrorStatusCode(code string) int {
+	switch code {
+	case "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException":
+		return http.StatusTooManyRequests
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation":
+		return http.StatusForbidden
+	case "ValidationError", "ValidationException", "InvalidParameterValue", "InvalidParameterValueException",
+		"InvalidParameterCombination", "InvalidParameterCombinationException", "MissingParameter", "InvalidNextToken":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAWSHttpError(t *testing.T) {
+	t.Run("Should map the code of an AWS error to the status and embed it with its message", func(t *testing.T) {
+		tests := []struct {
+			code   string
+			status int
+		}{
+			{"Throttling", http.StatusTooManyRequests},
+			{"RequestLimitExceeded", http.StatusTooManyRequests},
+			{"AccessDenied", http.StatusForbidden},
+			{"UnauthorizedOperation", http.StatusForbidden},
+			{"InvalidParameterValue", http.StatusBadRequest},
+			{"ValidationError", http.StatusBadRequest},
+			{"InternalFailure", http.StatusInternalServerError},
+		}
+		for _, tt := range tests {
+			err := fmt.Errorf("unable to call AWS API: %w", awserr.New(tt.code, "something went wrong", nil))
+
+			httpError := NewAWSHttpError("error in MetricsHandler", err)
+
+			assert.Equal(t, tt.status, httpError.StatusCode, tt.code)
+			assert.Equal(t, tt.code, httpError.AWSErrorCode)
+			assert.Equal(t, "something went wrong", httpError.AWSErrorMessage)
+			assert.Equal(t, "error in MetricsHandler: "+err.Error(), httpError.Message)
+		}
+	})
+
+	t.Run("Should return a 500 without AWS details for other errors", func(t *testing.T) {
+		httpError := NewAWSHttpError("error in MetricsHandler", fmt.Errorf("boom"))
+
+		assert.Equal(t, &HttpError{Message: "error in MetricsHandler: boom", Error: "boom", StatusCode: http.StatusInternalServerError}, httpError)
+	})
+}
@@ -0,0 +1,23 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type BootstrapRequest struct {
+	*ResourceRequest
+	// Namespace is the namespace whose metrics are returned, or the default namespace if it's empty
+	Namespace string
+}
+
+func GetBootstrapRequest(parameters url.Values) (BootstrapRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return BootstrapRequest{}, err
+	}
+
+	return BootstrapRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+	}, nil
+}
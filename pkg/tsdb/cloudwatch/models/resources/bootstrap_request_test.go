@@ -0,0 +1,27 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapRequest(t *testing.T) {
+	t.Run("Should parse the region and namespace", func(t *testing.T) {
+		request, err := GetBootstrapRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EBS"}})
+		require.NoError(t, err)
+		assert.Equal(t, BootstrapRequest{ResourceRequest: &ResourceRequest{Region: "us-east-1"}, Namespace: "AWS/EBS"}, request)
+	})
+
+	t.Run("Should leave the namespace empty if not given", func(t *testing.T) {
+		request, err := GetBootstrapRequest(map[string][]string{"region": {"us-east-1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "", request.Namespace)
+	})
+
+	t.Run("Should require a region", func(t *testing.T) {
+		_, err := GetBootstrapRequest(map[string][]string{"namespace": {"AWS/EBS"}})
+		assert.EqualError(t, err, "region is required")
+	})
+}
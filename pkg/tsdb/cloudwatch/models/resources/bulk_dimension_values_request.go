@@ -0,0 +1,55 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+type BulkDimensionValuesRequest struct {
+	*ResourceRequest
+	Namespace       string
+	MetricName      string
+	DimensionKeys   []string
+	DimensionFilter []*Dimension
+}
+
+func GetBulkDimensionValuesRequest(parameters url.Values) (BulkDimensionValuesRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return BulkDimensionValuesRequest{}, err
+	}
+
+	request := BulkDimensionValuesRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+		MetricName:      parameters.Get("metricName"),
+		DimensionKeys:   []string{},
+		DimensionFilter: []*Dimension{},
+	}
+
+	var dimensionKeys []string
+	if err := json.Unmarshal([]byte(parameters.Get("dimensionKeys")), &dimensionKeys); err != nil {
+		return BulkDimensionValuesRequest{}, fmt.Errorf("error unmarshaling dimensionKeys: %v", err)
+	}
+	dupCheck := make(map[string]struct{})
+	for _, key := range dimensionKeys {
+		if _, exists := dupCheck[key]; exists || key == "" {
+			continue
+		}
+		dupCheck[key] = struct{}{}
+		request.DimensionKeys = append(request.DimensionKeys, key)
+	}
+	if len(request.DimensionKeys) == 0 {
+		return BulkDimensionValuesRequest{}, fmt.Errorf("dimensionKeys is required")
+	}
+
+	dimensions, err := parseDimensionFilter(parameters.Get("dimensionFilters"))
+	if err != nil {
+		return BulkDimensionValuesRequest{}, err
+	}
+
+	request.DimensionFilter = dimensions
+
+	return request, nil
+}
@@ -0,0 +1,34 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkDimensionValuesRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetBulkDimensionValuesRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/EC2"},
+			"metricName":       {"CPUUtilization"},
+			"dimensionKeys":    {`["InstanceId", "InstanceType", "InstanceId"]`},
+			"dimensionFilters": {`{"AutoScalingGroupName": ["my-asg"]}`},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "AWS/EC2", request.Namespace)
+		assert.Equal(t, "CPUUtilization", request.MetricName)
+		assert.Equal(t, []string{"InstanceId", "InstanceType"}, request.DimensionKeys)
+		assert.Equal(t, []*Dimension{{Name: "AutoScalingGroupName", Value: "my-asg"}}, request.DimensionFilter)
+	})
+
+	t.Run("Should return an error if dimensionKeys is missing or empty", func(t *testing.T) {
+		_, err := GetBulkDimensionValuesRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.Error(t, err)
+
+		_, err = GetBulkDimensionValuesRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "dimensionKeys": {"[]"}})
+		require.EqualError(t, err, "dimensionKeys is required")
+	})
+}
@@ -0,0 +1,18 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type CallerIdentityRequest struct {
+	*ResourceRequest
+}
+
+func GetCallerIdentityRequest(parameters url.Values) (CallerIdentityRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return CallerIdentityRequest{}, err
+	}
+
+	return CallerIdentityRequest{ResourceRequest: resourceRequest}, nil
+}
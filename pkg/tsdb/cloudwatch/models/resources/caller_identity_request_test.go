@@ -0,0 +1,21 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerIdentityRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetCallerIdentityRequest(map[string][]string{"region": {"us-east-1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+	})
+
+	t.Run("Should return an error if region is missing", func(t *testing.T) {
+		_, err := GetCallerIdentityRequest(map[string][]string{})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,29 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type DimensionAutocompleteRequest struct {
+	*ResourceRequest
+	Namespace string
+}
+
+func GetDimensionAutocompleteRequest(parameters url.Values) (DimensionAutocompleteRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return DimensionAutocompleteRequest{}, err
+	}
+
+	request := DimensionAutocompleteRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+	}
+
+	if request.Namespace == "" {
+		return DimensionAutocompleteRequest{}, fmt.Errorf("namespace is required")
+	}
+
+	return request, nil
+}
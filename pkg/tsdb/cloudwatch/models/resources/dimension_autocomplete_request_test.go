@@ -0,0 +1,22 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDimensionAutocompleteRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetDimensionAutocompleteRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "AWS/EC2", request.Namespace)
+	})
+
+	t.Run("Should return an error if namespace is missing", func(t *testing.T) {
+		_, err := GetDimensionAutocompleteRequest(map[string][]string{"region": {"us-east-1"}})
+		require.Error(t, err)
+	})
+}
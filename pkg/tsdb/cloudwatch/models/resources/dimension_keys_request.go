@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"fmt"
 	"net/url"
 )
 
@@ -16,6 +17,8 @@ type DimensionKeysRequest struct {
 	Namespace       string
 	MetricName      string
 	DimensionFilter []*Dimension
+	// ExpandWildcards makes wildcard dimension filter values (e.g. i-0ab*) match server-side
+	ExpandWildcards bool
 }
 
 func (q *DimensionKeysRequest) Type() DimensionKeysRequestType {
@@ -31,12 +34,16 @@ func GetDimensionKeysRequest(parameters url.Values) (DimensionKeysRequest, error
 	if err != nil {
 		return DimensionKeysRequest{}, err
 	}
+	if parameters.Get("namespace") == "" {
+		return DimensionKeysRequest{}, fmt.Errorf("namespace is required")
+	}
 
 	request := DimensionKeysRequest{
 		ResourceRequest: resourceRequest,
 		Namespace:       parameters.Get("namespace"),
 		MetricName:      parameters.Get("metricName"),
 		DimensionFilter: []*Dimension{},
+		ExpandWildcards: parameters.Get("expandWildcards") == "true",
 	}
 
 	dimensions, err := parseDimensionFilter(parameters.Get("dimensionFilters"))
@@ -105,4 +105,9 @@ func TestDimensionKeyRequest(t *testing.T) {
 			assert.Equal(t, tc.expectedType, tc.dimensionKeysRequest.Type())
 		})
 	}
+
+	t.Run("Should return an error if the namespace is missing", func(t *testing.T) {
+		_, err := GetDimensionKeysRequest(map[string][]string{"region": {"us-east-1"}, "metricName": {"CPUUtilization"}})
+		require.EqualError(t, err, "namespace is required")
+	})
 }
@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"fmt"
 	"net/url"
 )
 
@@ -10,6 +11,8 @@ type DimensionValuesRequest struct {
 	MetricName      string
 	DimensionKey    string
 	DimensionFilter []*Dimension
+	// ExpandWildcards makes wildcard dimension filter values (e.g. i-0ab*) match server-side
+	ExpandWildcards bool
 }
 
 func GetDimensionValuesRequest(parameters url.Values) (DimensionValuesRequest, error) {
@@ -17,6 +20,9 @@ func GetDimensionValuesRequest(parameters url.Values) (DimensionValuesRequest, e
 	if err != nil {
 		return DimensionValuesRequest{}, err
 	}
+	if parameters.Get("namespace") == "" {
+		return DimensionValuesRequest{}, fmt.Errorf("namespace is required")
+	}
 
 	request := DimensionValuesRequest{
 		ResourceRequest: resourceRequest,
@@ -24,6 +30,7 @@ func GetDimensionValuesRequest(parameters url.Values) (DimensionValuesRequest, e
 		MetricName:      parameters.Get("metricName"),
 		DimensionKey:    parameters.Get("dimensionKey"),
 		DimensionFilter: []*Dimension{},
+		ExpandWildcards: parameters.Get("expandWildcards") == "true",
 	}
 
 	dimensions, err := parseDimensionFilter(parameters.Get("dimensionFilters"))
@@ -75,4 +75,48 @@ func TestDimensionValuesRequest(t *testing.T) {
 		assert.Equal(t, "InstanceId", request.DimensionFilter[0].Name)
 		assert.Equal(t, "", request.DimensionFilter[0].Value)
 	})
+
+	t.Run("Should parse expandWildcards parameter", func(t *testing.T) {
+		request, err := GetDimensionValuesRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/EC2"},
+			"metricName":       {"CPUUtilization"},
+			"dimensionKey":     {"InstanceId"},
+			"dimensionFilters": {"{\"InstanceId\": [\"i-1*\"]}"},
+			"expandWildcards":  {"true"},
+		})
+		require.NoError(t, err)
+		assert.True(t, request.ExpandWildcards)
+		assert.Equal(t, 1, len(request.DimensionFilter))
+		assert.Equal(t, "i-1*", request.DimensionFilter[0].Value)
+	})
+
+	t.Run("Should return an error pointing to a dimension that's in the dimension filter more than once", func(t *testing.T) {
+		_, err := GetDimensionValuesRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/EC2"},
+			"metricName":       {"CPUUtilization"},
+			"dimensionKey":     {"InstanceType"},
+			"dimensionFilters": {`{"InstanceId": "i-1", "AutoScalingGroupName": ["asg"], "InstanceId": ["i-2"]}`},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"InstanceId"`)
+	})
+
+	t.Run("Should accept a dimension filter with unique dimensions", func(t *testing.T) {
+		request, err := GetDimensionValuesRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/EC2"},
+			"metricName":       {"CPUUtilization"},
+			"dimensionKey":     {"InstanceType"},
+			"dimensionFilters": {`{"InstanceId": ["i-1", "i-2"], "AutoScalingGroupName": {"nested": 1}, "ImageId": null}`},
+		})
+		require.NoError(t, err)
+		assert.Len(t, request.DimensionFilter, 3)
+	})
+
+	t.Run("Should return an error if the namespace is missing", func(t *testing.T) {
+		_, err := GetDimensionValuesRequest(map[string][]string{"region": {"us-east-1"}, "dimensionKey": {"InstanceId"}})
+		require.EqualError(t, err, "namespace is required")
+	})
 }
@@ -0,0 +1,29 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type MetricStreamMetricsRequest struct {
+	*ResourceRequest
+	StreamName string
+}
+
+func GetMetricStreamMetricsRequest(parameters url.Values) (MetricStreamMetricsRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return MetricStreamMetricsRequest{}, err
+	}
+
+	request := MetricStreamMetricsRequest{
+		ResourceRequest: resourceRequest,
+		StreamName:      parameters.Get("streamName"),
+	}
+
+	if request.StreamName == "" {
+		return MetricStreamMetricsRequest{}, fmt.Errorf("streamName is required")
+	}
+
+	return request, nil
+}
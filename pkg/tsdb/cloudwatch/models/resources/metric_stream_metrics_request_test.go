@@ -0,0 +1,22 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricStreamMetricsRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetMetricStreamMetricsRequest(map[string][]string{"region": {"us-east-1"}, "streamName": {"my-stream"}})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "my-stream", request.StreamName)
+	})
+
+	t.Run("Should return an error if streamName is missing", func(t *testing.T) {
+		_, err := GetMetricStreamMetricsRequest(map[string][]string{"region": {"us-east-1"}})
+		require.Error(t, err)
+	})
+}
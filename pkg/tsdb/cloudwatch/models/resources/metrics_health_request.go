@@ -0,0 +1,20 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type MetricsHealthRequest struct {
+	*ResourceRequest
+}
+
+// GetMetricsHealthRequest parses the request of a metrics health check. The region is optional, the default region of
+// the data source is checked if it's missing.
+func GetMetricsHealthRequest(parameters url.Values) (MetricsHealthRequest, error) {
+	region := parameters.Get("region")
+	if region == "" {
+		region = "default"
+	}
+
+	return MetricsHealthRequest{ResourceRequest: &ResourceRequest{Region: region}}, nil
+}
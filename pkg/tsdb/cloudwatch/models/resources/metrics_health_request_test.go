@@ -0,0 +1,22 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHealthRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetMetricsHealthRequest(map[string][]string{"region": {"us-east-1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+	})
+
+	t.Run("Should use the default region if region is missing", func(t *testing.T) {
+		request, err := GetMetricsHealthRequest(map[string][]string{})
+		require.NoError(t, err)
+		assert.Equal(t, "default", request.Region)
+	})
+}
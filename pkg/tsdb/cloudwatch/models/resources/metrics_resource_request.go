@@ -1,7 +1,10 @@
 package resources
 
 import (
+	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
 type MetricsRequestType uint32
@@ -12,9 +15,83 @@ const (
 	CustomNamespaceRequestType
 )
 
+const (
+	// DefaultMetricsLimit is the number of metrics in a page of a custom namespace if only nextToken is given
+	DefaultMetricsLimit = 500
+	// MaxMetricsLimit caps the number of metrics in a page of a custom namespace
+	MaxMetricsLimit = 1000
+)
+
+const (
+	MetricsSortByName         = "name"
+	MetricsSortByResourceType = "resourceType"
+)
+
+const (
+	MetricNameMatchPrefix   = "prefix"
+	MetricNameMatchContains = "contains"
+)
+
 type MetricsRequest struct {
 	*ResourceRequest
+	// Namespace is the namespace of a request for a single namespace, and empty if more than one is requested
 	Namespace string
+	// Namespaces are all requested namespaces without duplicates, which can be given as repeated namespace parameters
+	// or as a comma-separated list
+	Namespaces     []string
+	PromNames      bool
+	ResourceType   string
+	GroupByAccount bool
+	// AccountStatus lists the metrics of each account separately with groupByAccount, so that an account whose
+	// metrics can't be listed is returned with its status rather than failing the request
+	AccountStatus    bool
+	IncludeTags      bool
+	ExpandDimensions bool
+	IncludeLatest    bool
+	// Details attaches the dimension keys of each metric, and the account and region the metrics of a custom namespace
+	// were listed from
+	Details bool
+	// Hierarchy returns the metrics with their dimensions as a tree of dimension combinations
+	Hierarchy bool
+	// WithAlarms marks each metric with whether there's an alarm on it
+	WithAlarms bool
+	// WithInsightRules marks each metric with whether Contributor Insights rules cover it
+	WithInsightRules bool
+	// InferPeriod infers the period of each metric from the spacing of its recent data points
+	InferPeriod bool
+	// Docs attaches the URL of the AWS documentation of each metric of a known namespace
+	Docs bool
+	// CostHints attaches the cost hint of each metric known to be charged for
+	CostHints bool
+	// SuggestThresholds attaches the suggested alarm threshold of each metric that has a curated one
+	SuggestThresholds bool
+	// IncludeUnitLabels attaches the unit and its display label to each metric whose unit is curated
+	IncludeUnitLabels bool
+	// MinDatapoints leaves out the metrics with fewer data points in the last hour, unless it's 0
+	MinDatapoints int
+	// RequireDimensions leaves out the metrics that don't have any dimensions
+	RequireDimensions bool
+	// Paginate lists the metrics with their dimensions a page at a time, starting at Cursor
+	Paginate bool
+	Cursor   string
+	// Sort is the field the metrics with their dimensions are sorted by before they're paginated
+	Sort string
+	// Paged lists the metrics of a custom namespace a page of at most Limit metrics at a time, starting at NextToken,
+	// and is set if either is given
+	Paged     bool
+	Limit     int
+	NextToken string
+	// Debug returns the metrics together with a trace of how the request was resolved
+	Debug bool
+	// Refresh lists the metrics of a custom namespace even if they're cached, and caches them again
+	Refresh bool
+	// Query leaves out the metrics whose name doesn't start with it, or doesn't contain it if Match is contains,
+	// ignoring case. It's given as q, or as metricName.
+	Query string
+	Match string
+	// AccountId lists the metrics of a custom namespace that are owned by the account with this id, which is either
+	// the monitoring account of the data source or one of its linked source accounts
+	AccountId string
 }
 
 func GetMetricsRequest(parameters url.Values) (*MetricsRequest, error) {
@@ -23,12 +100,128 @@ func GetMetricsRequest(parameters url.Values) (*MetricsRequest, error) {
 		return nil, err
 	}
 
+	sortBy := parameters.Get("sort")
+	if sortBy != "" && sortBy != MetricsSortByName && sortBy != MetricsSortByResourceType {
+		return nil, fmt.Errorf("sort must be %q or %q", MetricsSortByName, MetricsSortByResourceType)
+	}
+
+	query := parameters.Get("q")
+	if query == "" {
+		query = parameters.Get("metricName")
+	}
+	match := parameters.Get("match")
+	if match == "" {
+		match = MetricNameMatchPrefix
+	}
+	if match != MetricNameMatchPrefix && match != MetricNameMatchContains {
+		return nil, fmt.Errorf("match must be %q or %q", MetricNameMatchPrefix, MetricNameMatchContains)
+	}
+
+	accountId := parameters.Get("accountId")
+	if accountId != "" && !isAccountId(accountId) {
+		return nil, fmt.Errorf("accountId must be the 12 digit id of an AWS account")
+	}
+
+	minDatapoints := 0
+	if value := parameters.Get("minDatapoints"); value != "" {
+		minDatapoints, err = strconv.Atoi(value)
+		if err != nil || minDatapoints < 1 {
+			return nil, fmt.Errorf("minDatapoints must be a positive integer")
+		}
+	}
+
+	paged := parameters.Get("limit") != "" || parameters.Get("nextToken") != ""
+	limit := 0
+	if paged {
+		limit = DefaultMetricsLimit
+	}
+	if value := parameters.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		if limit > MaxMetricsLimit {
+			limit = MaxMetricsLimit
+		}
+	}
+
+	// namespaces can't contain commas, so a namespace with one is a list of namespaces
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, value := range parameters["namespace"] {
+		for _, namespace := range strings.Split(value, ",") {
+			namespace = strings.TrimSpace(namespace)
+			if namespace != "" && !seen[namespace] {
+				seen[namespace] = true
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+	namespace := ""
+	if len(namespaces) == 1 {
+		namespace = namespaces[0]
+	}
+
 	return &MetricsRequest{
-		ResourceRequest: resourceRequest,
-		Namespace:       parameters.Get("namespace"),
+		ResourceRequest:   resourceRequest,
+		Namespace:         namespace,
+		Namespaces:        namespaces,
+		PromNames:         parameters.Get("promNames") == "true",
+		ResourceType:      parameters.Get("resourceType"),
+		GroupByAccount:    parameters.Get("groupByAccount") == "true",
+		AccountStatus:     parameters.Get("accountStatus") == "true",
+		IncludeTags:       parameters.Get("includeTags") == "true",
+		ExpandDimensions:  parameters.Get("expandDimensions") == "true",
+		IncludeLatest:     parameters.Get("includeLatest") == "true",
+		Hierarchy:         parameters.Get("hierarchy") == "true",
+		Details:           parameters.Get("details") == "true",
+		WithAlarms:        parameters.Get("withAlarms") == "true",
+		WithInsightRules:  parameters.Get("withInsightRules") == "true",
+		InferPeriod:       parameters.Get("inferPeriod") == "true",
+		Docs:              parameters.Get("docs") == "true",
+		CostHints:         parameters.Get("costHints") == "true",
+		SuggestThresholds: parameters.Get("suggestThresholds") == "true",
+		IncludeUnitLabels: parameters.Get("includeUnitLabels") == "true",
+		MinDatapoints:     minDatapoints,
+		RequireDimensions: parameters.Get("requireDimensions") == "true",
+		Paginate:          parameters.Get("paginate") == "true" || parameters.Get("cursor") != "" || sortBy != "",
+		Cursor:            parameters.Get("cursor"),
+		Sort:              sortBy,
+		Paged:             paged,
+		Limit:             limit,
+		NextToken:         parameters.Get("nextToken"),
+		Debug:             parameters.Get("debug") == "true",
+		Refresh:           parameters.Get("refresh") == "true",
+		Query:             query,
+		Match:             match,
+		AccountId:         accountId,
 	}, nil
 }
 
+func isAccountId(accountId string) bool {
+	if len(accountId) != 12 {
+		return false
+	}
+	for _, c := range accountId {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (t MetricsRequestType) String() string {
+	switch t {
+	case MetricsByNamespaceRequestType:
+		return "metrics by namespace"
+	case AllMetricsRequestType:
+		return "all metrics"
+	case CustomNamespaceRequestType:
+		return "custom namespace"
+	}
+	return fmt.Sprintf("MetricsRequestType(%d)", uint32(t))
+}
+
 func (r *MetricsRequest) Type() MetricsRequestType {
 	if r.Namespace == "" {
 		return AllMetricsRequestType
@@ -14,6 +14,294 @@ func TestMetricsRequest(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "us-east-1", request.Region)
 		assert.Equal(t, "AWS/EC2", request.Namespace)
+		assert.False(t, request.PromNames)
+	})
+
+	t.Run("Should parse promNames parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "promNames": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.PromNames)
+	})
+
+	t.Run("Should parse resourceType parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "resourceType": {"ec2:instance"}})
+		require.NoError(t, err)
+		assert.Equal(t, "ec2:instance", request.ResourceType)
+	})
+
+	t.Run("Should parse groupByAccount parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "groupByAccount": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.GroupByAccount)
+	})
+
+	t.Run("Should parse includeTags parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "includeTags": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.IncludeTags)
+	})
+
+	t.Run("Should parse expandDimensions parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "expandDimensions": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.ExpandDimensions)
+		assert.False(t, request.IncludeTags)
+	})
+
+	t.Run("Should parse includeLatest parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "includeLatest": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.IncludeLatest)
+	})
+
+	t.Run("Should parse withAlarms parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "withAlarms": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.WithAlarms)
+	})
+
+	t.Run("Should parse withInsightRules parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/DynamoDB"}, "withInsightRules": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.WithInsightRules)
+	})
+
+	t.Run("Should parse inferPeriod parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "inferPeriod": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.InferPeriod)
+	})
+
+	t.Run("Should parse a single namespace", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.Equal(t, "AWS/EC2", request.Namespace)
+		assert.Equal(t, []string{"AWS/EC2"}, request.Namespaces)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "", request.Namespace)
+		assert.Empty(t, request.Namespaces)
+		assert.Equal(t, AllMetricsRequestType, request.Type())
+	})
+
+	t.Run("Should parse repeated and comma-separated namespaces without duplicates", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2, MyApp", "OtherApp", "MyApp,"}})
+		require.NoError(t, err)
+		assert.Equal(t, "", request.Namespace)
+		assert.Equal(t, []string{"AWS/EC2", "MyApp", "OtherApp"}, request.Namespaces)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp", "MyApp"}})
+		require.NoError(t, err)
+		assert.Equal(t, "MyApp", request.Namespace)
+		assert.Equal(t, []string{"MyApp"}, request.Namespaces)
+	})
+
+	t.Run("Should parse the metric name query", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.Equal(t, "", request.Query)
+		assert.Equal(t, MetricNameMatchPrefix, request.Match)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "q": {"cpu"}, "match": {"contains"}})
+		require.NoError(t, err)
+		assert.Equal(t, "cpu", request.Query)
+		assert.Equal(t, MetricNameMatchContains, request.Match)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "metricName": {"CPU"}})
+		require.NoError(t, err)
+		assert.Equal(t, "CPU", request.Query)
+
+		_, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "q": {"cpu"}, "match": {"regex"}})
+		require.EqualError(t, err, `match must be "prefix" or "contains"`)
+	})
+
+	t.Run("Should parse costHints parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.False(t, request.CostHints)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "costHints": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.CostHints)
+	})
+
+	t.Run("Should parse suggestThresholds parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.False(t, request.SuggestThresholds)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "suggestThresholds": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.SuggestThresholds)
+	})
+
+	t.Run("Should parse accountStatus parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "groupByAccount": {"true"}})
+		require.NoError(t, err)
+		assert.False(t, request.AccountStatus)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "groupByAccount": {"true"}, "accountStatus": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.AccountStatus)
+	})
+
+	t.Run("Should parse includeUnitLabels parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.False(t, request.IncludeUnitLabels)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "includeUnitLabels": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.IncludeUnitLabels)
+	})
+
+	t.Run("Should parse docs parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.False(t, request.Docs)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "docs": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Docs)
+	})
+
+	t.Run("Should parse minDatapoints parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.Zero(t, request.MinDatapoints)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "minDatapoints": {"10"}})
+		require.NoError(t, err)
+		assert.Equal(t, 10, request.MinDatapoints)
+	})
+
+	t.Run("Should return an error if minDatapoints isn't a positive integer", func(t *testing.T) {
+		for _, value := range []string{"0", "-1", "many"} {
+			_, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "minDatapoints": {value}})
+			assert.EqualError(t, err, "minDatapoints must be a positive integer")
+		}
+	})
+
+	t.Run("Should parse requireDimensions parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.NoError(t, err)
+		assert.False(t, request.RequireDimensions)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "requireDimensions": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.RequireDimensions)
+	})
+
+	t.Run("Should parse paginate parameter, which a cursor implies", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "paginate": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Paginate)
+		assert.Empty(t, request.Cursor)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "cursor": {"abc.def"}})
+		require.NoError(t, err)
+		assert.True(t, request.Paginate)
+		assert.Equal(t, "abc.def", request.Cursor)
+	})
+
+	t.Run("Should parse sort parameter, which implies paginate", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "sort": {"resourceType"}})
+		require.NoError(t, err)
+		assert.Equal(t, MetricsSortByResourceType, request.Sort)
+		assert.True(t, request.Paginate)
+
+		_, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "sort": {"size"}})
+		require.Error(t, err)
+	})
+
+	t.Run("Should parse limit and nextToken parameters, which imply a paged listing", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.False(t, request.Paged)
+		assert.Equal(t, 0, request.Limit)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "limit": {"100"}})
+		require.NoError(t, err)
+		assert.True(t, request.Paged)
+		assert.Equal(t, 100, request.Limit)
+		assert.Empty(t, request.NextToken)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "nextToken": {"0:abc"}})
+		require.NoError(t, err)
+		assert.True(t, request.Paged)
+		assert.Equal(t, DefaultMetricsLimit, request.Limit)
+		assert.Equal(t, "0:abc", request.NextToken)
+	})
+
+	t.Run("Should cap limit", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "limit": {"100000"}})
+		require.NoError(t, err)
+		assert.Equal(t, MaxMetricsLimit, request.Limit)
+	})
+
+	t.Run("Should return an error if limit isn't a positive integer", func(t *testing.T) {
+		for _, value := range []string{"0", "-1", "many"} {
+			_, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "limit": {value}})
+			assert.EqualError(t, err, "limit must be a positive integer")
+		}
+	})
+
+	t.Run("Should parse refresh parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.False(t, request.Refresh)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "refresh": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Refresh)
+	})
+
+	t.Run("Should parse debug parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.False(t, request.Debug)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "debug": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Debug)
+	})
+
+	t.Run("Should parse hierarchy parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/ApplicationELB"}})
+		require.NoError(t, err)
+		assert.False(t, request.Hierarchy)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/ApplicationELB"}, "hierarchy": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Hierarchy)
+	})
+
+	t.Run("Should parse details parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.False(t, request.Details)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "details": {"true"}})
+		require.NoError(t, err)
+		assert.True(t, request.Details)
+	})
+
+	t.Run("Should parse accountId parameter", func(t *testing.T) {
+		request, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}})
+		require.NoError(t, err)
+		assert.Empty(t, request.AccountId)
+
+		request, err = GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "accountId": {"111111111111"}})
+		require.NoError(t, err)
+		assert.Equal(t, "111111111111", request.AccountId)
+	})
+
+	t.Run("Should return an error if accountId isn't the id of an AWS account", func(t *testing.T) {
+		for _, value := range []string{"11111111111", "1111111111111", "11111111111a", "all"} {
+			_, err := GetMetricsRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"MyApp"}, "accountId": {value}})
+			assert.EqualError(t, err, "accountId must be the 12 digit id of an AWS account")
+		}
 	})
 
 	tests := []struct {
@@ -0,0 +1,39 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// DefaultNamespacesLimit caps the number of namespaces returned for a prefix if no limit is given
+const DefaultNamespacesLimit = 50
+
+type NamespacesRequest struct {
+	Prefix      string
+	Limit       int
+	WithCounts  bool
+	WithAliases bool
+}
+
+func GetNamespacesRequest(parameters url.Values) (NamespacesRequest, error) {
+	request := NamespacesRequest{
+		Prefix:      parameters.Get("prefix"),
+		WithCounts:  parameters.Get("withCounts") == "true",
+		WithAliases: parameters.Get("withAliases") == "true",
+	}
+
+	if request.Prefix != "" {
+		request.Limit = DefaultNamespacesLimit
+	}
+
+	if limit := parameters.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 {
+			return NamespacesRequest{}, fmt.Errorf("limit must be a positive integer")
+		}
+		request.Limit = l
+	}
+
+	return request, nil
+}
@@ -0,0 +1,45 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacesRequest(t *testing.T) {
+	t.Run("Should not cap the result without a prefix", func(t *testing.T) {
+		request, err := GetNamespacesRequest(map[string][]string{})
+		require.NoError(t, err)
+		assert.Equal(t, NamespacesRequest{}, request)
+	})
+
+	t.Run("Should use the default limit with a prefix", func(t *testing.T) {
+		request, err := GetNamespacesRequest(map[string][]string{"prefix": {"aws/"}})
+		require.NoError(t, err)
+		assert.Equal(t, NamespacesRequest{Prefix: "aws/", Limit: DefaultNamespacesLimit}, request)
+	})
+
+	t.Run("Should parse limit", func(t *testing.T) {
+		request, err := GetNamespacesRequest(map[string][]string{"prefix": {"aws/"}, "limit": {"10"}})
+		require.NoError(t, err)
+		assert.Equal(t, NamespacesRequest{Prefix: "aws/", Limit: 10}, request)
+	})
+
+	t.Run("Should parse withCounts", func(t *testing.T) {
+		request, err := GetNamespacesRequest(map[string][]string{"withCounts": {"true"}})
+		require.NoError(t, err)
+		assert.Equal(t, NamespacesRequest{WithCounts: true}, request)
+	})
+
+	t.Run("Should parse withAliases", func(t *testing.T) {
+		request, err := GetNamespacesRequest(map[string][]string{"withAliases": {"true"}})
+		require.NoError(t, err)
+		assert.Equal(t, NamespacesRequest{WithAliases: true}, request)
+	})
+
+	t.Run("Should return an error for an invalid limit", func(t *testing.T) {
+		_, err := GetNamespacesRequest(map[string][]string{"limit": {"-1"}})
+		require.Error(t, err)
+	})
+}
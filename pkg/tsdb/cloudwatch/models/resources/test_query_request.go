@@ -0,0 +1,65 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// DefaultTestQueryPeriod is the period of a test query if none is given, in seconds
+const DefaultTestQueryPeriod = 300
+
+// TestQueryRequest is a metric query to validate by running it over a short window. The dimensions must have a single
+// value each, since the query is run for a single metric.
+type TestQueryRequest struct {
+	*ResourceRequest
+	Namespace  string
+	MetricName string
+	// Statistic is the statistic to aggregate the data points by, the default statistic of the metric if it's empty
+	Statistic  string
+	Period     int64
+	Dimensions []*Dimension
+}
+
+func GetTestQueryRequest(parameters url.Values) (TestQueryRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return TestQueryRequest{}, err
+	}
+
+	request := TestQueryRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+		MetricName:      parameters.Get("metricName"),
+		Statistic:       parameters.Get("statistic"),
+		Period:          DefaultTestQueryPeriod,
+	}
+
+	if request.Namespace == "" || request.MetricName == "" {
+		return TestQueryRequest{}, fmt.Errorf("namespace and metricName are required")
+	}
+
+	if period := parameters.Get("period"); period != "" {
+		p, err := strconv.ParseInt(period, 10, 64)
+		// AWS only accepts high resolution periods below a minute and multiples of a minute otherwise
+		if err != nil || p <= 0 || (p >= 60 && p%60 != 0) || (p < 60 && 60%p != 0) {
+			return TestQueryRequest{}, fmt.Errorf("period must be 1, 5, 10, 30 or a multiple of 60 seconds")
+		}
+		request.Period = p
+	}
+
+	dimensions, err := parseDimensionFilter(parameters.Get("dimensions"))
+	if err != nil {
+		return TestQueryRequest{}, err
+	}
+	seen := map[string]struct{}{}
+	for _, dimension := range dimensions {
+		if _, exists := seen[dimension.Name]; exists || dimension.Value == "" {
+			return TestQueryRequest{}, fmt.Errorf("dimension %q needs a single value other than a wildcard", dimension.Name)
+		}
+		seen[dimension.Name] = struct{}{}
+	}
+	request.Dimensions = dimensions
+
+	return request, nil
+}
@@ -0,0 +1,55 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestQueryRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := GetTestQueryRequest(map[string][]string{
+			"region":     {"us-east-1"},
+			"namespace":  {"AWS/EC2"},
+			"metricName": {"CPUUtilization"},
+			"statistic":  {"Maximum"},
+			"period":     {"60"},
+			"dimensions": {`{"InstanceId": "i-1"}`},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "AWS/EC2", request.Namespace)
+		assert.Equal(t, "CPUUtilization", request.MetricName)
+		assert.Equal(t, "Maximum", request.Statistic)
+		assert.Equal(t, int64(60), request.Period)
+		assert.Equal(t, []*Dimension{{Name: "InstanceId", Value: "i-1"}}, request.Dimensions)
+	})
+
+	t.Run("Should default the period and leave the statistic empty", func(t *testing.T) {
+		request, err := GetTestQueryRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "metricName": {"CPUUtilization"}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(DefaultTestQueryPeriod), request.Period)
+		assert.Empty(t, request.Statistic)
+		assert.Empty(t, request.Dimensions)
+	})
+
+	t.Run("Should require a namespace and metric name", func(t *testing.T) {
+		_, err := GetTestQueryRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.Error(t, err)
+	})
+
+	t.Run("Should reject periods AWS doesn't accept", func(t *testing.T) {
+		for _, period := range []string{"0", "-60", "7", "90", "abc"} {
+			_, err := GetTestQueryRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "metricName": {"CPUUtilization"}, "period": {period}})
+			require.Error(t, err, period)
+		}
+	})
+
+	t.Run("Should reject dimensions without a single value", func(t *testing.T) {
+		for _, dimensions := range []string{`{"InstanceId": ["i-1", "i-2"]}`, `{"InstanceId": "*"}`, `{"InstanceId": null}`} {
+			_, err := GetTestQueryRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "metricName": {"CPUUtilization"}, "dimensions": {dimensions}})
+			require.Error(t, err, dimensions)
+		}
+	})
+}
@@ -1,11 +1,183 @@
 package resources
 
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
 type Dimension struct {
 	Name  string
 	Value string
 }
 
 type Metric struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	PrometheusName   string `json:"prometheusName,omitempty"`
+	DefaultStatistic string `json:"defaultStatistic,omitempty"`
+	ResourceType     string `json:"resourceType,omitempty"`
+	// Period is the period in seconds the metric is reported at, if it's known or was inferred
+	Period int64 `json:"period,omitempty"`
+	// DocsURL is the URL of the AWS documentation of the metric, if its namespace is known
+	DocsURL string `json:"docsUrl,omitempty"`
+	// CostHint tells why the metric is charged for, if it's known to be, e.g. detailedMonitoring
+	CostHint string `json:"costHint,omitempty"`
+	// SuggestedThreshold is a reasonable default alarm threshold of the metric, if one is curated
+	SuggestedThreshold *SuggestedThreshold `json:"suggestedThreshold,omitempty"`
+	// Unit is the CloudWatch unit of the metric and UnitLabel its display label, if the unit of the metric is curated
+	Unit      string `json:"unit,omitempty"`
+	UnitLabel string `json:"unitLabel,omitempty"`
+	// AccountId is the id of the account that owns the metric, if the metrics of a linked account were requested
+	AccountId string `json:"accountId,omitempty"`
+	// Details are the dimension keys of the metric and where it was listed from, if they were requested
+	Details *MetricDetails `json:"details,omitempty"`
+}
+
+// MetricDetails are the dimension keys a metric is reported with, and for a metric of a custom namespace the account
+// and region it was listed from. The dimension keys of a hardcoded metric are those of its namespace, since the
+// dimensions of the individual metrics aren't known.
+type MetricDetails struct {
+	DimensionKeys []string `json:"dimensionKeys"`
+	AccountId     string   `json:"accountId,omitempty"`
+	Region        string   `json:"region,omitempty"`
+}
+
+// SuggestedThreshold is an alarm threshold suggested for a metric, in the terms of a CloudWatch alarm, e.g. the
+// Average of CPUUtilization GreaterThanThreshold 80
+type SuggestedThreshold struct {
+	Statistic          string  `json:"statistic"`
+	ComparisonOperator string  `json:"comparisonOperator"`
+	Threshold          float64 `json:"threshold"`
+}
+
+// MetricResponse is a metric returned by ListMetrics together with the id of the account that owns it.
+// AccountId is only set when linked accounts are included in the request.
+type MetricResponse struct {
+	*cloudwatch.Metric
+	AccountId *string
+}
+
+// AccountMetrics are the metrics of a single account. Label is the account label of a linked source account,
+// if it could be resolved. Status is whether the metrics of the account could be listed, if it was asked for, with
+// the code and message of the error if they couldn't.
+type AccountMetrics struct {
+	Label     string   `json:"label,omitempty"`
+	Metrics   []Metric `json:"metrics"`
+	Status    string   `json:"status,omitempty"`
+	ErrorCode string   `json:"errorCode,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+const (
+	// AccountStatusOK is the status of an account whose metrics were listed
+	AccountStatusOK = "ok"
+	// AccountStatusDenied is the status of an account whose metrics the data source isn't allowed to list
+	AccountStatusDenied = "denied"
+	// AccountStatusError is the status of an account whose metrics couldn't be listed for another reason
+	AccountStatusError = "error"
+)
+
+// TaggedMetric is a metric with the values of its dimensions, and the tags of the resource it belongs to, its latest
+// data point, whether it has an alarm and whether Contributor Insights rules cover it if they were requested.
+type TaggedMetric struct {
+	Metric
+	Dimensions      map[string]string `json:"dimensions"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	Latest          *DataPoint        `json:"latest,omitempty"`
+	HasAlarm        *bool             `json:"hasAlarm,omitempty"`
+	HasInsightRules *bool             `json:"hasInsightRules,omitempty"`
+}
+
+// DataPoint is the value of a metric at a point in time
+type DataPoint struct {
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TaggedMetricsPage is a page of metrics with their dimensions. NextCursor is an opaque cursor to request the next
+// page with, and is empty if it's the last page. Truncated is set if the pages of a sorted listing don't include all
+// metrics, since only the first ones up to a cap were sorted.
+type TaggedMetricsPage struct {
+	Metrics    []TaggedMetric `json:"metrics"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	Truncated  bool           `json:"truncated,omitempty"`
+}
+
+// DimensionHierarchy is the tree of the dimension combinations of the metrics of a namespace. Order is the order of
+// the dimension keys from the root to the leaves. Metrics are the metrics reported without dimensions.
+type DimensionHierarchy struct {
+	Order    []string         `json:"order"`
+	Metrics  []TaggedMetric   `json:"metrics,omitempty"`
+	Children []*DimensionNode `json:"children"`
+}
+
+// DimensionNode is the value of a dimension key within the dimension values of its ancestors. Metrics are the metrics
+// reported with exactly the dimensions of the node and its ancestors, and Children are the values of the keys that
+// follow in the order of the hierarchy.
+type DimensionNode struct {
+	Dimension string           `json:"dimension"`
+	Value     string           `json:"value"`
+	Metrics   []TaggedMetric   `json:"metrics,omitempty"`
+	Children  []*DimensionNode `json:"children,omitempty"`
+}
+
+// MetricsPage is a page of the metrics of a custom namespace. NextToken is the token to request the next page with,
+// and is empty if it's the last page.
+type MetricsPage struct {
+	Metrics   []Metric `json:"metrics"`
+	NextToken string   `json:"nextToken,omitempty"`
+}
+
+// DebugResponse is the response of a request with debug, which is the response it has otherwise together with the
+// trace of how it was resolved
+type DebugResponse struct {
+	Result interface{} `json:"result"`
+	Trace  []string    `json:"trace"`
+}
+
+// Bootstrap is what the query editor loads with: the namespaces of the data source, together with the metrics of
+// Namespace, which is the requested namespace or the default one.
+type Bootstrap struct {
+	Namespaces []string `json:"namespaces"`
+	Namespace  string   `json:"namespace"`
+	Metrics    []Metric `json:"metrics"`
+}
+
+// NamespaceMetricCount is a namespace together with the number of metrics in it. The count of a custom namespace is
+// taken from a capped listing of its metrics, so it's only a lower bound if Approximate is set.
+type NamespaceMetricCount struct {
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	MetricCount int    `json:"metricCount"`
+	Approximate bool   `json:"approximate"`
+}
+
+// NamespaceWithAlias is a namespace together with its friendly name, if it has one
+type NamespaceWithAlias struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// TestQueryResult is the result of a test query. OK is set if the query returned data points, otherwise Message tells
+// why it didn't.
+type TestQueryResult struct {
+	OK         bool        `json:"ok"`
+	Message    string      `json:"message,omitempty"`
+	DataPoints []DataPoint `json:"dataPoints"`
+}
+
+// MetricsHealth is whether the metrics of a data source can be listed, with the code and message of the AWS error if
+// they can't
+type MetricsHealth struct {
+	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// CallerIdentity is the IAM principal the credentials of a data source authenticate as
+type CallerIdentity struct {
+	Account string `json:"account"`
+	Arn     string `json:"arn"`
+	UserId  string `json:"userId"`
 }
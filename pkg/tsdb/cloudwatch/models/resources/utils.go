@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -15,6 +16,10 @@ func parseDimensionFilter(dimensionFilter string) ([]*Dimension, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error unmarshaling dimensionFilters: %v", err)
 		}
+		// unmarshaling keeps the last of duplicate keys, while AWS rejects duplicate dimensions with a confusing error
+		if key, found := findDuplicateKey(dimensionFilterJson); found {
+			return nil, fmt.Errorf("dimensionFilters contains the dimension %q more than once, list its values in a single array instead", key)
+		}
 	}
 
 	dimensions := []*Dimension{}
@@ -45,6 +50,37 @@ func parseDimensionFilter(dimensionFilter string) ([]*Dimension, error) {
 	return dimensions, nil
 }
 
+// findDuplicateKey returns the first key that's repeated in the JSON object. The object is expected to be valid.
+func findDuplicateKey(object []byte) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(object))
+	if _, err := decoder.Token(); err != nil {
+		return "", false
+	}
+
+	seen := map[string]struct{}{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return "", false
+		}
+		if _, exists := seen[key]; exists {
+			return key, true
+		}
+		seen[key] = struct{}{}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
 func isCustomNamespace(namespace string) bool {
 	if _, ok := constants.NamespaceMetricsMap[namespace]; ok {
 		return false
@@ -3,22 +3,43 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+// DefaultListMetricsCacheTTL is how long the metrics of custom namespaces are cached unless the data source sets it
+const DefaultListMetricsCacheTTL = 2 * time.Minute
+
 type CloudWatchSettings struct {
 	awsds.AWSDatasourceSettings
 	Namespace string `json:"customMetricsNamespaces"`
+	// ListMetricsCacheTTL is how long the metrics listed for custom namespaces are cached, where 0 disables the cache.
+	// It's set with a duration string, e.g. "5m", as listMetricsCacheTTL.
+	ListMetricsCacheTTL time.Duration `json:"-"`
 }
 
 func LoadCloudWatchSettings(config backend.DataSourceInstanceSettings) (CloudWatchSettings, error) {
-	instance := CloudWatchSettings{}
+	instance := CloudWatchSettings{ListMetricsCacheTTL: DefaultListMetricsCacheTTL}
 	if config.JSONData != nil && len(config.JSONData) > 1 {
 		if err := json.Unmarshal(config.JSONData, &instance); err != nil {
 			return CloudWatchSettings{}, fmt.Errorf("could not unmarshal DatasourceSettings json: %w", err)
 		}
+
+		var cacheSettings struct {
+			ListMetricsCacheTTL string `json:"listMetricsCacheTTL"`
+		}
+		if err := json.Unmarshal(config.JSONData, &cacheSettings); err != nil {
+			return CloudWatchSettings{}, fmt.Errorf("could not unmarshal DatasourceSettings json: %w", err)
+		}
+		if cacheSettings.ListMetricsCacheTTL != "" {
+			ttl, err := time.ParseDuration(cacheSettings.ListMetricsCacheTTL)
+			if err != nil || ttl < 0 {
+				return CloudWatchSettings{}, fmt.Errorf("listMetricsCacheTTL must be a non-negative duration, got %q", cacheSettings.ListMetricsCacheTTL)
+			}
+			instance.ListMetricsCacheTTL = ttl
+		}
 	}
 
 	if instance.Region == "default" || instance.Region == "" {
@@ -1,19 +1,27 @@
 package models
 
 import (
+	"context"
 	"net/url"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 type RequestContext struct {
-	MetricsClientProvider MetricsClientProvider
-	Settings              CloudWatchSettings
+	MetricsClientProvider      MetricsClientProvider
+	OAMAPIProvider             OAMAPIProvider
+	ResourceTaggingAPIProvider ResourceTaggingAPIProvider
+	AlarmsAPIProvider          AlarmsAPIProvider
+	InsightRulesAPIProvider    InsightRulesAPIProvider
+	STSAPIProvider             STSAPIProvider
+	Settings                   CloudWatchSettings
+	// CursorSigningKey is the key the cursors of paginated listings are signed with
+	CursorSigningKey []byte
 }
 
 type RequestContextFactoryFunc func(pluginCtx backend.PluginContext, region string) (reqCtx RequestContext, err error)
 
-type RouteHandlerFunc func(pluginCtx backend.PluginContext, reqContextFactory RequestContextFactoryFunc, parameters url.Values) ([]byte, *HttpError)
+type RouteHandlerFunc func(ctx context.Context, pluginCtx backend.PluginContext, reqContextFactory RequestContextFactoryFunc, parameters url.Values) ([]byte, *HttpError)
 
 type cloudWatchLink struct {
 	View    string        `json:"view"`
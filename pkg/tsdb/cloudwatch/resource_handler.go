@@ -22,8 +22,15 @@ func (e *cloudWatchExecutor) newResourceMux() *http.ServeMux {
 	mux.HandleFunc("/all-log-groups", handleResourceReq(e.handleGetAllLogGroups))
 	mux.HandleFunc("/metrics", routes.ResourceRequestMiddleware(routes.MetricsHandler, logger, e.getRequestContext))
 	mux.HandleFunc("/dimension-values", routes.ResourceRequestMiddleware(routes.DimensionValuesHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/bulk-dimension-values", routes.ResourceRequestMiddleware(routes.BulkDimensionValuesHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/dimension-autocomplete", routes.ResourceRequestMiddleware(routes.DimensionAutocompleteHandler, logger, e.getRequestContext))
 	mux.HandleFunc("/dimension-keys", routes.ResourceRequestMiddleware(routes.DimensionKeysHandler, logger, e.getRequestContext))
 	mux.HandleFunc("/namespaces", routes.ResourceRequestMiddleware(routes.NamespacesHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/bootstrap", routes.ResourceRequestMiddleware(routes.BootstrapHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/metric-stream-metrics", routes.ResourceRequestMiddleware(routes.MetricStreamMetricsHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/test-query", routes.ResourceRequestMiddleware(routes.TestQueryHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/caller-identity", routes.ResourceRequestMiddleware(routes.CallerIdentityHandler, logger, e.getRequestContext))
+	mux.HandleFunc("/metrics-health", routes.ResourceRequestMiddleware(routes.MetricsHealthHandler, logger, e.getRequestContext))
 	return mux
 }
 
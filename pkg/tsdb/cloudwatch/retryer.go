@@ -0,0 +1,57 @@
+package cloudwatch
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// retryer retries AWS requests like the SDK's default retryer, i.e. the same errors are retried, but with the delays
+// of the configured retry policy
+type retryer struct {
+	client.DefaultRetryer
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	jitter    float64
+}
+
+// newRetryer returns the retryer of the retry policy in the settings, or nil if the SDK's default policy is kept
+func newRetryer(cfg *setting.Cfg) request.Retryer {
+	if cfg.AWSMaxAttempts <= 0 {
+		return nil
+	}
+	return retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: cfg.AWSMaxAttempts - 1},
+		baseDelay:      cfg.AWSRetryBaseDelay,
+		maxDelay:       cfg.AWSRetryMaxDelay,
+		jitter:         cfg.AWSRetryJitter,
+	}
+}
+
+// RetryRules returns the base delay doubled for every earlier retry, capped at the max delay, with the jitter fraction
+// of it randomized.
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+	delay := r.maxDelay
+	// shifting by more than this could overflow
+	if req.RetryCount < 32 {
+		if backoff := r.baseDelay << uint(req.RetryCount); backoff > 0 && backoff < delay {
+			delay = backoff
+		}
+	}
+	return delay - time.Duration(rand.Float64()*r.jitter*float64(delay))
+}
+
+// withRetryPolicy returns a copy of the session whose clients use the retry policy in the settings, or the session
+// itself if the SDK's default policy is kept
+func withRetryPolicy(sess *session.Session, cfg *setting.Cfg) *session.Session {
+	retryer := newRetryer(cfg)
+	if retryer == nil {
+		return sess
+	}
+	return sess.Copy(request.WithRetryer(aws.NewConfig(), retryer))
+}
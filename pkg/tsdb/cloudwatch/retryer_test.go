@@ -0,0 +1,150 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryTestConfig() *setting.Cfg {
+	cfg := newTestConfig()
+	cfg.AWSMaxAttempts = 5
+	cfg.AWSRetryBaseDelay = time.Second
+	cfg.AWSRetryMaxDelay = 5 * time.Second
+	return cfg
+}
+
+func TestNewRetryer(t *testing.T) {
+	t.Run("the SDK's default policy is kept without max attempts", func(t *testing.T) {
+		assert.Nil(t, newRetryer(newTestConfig()))
+	})
+
+	t.Run("retries all but the first attempt", func(t *testing.T) {
+		r := newRetryer(newRetryTestConfig())
+		require.NotNil(t, r)
+		assert.Equal(t, 4, r.MaxRetries())
+	})
+
+	t.Run("delays double up to the max delay", func(t *testing.T) {
+		r := newRetryer(newRetryTestConfig())
+		delays := make([]time.Duration, 0, 5)
+		for retryCount := 0; retryCount < 5; retryCount++ {
+			delays = append(delays, r.RetryRules(&request.Request{RetryCount: retryCount}))
+		}
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+		assert.Equal(t, 5*time.Second, r.RetryRules(&request.Request{RetryCount: 100}))
+	})
+
+	t.Run("the jitter randomizes a fraction of the delay", func(t *testing.T) {
+		cfg := newRetryTestConfig()
+		cfg.AWSRetryJitter = 0.5
+		r := newRetryer(cfg)
+		for i := 0; i < 100; i++ {
+			delay := r.RetryRules(&request.Request{RetryCount: 1})
+			assert.LessOrEqual(t, delay, 2*time.Second)
+			assert.Greater(t, delay, time.Second)
+		}
+	})
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("us-east-1"), Credentials: credentials.AnonymousCredentials})
+	require.NoError(t, err)
+
+	t.Run("clients keep the SDK's default policy without max attempts", func(t *testing.T) {
+		assert.Same(t, sess, withRetryPolicy(sess, newTestConfig()))
+	})
+
+	t.Run("clients use the configured retryer", func(t *testing.T) {
+		cfg := newRetryTestConfig()
+		retrySess := withRetryPolicy(sess, cfg)
+
+		for _, c := range []*client.Client{
+			cloudwatch.New(retrySess).Client,
+			oam.New(retrySess).Client,
+			resourcegroupstaggingapi.New(retrySess).Client,
+		} {
+			assert.Equal(t, newRetryer(cfg), c.Retryer)
+			assert.Equal(t, 4, c.MaxRetries())
+		}
+		assert.Nil(t, sess.Config.Retryer)
+	})
+}
+
+func Test_getRequestContext_uses_retry_policy(t *testing.T) {
+	origNewMetricsAPI := NewMetricsAPI
+	origNewOAMAPI := NewOAMAPI
+	origNewRGTAClient := newRGTAClient
+	origNewAlarmsAPI := NewAlarmsAPI
+	origNewInsightRulesAPI := NewInsightRulesAPI
+	origNewSTSAPI := NewSTSAPI
+	t.Cleanup(func() {
+		NewMetricsAPI = origNewMetricsAPI
+		NewOAMAPI = origNewOAMAPI
+		newRGTAClient = origNewRGTAClient
+		NewAlarmsAPI = origNewAlarmsAPI
+		NewInsightRulesAPI = origNewInsightRulesAPI
+		NewSTSAPI = origNewSTSAPI
+	})
+	var sessions []*session.Session
+	NewMetricsAPI = func(sess *session.Session) models.CloudWatchMetricsAPIProvider {
+		sessions = append(sessions, sess)
+		return fakeCheckHealthClient{}
+	}
+	NewOAMAPI = func(sess *session.Session) models.OAMAPIProvider {
+		sessions = append(sessions, sess)
+		return &mocks.FakeOAMClient{}
+	}
+	newRGTAClient = func(provider client.ConfigProvider) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+		sessions = append(sessions, provider.(*session.Session))
+		return fakeRGTAClient{}
+	}
+	NewAlarmsAPI = func(sess *session.Session) models.AlarmsAPIProvider {
+		sessions = append(sessions, sess)
+		return &mocks.FakeAlarmsClient{}
+	}
+	NewInsightRulesAPI = func(sess *session.Session) models.InsightRulesAPIProvider {
+		sessions = append(sessions, sess)
+		return &mocks.FakeInsightRulesClient{}
+	}
+	NewSTSAPI = func(sess *session.Session) models.STSAPIProvider {
+		sessions = append(sessions, sess)
+		return &mocks.FakeSTSClient{}
+	}
+
+	sessionCache := &fakeSessionCache{getSession: func(c awsds.SessionConfig) (*session.Session, error) {
+		return &session.Session{Config: &aws.Config{}}, nil
+	}}
+	im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		return DataSource{Settings: models.CloudWatchSettings{}}, nil
+	})
+	cfg := newRetryTestConfig()
+	executor := newExecutor(im, cfg, sessionCache, featuremgmt.WithFeatures())
+
+	_, err := executor.getRequestContext(backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}, "us-east-2")
+	require.NoError(t, err)
+
+	require.Len(t, sessions, 6)
+	for _, sess := range sessions {
+		assert.Equal(t, newRetryer(cfg), sess.Config.Retryer)
+	}
+}
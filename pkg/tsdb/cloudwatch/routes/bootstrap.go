@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+// defaultBootstrapNamespace is the namespace whose metrics are bootstrapped if none is requested, unless the data
+// source doesn't have it, in which case it's the first of its namespaces
+const defaultBootstrapNamespace = "AWS/EC2"
+
+// bootstrapMetricsCacheTTL is how long the metrics of a custom namespace are cached for bootstrapping
+const bootstrapMetricsCacheTTL = 5 * time.Minute
+
+type cachedMetrics struct {
+	metrics []resources.Metric
+	expires time.Time
+}
+
+// bootstrapMetricsCache caches the metrics of custom namespaces by data source, region and namespace, since the
+// query editor bootstraps every time it's opened
+var bootstrapMetricsCache = struct {
+	sync.Mutex
+	metrics map[string]cachedMetrics
+}{metrics: make(map[string]cachedMetrics)}
+
+// BootstrapHandler returns the namespaces of the data source together with the metrics of the requested namespace, or
+// of the default namespace, so that the query editor loads with a single request. Only the namespaces of the data
+// source can be requested.
+func BootstrapHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	bootstrapRequest, err := resources.GetBootstrapRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in BootstrapHandler", http.StatusBadRequest, err)
+	}
+
+	reqCtx, err := reqCtxFactory(pluginCtx, "default")
+	if err != nil {
+		return nil, models.NewHttpError("error in BootstrapHandler", http.StatusInternalServerError, err)
+	}
+
+	namespaces := getNamespaces(reqCtx.Settings)
+	sort.Strings(namespaces)
+
+	namespace := services.ResolveNamespaceAlias(bootstrapRequest.Namespace)
+	if namespace == "" {
+		namespace = defaultNamespace(namespaces)
+	} else if !containsNamespace(namespaces, namespace) {
+		return nil, models.NewHttpError("error in BootstrapHandler", http.StatusBadRequest, fmt.Errorf("namespace %q is not one of the namespaces of the data source", namespace))
+	}
+
+	metrics := []resources.Metric{}
+	if namespace != "" {
+		metrics, err = bootstrapMetrics(ctx, pluginCtx, reqCtxFactory, bootstrapRequest.Region, namespace)
+		if err != nil {
+			return nil, models.NewHttpError("error in BootstrapHandler", http.StatusInternalServerError, err)
+		}
+	}
+
+	bootstrapResponse, err := json.Marshal(resources.Bootstrap{Namespaces: namespaces, Namespace: namespace, Metrics: metrics})
+	if err != nil {
+		return nil, models.NewHttpError("error in BootstrapHandler", http.StatusInternalServerError, err)
+	}
+
+	return bootstrapResponse, nil
+}
+
+// bootstrapMetrics returns the metrics of the namespace as the metrics route does. The metrics of a custom namespace
+// are listed and cached, the others are hard-coded.
+func bootstrapMetrics(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string, namespace string) ([]resources.Metric, error) {
+	if containsNamespace(services.GetHardCodedNamespaces(), namespace) {
+		metrics, err := services.GetHardCodedMetricsByNamespace(namespace)
+		if err != nil {
+			return nil, err
+		}
+		return services.AddResourceTypes(services.AddPeriods(services.AddDefaultStatistics(metrics))), nil
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+	cacheKey := fmt.Sprintf("%d/%s/%s", dataSourceID, region, namespace)
+
+	bootstrapMetricsCache.Lock()
+	cached, exists := bootstrapMetricsCache.metrics[cacheKey]
+	bootstrapMetricsCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.metrics, nil
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, region)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := service.GetMetricsByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	metrics = services.AddPeriods(services.AddDefaultStatistics(metrics))
+
+	bootstrapMetricsCache.Lock()
+	bootstrapMetricsCache.metrics[cacheKey] = cachedMetrics{metrics: metrics, expires: time.Now().Add(bootstrapMetricsCacheTTL)}
+	bootstrapMetricsCache.Unlock()
+	return metrics, nil
+}
+
+// defaultNamespace returns the namespace to bootstrap if none is requested, which is empty if there are no namespaces
+func defaultNamespace(namespaces []string) string {
+	if len(namespaces) == 0 {
+		return ""
+	}
+	if containsNamespace(namespaces, defaultBootstrapNamespace) {
+		return defaultBootstrapNamespace
+	}
+	return namespaces[0]
+}
+
+func containsNamespace(namespaces []string, namespace string) bool {
+	for _, n := range namespaces {
+		if n == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+func Test_Bootstrap_Route(t *testing.T) {
+	customNamespaces := ""
+	factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+		return models.RequestContext{
+			Settings: models.CloudWatchSettings{
+				Namespace: customNamespaces,
+			},
+		}, nil
+	}
+	origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+	t.Cleanup(func() {
+		services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+	})
+	services.GetHardCodedNamespaces = func() []string {
+		return []string{"AWS/EC2", "AWS/EBS"}
+	}
+
+	bootstrap := func(t *testing.T, query string) (int, resources.Bootstrap) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/bootstrap?"+query, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(BootstrapHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		var response resources.Bootstrap
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		}
+		return rr.Code, response
+	}
+
+	metricNames := func(metrics []resources.Metric) []string {
+		names := []string{}
+		for _, metric := range metrics {
+			names = append(names, metric.Name)
+		}
+		return names
+	}
+
+	t.Run("returns the namespaces together with the metrics of the default namespace", func(t *testing.T) {
+		customNamespaces = "CustomA"
+		code, response := bootstrap(t, "region=us-east-1")
+		require.Equal(t, http.StatusOK, code)
+
+		assert.Equal(t, []string{"AWS/EBS", "AWS/EC2", "CustomA"}, response.Namespaces)
+		assert.Equal(t, "AWS/EC2", response.Namespace)
+		// the metrics are the ones the metrics route returns for the namespace
+		expected, err := services.GetHardCodedMetricsByNamespace("AWS/EC2")
+		require.NoError(t, err)
+		assert.Equal(t, services.AddResourceTypes(services.AddPeriods(services.AddDefaultStatistics(expected))), response.Metrics)
+	})
+
+	t.Run("returns the metrics of the requested namespace", func(t *testing.T) {
+		customNamespaces = ""
+		code, response := bootstrap(t, "region=us-east-1&namespace=AWS/EBS")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "AWS/EBS", response.Namespace)
+		require.NotEmpty(t, response.Metrics)
+		assert.Equal(t, "AWS/EBS", response.Metrics[0].Namespace)
+	})
+
+	t.Run("returns the metrics of a namespace requested by its alias", func(t *testing.T) {
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/EC2", "AWS/ES"}
+		}
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = func() []string {
+				return []string{"AWS/EC2", "AWS/EBS"}
+			}
+		})
+		customNamespaces = ""
+		code, response := bootstrap(t, "region=us-east-1&namespace=OpenSearch")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "AWS/ES", response.Namespace)
+		require.NotEmpty(t, response.Metrics)
+		assert.Equal(t, "AWS/ES", response.Metrics[0].Namespace)
+	})
+
+	t.Run("lists and caches the metrics of a custom namespace", func(t *testing.T) {
+		t.Cleanup(func() {
+			bootstrapMetricsCache.metrics = make(map[string]cachedMetrics)
+		})
+		customNamespaces = "CustomA,CustomB"
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "CustomB").Return([]resources.Metric{{Namespace: "CustomB", Name: "Requests"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			code, response := bootstrap(t, "region=us-east-1&namespace=CustomB")
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, []string{"AWS/EBS", "AWS/EC2", "CustomA", "CustomB"}, response.Namespaces)
+			assert.Equal(t, "CustomB", response.Namespace)
+			assert.Equal(t, []string{"Requests"}, metricNames(response.Metrics))
+		}
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 1)
+	})
+
+	t.Run("returns 400 for a namespace the data source doesn't have", func(t *testing.T) {
+		customNamespaces = "CustomA"
+		code, _ := bootstrap(t, "region=us-east-1&namespace=CustomB")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("defaults to the first namespace without AWS/EC2", func(t *testing.T) {
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/EBS"}
+		}
+		customNamespaces = ""
+		code, response := bootstrap(t, "region=us-east-1")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "AWS/EBS", response.Namespace)
+	})
+
+	t.Run("returns 500 if the metrics of a custom namespace can't be listed", func(t *testing.T) {
+		customNamespaces = "CustomA"
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "CustomA").Return([]resources.Metric{}, fmt.Errorf("some error"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		code, _ := bootstrap(t, "region=us-east-1&namespace=CustomA")
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+
+	t.Run("returns 400 without a region", func(t *testing.T) {
+		code, _ := bootstrap(t, "namespace=AWS/EC2")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+func BulkDimensionValuesHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	bulkDimensionValuesRequest, err := resources.GetBulkDimensionValuesRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in BulkDimensionValuesHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, bulkDimensionValuesRequest.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in BulkDimensionValuesHandler", http.StatusInternalServerError, err)
+	}
+
+	response, err := service.GetDimensionValuesByDimensionKeys(ctx, bulkDimensionValuesRequest)
+	if err != nil {
+		return nil, models.NewHttpError("error in BulkDimensionValuesHandler", http.StatusInternalServerError, err)
+	}
+
+	bulkDimensionValuesResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in BulkDimensionValuesHandler", http.StatusInternalServerError, err)
+	}
+
+	return bulkDimensionValuesResponse, nil
+}
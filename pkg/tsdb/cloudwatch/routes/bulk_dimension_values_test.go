@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+func Test_BulkDimensionValues_Route(t *testing.T) {
+	t.Run("returns the values of each dimension key", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionValuesByDimensionKeys", mock.Anything, mock.MatchedBy(func(r resources.BulkDimensionValuesRequest) bool {
+			return r.ResourceRequest != nil && *r.ResourceRequest == resources.ResourceRequest{Region: "us-east-2"} &&
+				r.Namespace == "AWS/EC2" &&
+				r.MetricName == "CPUUtilization" &&
+				assert.Equal(t, []string{"InstanceId", "InstanceType"}, r.DimensionKeys)
+		})).Return(map[string][]string{"InstanceId": {"i-1", "i-2"}, "InstanceType": {"t2.micro"}}, nil).Once()
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/bulk-dimension-values?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization&dimensionKeys=["InstanceId","InstanceType"]`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(BulkDimensionValuesHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"InstanceId":["i-1","i-2"],"InstanceType":["t2.micro"]}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if no dimension keys are given", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/bulk-dimension-values?region=us-east-2&namespace=AWS/EC2&dimensionKeys=[]`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(BulkDimensionValuesHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"Message":"error in BulkDimensionValuesHandler: dimensionKeys is required","Error":"dimensionKeys is required","StatusCode":400}`, rr.Body.String())
+	})
+
+	t.Run("returns 500 if GetDimensionValuesByDimensionKeys returns an error", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionValuesByDimensionKeys", mock.Anything, mock.Anything).Return(map[string][]string{}, fmt.Errorf("some error"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/bulk-dimension-values?region=us-east-2&namespace=AWS/EC2&dimensionKeys=["InstanceId"]`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(BulkDimensionValuesHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, `{"Message":"error in BulkDimensionValuesHandler: some error","Error":"some error","StatusCode":500}`, rr.Body.String())
+	})
+}
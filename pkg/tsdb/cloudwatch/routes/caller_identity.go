@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+// CallerIdentityHandler returns the IAM principal the data source authenticates as, so that access issues can be
+// debugged by confirming which user or role the requests are made as
+func CallerIdentityHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	callerIdentityRequest, err := resources.GetCallerIdentityRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in CallerIdentityHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newCallerIdentityService(pluginCtx, reqCtxFactory, callerIdentityRequest.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in CallerIdentityHandler", http.StatusInternalServerError, err)
+	}
+
+	identity, err := service.GetCallerIdentity()
+	if err != nil {
+		status, err := callerIdentityError(err)
+		return nil, models.NewHttpError("error in CallerIdentityHandler", status, err)
+	}
+
+	response, err := json.Marshal(identity)
+	if err != nil {
+		return nil, models.NewHttpError("error in CallerIdentityHandler", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+// callerIdentityError maps the errors of AWS to the status of the response, and explains the errors caused by the
+// credentials of the data source, since they're why the identity is asked for
+func callerIdentityError(err error) (int, error) {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return http.StatusInternalServerError, err
+	}
+
+	switch awsErr.Code() {
+	case "NoCredentialProviders":
+		return http.StatusUnauthorized, fmt.Errorf("no credentials were found for the data source: %w", err)
+	case "InvalidClientTokenId", "SignatureDoesNotMatch", "UnrecognizedClientException":
+		return http.StatusUnauthorized, fmt.Errorf("the credentials of the data source are invalid: %w", err)
+	case "ExpiredToken", "ExpiredTokenException":
+		return http.StatusUnauthorized, fmt.Errorf("the credentials of the data source have expired: %w", err)
+	case "AccessDenied", "AccessDeniedException":
+		// GetCallerIdentity needs no permissions, so access is denied when assuming the role of the data source fails
+		return http.StatusForbidden, fmt.Errorf("access was denied, check that the data source is allowed to assume its role: %w", err)
+	case "Throttling", "ThrottlingException":
+		return http.StatusTooManyRequests, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+var newCallerIdentityService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.CallerIdentityProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// the identity is cached until the settings of the data source are updated, which may change its credentials
+	var dataSourceID, updated int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+		updated = pluginCtx.DataSourceInstanceSettings.Updated.UnixNano()
+	}
+
+	return services.NewCallerIdentityService(reqCtx.STSAPIProvider, fmt.Sprintf("%d/%d/%s", dataSourceID, updated, region)), nil
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CallerIdentity_Route(t *testing.T) {
+	// each test uses a region of its own, since the identity is cached by region
+	newFactory := func(fakeSTSClient *mocks.FakeSTSClient) models.RequestContextFactoryFunc {
+		return func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+			return models.RequestContext{STSAPIProvider: fakeSTSClient}, nil
+		}
+	}
+
+	t.Run("returns the identity of the data source", func(t *testing.T) {
+		fakeSTSClient := &mocks.FakeSTSClient{}
+		fakeSTSClient.On("GetCallerIdentity", mock.Anything).Return(&sts.GetCallerIdentityOutput{
+			Account: aws.String("123456789012"),
+			Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/grafana/session"),
+			UserId:  aws.String("AROAEXAMPLE:session"),
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/caller-identity?region=us-east-1", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(CallerIdentityHandler, logger, newFactory(fakeSTSClient)))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"account":"123456789012","arn":"arn:aws:sts::123456789012:assumed-role/grafana/session","userId":"AROAEXAMPLE:session"}`, rr.Body.String())
+	})
+
+	testCases := []struct {
+		desc    string
+		region  string
+		err     error
+		status  int
+		message string
+	}{
+		{desc: "returns 403 if assuming the role is denied", region: "us-east-2", err: awserr.New("AccessDenied", "User is not authorized to perform: sts:AssumeRole", nil), status: http.StatusForbidden, message: "check that the data source is allowed to assume its role"},
+		{desc: "returns 401 if the credentials are invalid", region: "us-west-1", err: awserr.New("InvalidClientTokenId", "The security token included in the request is invalid", nil), status: http.StatusUnauthorized, message: "the credentials of the data source are invalid"},
+		{desc: "returns 401 if the credentials have expired", region: "us-west-2", err: awserr.New("ExpiredToken", "The security token included in the request is expired", nil), status: http.StatusUnauthorized, message: "the credentials of the data source have expired"},
+		{desc: "returns 401 if there are no credentials", region: "eu-west-1", err: awserr.New("NoCredentialProviders", "no valid providers in chain", nil), status: http.StatusUnauthorized, message: "no credentials were found for the data source"},
+		{desc: "returns 500 for other errors", region: "eu-west-2", err: awserr.New("InternalFailure", "internal failure", nil), status: http.StatusInternalServerError, message: "internal failure"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			fakeSTSClient := &mocks.FakeSTSClient{}
+			fakeSTSClient.On("GetCallerIdentity", mock.Anything).Return(&sts.GetCallerIdentityOutput{}, tc.err)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/caller-identity?region="+tc.region, nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(CallerIdentityHandler, logger, newFactory(fakeSTSClient)))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tc.status, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.message)
+		})
+	}
+
+	t.Run("returns 400 if the region is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/caller-identity", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(CallerIdentityHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
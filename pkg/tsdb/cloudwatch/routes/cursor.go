@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor wraps the next token of a listing in an opaque cursor, so that the token returned by AWS isn't exposed
+// to the frontend. The cursor is signed with the key and bound to the scope of the listing (e.g. its data source,
+// region and namespace), so a tampered cursor, or one of another listing, is rejected by decodeCursor.
+// An empty token, i.e. no pages left, is an empty cursor.
+func encodeCursor(key []byte, scope string, nextToken string) string {
+	if nextToken == "" {
+		return ""
+	}
+
+	token := base64.RawURLEncoding.EncodeToString([]byte(nextToken))
+	signature := base64.RawURLEncoding.EncodeToString(signCursor(key, scope, nextToken))
+	return token + "." + signature
+}
+
+// decodeCursor returns the next token wrapped in a cursor created by encodeCursor with the same key and scope
+func decodeCursor(key []byte, scope string, cursor string) (string, error) {
+	token, signature, found := strings.Cut(cursor, ".")
+	if !found {
+		return "", errInvalidCursor
+	}
+
+	nextToken, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	if !hmac.Equal(mac, signCursor(key, scope, string(nextToken))) {
+		return "", errInvalidCursor
+	}
+
+	return string(nextToken), nil
+}
+
+func signCursor(key []byte, scope string, nextToken string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nextToken))
+	return mac.Sum(nil)
+}
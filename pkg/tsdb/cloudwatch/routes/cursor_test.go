@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cursor(t *testing.T) {
+	key := []byte("secret")
+	scope := "metrics/1/us-east-1/AWS/EC2"
+
+	t.Run("round trips the next token", func(t *testing.T) {
+		cursor := encodeCursor(key, scope, "aws/next+token==")
+		assert.NotContains(t, cursor, "aws/next+token==")
+
+		nextToken, err := decodeCursor(key, scope, cursor)
+		require.NoError(t, err)
+		assert.Equal(t, "aws/next+token==", nextToken)
+	})
+
+	t.Run("an empty next token is an empty cursor", func(t *testing.T) {
+		assert.Empty(t, encodeCursor(key, scope, ""))
+	})
+
+	t.Run("rejects tampered cursors", func(t *testing.T) {
+		token, signature, _ := strings.Cut(encodeCursor(key, scope, "token"), ".")
+		otherToken, _, _ := strings.Cut(encodeCursor(key, scope, "other"), ".")
+
+		for name, tampered := range map[string]string{
+			"replaced token":     otherToken + "." + signature,
+			"modified signature": token + "." + strings.ToUpper(signature),
+			"missing signature":  token,
+			"not base64":         "!!!." + signature,
+		} {
+			_, err := decodeCursor(key, scope, tampered)
+			assert.ErrorIs(t, err, errInvalidCursor, name)
+		}
+	})
+
+	t.Run("rejects cursors signed with another key or for another scope", func(t *testing.T) {
+		cursor := encodeCursor(key, scope, "token")
+
+		_, err := decodeCursor([]byte("other secret"), scope, cursor)
+		assert.ErrorIs(t, err, errInvalidCursor)
+
+		_, err = decodeCursor(key, "metrics/1/us-east-1/AWS/Lambda", cursor)
+		assert.ErrorIs(t, err, errInvalidCursor)
+	})
+}
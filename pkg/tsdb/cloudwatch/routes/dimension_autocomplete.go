@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+// DimensionAutocompleteHandler returns every dimension key of a namespace with its values in a single response, for
+// pickers that offer keys and values together
+func DimensionAutocompleteHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	dimensionAutocompleteRequest, err := resources.GetDimensionAutocompleteRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in DimensionAutocompleteHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newDimensionAutocompleteService(pluginCtx, reqCtxFactory, dimensionAutocompleteRequest.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in DimensionAutocompleteHandler", http.StatusInternalServerError, err)
+	}
+
+	values, err := service.GetDimensionAutocomplete(ctx, dimensionAutocompleteRequest.Namespace)
+	if err != nil {
+		return nil, models.NewHttpError("error in DimensionAutocompleteHandler", http.StatusInternalServerError, err)
+	}
+
+	response, err := json.Marshal(values)
+	if err != nil {
+		return nil, models.NewHttpError("error in DimensionAutocompleteHandler", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+var newDimensionAutocompleteService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.DimensionAutocompleteProvider, error) {
+	listMetricsService, err := newListMetricsService(pluginCtx, reqCtxFactory, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	return services.NewDimensionAutocompleteService(listMetricsService, fmt.Sprintf("%d/%s", dataSourceID, region)), nil
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+)
+
+func Test_DimensionAutocomplete_Route(t *testing.T) {
+	t.Run("returns the values of every dimension key of the namespace", func(t *testing.T) {
+		mockAutocompleteService := mocks.DimensionAutocompleteServiceMock{}
+		mockAutocompleteService.On("GetDimensionAutocomplete", mock.Anything, "AWS/EC2").Return(map[string][]string{"InstanceId": {"i-1", "i-2"}, "InstanceType": {"t2.micro"}}, nil)
+		newDimensionAutocompleteService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.DimensionAutocompleteProvider, error) {
+			return &mockAutocompleteService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/dimension-autocomplete?region=us-east-2&namespace=AWS/EC2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionAutocompleteHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"InstanceId":["i-1","i-2"],"InstanceType":["t2.micro"]}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if namespace is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/dimension-autocomplete?region=us-east-2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionAutocompleteHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 500 if the values can't be listed", func(t *testing.T) {
+		mockAutocompleteService := mocks.DimensionAutocompleteServiceMock{}
+		mockAutocompleteService.On("GetDimensionAutocomplete", mock.Anything, "AWS/EC2").Return(map[string][]string(nil), fmt.Errorf("access denied"))
+		newDimensionAutocompleteService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.DimensionAutocompleteProvider, error) {
+			return &mockAutocompleteService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/dimension-autocomplete?region=us-east-2&namespace=AWS/EC2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionAutocompleteHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
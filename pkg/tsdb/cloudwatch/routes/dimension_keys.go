@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -11,7 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
 )
 
-func DimensionKeysHandler(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+func DimensionKeysHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
 	dimensionKeysRequest, err := resources.GetDimensionKeysRequest(parameters)
 	if err != nil {
 		return nil, models.NewHttpError("error in DimensionKeyHandler", http.StatusBadRequest, err)
@@ -25,7 +27,7 @@ func DimensionKeysHandler(pluginCtx backend.PluginContext, reqCtxFactory models.
 	var response []string
 	switch dimensionKeysRequest.Type() {
 	case resources.FilterDimensionKeysRequest:
-		response, err = service.GetDimensionKeysByDimensionFilter(dimensionKeysRequest)
+		response, err = service.GetDimensionKeysByDimensionFilter(ctx, dimensionKeysRequest)
 	default:
 		response, err = services.GetHardCodedDimensionKeysByNamespace(dimensionKeysRequest.Namespace)
 	}
@@ -50,5 +52,22 @@ var newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory
 		return nil, err
 	}
 
-	return services.NewListMetricsService(metricClient.MetricsClientProvider), nil
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+	// the default region is cached as the region of the data source it resolves to
+	if region == "default" {
+		region = metricClient.Settings.Region
+	}
+
+	service := services.NewListMetricsService(metricClient.MetricsClientProvider)
+	return services.NewCachedListMetricsService(service, listMetricsCacheKey(dataSourceID, region, metricClient.Settings), metricClient.Settings.ListMetricsCacheTTL), nil
+}
+
+// listMetricsCacheKey identifies the account and region whose metrics are listed by the credentials and role of the
+// data source, so that metrics listed before the credentials or the role of the data source changed aren't returned
+func listMetricsCacheKey(dataSourceID int64, region string, settings models.CloudWatchSettings) string {
+	return fmt.Sprintf("%d/%s/%s/%s/%s/%s/%s", dataSourceID, settings.AuthType, settings.Profile, settings.AccessKey,
+		settings.AssumeRoleARN, settings.ExternalID, region)
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +25,7 @@ var logger = &logtest.Fake{}
 func Test_DimensionKeys_Route(t *testing.T) {
 	t.Run("calls FilterDimensionKeysRequest when a StandardDimensionKeysRequest is passed", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.MatchedBy(func(r resources.DimensionKeysRequest) bool {
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything, mock.MatchedBy(func(r resources.DimensionKeysRequest) bool {
 			return r.ResourceRequest != nil && *r.ResourceRequest == resources.ResourceRequest{Region: "us-east-2"} &&
 				r.Namespace == "AWS/EC2" &&
 				r.MetricName == "CPUUtilization" &&
@@ -66,7 +67,7 @@ func Test_DimensionKeys_Route(t *testing.T) {
 
 	t.Run("return 500 if GetDimensionKeysByDimensionFilter returns an error", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).Return([]string{}, fmt.Errorf("some error"))
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything, mock.Anything).Return([]string{}, fmt.Errorf("some error"))
 		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
 			return &mockListMetricsService, nil
 		}
@@ -77,4 +78,48 @@ func Test_DimensionKeys_Route(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		assert.Equal(t, `{"Message":"error in DimensionKeyHandler: some error","Error":"some error","StatusCode":500}`, rr.Body.String())
 	})
+
+	t.Run("returns 400 if the namespace is missing", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/dimension-keys?region=us-east-2&metricName=CPUUtilization`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionKeysHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"Message":"error in DimensionKeyHandler: namespace is required","Error":"namespace is required","StatusCode":400}`, rr.Body.String())
+		mockListMetricsService.AssertNotCalled(t, "GetDimensionKeysByDimensionFilter", mock.Anything, mock.Anything)
+	})
+}
+
+func Test_listMetricsCacheKey(t *testing.T) {
+	settings := models.CloudWatchSettings{}
+	settings.AuthType = awsds.AuthTypeKeys
+	settings.AccessKey = "AKIAOLD"
+	settings.AssumeRoleARN = "arn:aws:iam::111111111111:role/old"
+	key := listMetricsCacheKey(1, "us-east-1", settings)
+	assert.Equal(t, key, listMetricsCacheKey(1, "us-east-1", settings))
+
+	t.Run("changes with the role of the data source", func(t *testing.T) {
+		changed := settings
+		changed.AssumeRoleARN = "arn:aws:iam::222222222222:role/new"
+		assert.NotEqual(t, key, listMetricsCacheKey(1, "us-east-1", changed))
+	})
+
+	t.Run("changes with the credentials of the data source", func(t *testing.T) {
+		changed := settings
+		changed.AccessKey = "AKIANEW"
+		assert.NotEqual(t, key, listMetricsCacheKey(1, "us-east-1", changed))
+		changed = settings
+		changed.AuthType = awsds.AuthTypeSharedCreds
+		changed.Profile = "production"
+		assert.NotEqual(t, key, listMetricsCacheKey(1, "us-east-1", changed))
+	})
+
+	t.Run("changes with the data source and region", func(t *testing.T) {
+		assert.NotEqual(t, key, listMetricsCacheKey(2, "us-east-1", settings))
+		assert.NotEqual(t, key, listMetricsCacheKey(1, "us-west-2", settings))
+	})
 }
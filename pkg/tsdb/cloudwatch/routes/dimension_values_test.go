@@ -18,7 +18,7 @@ import (
 func Test_DimensionValues_Route(t *testing.T) {
 	t.Run("Calls GetDimensionValuesByDimensionFilter when a valid request is passed", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetDimensionValuesByDimensionFilter", mock.MatchedBy(func(r resources.DimensionValuesRequest) bool {
+		mockListMetricsService.On("GetDimensionValuesByDimensionFilter", mock.Anything, mock.MatchedBy(func(r resources.DimensionValuesRequest) bool {
 			return r.ResourceRequest != nil && *r.ResourceRequest == resources.ResourceRequest{Region: "us-east-2"} &&
 				r.Namespace == "AWS/EC2" &&
 				r.MetricName == "CPUUtilization" &&
@@ -38,7 +38,7 @@ func Test_DimensionValues_Route(t *testing.T) {
 
 	t.Run("returns 500 if GetDimensionValuesByDimensionFilter returns an error", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetDimensionValuesByDimensionFilter", mock.Anything).Return([]string{}, fmt.Errorf("some error"))
+		mockListMetricsService.On("GetDimensionValuesByDimensionFilter", mock.Anything, mock.Anything).Return([]string{}, fmt.Errorf("some error"))
 		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
 			return &mockListMetricsService, nil
 		}
@@ -49,4 +49,32 @@ func Test_DimensionValues_Route(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		assert.Equal(t, `{"Message":"error in DimensionValuesHandler: some error","Error":"some error","StatusCode":500}`, rr.Body.String())
 	})
+
+	t.Run("returns 400 if a dimension is in the dimension filter more than once", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/dimension-values?region=us-east-2&dimensionKey=instanceId&namespace=AWS/EC2&metricName=CPUUtilization&dimensionFilters={"NodeID":["Shared"],"NodeID":["Other"]}`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionValuesHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `dimension \"NodeID\" more than once`)
+		mockListMetricsService.AssertNotCalled(t, "GetDimensionValuesByDimensionFilter", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 if the namespace is missing", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/dimension-values?region=us-east-2&dimensionKey=InstanceId`, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(DimensionValuesHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"Message":"error in DimensionValuesHandler: namespace is required","Error":"namespace is required","StatusCode":400}`, rr.Body.String())
+		mockListMetricsService.AssertNotCalled(t, "GetDimensionValuesByDimensionFilter", mock.Anything, mock.Anything)
+	})
 }
@@ -8,14 +8,17 @@ import (
 )
 
 func respondWithError(rw http.ResponseWriter, httpError *models.HttpError) {
-	response, err := json.Marshal(httpError)
-	if err != nil {
-		rw.WriteHeader(http.StatusInternalServerError)
-		return
+	response := httpError.Body
+	if response == nil {
+		var err error
+		if response, err = json.Marshal(httpError); err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(httpError.StatusCode)
-	_, err = rw.Write(response)
+	_, err := rw.Write(response)
 	if err != nil {
 		rw.WriteHeader(http.StatusInternalServerError)
 	}
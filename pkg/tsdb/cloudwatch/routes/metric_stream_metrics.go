@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+func MetricStreamMetricsHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	metricStreamRequest, err := resources.GetMetricStreamMetricsRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricStreamMetricsHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricStreamRequest.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricStreamMetricsHandler", http.StatusInternalServerError, err)
+	}
+
+	metrics, err := service.GetMetricsByMetricStream(ctx, metricStreamRequest.StreamName)
+	if errors.Is(err, services.ErrMetricStreamNotFound) {
+		return nil, models.NewHttpError("error in MetricStreamMetricsHandler", http.StatusNotFound, err)
+	}
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricStreamMetricsHandler", http.StatusInternalServerError, err)
+	}
+
+	metricsResponse, err := json.Marshal(metrics)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricStreamMetricsHandler", http.StatusInternalServerError, err)
+	}
+
+	return metricsResponse, nil
+}
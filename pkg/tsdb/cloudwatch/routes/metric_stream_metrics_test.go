@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+func Test_MetricStreamMetrics_Route(t *testing.T) {
+	t.Run("returns the metrics of the stream", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByMetricStream", mock.Anything, "my-stream").Return([]resources.Metric{{Name: "CPUUtilization", Namespace: "AWS/EC2"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metric-stream-metrics?region=us-east-2&streamName=my-stream", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricStreamMetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"CPUUtilization","namespace":"AWS/EC2"}]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if streamName is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metric-stream-metrics?region=us-east-2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricStreamMetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 404 if the stream doesn't exist", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByMetricStream", mock.Anything, "unknown").Return([]resources.Metric{}, fmt.Errorf("%w: %q", services.ErrMetricStreamNotFound, "unknown"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metric-stream-metrics?region=us-east-2&streamName=unknown", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricStreamMetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
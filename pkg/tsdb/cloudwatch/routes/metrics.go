@@ -1,7 +1,9 @@
 package routes
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -11,34 +13,626 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
 )
 
-func MetricsHandler(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+// maxSortedMetricsResults caps the number of metrics listed to be sorted before they're paginated
+const maxSortedMetricsResults = 5000
+
+// sortedMetricsPageSize is the number of metrics in a page of a sorted listing, which is the size of a ListMetrics page
+const sortedMetricsPageSize = 500
+
+func MetricsHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
 	metricsRequest, err := resources.GetMetricsRequest(parameters)
 	if err != nil {
 		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
 	}
+	trace := newMetricsTrace(metricsRequest.Debug)
+	traceRegion(trace, pluginCtx, reqCtxFactory, metricsRequest.Region)
+
+	withDimensions := metricsRequest.IncludeTags || metricsRequest.ExpandDimensions || metricsRequest.Hierarchy || metricsRequest.Paginate || metricsRequest.IncludeLatest || metricsRequest.WithAlarms || metricsRequest.WithInsightRules || metricsRequest.InferPeriod || metricsRequest.MinDatapoints > 0
+	if metricsRequest.AccountStatus && !metricsRequest.GroupByAccount {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("accountStatus is only supported with groupByAccount"))
+	}
+
+	if len(metricsRequest.Namespaces) > 1 {
+		if metricsRequest.Paged || metricsRequest.GroupByAccount || metricsRequest.AccountId != "" || metricsRequest.Details || withDimensions {
+			return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("more than one namespace can't be combined with limit, nextToken, groupByAccount, accountId, details or the parameters listing metrics with their dimensions"))
+		}
+		return metricsOfNamespaces(ctx, pluginCtx, reqCtxFactory, metricsRequest, trace)
+	}
+
+	// namespaces can be requested by their alias, e.g. ALB for AWS/ApplicationELB
+	if namespace := services.ResolveNamespaceAlias(metricsRequest.Namespace); namespace != metricsRequest.Namespace {
+		trace.add("namespace: alias %q resolved to %q", metricsRequest.Namespace, namespace)
+		metricsRequest.Namespace = namespace
+	}
+	trace.add("request type: %s", metricsRequest.Type())
+
+	if metricsRequest.AccountId != "" {
+		if metricsRequest.Paged || metricsRequest.GroupByAccount || metricsRequest.RequireDimensions || metricsRequest.Details || withDimensions {
+			return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("accountId can't be combined with limit, nextToken, groupByAccount, requireDimensions, details or the parameters listing metrics with their dimensions"))
+		}
+		return metricsOfAccount(ctx, pluginCtx, reqCtxFactory, metricsRequest, trace)
+	}
+
+	if metricsRequest.Details && (metricsRequest.Paged || metricsRequest.GroupByAccount || withDimensions) {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("details can't be combined with limit, nextToken, groupByAccount or the parameters listing metrics with their dimensions, which are returned with their dimensions already"))
+	}
+
+	if metricsRequest.Paged {
+		if metricsRequest.GroupByAccount || withDimensions {
+			return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("limit and nextToken can't be combined with groupByAccount or the parameters listing metrics with their dimensions, which are paginated with cursor"))
+		}
+		if metricsRequest.Type() != resources.CustomNamespaceRequestType {
+			return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("limit and nextToken are only supported for custom namespaces"))
+		}
+	}
+
+	if metricsRequest.GroupByAccount {
+		return metricsByAccount(ctx, pluginCtx, reqCtxFactory, metricsRequest, trace)
+	}
+
+	if withDimensions {
+		return metricsWithDimensions(ctx, pluginCtx, reqCtxFactory, metricsRequest, trace)
+	}
 
 	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
 	if err != nil {
-		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
 	}
 
 	var metrics []resources.Metric
+	nextToken := ""
 	switch metricsRequest.Type() {
 	case resources.AllMetricsRequestType:
+		trace.add("source: hardcoded metrics of all namespaces")
 		metrics = services.GetAllHardCodedMetrics()
 	case resources.MetricsByNamespaceRequestType:
+		trace.add("source: hardcoded metrics of the namespace")
 		metrics, err = services.GetHardCodedMetricsByNamespace(metricsRequest.Namespace)
 	case resources.CustomNamespaceRequestType:
-		metrics, err = service.GetMetricsByNamespace(metricsRequest.Namespace)
+		switch {
+		case metricsRequest.Paged:
+			trace.add("source: ListMetrics, a page of at most %d metrics starting at nextToken %q", metricsRequest.Limit, metricsRequest.NextToken)
+			var reqCtx models.RequestContext
+			if reqCtx, err = reqCtxFactory(pluginCtx, metricsRequest.Region); err != nil {
+				return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+			}
+			cursorKey, cursorScope := reqCtx.CursorSigningKey, metricsCursorScope(pluginCtx, metricsRequest)
+			pageToken := ""
+			if metricsRequest.NextToken != "" {
+				if pageToken, err = decodeCursor(cursorKey, cursorScope, metricsRequest.NextToken); err != nil {
+					return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
+				}
+			}
+			metrics, pageToken, err = service.GetMetricsByNamespacePage(ctx, metricsRequest.Namespace, metricsRequest.RequireDimensions, metricsRequest.Limit, pageToken)
+			nextToken = encodeCursor(cursorKey, cursorScope, pageToken)
+		case metricsRequest.Details:
+			// the dimension keys of the metrics are only known from their dimension combinations, so they aren't cached
+			trace.add("source: ListMetrics with dimensions, all pages up to the page limit, collapsed into metrics with their dimension keys")
+			var tagged []resources.TaggedMetric
+			if tagged, err = service.GetMetricsWithAllDimensionsByNamespace(ctx, metricsRequest.Namespace); err == nil {
+				metrics = services.MetricsWithDimensionKeys(tagged)
+			}
+			if metricsRequest.RequireDimensions {
+				metrics = filterMetricsWithoutDimensionKeys(metrics)
+			}
+		case metricsRequest.RequireDimensions:
+			trace.add("source: ListMetrics, the metrics with dimensions of all pages up to the page limit")
+			metrics, err = service.GetDimensionedMetricsByNamespace(ctx, metricsRequest.Namespace)
+		case metricsRequest.Refresh:
+			trace.add("source: ListMetrics, all pages up to the page limit, bypassing the cache")
+			metrics, err = service.RefreshMetricsByNamespace(ctx, metricsRequest.Namespace)
+		default:
+			trace.add("source: ListMetrics, all pages up to the page limit, cached")
+			metrics, err = service.GetMetricsByNamespace(ctx, metricsRequest.Namespace)
+		}
+	}
+	if errors.Is(err, services.ErrInvalidNextToken) {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
+	}
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	if metricsRequest.RequireDimensions && metricsRequest.Type() != resources.CustomNamespaceRequestType {
+		metrics = services.FilterHardCodedMetricsWithoutDimensions(metrics)
+	}
+
+	if metricsRequest.Details {
+		if metricsRequest.Type() == resources.CustomNamespaceRequestType {
+			accountId, region := metricsOrigin(pluginCtx, reqCtxFactory, metricsRequest.Region, trace)
+			metrics = services.AddMetricOrigin(metrics, accountId, region)
+		} else {
+			metrics = services.AddHardCodedDimensionKeys(metrics)
+		}
+	}
+
+	metrics = services.AddPeriods(services.AddDefaultStatistics(metrics))
+	if metricsRequest.Type() != resources.CustomNamespaceRequestType {
+		// the dimensions of hardcoded metrics aren't known, so they get the primary resource type of their namespace
+		metrics = services.AddResourceTypes(metrics)
+	}
+	metrics = decorateMetrics(metrics, metricsRequest)
+
+	trace.add("result: %d metrics", len(metrics))
+
+	// existing callers expect an array, so the metrics are only returned with the next token if a page was requested
+	var response interface{} = metrics
+	if metricsRequest.Paged {
+		response = resources.MetricsPage{Metrics: metrics, NextToken: nextToken}
+	}
+
+	metricsResponse, err := trace.marshal(response)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	return metricsResponse, nil
+}
+
+// metricsByAccount lists the metrics of a custom namespace across linked accounts and returns them by owning account.
+// Account labels are resolved on a best effort basis, since only monitoring accounts are allowed to list their links.
+func metricsByAccount(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, metricsRequest *resources.MetricsRequest, trace *metricsTrace) ([]byte, *models.HttpError) {
+	if metricsRequest.Type() != resources.CustomNamespaceRequestType {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("groupByAccount is only supported for custom namespaces"))
+	}
+	if metricsRequest.RequireDimensions {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("groupByAccount can't be combined with requireDimensions"))
+	}
+	if metricsRequest.AccountStatus {
+		return metricsByAccountWithStatus(ctx, pluginCtx, reqCtxFactory, metricsRequest, trace)
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	trace.add("source: ListMetrics across the linked accounts, grouped by owning account")
+	metricsByAccount, err := service.GetMetricsByNamespaceGroupedByAccount(ctx, metricsRequest.Namespace)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
 	}
+
+	var labels map[string]string
+	if accountsService, err := newAccountsService(pluginCtx, reqCtxFactory, metricsRequest.Region); err == nil {
+		trace.add("aws: ListSinks and ListAttachedLinks to label the accounts")
+		if labels, err = accountsService.GetAccountLabels(); err != nil {
+			trace.add("accounts: not labeled: %s", err)
+		}
+	}
+
+	response := make(map[string]resources.AccountMetrics, len(metricsByAccount))
+	for accountId, metrics := range metricsByAccount {
+		metrics = decorateMetrics(services.AddPeriods(services.AddDefaultStatistics(metrics)), metricsRequest)
+		if len(metrics) == 0 {
+			continue
+		}
+		response[accountId] = resources.AccountMetrics{Label: labels[accountId], Metrics: metrics}
+	}
+	trace.add("result: metrics of %d accounts", len(response))
+
+	metricsResponse, err := trace.marshal(response)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	return metricsResponse, nil
+}
+
+// metricsOfAccount lists the metrics of a custom namespace owned by a single account, which is either the monitoring
+// account of the data source or one of its linked source accounts. Only monitoring accounts can list the metrics of
+// other accounts, so the request is rejected if the account of the data source isn't one, rather than returning no
+// metrics.
+func metricsOfAccount(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, metricsRequest *resources.MetricsRequest, trace *metricsTrace) ([]byte, *models.HttpError) {
+	if metricsRequest.Type() != resources.CustomNamespaceRequestType {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("accountId is only supported for custom namespaces"))
+	}
+
+	accountsService, err := newAccountsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+	trace.add("aws: ListSinks to check that the account of the data source is a monitoring account")
+	isMonitoringAccount, err := accountsService.IsMonitoringAccount()
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", fmt.Errorf("unable to check whether the account of the data source is a monitoring account: %w", err))
+	}
+	if !isMonitoringAccount {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("accountId is only supported if the account of the data source is a CloudWatch cross-account observability monitoring account"))
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	trace.add("source: ListMetrics across the linked accounts, owned by account %s", metricsRequest.AccountId)
+	metrics, err := service.GetMetricsByNamespaceOfAccount(ctx, metricsRequest.Namespace, metricsRequest.AccountId)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	metrics = decorateMetrics(services.AddPeriods(services.AddDefaultStatistics(metrics)), metricsRequest)
+	trace.add("result: %d metrics", len(metrics))
+
+	metricsResponse, err := trace.marshal(metrics)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	return metricsResponse, nil
+}
+
+// metricsWithDimensions lists the metrics of a namespace with their dimension values, so every combination of
+// dimension values is returned as a metric of its own. With expandDimensions all pages up to the page limit are
+// listed, otherwise only the first page. With paginate the page starting at the cursor is listed and returned together
+// with the cursor of the next page. With includeTags the tags of the resource each metric belongs to are attached, and
+// with includeLatest the latest data point of each metric. With withAlarms each metric is marked with whether it has
+// an alarm, unless the alarms can't be described, and with withInsightRules with whether Contributor Insights rules cover
+// it, unless the rules can't be described. With inferPeriod each metric is marked with the period inferred from its
+// recent data points. With docs the URL of the AWS documentation is attached to the metrics of known namespaces, and
+// with costHints the cost hint to the metrics known to be charged for, and with suggestThresholds the suggested alarm
+// threshold to the metrics that have a curated one. With requireDimensions the metrics without dimensions are left
+// out, with q the metrics whose name doesn't match it, and with minDatapoints the metrics with fewer data points in the last hour, which may leave a page with fewer
+// metrics than were listed.
+// With sort the metrics of a custom namespace are listed up to a cap, sorted as a whole and then paginated, so that the
+// order is consistent across pages. Truncated is set on every page if the cap was reached.
+// With hierarchy all pages up to the page limit are listed like with expandDimensions, and the metrics are returned as
+// a tree of their dimension combinations in the curated order of the namespace, for the UI to drill down into.
+func metricsWithDimensions(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, metricsRequest *resources.MetricsRequest, trace *metricsTrace) ([]byte, *models.HttpError) {
+	if metricsRequest.Namespace == "" {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("includeTags, includeLatest, withAlarms, withInsightRules, inferPeriod, minDatapoints, expandDimensions, hierarchy and paginate require a namespace"))
+	}
+	if metricsRequest.Paginate && metricsRequest.ExpandDimensions {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("paginate can't be combined with expandDimensions"))
+	}
+	if metricsRequest.Paginate && metricsRequest.Hierarchy {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("paginate, cursor and sort can't be combined with hierarchy, which returns all metrics as a single tree"))
+	}
+	if metricsRequest.Sort != "" && metricsRequest.Type() != resources.CustomNamespaceRequestType {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, fmt.Errorf("sort is only supported for custom namespaces"))
+	}
+
+	var cursorKey []byte
+	var cursorScope string
+	nextToken := ""
+	if metricsRequest.Paginate {
+		reqCtx, err := reqCtxFactory(pluginCtx, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		cursorKey = reqCtx.CursorSigningKey
+		cursorScope = metricsCursorScope(pluginCtx, metricsRequest)
+
+		if metricsRequest.Cursor != "" {
+			nextToken, err = decodeCursor(cursorKey, cursorScope, metricsRequest.Cursor)
+			if err != nil {
+				return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
+			}
+		}
+	}
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
 	if err != nil {
-		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	var metrics []resources.TaggedMetric
+	truncated := false
+	switch {
+	case metricsRequest.Sort != "":
+		trace.add("source: ListMetrics with dimensions, up to %d metrics sorted by %s", maxSortedMetricsResults, metricsRequest.Sort)
+		metrics, truncated, err = service.GetMetricsWithDimensionsByNamespaceUpTo(ctx, metricsRequest.Namespace, maxSortedMetricsResults)
+	case metricsRequest.ExpandDimensions || metricsRequest.Hierarchy:
+		trace.add("source: ListMetrics with dimensions, all pages up to the page limit")
+		metrics, err = service.GetMetricsWithAllDimensionsByNamespace(ctx, metricsRequest.Namespace)
+	default:
+		trace.add("source: ListMetrics with dimensions, a single page starting at the cursor")
+		metrics, nextToken, err = service.GetMetricsWithDimensionsByNamespace(ctx, metricsRequest.Namespace, nextToken)
+	}
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	if metricsRequest.ResourceType != "" || metricsRequest.RequireDimensions || metricsRequest.Query != "" {
+		filtered := []resources.TaggedMetric{}
+		for _, metric := range metrics {
+			if metricsRequest.ResourceType != "" && metric.ResourceType != metricsRequest.ResourceType {
+				continue
+			}
+			if !services.MetricNameMatchesQuery(metric.Name, metricsRequest.Query, metricsRequest.Match == resources.MetricNameMatchContains) {
+				continue
+			}
+			if metricsRequest.RequireDimensions && len(metric.Dimensions) == 0 {
+				continue
+			}
+			filtered = append(filtered, metric)
+		}
+		metrics = filtered
 	}
 
-	metricsResponse, err := json.Marshal(metrics)
+	if metricsRequest.Sort != "" {
+		// the next token of a sorted listing is the sort key of the last metric of the page
+		metrics, nextToken = services.SortAndPageMetrics(metrics, metricsRequest.Sort, nextToken, sortedMetricsPageSize)
+	}
+
+	if metricsRequest.MinDatapoints > 0 {
+		trace.add("aws: GetMetricData to count the data points of the last hour, at least %d required", metricsRequest.MinDatapoints)
+		datapointCountsService, err := newDatapointCountsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		metrics, err = datapointCountsService.FilterByMinDatapoints(ctx, metrics, metricsRequest.MinDatapoints)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	if metricsRequest.IncludeTags {
+		trace.add("aws: GetResources to attach the resource tags, cached per data source and region")
+		tagsService, err := newResourceTagsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		if err := tagsService.AddResourceTags(metrics); err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	if metricsRequest.IncludeLatest {
+		trace.add("aws: GetMetricData to attach the latest data points")
+		latestService, err := newLatestDataPointsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		if err := latestService.AddLatestDataPoints(ctx, metrics); err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	if metricsRequest.InferPeriod {
+		trace.add("aws: GetMetricData to infer the periods")
+		periodsService, err := newPeriodsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		if err := periodsService.InferPeriods(ctx, metrics); err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	if metricsRequest.WithAlarms {
+		trace.add("aws: DescribeAlarms to mark the metrics with alarms, cached per data source and region")
+		alarmsService, err := newAlarmsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		if err := alarmsService.AddAlarmFlags(metrics); err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	if metricsRequest.WithInsightRules {
+		trace.add("aws: DescribeInsightRules to mark the metrics covered by Contributor Insights rules, cached per data source and region")
+		insightRulesService, err := newInsightRulesService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+		if err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+		if err := insightRulesService.AddInsightRuleFlags(metrics); err != nil {
+			return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+		}
+	}
+
+	// the metrics have been filtered by name and resource type already
+	annotated := make([]resources.Metric, 0, len(metrics))
+	for _, metric := range metrics {
+		annotated = append(annotated, metric.Metric)
+	}
+	for i, metric := range annotateMetrics(annotated, metricsRequest) {
+		metrics[i].Metric = metric
+	}
+
+	trace.add("result: %d metrics", len(metrics))
+
+	var response interface{} = metrics
+	if metricsRequest.Paginate {
+		response = resources.TaggedMetricsPage{Metrics: metrics, NextCursor: encodeCursor(cursorKey, cursorScope, nextToken), Truncated: truncated}
+	}
+	if metricsRequest.Hierarchy {
+		if order := services.GetDimensionHierarchy(metricsRequest.Namespace); order != nil {
+			trace.add("hierarchy: curated order %v", order)
+		} else {
+			trace.add("hierarchy: no curated order, dimension keys in alphabetical order")
+		}
+		response = services.BuildDimensionHierarchy(metricsRequest.Namespace, metrics)
+	}
+
+	metricsResponse, err := trace.marshal(response)
 	if err != nil {
-		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
 	}
 
 	return metricsResponse, nil
 }
+
+// traceRegion adds the region to the trace, and the region of the data source it's resolved to if it's the default
+func traceRegion(trace *metricsTrace, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) {
+	if trace == nil {
+		return
+	}
+	if region != "default" {
+		trace.add("region: %s", region)
+		return
+	}
+
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		trace.add("region: %s, not resolved: %s", region, err)
+		return
+	}
+	trace.add("region: %s, resolved to %s", region, reqCtx.Settings.Region)
+}
+
+// metricsOrigin returns the account and region the metrics of a custom namespace are listed from. The account is the
+// one the data source authenticates as, and is left empty if it can't be resolved, since it's only informational.
+func metricsOrigin(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string, trace *metricsTrace) (string, string) {
+	if region == "default" {
+		if reqCtx, err := reqCtxFactory(pluginCtx, region); err == nil {
+			region = reqCtx.Settings.Region
+		}
+	}
+
+	service, err := newCallerIdentityService(pluginCtx, reqCtxFactory, region)
+	if err != nil {
+		trace.add("details: account not resolved: %s", err)
+		return "", region
+	}
+	trace.add("aws: GetCallerIdentity to resolve the account of the metrics, cached per data source and region")
+	identity, err := service.GetCallerIdentity()
+	if err != nil {
+		trace.add("details: account not resolved: %s", err)
+		return "", region
+	}
+	return identity.Account, region
+}
+
+// filterMetricsWithoutDimensionKeys leaves out the metrics whose details have no dimension keys
+func filterMetricsWithoutDimensionKeys(metrics []resources.Metric) []resources.Metric {
+	filtered := []resources.Metric{}
+	for _, metric := range metrics {
+		if metric.Details != nil && len(metric.Details.DimensionKeys) > 0 {
+			filtered = append(filtered, metric)
+		}
+	}
+	return filtered
+}
+
+// metricsCursorScope is the scope the cursor of a metrics listing is bound to, so it's only accepted for the next page
+// of the same listing
+func metricsCursorScope(pluginCtx backend.PluginContext, metricsRequest *resources.MetricsRequest) string {
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	scope := fmt.Sprintf("metrics/%d/%s/%s", dataSourceID, metricsRequest.Region, metricsRequest.Namespace)
+	if metricsRequest.Sort != "" {
+		// the cursor of a sorted listing is a sort key rather than a token of AWS
+		scope += "/sort=" + metricsRequest.Sort
+	}
+	if metricsRequest.Paged {
+		// the next token of a page of at most limit metrics is a token of AWS prefixed with an offset into its page
+		scope += "/paged"
+	}
+	return scope
+}
+
+// decorateMetrics filters the metrics by the name query and resource type of the request and attaches what it asks for.
+// ListMetrics can only filter by exact metric name, so the metrics of custom namespaces are filtered by their name once
+// they're listed, which they are from the cache if they're cached.
+func decorateMetrics(metrics []resources.Metric, metricsRequest *resources.MetricsRequest) []resources.Metric {
+	metrics = services.FilterMetricsByName(metrics, metricsRequest.Query, metricsRequest.Match == resources.MetricNameMatchContains)
+	if metricsRequest.ResourceType != "" {
+		metrics = services.FilterMetricsByResourceType(metrics, metricsRequest.ResourceType)
+	}
+	if metricsRequest.PromNames {
+		metrics = services.AddPrometheusNames(metrics)
+	}
+	return annotateMetrics(metrics, metricsRequest)
+}
+
+// annotateMetrics attaches the curated docs URLs, cost hints, suggested thresholds and units the request asks for,
+// which are also attached to the metrics listed with their dimensions
+func annotateMetrics(metrics []resources.Metric, metricsRequest *resources.MetricsRequest) []resources.Metric {
+	if metricsRequest.Docs {
+		metrics = services.AddDocsURLs(metrics)
+	}
+	if metricsRequest.CostHints {
+		metrics = services.AddCostHints(metrics)
+	}
+	if metricsRequest.SuggestThresholds {
+		metrics = services.AddSuggestedThresholds(metrics)
+	}
+	if metricsRequest.IncludeUnitLabels {
+		metrics = services.AddUnitLabels(metrics)
+	}
+	return metrics
+}
+
+var newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewAccountsService(reqCtx.OAMAPIProvider), nil
+}
+
+var newAlarmsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AlarmsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	return services.NewAlarmsService(reqCtx.AlarmsAPIProvider, fmt.Sprintf("%d/%s", dataSourceID, region)), nil
+}
+
+var newDatapointCountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.DatapointCountsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewDatapointCountsService(reqCtx.MetricsClientProvider), nil
+}
+
+var newInsightRulesService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.InsightRulesProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	return services.NewInsightRulesService(reqCtx.InsightRulesAPIProvider, fmt.Sprintf("%d/%s", dataSourceID, region)), nil
+}
+
+var newLatestDataPointsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.LatestDataPointsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewLatestDataPointsService(reqCtx.MetricsClientProvider), nil
+}
+
+var newPeriodsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.PeriodsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewPeriodsService(reqCtx.MetricsClientProvider), nil
+}
+
+var newResourceTagsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ResourceTagsProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	return services.NewResourceTagsService(reqCtx.ResourceTaggingAPIProvider, fmt.Sprintf("%d/%s", dataSourceID, region)), nil
+}
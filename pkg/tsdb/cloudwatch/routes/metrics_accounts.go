@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentAccountMetricsRequests limits the number of accounts whose metrics are listed concurrently
+const maxConcurrentAccountMetricsRequests = 5
+
+// metricsByAccountWithStatus lists the metrics of a custom namespace of the monitoring account and each of its linked
+// source accounts separately, so that an account whose metrics can't be listed, e.g. because access to it is denied,
+// is returned with its status and error instead of failing the request, and the metrics of the other accounts are
+// returned regardless. The request only fails if the linked accounts can't be listed.
+func metricsByAccountWithStatus(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, metricsRequest *resources.MetricsRequest, trace *metricsTrace) ([]byte, *models.HttpError) {
+	accountsService, err := newAccountsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+	trace.add("aws: ListSinks and ListAttachedLinks to list and label the linked accounts")
+	labels, err := accountsService.GetAccountLabels()
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	accountIds := make([]string, 0, len(labels)+1)
+	for accountId := range labels {
+		accountIds = append(accountIds, accountId)
+	}
+	if monitoringAccount := monitoringAccountId(pluginCtx, reqCtxFactory, metricsRequest.Region, trace); monitoringAccount != "" {
+		if _, linked := labels[monitoringAccount]; !linked {
+			accountIds = append(accountIds, monitoringAccount)
+		}
+	}
+	sort.Strings(accountIds)
+
+	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	trace.add("source: ListMetrics of each of %d accounts, with the status of each account", len(accountIds))
+	metricsByAccount := make([][]resources.Metric, len(accountIds))
+	errs := make([]error, len(accountIds))
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentAccountMetricsRequests)
+	for i, accountId := range accountIds {
+		i, accountId := i, accountId
+		eg.Go(func() error {
+			metricsByAccount[i], errs[i] = service.GetMetricsByNamespaceOfAccount(ctx, metricsRequest.Namespace, accountId)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	response := make(map[string]resources.AccountMetrics, len(accountIds))
+	for i, accountId := range accountIds {
+		accountMetrics := resources.AccountMetrics{Label: labels[accountId], Metrics: []resources.Metric{}, Status: resources.AccountStatusOK}
+		if errs[i] != nil {
+			trace.add("account %s: metrics can't be listed: %s", accountId, errs[i])
+			accountMetrics.Status, accountMetrics.ErrorCode, accountMetrics.Error = accountError(errs[i])
+		} else {
+			accountMetrics.Metrics = decorateMetrics(services.AddPeriods(services.AddDefaultStatistics(metricsByAccount[i])), metricsRequest)
+		}
+		response[accountId] = accountMetrics
+	}
+	trace.add("result: metrics of %d accounts", len(response))
+
+	metricsResponse, err := trace.marshal(response)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	return metricsResponse, nil
+}
+
+// monitoringAccountId returns the id of the account of the data source, or an empty string if it can't be resolved,
+// in which case only the metrics of the linked accounts are listed
+func monitoringAccountId(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string, trace *metricsTrace) string {
+	service, err := newCallerIdentityService(pluginCtx, reqCtxFactory, region)
+	if err != nil {
+		trace.add("accounts: monitoring account not resolved: %s", err)
+		return ""
+	}
+	trace.add("aws: GetCallerIdentity to resolve the monitoring account, cached per data source and region")
+	identity, err := service.GetCallerIdentity()
+	if err != nil {
+		trace.add("accounts: monitoring account not resolved: %s", err)
+		return ""
+	}
+	return identity.Account
+}
+
+// accountError returns the status of an account whose metrics can't be listed, with the code and message of the error.
+// Access is denied if the AWS error maps to 403.
+func accountError(err error) (string, string, string) {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return resources.AccountStatusError, "", err.Error()
+	}
+	if models.AWSErrorStatusCode(awsErr.Code()) == http.StatusForbidden {
+		return resources.AccountStatusDenied, awsErr.Code(), awsErr.Message()
+	}
+	return resources.AccountStatusError, awsErr.Code(), awsErr.Message()
+}
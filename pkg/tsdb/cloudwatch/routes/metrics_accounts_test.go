@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Metrics_Route_AccountStatus(t *testing.T) {
+	origNewListMetricsService, origNewAccountsService, origNewCallerIdentityService := newListMetricsService, newAccountsService, newCallerIdentityService
+	t.Cleanup(func() {
+		newListMetricsService, newAccountsService, newCallerIdentityService = origNewListMetricsService, origNewAccountsService, origNewCallerIdentityService
+	})
+	stubAccounts := func(labels map[string]string, labelsErr error, monitoringAccount string, identityErr error) {
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("GetAccountLabels").Return(labels, labelsErr)
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		mockCallerIdentityService := mocks.CallerIdentityServiceMock{}
+		mockCallerIdentityService.On("GetCallerIdentity").Return(resources.CallerIdentity{Account: monitoringAccount}, identityErr)
+		newCallerIdentityService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.CallerIdentityProvider, error) {
+			return &mockCallerIdentityService, nil
+		}
+	}
+
+	t.Run("returns the status of each account with the metrics of the accessible ones", func(t *testing.T) {
+		stubAccounts(map[string]string{"111111111111": "production", "222222222222": "staging", "333333333333": "sandbox"}, nil, "999999999999", nil)
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "999999999999").Return([]resources.Metric{{Namespace: "MyApp", Name: "Requests", AccountId: "999999999999"}}, nil)
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "111111111111").Return([]resources.Metric{{Namespace: "MyApp", Name: "Latency", AccountId: "111111111111"}}, nil)
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "222222222222").Return([]resources.Metric{}, awserr.New("AccessDeniedException", "not authorized to perform: cloudwatch:ListMetrics", nil))
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "333333333333").Return([]resources.Metric{}, fmt.Errorf("connection reset"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&groupByAccount=true&accountStatus=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"999999999999": {"status": "ok", "metrics": [{"name":"Requests","namespace":"MyApp","accountId":"999999999999"}]},
+			"111111111111": {"label": "production", "status": "ok", "metrics": [{"name":"Latency","namespace":"MyApp","accountId":"111111111111"}]},
+			"222222222222": {"label": "staging", "status": "denied", "errorCode": "AccessDeniedException", "error": "not authorized to perform: cloudwatch:ListMetrics", "metrics": []},
+			"333333333333": {"label": "sandbox", "status": "error", "error": "connection reset", "metrics": []}
+		}`, rr.Body.String())
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespaceOfAccount", 4)
+	})
+
+	t.Run("returns an accessible account without metrics", func(t *testing.T) {
+		stubAccounts(map[string]string{"111111111111": "production"}, nil, "111111111111", nil)
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "111111111111").Return([]resources.Metric{}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&groupByAccount=true&accountStatus=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"111111111111": {"label": "production", "status": "ok", "metrics": []}}`, rr.Body.String())
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespaceOfAccount", 1)
+	})
+
+	t.Run("lists the linked accounts only if the monitoring account can't be resolved", func(t *testing.T) {
+		stubAccounts(map[string]string{"111111111111": "production"}, nil, "", fmt.Errorf("no credentials"))
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "MyApp", "111111111111").Return([]resources.Metric{{Namespace: "MyApp", Name: "Latency", AccountId: "111111111111"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&groupByAccount=true&accountStatus=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"111111111111": {"label": "production", "status": "ok", "metrics": [{"name":"Latency","namespace":"MyApp","accountId":"111111111111"}]}}`, rr.Body.String())
+	})
+
+	t.Run("fails if the linked accounts can't be listed", func(t *testing.T) {
+		stubAccounts(map[string]string{}, fmt.Errorf("unable to list sinks: %w", awserr.New("AccessDeniedException", "not authorized to perform: oam:ListSinks", nil)), "999999999999", nil)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&groupByAccount=true&accountStatus=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"AWSErrorCode":"AccessDeniedException"`)
+	})
+
+	t.Run("returns 400 if accountStatus is used without groupByAccount", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&accountStatus=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "accountStatus is only supported with groupByAccount")
+	})
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+// MetricsHealthHandler checks that the credentials of the data source are allowed to list metrics in a region, so
+// that a missing permission is reported when the data source is configured rather than when metrics are browsed.
+// A failure is returned as a health of its own, with the code and message of the AWS error verbatim.
+func MetricsHealthHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	metricsHealthRequest, err := resources.GetMetricsHealthRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricsHealthHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newMetricsHealthService(pluginCtx, reqCtxFactory, metricsHealthRequest.Region)
+	if err != nil {
+		return nil, metricsHealthError(err)
+	}
+
+	if err := service.CheckListMetrics(ctx); err != nil {
+		return nil, metricsHealthError(err)
+	}
+
+	response, err := json.Marshal(resources.MetricsHealth{Status: "ok"})
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricsHealthHandler", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+// metricsHealthError returns the failed health check as the body of the error, with the status the AWS error maps to.
+// Errors of the credentials themselves are 401, unlike in other routes, since they're what's being checked.
+func metricsHealthError(err error) *models.HttpError {
+	health := resources.MetricsHealth{Status: "error", Message: err.Error()}
+	status := http.StatusInternalServerError
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		health.Code = awsErr.Code()
+		health.Message = awsErr.Message()
+		switch awsErr.Code() {
+		case "NoCredentialProviders", "InvalidClientTokenId", "SignatureDoesNotMatch", "UnrecognizedClientException",
+			"ExpiredToken", "ExpiredTokenException":
+			status = http.StatusUnauthorized
+		default:
+			status = models.AWSErrorStatusCode(awsErr.Code())
+		}
+	}
+
+	httpError := models.NewHttpError("error in MetricsHealthHandler", status, err)
+	body, err := json.Marshal(health)
+	if err == nil {
+		httpError.Body = body
+	}
+	return httpError
+}
+
+var newMetricsHealthService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.MetricsHealthProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewMetricsHealthService(reqCtx.MetricsClientProvider), nil
+}
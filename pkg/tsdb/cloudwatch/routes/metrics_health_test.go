@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_MetricsHealth_Route(t *testing.T) {
+	var requestedRegion string
+	newFactory := func(fakeMetricsClient *mocks.FakeMetricsClient) models.RequestContextFactoryFunc {
+		return func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+			requestedRegion = region
+			return models.RequestContext{MetricsClientProvider: fakeMetricsClient}, nil
+		}
+	}
+
+	t.Run("returns ok if the metrics can be listed", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, "", nil)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics-health?region=us-east-1", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHealthHandler, logger, newFactory(fakeMetricsClient)))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+		assert.Equal(t, "us-east-1", requestedRegion)
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsPage", 1)
+	})
+
+	t.Run("checks the default region if the region is missing", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, "", nil)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics-health", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHealthHandler, logger, newFactory(fakeMetricsClient)))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "default", requestedRegion)
+	})
+
+	testCases := []struct {
+		desc   string
+		err    error
+		status int
+		body   string
+	}{
+		{
+			desc:   "returns 403 and the AWS error if listing metrics is denied",
+			err:    awserr.New("AccessDenied", "User is not authorized to perform: cloudwatch:ListMetrics", nil),
+			status: http.StatusForbidden,
+			body:   `{"status":"error","code":"AccessDenied","message":"User is not authorized to perform: cloudwatch:ListMetrics"}`,
+		},
+		{
+			desc:   "returns 401 and the AWS error if the credentials are invalid",
+			err:    awserr.New("InvalidClientTokenId", "The security token included in the request is invalid", nil),
+			status: http.StatusUnauthorized,
+			body:   `{"status":"error","code":"InvalidClientTokenId","message":"The security token included in the request is invalid"}`,
+		},
+		{
+			desc:   "returns 429 and the AWS error if throttled",
+			err:    awserr.New("Throttling", "Rate exceeded", nil),
+			status: http.StatusTooManyRequests,
+			body:   `{"status":"error","code":"Throttling","message":"Rate exceeded"}`,
+		},
+		{
+			desc:   "returns 500 without a code for errors other than AWS errors",
+			err:    errors.New("connection reset"),
+			status: http.StatusInternalServerError,
+			body:   `{"status":"error","message":"connection reset"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			fakeMetricsClient := &mocks.FakeMetricsClient{}
+			fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, "", tc.err)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics-health?region=us-east-1", nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHealthHandler, logger, newFactory(fakeMetricsClient)))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tc.status, rr.Code)
+			assert.JSONEq(t, tc.body, rr.Body.String())
+		})
+	}
+
+	t.Run("returns the error if the request context can't be created", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics-health?region=us-east-1", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHealthHandler, logger, func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+			return models.RequestContext{}, awserr.New("NoCredentialProviders", "no valid providers in chain", nil)
+		}))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.JSONEq(t, `{"status":"error","code":"NoCredentialProviders","message":"no valid providers in chain"}`, rr.Body.String())
+	})
+}
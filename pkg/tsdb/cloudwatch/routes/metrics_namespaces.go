@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentNamespaceMetricsRequests limits the number of custom namespaces whose metrics are listed concurrently
+const maxConcurrentNamespaceMetricsRequests = 5
+
+type metricKey struct {
+	namespace string
+	name      string
+}
+
+// metricsOfNamespaces lists the metrics of more than one namespace and merges them into a single list, in the order
+// the namespaces were requested, without duplicates. The metrics of custom namespaces are listed concurrently. A
+// namespace whose metrics can't be listed is left out and added to the trace, unless none of them can be listed.
+func metricsOfNamespaces(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, metricsRequest *resources.MetricsRequest, trace *metricsTrace) ([]byte, *models.HttpError) {
+	// namespaces can be requested by their alias, which may resolve to a namespace that's requested too
+	namespaces := make([]string, 0, len(metricsRequest.Namespaces))
+	seen := map[string]bool{}
+	for _, namespace := range metricsRequest.Namespaces {
+		if resolved := services.ResolveNamespaceAlias(namespace); resolved != namespace {
+			trace.add("namespace: alias %q resolved to %q", namespace, resolved)
+			namespace = resolved
+		}
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	trace.add("request type: metrics of %d namespaces", len(namespaces))
+
+	var service models.ListMetricsProvider
+	for _, namespace := range namespaces {
+		if !services.IsHardCodedNamespace(namespace) {
+			var err error
+			if service, err = newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region); err != nil {
+				return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+			}
+			break
+		}
+	}
+
+	metricsByNamespace := make([][]resources.Metric, len(namespaces))
+	errs := make([]error, len(namespaces))
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentNamespaceMetricsRequests)
+	for i, namespace := range namespaces {
+		i, namespace := i, namespace
+		if services.IsHardCodedNamespace(namespace) {
+			trace.add("source of %s: hardcoded metrics of the namespace", namespace)
+			metricsByNamespace[i], errs[i] = hardCodedMetricsOfNamespace(namespace, metricsRequest.RequireDimensions)
+			continue
+		}
+
+		switch {
+		case metricsRequest.RequireDimensions:
+			trace.add("source of %s: ListMetrics, the metrics with dimensions of all pages up to the page limit", namespace)
+		case metricsRequest.Refresh:
+			trace.add("source of %s: ListMetrics, all pages up to the page limit, bypassing the cache", namespace)
+		default:
+			trace.add("source of %s: ListMetrics, all pages up to the page limit, cached", namespace)
+		}
+		eg.Go(func() error {
+			switch {
+			case metricsRequest.RequireDimensions:
+				metricsByNamespace[i], errs[i] = service.GetDimensionedMetricsByNamespace(ctx, namespace)
+			case metricsRequest.Refresh:
+				metricsByNamespace[i], errs[i] = service.RefreshMetricsByNamespace(ctx, namespace)
+			default:
+				metricsByNamespace[i], errs[i] = service.GetMetricsByNamespace(ctx, namespace)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	metrics := []resources.Metric{}
+	seenMetrics := map[metricKey]bool{}
+	var failures []string
+	for i, namespace := range namespaces {
+		if errs[i] != nil {
+			trace.add("namespace %s: left out, its metrics can't be listed: %s", namespace, errs[i])
+			failures = append(failures, fmt.Sprintf("%s: %s", namespace, errs[i]))
+			continue
+		}
+		for _, metric := range metricsByNamespace[i] {
+			key := metricKey{namespace: metric.Namespace, name: metric.Name}
+			if !seenMetrics[key] {
+				seenMetrics[key] = true
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+	if len(failures) == len(namespaces) {
+		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, fmt.Errorf("the metrics of none of the namespaces can be listed: %s", strings.Join(failures, "; ")))
+	}
+
+	metrics = decorateMetrics(services.AddPeriods(services.AddDefaultStatistics(metrics)), metricsRequest)
+	trace.add("result: %d metrics", len(metrics))
+
+	metricsResponse, err := trace.marshal(metrics)
+	if err != nil {
+		return nil, models.NewAWSHttpError("error in MetricsHandler", err)
+	}
+
+	return metricsResponse, nil
+}
+
+// hardCodedMetricsOfNamespace returns the hardcoded metrics of a namespace with the primary resource type of the
+// namespace, since their dimensions aren't known
+func hardCodedMetricsOfNamespace(namespace string, requireDimensions bool) ([]resources.Metric, error) {
+	metrics, err := services.GetHardCodedMetricsByNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if requireDimensions {
+		metrics = services.FilterHardCodedMetricsWithoutDimensions(metrics)
+	}
+	return services.AddResourceTypes(metrics), nil
+}
@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Metrics_Route_MultipleNamespaces(t *testing.T) {
+	origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+	t.Cleanup(func() {
+		services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+	})
+	services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+		return []resources.Metric{{Namespace: namespace, Name: "CPUUtilization"}}, nil
+	}
+
+	t.Run("merges the metrics of hardcoded and custom namespaces without duplicates", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{{Namespace: "MyApp", Name: "Latency"}, {Namespace: "MyApp", Name: "Latency"}, {Namespace: "MyApp", Name: "CPUUtilization"}}, nil)
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "OtherApp").Return([]resources.Metric{{Namespace: "OtherApp", Name: "Latency"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2,MyApp&namespace=OtherApp&namespace=MyApp", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"ec2:instance","period":300},
+			{"name":"Latency","namespace":"MyApp"},
+			{"name":"CPUUtilization","namespace":"MyApp"},
+			{"name":"Latency","namespace":"OtherApp"}
+		]`, rr.Body.String())
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 2)
+	})
+
+	t.Run("resolves aliases to the namespaces they stand for", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=ALB,AWS/ApplicationELB,AWS/Lambda", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/ApplicationELB","resourceType":"elasticloadbalancing:loadbalancer"},
+			{"name":"CPUUtilization","namespace":"AWS/Lambda","resourceType":"lambda:function"}
+		]`, rr.Body.String())
+	})
+
+	t.Run("leaves out the namespaces whose metrics can't be listed", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{}, fmt.Errorf("throttled"))
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "OtherApp").Return([]resources.Metric{{Namespace: "OtherApp", Name: "Latency"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp,OtherApp&debug=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"result":[{"name":"Latency","namespace":"OtherApp"}]`)
+		assert.Contains(t, rr.Body.String(), `namespace MyApp: left out, its metrics can't be listed: throttled`)
+	})
+
+	t.Run("returns 500 if the metrics of none of the namespaces can be listed", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{}, fmt.Errorf("throttled"))
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "OtherApp").Return([]resources.Metric{}, fmt.Errorf("access denied"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp,OtherApp", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), "MyApp: throttled; OtherApp: access denied")
+	})
+
+	t.Run("lists the metrics of custom namespaces concurrently up to the limit", func(t *testing.T) {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}).Return([]resources.Metric{}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=A,B,C,D,E,F,G,H", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 8)
+		assert.Greater(t, maxRunning, 1)
+		assert.LessOrEqual(t, maxRunning, maxConcurrentNamespaceMetricsRequests)
+	})
+
+	t.Run("returns 400 if more than one namespace is combined with paging", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp,OtherApp&limit=10", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "more than one namespace can't be combined with")
+	})
+
+	t.Run("returns 400 if more than one namespace is combined with the dimensions of the metrics", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&namespace=OtherApp&expandDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
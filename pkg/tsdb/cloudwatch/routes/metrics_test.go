@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 	"github.com/stretchr/testify/assert"
@@ -21,7 +26,7 @@ import (
 func Test_Metrics_Route(t *testing.T) {
 	t.Run("calls GetMetricsByNamespace when a CustomNamespaceRequestType is passed", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything).Return([]resources.Metric{}, nil)
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, mock.Anything).Return([]resources.Metric{}, nil)
 		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
 			return &mockListMetricsService, nil
 		}
@@ -32,6 +37,33 @@ func Test_Metrics_Route(t *testing.T) {
 		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 1)
 	})
 
+	t.Run("calls RefreshMetricsByNamespace for a custom namespace when refresh is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("RefreshMetricsByNamespace", mock.Anything, "customNamespace").Return([]resources.Metric{{Namespace: "customNamespace", Name: "Latency"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&refresh=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"customNamespace"}]`, rr.Body.String())
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsByNamespace", mock.Anything, mock.Anything)
+	})
+
+	t.Run("resolves a namespace alias to the hardcoded metrics of its namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=ALB", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		res := []resources.Metric{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.NotEmpty(t, res)
+		assert.Equal(t, "AWS/ApplicationELB", res[0].Namespace)
+	})
+
 	t.Run("calls GetAllHardCodedMetrics when a AllMetricsRequestType is passed", func(t *testing.T) {
 		origGetAllHardCodedMetrics := services.GetAllHardCodedMetrics
 		t.Cleanup(func() {
@@ -77,7 +109,7 @@ func Test_Metrics_Route(t *testing.T) {
 
 	t.Run("returns 500 if GetMetricsByNamespace returns an error", func(t *testing.T) {
 		mockListMetricsService := mocks.ListMetricsServiceMock{}
-		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything).Return([]resources.Metric{}, fmt.Errorf("some error"))
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, mock.Anything).Return([]resources.Metric{}, fmt.Errorf("some error"))
 		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
 			return &mockListMetricsService, nil
 		}
@@ -88,4 +120,1248 @@ func Test_Metrics_Route(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		assert.Equal(t, `{"Message":"error in MetricsHandler: some error","Error":"some error","StatusCode":500}`, rr.Body.String())
 	})
+
+	t.Run("attaches prometheus names when promNames is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&promNames=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.JSONEq(t, `[{"name":"CPUUtilization","namespace":"AWS/EC2","prometheusName":"aws_ec2_cpu_utilization","defaultStatistic":"Average","resourceType":"ec2:instance","period":300}]`, rr.Body.String())
+	})
+
+	t.Run("attaches curated default statistics and periods", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/Lambda", Name: "Invocations"}, {Namespace: "AWS/Lambda", Name: "IteratorAge"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/Lambda", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.JSONEq(t, `[{"name":"Invocations","namespace":"AWS/Lambda","defaultStatistic":"Sum","resourceType":"lambda:function","period":60},{"name":"IteratorAge","namespace":"AWS/Lambda","resourceType":"lambda:function","period":60}]`, rr.Body.String())
+	})
+
+	t.Run("filters metrics by resource type", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, mock.Anything).Return([]resources.Metric{
+			{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"},
+			{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "autoscaling:autoScalingGroup"},
+			{Namespace: "AWS/EC2", Name: "NetworkIn", ResourceType: "ec2:instance"},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&resourceType=autoscaling:autoScalingGroup", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.JSONEq(t, `[{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"autoscaling:autoScalingGroup","period":300}]`, rr.Body.String())
+	})
+
+	t.Run("calls GetDimensionedMetricsByNamespace for a custom namespace when requireDimensions is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionedMetricsByNamespace", mock.Anything, "customNamespace").Return([]resources.Metric{{Namespace: "customNamespace", Name: "Latency"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&requireDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"customNamespace"}]`, rr.Body.String())
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsByNamespace", mock.Anything, mock.Anything)
+	})
+
+	t.Run("leaves out hardcoded metrics of namespaces without dimensions when requireDimensions is true", func(t *testing.T) {
+		origGetAllHardCodedMetrics := services.GetAllHardCodedMetrics
+		t.Cleanup(func() {
+			services.GetAllHardCodedMetrics = origGetAllHardCodedMetrics
+		})
+		services.GetAllHardCodedMetrics = func() []resources.Metric {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/SES", Name: "Bounce"}}
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&requireDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		res := []resources.Metric{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.Len(t, res, 1)
+		assert.Equal(t, "AWS/EC2", res[0].Namespace)
+
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/SES&requireDimensions=true", nil)
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+
+	t.Run("includes hardcoded metrics of namespaces without dimensions by default", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/SES", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		res := []resources.Metric{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.NotEmpty(t, res)
+	})
+
+	t.Run("leaves out metrics without dimensions from metrics with dimensions when requireDimensions is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "MyApp").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&expandDimensions=true&requireDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"MyApp","dimensions":{"Service":"api"}}]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if groupByAccount is combined with requireDimensions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&groupByAccount=true&requireDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("groups metrics by account and resolves account labels when groupByAccount is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceGroupedByAccount", mock.Anything, "customNamespace").Return(map[string][]resources.Metric{
+			"111111111111": {{Namespace: "customNamespace", Name: "Latency"}},
+			"222222222222": {{Namespace: "customNamespace", Name: "Errors"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("GetAccountLabels").Return(map[string]string{"111111111111": "production"}, nil)
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&groupByAccount=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"111111111111": {"label": "production", "metrics": [{"name":"Latency","namespace":"customNamespace"}]},
+			"222222222222": {"metrics": [{"name":"Errors","namespace":"customNamespace"}]}
+		}`, rr.Body.String())
+	})
+
+	t.Run("groups metrics by account without labels if the labels can't be resolved", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceGroupedByAccount", mock.Anything, "customNamespace").Return(map[string][]resources.Metric{
+			"111111111111": {{Namespace: "customNamespace", Name: "Latency"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("GetAccountLabels").Return(map[string]string{}, fmt.Errorf("access denied"))
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&groupByAccount=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"111111111111": {"metrics": [{"name":"Latency","namespace":"customNamespace"}]}}`, rr.Body.String())
+	})
+
+	t.Run("lists the metrics owned by the account when accountId is given", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespaceOfAccount", mock.Anything, "customNamespace", "111111111111").Return([]resources.Metric{
+			{Namespace: "customNamespace", Name: "Latency", AccountId: "111111111111"},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("IsMonitoringAccount").Return(true, nil)
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&accountId=111111111111", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"customNamespace","accountId":"111111111111"}]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if accountId is given but the account of the data source isn't a monitoring account", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("IsMonitoringAccount").Return(false, nil)
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&accountId=111111111111", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "monitoring account")
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsByNamespaceOfAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 500 if it can't be checked whether the account of the data source is a monitoring account", func(t *testing.T) {
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("IsMonitoringAccount").Return(false, fmt.Errorf("access denied"))
+		newAccountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AccountsProvider, error) {
+			return &mockAccountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&accountId=111111111111", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("returns 400 if accountId is used for a non custom namespace or combined with groupByAccount", func(t *testing.T) {
+		for _, query := range []string{"namespace=AWS/EC2&accountId=111111111111", "namespace=customNamespace&accountId=111111111111&groupByAccount=true", "namespace=customNamespace&accountId=111111111111&includeTags=true", "namespace=customNamespace,other&accountId=111111111111"} {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics?region=us-east-2&"+query, nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("maps the errors of AWS to the status and returns their code and message", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{awserr.New("Throttling", "Rate exceeded", nil), http.StatusTooManyRequests},
+			{awserr.New("AccessDenied", "not authorized to perform cloudwatch:ListMetrics", nil), http.StatusForbidden},
+			{awserr.New("InvalidParameterValue", "invalid namespace", nil), http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			mockListMetricsService := mocks.ListMetricsServiceMock{}
+			mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "customNamespace").Return([]resources.Metric{}, fmt.Errorf("unable to call AWS API: %w", tt.err))
+			newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+				return &mockListMetricsService, nil
+			}
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace", nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.status, rr.Code)
+
+			var body models.HttpError
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			awsErr := tt.err.(awserr.Error)
+			assert.Equal(t, awsErr.Code(), body.AWSErrorCode)
+			assert.Equal(t, awsErr.Message(), body.AWSErrorMessage)
+		}
+	})
+
+	t.Run("returns 500 without AWS details for other errors", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "customNamespace").Return([]resources.Metric{}, fmt.Errorf("connection reset"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "AWSErrorCode")
+	})
+
+	t.Run("attaches the dimension keys of the namespace to hardcoded metrics when details is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/DAX", Name: "CPUUtilization"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/DAX&details=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"CPUUtilization","namespace":"AWS/DAX","details":{"dimensionKeys":["Account","ClusterId","NodeId"]}}]`, rr.Body.String())
+	})
+
+	t.Run("attaches the dimension keys, account and region to the metrics of a custom namespace when details is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "customNamespace").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "customNamespace", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "customNamespace", Name: "Latency"}, Dimensions: map[string]string{"Service": "web", "Environment": "prod"}},
+			{Metric: resources.Metric{Namespace: "customNamespace", Name: "Errors"}, Dimensions: map[string]string{}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		origNewCallerIdentityService := newCallerIdentityService
+		t.Cleanup(func() {
+			newCallerIdentityService = origNewCallerIdentityService
+		})
+		mockCallerIdentityService := mocks.CallerIdentityServiceMock{}
+		mockCallerIdentityService.On("GetCallerIdentity").Return(resources.CallerIdentity{Account: "123456789012"}, nil)
+		newCallerIdentityService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.CallerIdentityProvider, error) {
+			return &mockCallerIdentityService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&details=true&requireDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"customNamespace","details":{"dimensionKeys":["Environment","Service"],"accountId":"123456789012","region":"us-east-2"}}]`, rr.Body.String())
+	})
+
+	t.Run("attaches the details without the account if it can't be resolved", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "customNamespace").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "customNamespace", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		origNewCallerIdentityService := newCallerIdentityService
+		t.Cleanup(func() {
+			newCallerIdentityService = origNewCallerIdentityService
+		})
+		mockCallerIdentityService := mocks.CallerIdentityServiceMock{}
+		mockCallerIdentityService.On("GetCallerIdentity").Return(resources.CallerIdentity{}, fmt.Errorf("access denied"))
+		newCallerIdentityService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.CallerIdentityProvider, error) {
+			return &mockCallerIdentityService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&details=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"customNamespace","details":{"dimensionKeys":["Service"],"region":"us-east-2"}}]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if details is combined with limit or the parameters listing metrics with their dimensions", func(t *testing.T) {
+		for _, query := range []string{"namespace=customNamespace&details=true&limit=10", "namespace=customNamespace&details=true&expandDimensions=true", "namespace=customNamespace,other&details=true"} {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics?region=us-east-2&"+query, nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("returns 400 if groupByAccount is used for a non custom namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&groupByAccount=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("attaches resource tags to the metrics when includeTags is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "autoscaling:autoScalingGroup"}, Dimensions: map[string]string{"AutoScalingGroupName": "asg"}},
+		}, "token-2", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockResourceTagsService := mocks.ResourceTagsServiceMock{}
+		mockResourceTagsService.On("AddResourceTags", mock.Anything).Run(func(args mock.Arguments) {
+			metrics := args.Get(0).([]resources.TaggedMetric)
+			require.Len(t, metrics, 1)
+			metrics[0].Tags = map[string]string{"team": "a"}
+		}).Return(nil)
+		newResourceTagsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ResourceTagsProvider, error) {
+			return &mockResourceTagsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&resourceType=ec2:instance&includeTags=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-1"},"tags":{"team":"a"}}]`, rr.Body.String())
+	})
+
+	t.Run("returns 500 if the resource tags can't be listed", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockResourceTagsService := mocks.ResourceTagsServiceMock{}
+		mockResourceTagsService.On("AddResourceTags", mock.Anything).Return(fmt.Errorf("access denied"))
+		newResourceTagsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ResourceTagsProvider, error) {
+			return &mockResourceTagsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&includeTags=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("returns 400 if includeTags is used without a namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&includeTags=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns every dimension combination without collapsing when expandDimensions is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/EC2").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockResourceTagsService := mocks.ResourceTagsServiceMock{}
+		newResourceTagsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ResourceTagsProvider, error) {
+			return &mockResourceTagsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&expandDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-1"}},
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-2"}}
+		]`, rr.Body.String())
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsWithDimensionsByNamespace", mock.Anything, mock.Anything, mock.Anything)
+		mockResourceTagsService.AssertNotCalled(t, "AddResourceTags", mock.Anything)
+	})
+
+	t.Run("returns a page of metrics with an opaque cursor for the next page when paginate is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}, "aws-token-2", nil)
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "aws-token-2").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&paginate=true", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var page resources.TaggedMetricsPage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		require.Len(t, page.Metrics, 1)
+		assert.Equal(t, "i-1", page.Metrics[0].Dimensions["InstanceId"])
+		require.NotEmpty(t, page.NextCursor)
+		assert.NotContains(t, rr.Body.String(), "aws-token-2")
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&cursor="+page.NextCursor, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"metrics":[{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-2"}}]}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 for a tampered cursor", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+
+		cursor := encodeCursor([]byte("secret"), "metrics/0/us-east-2/AWS/EC2", "aws-token-2")
+		tampered := encodeCursor([]byte("secret"), "metrics/0/us-east-2/AWS/EC2", "aws-token-3")
+		token, _, _ := strings.Cut(tampered, ".")
+		_, signature, _ := strings.Cut(cursor, ".")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&cursor="+token+"."+signature, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsWithDimensionsByNamespace", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns the pages of a custom namespace in a globally consistent order when sort is set", func(t *testing.T) {
+		metrics := make([]resources.TaggedMetric, 0, 1200)
+		for i := 1200; i > 0; i-- {
+			metrics = append(metrics, resources.TaggedMetric{Metric: resources.Metric{Namespace: "MyApp", Name: fmt.Sprintf("Metric%02d", i%7)}, Dimensions: map[string]string{"Id": fmt.Sprintf("%04d", i)}})
+		}
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespaceUpTo", mock.Anything, "MyApp", maxSortedMetricsResults).Return(metrics, false, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+
+		var listed []string
+		cursor := ""
+		for pages := 1; ; pages++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&sort=name&cursor="+cursor, nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var page resources.TaggedMetricsPage
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+			require.LessOrEqual(t, len(page.Metrics), sortedMetricsPageSize)
+			assert.False(t, page.Truncated)
+			for _, metric := range page.Metrics {
+				listed = append(listed, metric.Name+"/"+metric.Dimensions["Id"])
+			}
+			if page.NextCursor == "" {
+				require.Equal(t, 3, pages)
+				break
+			}
+			cursor = page.NextCursor
+		}
+
+		require.Len(t, listed, len(metrics))
+		assert.True(t, sort.StringsAreSorted(listed))
+	})
+
+	t.Run("rejects the cursor of a sorted listing without sort", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+
+		cursor := encodeCursor([]byte("secret"), "metrics/0/us-east-2/MyApp/sort=name", "Latency")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&cursor="+cursor, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsWithDimensionsByNamespace", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 if sort is used for a non custom namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&sort=name", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns the metrics as a tree of their dimensions when hierarchy is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/ApplicationELB").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/ApplicationELB", Name: "HealthyHostCount"}, Dimensions: map[string]string{"LoadBalancer": "app/web", "TargetGroup": "targetgroup/api"}},
+			{Metric: resources.Metric{Namespace: "AWS/ApplicationELB", Name: "RequestCount"}, Dimensions: map[string]string{"LoadBalancer": "app/web"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=ALB&hierarchy=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"order": ["LoadBalancer", "TargetGroup", "AvailabilityZone"],
+			"children": [{
+				"dimension": "LoadBalancer", "value": "app/web",
+				"metrics": [{"name":"RequestCount","namespace":"AWS/ApplicationELB","dimensions":{"LoadBalancer":"app/web"}}],
+				"children": [{
+					"dimension": "TargetGroup", "value": "targetgroup/api",
+					"metrics": [{"name":"HealthyHostCount","namespace":"AWS/ApplicationELB","dimensions":{"LoadBalancer":"app/web","TargetGroup":"targetgroup/api"}}]
+				}]
+			}]
+		}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if hierarchy is combined with paginate", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/ApplicationELB&paginate=true&hierarchy=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 400 if paginate is combined with expandDimensions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&paginate=true&expandDimensions=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("attaches the latest data point to the metrics when includeLatest is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockLatestDataPointsService := mocks.LatestDataPointsServiceMock{}
+		mockLatestDataPointsService.On("AddLatestDataPoints", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			metrics := args.Get(1).([]resources.TaggedMetric)
+			metrics[0].Latest = &resources.DataPoint{Value: 42, Timestamp: time.Date(2022, 11, 1, 10, 5, 0, 0, time.UTC)}
+		}).Return(nil)
+		newLatestDataPointsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.LatestDataPointsProvider, error) {
+			return &mockLatestDataPointsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&includeLatest=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-1"},"latest":{"value":42,"timestamp":"2022-11-01T10:05:00Z"}},
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-2"}}
+		]`, rr.Body.String())
+	})
+
+	t.Run("returns 500 if the latest data points can't be queried", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockLatestDataPointsService := mocks.LatestDataPointsServiceMock{}
+		mockLatestDataPointsService.On("AddLatestDataPoints", mock.Anything, mock.Anything).Return(fmt.Errorf("access denied"))
+		newLatestDataPointsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.LatestDataPointsProvider, error) {
+			return &mockLatestDataPointsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&includeLatest=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("marks the metrics with whether they have an alarm when withAlarms is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/EC2", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAlarmsService := mocks.AlarmsServiceMock{}
+		mockAlarmsService.On("AddAlarmFlags", mock.Anything).Run(func(args mock.Arguments) {
+			metrics := args.Get(0).([]resources.TaggedMetric)
+			hasAlarm, hasNoAlarm := true, false
+			metrics[0].HasAlarm = &hasAlarm
+			metrics[1].HasAlarm = &hasNoAlarm
+		}).Return(nil)
+		newAlarmsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AlarmsProvider, error) {
+			return &mockAlarmsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&withAlarms=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-1"},"hasAlarm":true},
+			{"name":"CPUUtilization","namespace":"AWS/EC2","resourceType":"ec2:instance","dimensions":{"InstanceId":"i-2"},"hasAlarm":false}
+		]`, rr.Body.String())
+	})
+
+	t.Run("marks the metrics with whether Contributor Insights rules cover them when withInsightRules is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "AWS/DynamoDB", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "orders"}},
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "users"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockInsightRulesService := mocks.InsightRulesServiceMock{}
+		mockInsightRulesService.On("AddInsightRuleFlags", mock.Anything).Run(func(args mock.Arguments) {
+			metrics := args.Get(0).([]resources.TaggedMetric)
+			covered, notCovered := true, false
+			metrics[0].HasInsightRules = &covered
+			metrics[1].HasInsightRules = &notCovered
+		}).Return(nil)
+		newInsightRulesService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.InsightRulesProvider, error) {
+			return &mockInsightRulesService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/DynamoDB&withInsightRules=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"ConsumedReadCapacityUnits","namespace":"AWS/DynamoDB","dimensions":{"TableName":"orders"},"hasInsightRules":true},
+			{"name":"ConsumedReadCapacityUnits","namespace":"AWS/DynamoDB","dimensions":{"TableName":"users"},"hasInsightRules":false}
+		]`, rr.Body.String())
+	})
+
+	t.Run("infers the period of the metrics when inferPeriod is true", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "MyApp", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "web"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockPeriodsService := mocks.PeriodsServiceMock{}
+		mockPeriodsService.On("InferPeriods", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			metrics := args.Get(1).([]resources.TaggedMetric)
+			metrics[0].Period = 60
+		}).Return(nil)
+		newPeriodsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.PeriodsProvider, error) {
+			return &mockPeriodsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&inferPeriod=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"Latency","namespace":"MyApp","dimensions":{"Service":"api"},"period":60},
+			{"name":"Latency","namespace":"MyApp","dimensions":{"Service":"web"}}
+		]`, rr.Body.String())
+	})
+
+	t.Run("leaves out the metrics with fewer data points than minDatapoints", func(t *testing.T) {
+		listed := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "idle"}},
+		}
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "MyApp", "").Return(listed, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockDatapointCountsService := mocks.DatapointCountsServiceMock{}
+		mockDatapointCountsService.On("FilterByMinDatapoints", mock.Anything, listed, 10).Return(listed[:1], nil)
+		newDatapointCountsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.DatapointCountsProvider, error) {
+			return &mockDatapointCountsService, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&minDatapoints=10", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"MyApp","dimensions":{"Service":"api"}}]`, rr.Body.String())
+		mockDatapointCountsService.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 if minDatapoints is used without a namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&minDatapoints=10", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("attaches docs URLs to the metrics of known namespaces when docs is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/EC2", Name: "StatusCheckFailed"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&docs=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"ec2:instance","period":300,"docsUrl":"https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/viewing_metrics_with_cloudwatch.html"},
+			{"name":"StatusCheckFailed","namespace":"AWS/EC2","defaultStatistic":"Maximum","resourceType":"ec2:instance","period":300,"docsUrl":"https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html"}
+		]`, rr.Body.String())
+	})
+
+	t.Run("attaches docs URLs to the metrics with dimensions of known namespaces only", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/Lambda").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Errors"}, Dimensions: map[string]string{"FunctionName": "f"}},
+		}, nil)
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "MyApp").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/Lambda&expandDimensions=true&docs=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Errors","namespace":"AWS/Lambda","dimensions":{"FunctionName":"f"},"docsUrl":"https://docs.aws.amazon.com/lambda/latest/dg/monitoring-metrics.html"}]`, rr.Body.String())
+
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&expandDimensions=true&docs=true", nil)
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"MyApp","dimensions":{"Service":"api"}}]`, rr.Body.String())
+	})
+
+	t.Run("attaches cost hints to the metrics known to be charged for when costHints is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/EC2", Name: "StatusCheckFailed"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&costHints=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"ec2:instance","period":300,"costHint":"detailedMonitoring"},
+			{"name":"StatusCheckFailed","namespace":"AWS/EC2","defaultStatistic":"Maximum","resourceType":"ec2:instance","period":300}
+		]`, rr.Body.String())
+	})
+
+	t.Run("attaches cost hints to the metrics with dimensions", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/S3").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/S3", Name: "GetRequests"}, Dimensions: map[string]string{"BucketName": "b", "FilterId": "all"}},
+			{Metric: resources.Metric{Namespace: "AWS/S3", Name: "BucketSizeBytes"}, Dimensions: map[string]string{"BucketName": "b", "StorageType": "StandardStorage"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/S3&expandDimensions=true&costHints=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"GetRequests","namespace":"AWS/S3","dimensions":{"BucketName":"b","FilterId":"all"},"costHint":"customMetric"},
+			{"name":"BucketSizeBytes","namespace":"AWS/S3","dimensions":{"BucketName":"b","StorageType":"StandardStorage"}}
+		]`, rr.Body.String())
+	})
+
+	t.Run("returns a page of the metrics of a custom namespace with the next token when limit is set", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespacePage", mock.Anything, "customNamespace", false, 2, "").Return([]resources.Metric{{Namespace: "customNamespace", Name: "Latency"}, {Namespace: "customNamespace", Name: "Errors"}}, "2:token", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&limit=2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var page resources.MetricsPage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		assert.Equal(t, []resources.Metric{{Namespace: "customNamespace", Name: "Latency"}, {Namespace: "customNamespace", Name: "Errors"}}, page.Metrics)
+		assert.NotContains(t, page.NextToken, "token")
+		nextToken, err := decodeCursor([]byte("secret"), "metrics/0/us-east-2/customNamespace/paged", page.NextToken)
+		require.NoError(t, err)
+		assert.Equal(t, "2:token", nextToken)
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsByNamespace", mock.Anything, mock.Anything)
+	})
+
+	t.Run("passes the next token and requireDimensions through and caps the limit", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespacePage", mock.Anything, "customNamespace", true, resources.MaxMetricsLimit, "2:token").Return([]resources.Metric{{Namespace: "customNamespace", Name: "Latency"}}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		cursor := encodeCursor([]byte("secret"), "metrics/0/us-east-2/customNamespace/paged", "2:token")
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&requireDimensions=true&limit=100000&nextToken="+cursor, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"metrics":[{"name":"Latency","namespace":"customNamespace"}]}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 for a next token it didn't return", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+
+		for _, nextToken := range []string{
+			"2:token",
+			encodeCursor([]byte("other secret"), "metrics/0/us-east-2/customNamespace/paged", "2:token"),
+			// a cursor of the listing with dimensions isn't a next token of a page
+			encodeCursor([]byte("secret"), "metrics/0/us-east-2/customNamespace", "token"),
+		} {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&nextToken="+nextToken, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		}
+		mockListMetricsService.AssertNotCalled(t, "GetMetricsByNamespacePage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 for a signed next token the service rejects", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespacePage", mock.Anything, "customNamespace", false, resources.DefaultMetricsLimit, "token").Return([]resources.Metric{}, "", services.ErrInvalidNextToken)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		cursor := encodeCursor([]byte("secret"), "metrics/0/us-east-2/customNamespace/paged", "token")
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&nextToken="+cursor, nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 400 if limit isn't numeric", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&limit=all", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "limit must be a positive integer")
+	})
+
+	t.Run("returns 400 if limit is used for a non custom namespace", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&limit=10", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 400 if limit is combined with the parameters listing metrics with their dimensions", func(t *testing.T) {
+		for _, param := range []string{"includeTags=true", "paginate=true", "groupByAccount=true"} {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&limit=10&"+param, nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, param)
+		}
+	})
+
+	t.Run("doesn't return a trace without debug", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		res := []resources.Metric{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.NotContains(t, rr.Body.String(), "trace")
+	})
+
+	t.Run("traces the hardcoded metrics of all namespaces with debug", func(t *testing.T) {
+		origGetAllHardCodedMetrics := services.GetAllHardCodedMetrics
+		t.Cleanup(func() { services.GetAllHardCodedMetrics = origGetAllHardCodedMetrics })
+		services.GetAllHardCodedMetrics = func() []resources.Metric {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}}
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{Settings: models.CloudWatchSettings{AWSDatasourceSettings: awsds.AWSDatasourceSettings{Region: "eu-west-1"}}}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=default&debug=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res resources.DebugResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, []string{
+			"region: default, resolved to eu-west-1",
+			"request type: all metrics",
+			"source: hardcoded metrics of all namespaces",
+			"result: 1 metrics",
+		}, res.Trace)
+		assert.Len(t, res.Result, 1)
+	})
+
+	t.Run("traces the hardcoded metrics of a namespace requested by its alias with debug", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=ALB&debug=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res resources.DebugResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.Len(t, res.Trace, 5)
+		assert.Equal(t, []string{
+			"region: us-east-2",
+			`namespace: alias "ALB" resolved to "AWS/ApplicationELB"`,
+			"request type: metrics by namespace",
+			"source: hardcoded metrics of the namespace",
+		}, res.Trace[:4])
+		assert.Regexp(t, `^result: [1-9][0-9]* metrics$`, res.Trace[4])
+		assert.NotEmpty(t, res.Result)
+	})
+
+	t.Run("traces the listed metrics of a custom namespace with debug", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespacePage", mock.Anything, "customNamespace", false, 10, "").Return([]resources.Metric{{Namespace: "customNamespace", Name: "Latency"}}, "1:token", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{CursorSigningKey: []byte("secret")}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&limit=10&debug=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"result":{"metrics":[{"name":"Latency","namespace":"customNamespace"}],"nextToken":"`+encodeCursor([]byte("secret"), "metrics/0/us-east-2/customNamespace/paged", "1:token")+`"},
+			"trace":[
+				"region: us-east-2",
+				"request type: custom namespace",
+				"source: ListMetrics, a page of at most 10 metrics starting at nextToken \"\"",
+				"result: 1 metrics"
+			]
+		}`, rr.Body.String())
+	})
+
+	t.Run("traces the AWS calls made for the metrics with dimensions with debug", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithDimensionsByNamespace", mock.Anything, "customNamespace", "").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "customNamespace", Name: "Latency"}, Dimensions: map[string]string{"Host": "a"}},
+		}, "", nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		mockAlarmsService := mocks.AlarmsServiceMock{}
+		mockAlarmsService.On("AddAlarmFlags", mock.Anything).Return(nil)
+		newAlarmsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.AlarmsProvider, error) {
+			return &mockAlarmsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=customNamespace&withAlarms=true&debug=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var res resources.DebugResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, []string{
+			"region: us-east-2",
+			"request type: custom namespace",
+			"source: ListMetrics with dimensions, a single page starting at the cursor",
+			"aws: DescribeAlarms to mark the metrics with alarms, cached per data source and region",
+			"result: 1 metrics",
+		}, res.Trace)
+	})
+
+	t.Run("attaches suggested thresholds to the curated metrics when suggestThresholds is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/EC2", Name: "NetworkIn"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&suggestThresholds=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"ec2:instance","period":300,"suggestedThreshold":{"statistic":"Average","comparisonOperator":"GreaterThanThreshold","threshold":80}},
+			{"name":"NetworkIn","namespace":"AWS/EC2","defaultStatistic":"Sum","resourceType":"ec2:instance","period":300}
+		]`, rr.Body.String())
+	})
+
+	t.Run("attaches suggested thresholds to the metrics with dimensions", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/Lambda").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Errors"}, Dimensions: map[string]string{"FunctionName": "f"}},
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Duration"}, Dimensions: map[string]string{"FunctionName": "f"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/Lambda&expandDimensions=true&suggestThresholds=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"Errors","namespace":"AWS/Lambda","dimensions":{"FunctionName":"f"},"suggestedThreshold":{"statistic":"Sum","comparisonOperator":"GreaterThanThreshold","threshold":0}},
+			{"name":"Duration","namespace":"AWS/Lambda","dimensions":{"FunctionName":"f"}}
+		]`, rr.Body.String())
+	})
+
+	t.Run("attaches the units and their labels to the curated metrics when includeUnitLabels is true", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/EC2", Name: "MetadataNoToken"}}, nil
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&includeUnitLabels=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"CPUUtilization","namespace":"AWS/EC2","defaultStatistic":"Average","resourceType":"ec2:instance","period":300,"unit":"Percent","unitLabel":"percent"},
+			{"name":"MetadataNoToken","namespace":"AWS/EC2","resourceType":"ec2:instance","period":300}
+		]`, rr.Body.String())
+	})
+
+	t.Run("attaches the units and their labels to the metrics with dimensions", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "AWS/Lambda").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Duration"}, Dimensions: map[string]string{"FunctionName": "f"}},
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "PostRuntimeExtensionsDuration"}, Dimensions: map[string]string{"FunctionName": "f"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/Lambda&expandDimensions=true&includeUnitLabels=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"Duration","namespace":"AWS/Lambda","dimensions":{"FunctionName":"f"},"unit":"Milliseconds","unitLabel":"milliseconds"},
+			{"name":"PostRuntimeExtensionsDuration","namespace":"AWS/Lambda","dimensions":{"FunctionName":"f"}}
+		]`, rr.Body.String())
+	})
+
+	t.Run("filters the metrics by the prefix of their name", func(t *testing.T) {
+		origGetHardCodedMetricsByNamespace := services.GetHardCodedMetricsByNamespace
+		t.Cleanup(func() {
+			services.GetHardCodedMetricsByNamespace = origGetHardCodedMetricsByNamespace
+		})
+		services.GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+			return []resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "AWS/EC2", Name: "CPUCreditBalance"}, {Namespace: "AWS/EC2", Name: "NetworkIn"}}, nil
+		}
+
+		testCases := []struct {
+			query    string
+			expected []string
+		}{
+			{query: "", expected: []string{"CPUUtilization", "CPUCreditBalance", "NetworkIn"}},
+			{query: "q=cpu", expected: []string{"CPUUtilization", "CPUCreditBalance"}},
+			{query: "metricName=CPUUtil", expected: []string{"CPUUtilization"}},
+			{query: "q=in", expected: []string{}},
+			{query: "q=in&match=contains", expected: []string{"NetworkIn"}},
+		}
+		for _, tc := range testCases {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&"+tc.query, nil)
+			handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+			handler.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+			res := []resources.Metric{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			names := []string{}
+			for _, metric := range res {
+				names = append(names, metric.Name)
+			}
+			assert.Equal(t, tc.expected, names, tc.query)
+		}
+	})
+
+	t.Run("filters all metrics by the prefix of their name", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&q=cpuutilization", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		res := []resources.Metric{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.NotEmpty(t, res)
+		for _, metric := range res {
+			assert.Equal(t, "cpuutilization", strings.ToLower(metric.Name))
+		}
+	})
+
+	t.Run("filters the metrics of a custom namespace by their name once they're listed", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{{Namespace: "MyApp", Name: "Latency"}, {Namespace: "MyApp", Name: "Errors"}}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&q=lat", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Latency","namespace":"MyApp"}]`, rr.Body.String())
+	})
+
+	t.Run("filters the metrics with dimensions by their name", func(t *testing.T) {
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsWithAllDimensionsByNamespace", mock.Anything, "MyApp").Return([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Errors"}, Dimensions: map[string]string{"Service": "api"}},
+		}, nil)
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=MyApp&expandDimensions=true&q=ERR", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"name":"Errors","namespace":"MyApp","dimensions":{"Service":"api"}}]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if match is neither prefix nor contains", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/metrics?region=us-east-2&namespace=AWS/EC2&q=cpu&match=regex", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(MetricsHandler, logger, nil))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
 }
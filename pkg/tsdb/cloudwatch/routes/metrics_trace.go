@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// metricsTrace is the trace of how MetricsHandler resolved a request with debug, for support. It's nil unless debug
+// is set, and adding to a nil trace does nothing, so that tracing costs nothing otherwise.
+type metricsTrace []string
+
+func newMetricsTrace(debug bool) *metricsTrace {
+	if !debug {
+		return nil
+	}
+	return &metricsTrace{}
+}
+
+func (t *metricsTrace) add(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	*t = append(*t, fmt.Sprintf(format, args...))
+}
+
+// marshal returns the JSON of the response, together with the trace if there is one
+func (t *metricsTrace) marshal(response interface{}) ([]byte, error) {
+	if t == nil {
+		return json.Marshal(response)
+	}
+	return json.Marshal(resources.DebugResponse{Result: response, Trace: *t})
+}
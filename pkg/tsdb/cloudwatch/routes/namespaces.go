@@ -1,34 +1,167 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+	"golang.org/x/sync/errgroup"
 )
 
-func NamespacesHandler(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, _ url.Values) ([]byte, *models.HttpError) {
+// maxConcurrentMetricCountRequests limits the number of custom namespaces whose metrics are counted concurrently
+const maxConcurrentMetricCountRequests = 5
+
+// metricCountCacheTTL is how long the metric count of a custom namespace is cached
+const metricCountCacheTTL = 10 * time.Minute
+
+type cachedMetricCount struct {
+	count       int
+	approximate bool
+	expires     time.Time
+}
+
+// metricCountCache caches the metric counts of custom namespaces by data source and namespace
+var metricCountCache = struct {
+	sync.Mutex
+	counts map[string]cachedMetricCount
+}{counts: make(map[string]cachedMetricCount)}
+
+func NamespacesHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	namespacesRequest, err := resources.GetNamespacesRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in NamespacesHandler", http.StatusBadRequest, err)
+	}
+
 	reqCtx, err := reqCtxFactory(pluginCtx, "default")
 	if err != nil {
 		return nil, models.NewHttpError("error in NamespacesHandler", http.StatusInternalServerError, err)
 	}
 
-	result := services.GetHardCodedNamespaces()
-	customNamespace := reqCtx.Settings.Namespace
-	if customNamespace != "" {
-		result = append(result, strings.Split(customNamespace, ",")...)
+	result := getNamespaces(reqCtx.Settings)
+
+	if namespacesRequest.Prefix != "" {
+		// match case-insensitively, but return the namespaces as they're spelled. Namespaces also match by their alias.
+		prefix := strings.ToLower(namespacesRequest.Prefix)
+		matching := []string{}
+		for _, namespace := range result {
+			alias := services.GetNamespaceAlias(namespace)
+			if strings.HasPrefix(strings.ToLower(namespace), prefix) || (alias != "" && strings.HasPrefix(strings.ToLower(alias), prefix)) {
+				matching = append(matching, namespace)
+			}
+		}
+		result = matching
 	}
 	sort.Strings(result)
 
-	namespacesResponse, err := json.Marshal(result)
+	if namespacesRequest.Limit > 0 && len(result) > namespacesRequest.Limit {
+		result = result[:namespacesRequest.Limit]
+	}
+
+	var response interface{} = result
+	if namespacesRequest.WithCounts {
+		service, err := newListMetricsService(pluginCtx, reqCtxFactory, "default")
+		if err != nil {
+			return nil, models.NewHttpError("error in NamespacesHandler", http.StatusInternalServerError, err)
+		}
+		counts, err := getNamespaceMetricCounts(ctx, pluginCtx, service, result)
+		if err != nil {
+			return nil, models.NewHttpError("error in NamespacesHandler", http.StatusInternalServerError, err)
+		}
+		if namespacesRequest.WithAliases {
+			for i := range counts {
+				counts[i].Alias = services.GetNamespaceAlias(counts[i].Name)
+			}
+		}
+		response = counts
+	} else if namespacesRequest.WithAliases {
+		namespaces := make([]resources.NamespaceWithAlias, 0, len(result))
+		for _, namespace := range result {
+			namespaces = append(namespaces, resources.NamespaceWithAlias{Name: namespace, Alias: services.GetNamespaceAlias(namespace)})
+		}
+		response = namespaces
+	}
+
+	namespacesResponse, err := json.Marshal(response)
 	if err != nil {
 		return nil, models.NewHttpError("error in NamespacesHandler", http.StatusInternalServerError, err)
 	}
 
 	return namespacesResponse, nil
 }
+
+// getNamespaces returns the namespaces of the data source, which are the hard-coded namespaces and the custom
+// namespaces of its settings, unsorted
+func getNamespaces(settings models.CloudWatchSettings) []string {
+	namespaces := services.GetHardCodedNamespaces()
+	if settings.Namespace != "" {
+		namespaces = append(namespaces, strings.Split(settings.Namespace, ",")...)
+	}
+	return namespaces
+}
+
+// getNamespaceMetricCounts returns the metric count of each namespace. Hard-coded namespaces have a known count.
+// Custom namespaces are counted by listing their metrics, which is capped, so their count may be approximate.
+func getNamespaceMetricCounts(ctx context.Context, pluginCtx backend.PluginContext, service models.ListMetricsProvider, namespaces []string) ([]resources.NamespaceMetricCount, error) {
+	hardCoded := make(map[string]struct{})
+	for _, namespace := range services.GetHardCodedNamespaces() {
+		hardCoded[namespace] = struct{}{}
+	}
+
+	var dataSourceID int64
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		dataSourceID = pluginCtx.DataSourceInstanceSettings.ID
+	}
+
+	counts := make([]resources.NamespaceMetricCount, len(namespaces))
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentMetricCountRequests)
+	for i, namespace := range namespaces {
+		i, namespace := i, namespace
+		if _, exists := hardCoded[namespace]; exists {
+			metrics, err := services.GetHardCodedMetricsByNamespace(namespace)
+			if err != nil {
+				return nil, err
+			}
+			counts[i] = resources.NamespaceMetricCount{Name: namespace, MetricCount: len(metrics)}
+			continue
+		}
+
+		eg.Go(func() error {
+			cacheKey := fmt.Sprintf("%d/%s", dataSourceID, namespace)
+			metricCountCache.Lock()
+			cached, exists := metricCountCache.counts[cacheKey]
+			metricCountCache.Unlock()
+			if exists && time.Now().Before(cached.expires) {
+				counts[i] = resources.NamespaceMetricCount{Name: namespace, MetricCount: cached.count, Approximate: cached.approximate}
+				return nil
+			}
+
+			count, approximate, err := service.GetMetricCountByNamespace(ctx, namespace)
+			if err != nil {
+				return err
+			}
+
+			metricCountCache.Lock()
+			metricCountCache.counts[cacheKey] = cachedMetricCount{count: count, approximate: approximate, expires: time.Now().Add(metricCountCacheTTL)}
+			metricCountCache.Unlock()
+			counts[i] = resources.NamespaceMetricCount{Name: namespace, MetricCount: count, Approximate: approximate}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
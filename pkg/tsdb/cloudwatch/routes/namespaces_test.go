@@ -1,13 +1,17 @@
 package routes
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
 )
@@ -70,4 +74,138 @@ func Test_Namespaces_Route(t *testing.T) {
 		handler.ServeHTTP(rr, req)
 		assert.JSONEq(t, `["ACustomNamespace2", "AWS/ELB", "AWS/XYZ", "DCustomNamespace1"]`, rr.Body.String())
 	})
+
+	t.Run("returns namespaces matching the prefix case-insensitively in their canonical casing", func(t *testing.T) {
+		origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+		})
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/EC2", "AWS/ECS", "AWS/ELB", "AWS/Lambda"}
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?prefix=aws/ec", nil)
+		customNamespaces = "aws/ecCustom,MyApp"
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `["AWS/EC2", "AWS/ECS", "aws/ecCustom"]`, rr.Body.String())
+	})
+
+	t.Run("returns namespaces whose alias matches the prefix", func(t *testing.T) {
+		origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+		})
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/ApplicationELB", "AWS/EC2", "AWS/Lambda"}
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?prefix=al", nil)
+		customNamespaces = ""
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `["AWS/ApplicationELB"]`, rr.Body.String())
+	})
+
+	t.Run("returns the canonical name and alias of each namespace with aliases", func(t *testing.T) {
+		origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+		})
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/ApplicationELB", "AWS/EC2"}
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?withAliases=true", nil)
+		customNamespaces = "MyApp"
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[
+			{"name":"AWS/ApplicationELB","alias":"ALB"},
+			{"name":"AWS/EC2"},
+			{"name":"MyApp"}
+		]`, rr.Body.String())
+	})
+
+	t.Run("caps the result to the limit", func(t *testing.T) {
+		origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+		})
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/ELB", "AWS/ECS", "AWS/EC2", "AWS/Lambda"}
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?prefix=AWS/E&limit=2", nil)
+		customNamespaces = ""
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.JSONEq(t, `["AWS/EC2", "AWS/ECS"]`, rr.Body.String())
+	})
+
+	t.Run("returns 400 for an invalid limit", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?prefix=AWS&limit=zero", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"Message":"error in NamespacesHandler: limit must be a positive integer","Error":"limit must be a positive integer","StatusCode":400}`, rr.Body.String())
+	})
+
+	t.Run("returns the metric count of each namespace with counts", func(t *testing.T) {
+		origGetHardCodedNamespaces := services.GetHardCodedNamespaces
+		t.Cleanup(func() {
+			services.GetHardCodedNamespaces = origGetHardCodedNamespaces
+			metricCountCache.counts = make(map[string]cachedMetricCount)
+		})
+		services.GetHardCodedNamespaces = func() []string {
+			return []string{"AWS/EC2"}
+		}
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricCountByNamespace", mock.Anything, "CustomA").Return(3, false, nil).Once()
+		mockListMetricsService.On("GetMetricCountByNamespace", mock.Anything, "CustomB").Return(1000, true, nil).Once()
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		customNamespaces = "CustomA,CustomB"
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		expected := fmt.Sprintf(`[
+			{"name":"AWS/EC2","metricCount":%d,"approximate":false},
+			{"name":"CustomA","metricCount":3,"approximate":false},
+			{"name":"CustomB","metricCount":1000,"approximate":true}
+		]`, len(constants.NamespaceMetricsMap["AWS/EC2"]))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/namespaces?withCounts=true", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, expected, rr.Body.String())
+
+		// the counts of custom namespaces are cached
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/namespaces?withCounts=true", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, expected, rr.Body.String())
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricCountByNamespace", 2)
+	})
+
+	t.Run("returns 500 if a custom namespace can't be counted", func(t *testing.T) {
+		t.Cleanup(func() {
+			metricCountCache.counts = make(map[string]cachedMetricCount)
+		})
+		mockListMetricsService := mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricCountByNamespace", mock.Anything, "CustomA").Return(0, false, fmt.Errorf("some error"))
+		newListMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.ListMetricsProvider, error) {
+			return &mockListMetricsService, nil
+		}
+		customNamespaces = "CustomA"
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/namespaces?prefix=Custom&withCounts=true", nil)
+		handler := http.HandlerFunc(ResourceRequestMiddleware(NamespacesHandler, logger, factoryFunc))
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, `{"Message":"error in NamespacesHandler: some error","Error":"some error","StatusCode":500}`, rr.Body.String())
+	})
 }
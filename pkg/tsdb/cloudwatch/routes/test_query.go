@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/services"
+)
+
+// TestQueryHandler runs a metric query over a short window and returns the sample data points, so that a query can
+// be validated before it's added to a panel
+func TestQueryHandler(ctx context.Context, pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models.HttpError) {
+	testQueryRequest, err := resources.GetTestQueryRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in TestQueryHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := newTestQueryService(pluginCtx, reqCtxFactory, testQueryRequest.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in TestQueryHandler", http.StatusInternalServerError, err)
+	}
+
+	result, err := service.RunTestQuery(ctx, testQueryRequest)
+	if err != nil {
+		return nil, models.NewHttpError("error in TestQueryHandler", testQueryErrorStatus(err), err)
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		return nil, models.NewHttpError("error in TestQueryHandler", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+// testQueryErrorStatus maps the errors of AWS to the status of the response, so that an invalid query or missing
+// permissions can be told apart from a failure
+func testQueryErrorStatus(err error) int {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return http.StatusInternalServerError
+	}
+
+	switch awsErr.Code() {
+	case cloudwatch.ErrCodeInvalidParameterValueException, cloudwatch.ErrCodeInvalidParameterCombinationException,
+		cloudwatch.ErrCodeMissingRequiredParameterException, "ValidationError":
+		return http.StatusBadRequest
+	case "AccessDenied", "AccessDeniedException":
+		return http.StatusForbidden
+	case "Throttling", "ThrottlingException":
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+var newTestQueryService = func(pluginCtx backend.PluginContext, reqCtxFactory models.RequestContextFactoryFunc, region string) (models.TestQueryProvider, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewTestQueryService(reqCtx.MetricsClientProvider), nil
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+)
+
+func Test_TestQuery_Route(t *testing.T) {
+	handlerWithClient := func(client models.MetricsClientProvider) http.HandlerFunc {
+		factoryFunc := func(pluginCtx backend.PluginContext, region string) (reqCtx models.RequestContext, err error) {
+			return models.RequestContext{MetricsClientProvider: client}, nil
+		}
+		return http.HandlerFunc(ResourceRequestMiddleware(TestQueryHandler, logger, factoryFunc))
+	}
+
+	t.Run("returns the sample data points of the query", func(t *testing.T) {
+		timestamp := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("test"), Values: []*float64{aws.Float64(42)}, Timestamps: []*time.Time{aws.Time(timestamp)}},
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", `/test-query?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization&dimensions={"InstanceId":"i-1"}`, nil)
+		handlerWithClient(fakeMetricsClient).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"ok":true,"dataPoints":[{"value":42,"timestamp":"2022-11-01T10:00:00Z"}]}`, rr.Body.String())
+	})
+
+	t.Run("returns 400 if the query is rejected by AWS", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{},
+			awserr.New(cloudwatch.ErrCodeInvalidParameterValueException, "the statistic is invalid", nil))
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test-query?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization&statistic=Middle", nil)
+		handlerWithClient(fakeMetricsClient).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "the statistic is invalid")
+	})
+
+	t.Run("returns 403 if access to the metrics is denied", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{},
+			awserr.New("AccessDenied", "not authorized to perform cloudwatch:GetMetricData", nil))
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test-query?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization", nil)
+		handlerWithClient(fakeMetricsClient).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 500 if the query fails otherwise", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, fmt.Errorf("connection reset"))
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test-query?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization", nil)
+		handlerWithClient(fakeMetricsClient).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("returns 400 for an invalid request", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test-query?region=us-east-2&namespace=AWS/EC2", nil)
+		handlerWithClient(fakeMetricsClient).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		fakeMetricsClient.AssertNotCalled(t, "GetMetricData", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+)
+
+type AccountsService struct {
+	models.OAMAPIProvider
+}
+
+func NewAccountsService(oamClient models.OAMAPIProvider) models.AccountsProvider {
+	return &AccountsService{oamClient}
+}
+
+// GetAccountLabels returns the labels of the source accounts linked to the monitoring account, by account id.
+// The account id of a source account is taken from the ARN of its link, which is owned by the source account.
+// An account that isn't a monitoring account has no sinks, in which case no labels are returned.
+func (a *AccountsService) GetAccountLabels() (map[string]string, error) {
+	labels := make(map[string]string)
+
+	var nextToken *string
+	for {
+		sinks, err := a.ListSinks(&oam.ListSinksInput{NextToken: nextToken})
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "unable to list sinks", err)
+		}
+
+		for _, sink := range sinks.Items {
+			if err := a.addLinkLabels(labels, sink.Arn); err != nil {
+				return nil, err
+			}
+		}
+
+		if sinks.NextToken == nil {
+			break
+		}
+		nextToken = sinks.NextToken
+	}
+
+	return labels, nil
+}
+
+// IsMonitoringAccount returns whether the account is a monitoring account of CloudWatch cross-account observability,
+// which is the case if it has a sink that source accounts can link to.
+func (a *AccountsService) IsMonitoringAccount() (bool, error) {
+	sinks, err := a.ListSinks(&oam.ListSinksInput{MaxResults: aws.Int64(1)})
+	if err != nil {
+		return false, fmt.Errorf("%v: %w", "unable to list sinks", err)
+	}
+
+	return len(sinks.Items) > 0, nil
+}
+
+func (a *AccountsService) addLinkLabels(labels map[string]string, sinkIdentifier *string) error {
+	var nextToken *string
+	for {
+		links, err := a.ListAttachedLinks(&oam.ListAttachedLinksInput{SinkIdentifier: sinkIdentifier, NextToken: nextToken})
+		if err != nil {
+			return fmt.Errorf("%v: %w", "unable to list attached links", err)
+		}
+
+		for _, link := range links.Items {
+			linkArn, err := arn.Parse(aws.StringValue(link.LinkArn))
+			if err != nil {
+				continue
+			}
+			labels[linkArn.AccountID] = aws.StringValue(link.Label)
+		}
+
+		if links.NextToken == nil {
+			return nil
+		}
+		nextToken = links.NextToken
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsService_GetAccountLabels(t *testing.T) {
+	t.Run("Should return the labels of the linked accounts by account id", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", mock.Anything).Return(&oam.ListSinksOutput{Items: []*oam.ListSinksItem{
+			{Arn: aws.String("arn:aws:oam:us-east-1:000000000000:sink/sink-id")},
+		}}, nil)
+		fakeOAMClient.On("ListAttachedLinks", &oam.ListAttachedLinksInput{SinkIdentifier: aws.String("arn:aws:oam:us-east-1:000000000000:sink/sink-id")}).Return(&oam.ListAttachedLinksOutput{
+			Items: []*oam.ListAttachedLinksItem{
+				{Label: aws.String("production"), LinkArn: aws.String("arn:aws:oam:us-east-1:111111111111:link/link-1")},
+			},
+			NextToken: aws.String("next"),
+		}, nil)
+		fakeOAMClient.On("ListAttachedLinks", &oam.ListAttachedLinksInput{SinkIdentifier: aws.String("arn:aws:oam:us-east-1:000000000000:sink/sink-id"), NextToken: aws.String("next")}).Return(&oam.ListAttachedLinksOutput{
+			Items: []*oam.ListAttachedLinksItem{
+				{Label: aws.String("staging"), LinkArn: aws.String("arn:aws:oam:us-east-1:222222222222:link/link-2")},
+				{Label: aws.String("invalid"), LinkArn: aws.String("not-an-arn")},
+			},
+		}, nil)
+		accountsService := NewAccountsService(fakeOAMClient)
+
+		labels, err := accountsService.GetAccountLabels()
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"111111111111": "production", "222222222222": "staging"}, labels)
+	})
+
+	t.Run("Should return no labels if the account isn't a monitoring account", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", mock.Anything).Return(&oam.ListSinksOutput{}, nil)
+		accountsService := NewAccountsService(fakeOAMClient)
+
+		labels, err := accountsService.GetAccountLabels()
+
+		require.NoError(t, err)
+		assert.Empty(t, labels)
+		fakeOAMClient.AssertNotCalled(t, "ListAttachedLinks", mock.Anything)
+	})
+
+	t.Run("Should return an error if the sinks can't be listed", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", mock.Anything).Return(&oam.ListSinksOutput{}, awserr.New("AccessDeniedException", "access denied", nil))
+		accountsService := NewAccountsService(fakeOAMClient)
+
+		_, err := accountsService.GetAccountLabels()
+
+		require.Error(t, err)
+	})
+}
+
+func TestAccountsService_IsMonitoringAccount(t *testing.T) {
+	t.Run("Should return true if the account has a sink", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", &oam.ListSinksInput{MaxResults: aws.Int64(1)}).Return(&oam.ListSinksOutput{Items: []*oam.ListSinksItem{
+			{Arn: aws.String("arn:aws:oam:us-east-1:000000000000:sink/sink-id")},
+		}}, nil)
+
+		isMonitoringAccount, err := NewAccountsService(fakeOAMClient).IsMonitoringAccount()
+
+		require.NoError(t, err)
+		assert.True(t, isMonitoringAccount)
+	})
+
+	t.Run("Should return false if the account has no sinks", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", mock.Anything).Return(&oam.ListSinksOutput{}, nil)
+
+		isMonitoringAccount, err := NewAccountsService(fakeOAMClient).IsMonitoringAccount()
+
+		require.NoError(t, err)
+		assert.False(t, isMonitoringAccount)
+	})
+
+	t.Run("Should return an error if the sinks can't be listed", func(t *testing.T) {
+		fakeOAMClient := &mocks.FakeOAMClient{}
+		fakeOAMClient.On("ListSinks", mock.Anything).Return(&oam.ListSinksOutput{}, awserr.New("AccessDeniedException", "access denied", nil))
+
+		_, err := NewAccountsService(fakeOAMClient).IsMonitoringAccount()
+
+		require.Error(t, err)
+	})
+}
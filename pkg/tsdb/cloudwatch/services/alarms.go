@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxAlarmFlagsMetrics caps the number of metrics marked with whether they have an alarm, which is the size of a page
+const maxAlarmFlagsMetrics = 500
+
+// maxConcurrentAlarmsRequests limits the number of DescribeAlarmsForMetric calls made concurrently
+const maxConcurrentAlarmsRequests = 5
+
+// alarmsCacheTTL is how long the alarms on a metric are cached
+const alarmsCacheTTL = 5 * time.Minute
+
+type cachedAlarms struct {
+	// dimensions are the dimensions of the metrics with an alarm, by their dimensions key
+	dimensions map[string]struct{}
+	expires    time.Time
+}
+
+// alarmsCache caches the dimensions of the metrics with an alarm by cache key, namespace and metric name
+var alarmsCache = struct {
+	sync.Mutex
+	entries map[string]cachedAlarms
+}{entries: make(map[string]cachedAlarms)}
+
+type AlarmsService struct {
+	models.AlarmsAPIProvider
+	cacheKey string
+}
+
+// NewAlarmsService returns a service marking metrics with whether they have an alarm. The alarms are cached under the
+// cache key, which has to identify the account and region of the client.
+func NewAlarmsService(alarmsClient models.AlarmsAPIProvider, cacheKey string) models.AlarmsProvider {
+	return &AlarmsService{alarmsClient, cacheKey}
+}
+
+// AddAlarmFlags sets HasAlarm of each metric to whether there's an alarm on the metric with exactly its dimensions.
+// The alarms are described once per metric name rather than once per metric, and only the first metrics up to the cap
+// are marked. If describing alarms isn't allowed, the metrics aren't marked at all and no error is returned, so that
+// listing metrics doesn't depend on the permission.
+func (a *AlarmsService) AddAlarmFlags(metrics []resources.TaggedMetric) error {
+	if len(metrics) > maxAlarmFlagsMetrics {
+		metrics = metrics[:maxAlarmFlagsMetrics]
+	}
+
+	var mu sync.Mutex
+	alarmsByMetric := make(map[string]map[string]struct{})
+	described := make(map[string]struct{})
+
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentAlarmsRequests)
+	for _, metric := range metrics {
+		namespace, name := metric.Namespace, metric.Name
+		key := namespace + "/" + name
+		if _, exists := described[key]; exists {
+			continue
+		}
+		described[key] = struct{}{}
+
+		eg.Go(func() error {
+			dimensions, err := a.getAlarmedDimensions(namespace, name)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			alarmsByMetric[key] = dimensions
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && (awsErr.Code() == "AccessDenied" || awsErr.Code() == "AccessDeniedException") {
+			return nil
+		}
+		return err
+	}
+
+	for i, metric := range metrics {
+		_, hasAlarm := alarmsByMetric[metric.Namespace+"/"+metric.Name][dimensionsKey(metric.Dimensions)]
+		metrics[i].HasAlarm = aws.Bool(hasAlarm)
+	}
+
+	return nil
+}
+
+// getAlarmedDimensions returns the dimensions of the metrics with the name that have an alarm, by their dimensions key
+func (a *AlarmsService) getAlarmedDimensions(namespace string, name string) (map[string]struct{}, error) {
+	cacheKey := a.cacheKey + "/" + namespace + "/" + name
+	alarmsCache.Lock()
+	cached, exists := alarmsCache.entries[cacheKey]
+	alarmsCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.dimensions, nil
+	}
+
+	// without dimensions the alarms on the metric with any dimensions are described
+	output, err := a.DescribeAlarmsForMetric(&cloudwatch.DescribeAlarmsForMetricInput{
+		Namespace:  aws.String(namespace),
+		MetricName: aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	dimensions := make(map[string]struct{}, len(output.MetricAlarms))
+	for _, alarm := range output.MetricAlarms {
+		alarmDimensions := make(map[string]string, len(alarm.Dimensions))
+		for _, dimension := range alarm.Dimensions {
+			alarmDimensions[aws.StringValue(dimension.Name)] = aws.StringValue(dimension.Value)
+		}
+		dimensions[dimensionsKey(alarmDimensions)] = struct{}{}
+	}
+
+	alarmsCache.Lock()
+	alarmsCache.entries[cacheKey] = cachedAlarms{dimensions: dimensions, expires: time.Now().Add(alarmsCacheTTL)}
+	alarmsCache.Unlock()
+
+	return dimensions, nil
+}
+
+// dimensionsKey joins the sorted dimensions with NUL bytes, so that equal dimensions have equal keys
+func dimensionsKey(dimensions map[string]string) string {
+	pairs := make([]string, 0, len(dimensions))
+	for key, value := range dimensions {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
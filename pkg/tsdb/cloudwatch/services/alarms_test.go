@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func metricAlarm(dimensions map[string]string) *cloudwatch.MetricAlarm {
+	alarm := &cloudwatch.MetricAlarm{}
+	for name, value := range dimensions {
+		alarm.Dimensions = append(alarm.Dimensions, &cloudwatch.Dimension{Name: aws.String(name), Value: aws.String(value)})
+	}
+	return alarm
+}
+
+func TestAlarmsService_AddAlarmFlags(t *testing.T) {
+	t.Cleanup(func() {
+		alarmsCache.entries = make(map[string]cachedAlarms)
+	})
+
+	t.Run("Should mark the metrics with an alarm on exactly their dimensions", func(t *testing.T) {
+		fakeAlarmsClient := &mocks.FakeAlarmsClient{}
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", &cloudwatch.DescribeAlarmsForMetricInput{
+			Namespace:  aws.String("AWS/EC2"),
+			MetricName: aws.String("CPUUtilization"),
+		}).Return(&cloudwatch.DescribeAlarmsForMetricOutput{
+			MetricAlarms: []*cloudwatch.MetricAlarm{
+				metricAlarm(map[string]string{"InstanceId": "i-1"}),
+				metricAlarm(map[string]string{"InstanceId": "i-2", "AutoScalingGroupName": "asg"}),
+			},
+		}, nil)
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", &cloudwatch.DescribeAlarmsForMetricInput{
+			Namespace:  aws.String("AWS/EC2"),
+			MetricName: aws.String("NetworkIn"),
+		}).Return(&cloudwatch.DescribeAlarmsForMetricOutput{}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"AutoScalingGroupName": "asg", "InstanceId": "i-2"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "NetworkIn"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}
+
+		err := NewAlarmsService(fakeAlarmsClient, "test/us-east-1").AddAlarmFlags(metrics)
+
+		require.NoError(t, err)
+		require.NotNil(t, metrics[0].HasAlarm)
+		assert.True(t, *metrics[0].HasAlarm)
+		require.NotNil(t, metrics[1].HasAlarm)
+		assert.False(t, *metrics[1].HasAlarm)
+		require.NotNil(t, metrics[2].HasAlarm)
+		assert.True(t, *metrics[2].HasAlarm)
+		require.NotNil(t, metrics[3].HasAlarm)
+		assert.False(t, *metrics[3].HasAlarm)
+		fakeAlarmsClient.AssertNumberOfCalls(t, "DescribeAlarmsForMetric", 2)
+	})
+
+	t.Run("Should cache the alarms of a metric", func(t *testing.T) {
+		fakeAlarmsClient := &mocks.FakeAlarmsClient{}
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", mock.Anything).Return(&cloudwatch.DescribeAlarmsForMetricOutput{
+			MetricAlarms: []*cloudwatch.MetricAlarm{metricAlarm(map[string]string{"FunctionName": "my-function"})},
+		}, nil)
+		alarmsService := NewAlarmsService(fakeAlarmsClient, "cached/us-east-1")
+
+		for i := 0; i < 2; i++ {
+			metrics := []resources.TaggedMetric{
+				{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Errors"}, Dimensions: map[string]string{"FunctionName": "my-function"}},
+			}
+			require.NoError(t, alarmsService.AddAlarmFlags(metrics))
+			require.NotNil(t, metrics[0].HasAlarm)
+			assert.True(t, *metrics[0].HasAlarm)
+		}
+		fakeAlarmsClient.AssertNumberOfCalls(t, "DescribeAlarmsForMetric", 1)
+	})
+
+	t.Run("Should only mark the metrics up to the cap", func(t *testing.T) {
+		fakeAlarmsClient := &mocks.FakeAlarmsClient{}
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", mock.Anything).Return(&cloudwatch.DescribeAlarmsForMetricOutput{}, nil)
+		metrics := make([]resources.TaggedMetric, maxAlarmFlagsMetrics+1)
+		for i := range metrics {
+			metrics[i] = resources.TaggedMetric{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}}
+		}
+
+		err := NewAlarmsService(fakeAlarmsClient, "capped/us-east-1").AddAlarmFlags(metrics)
+
+		require.NoError(t, err)
+		assert.NotNil(t, metrics[maxAlarmFlagsMetrics-1].HasAlarm)
+		assert.Nil(t, metrics[maxAlarmFlagsMetrics].HasAlarm)
+	})
+
+	t.Run("Should leave the metrics unmarked if describing alarms is denied", func(t *testing.T) {
+		fakeAlarmsClient := &mocks.FakeAlarmsClient{}
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", mock.Anything).Return(&cloudwatch.DescribeAlarmsForMetricOutput{}, awserr.New("AccessDenied", "access denied", nil))
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}
+
+		err := NewAlarmsService(fakeAlarmsClient, "denied/us-east-1").AddAlarmFlags(metrics)
+
+		require.NoError(t, err)
+		assert.Nil(t, metrics[0].HasAlarm)
+	})
+
+	t.Run("Should return other errors of the API", func(t *testing.T) {
+		fakeAlarmsClient := &mocks.FakeAlarmsClient{}
+		fakeAlarmsClient.On("DescribeAlarmsForMetric", mock.Anything).Return(&cloudwatch.DescribeAlarmsForMetricOutput{}, awserr.New("InternalFailure", "internal failure", nil))
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}
+
+		err := NewAlarmsService(fakeAlarmsClient, "failing/us-east-1").AddAlarmFlags(metrics)
+
+		require.Error(t, err)
+	})
+}
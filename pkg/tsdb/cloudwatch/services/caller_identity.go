@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// callerIdentityCacheTTL is how long the caller identity of a data source is cached. It's brief, since the identity is
+// asked for while access issues are being fixed, e.g. by changing the role the data source assumes.
+const callerIdentityCacheTTL = time.Minute
+
+type cachedCallerIdentity struct {
+	identity resources.CallerIdentity
+	expires  time.Time
+}
+
+// callerIdentityCache caches the caller identity by cache key
+var callerIdentityCache = struct {
+	sync.Mutex
+	entries map[string]cachedCallerIdentity
+}{entries: make(map[string]cachedCallerIdentity)}
+
+type CallerIdentityService struct {
+	models.STSAPIProvider
+	cacheKey string
+}
+
+// NewCallerIdentityService returns a service resolving the IAM principal the credentials of the client authenticate
+// as. The identity is cached under the cache key, which has to identify the credentials of the client.
+func NewCallerIdentityService(stsClient models.STSAPIProvider, cacheKey string) models.CallerIdentityProvider {
+	return &CallerIdentityService{stsClient, cacheKey}
+}
+
+// GetCallerIdentity returns the account, ARN and user id of the IAM principal the requests of the data source are made
+// as, which is the assumed role if the data source assumes one. An identity that can't be resolved isn't cached.
+func (s *CallerIdentityService) GetCallerIdentity() (resources.CallerIdentity, error) {
+	callerIdentityCache.Lock()
+	cached, exists := callerIdentityCache.entries[s.cacheKey]
+	callerIdentityCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.identity, nil
+	}
+
+	output, err := s.STSAPIProvider.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return resources.CallerIdentity{}, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+	identity := resources.CallerIdentity{
+		Account: aws.StringValue(output.Account),
+		Arn:     aws.StringValue(output.Arn),
+		UserId:  aws.StringValue(output.UserId),
+	}
+
+	callerIdentityCache.Lock()
+	callerIdentityCache.entries[s.cacheKey] = cachedCallerIdentity{identity: identity, expires: time.Now().Add(callerIdentityCacheTTL)}
+	callerIdentityCache.Unlock()
+
+	return identity, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerIdentityService_GetCallerIdentity(t *testing.T) {
+	t.Cleanup(func() {
+		callerIdentityCache.entries = make(map[string]cachedCallerIdentity)
+	})
+	output := &sts.GetCallerIdentityOutput{
+		Account: aws.String("123456789012"),
+		Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/grafana/session"),
+		UserId:  aws.String("AROAEXAMPLE:session"),
+	}
+	identity := resources.CallerIdentity{Account: "123456789012", Arn: "arn:aws:sts::123456789012:assumed-role/grafana/session", UserId: "AROAEXAMPLE:session"}
+
+	t.Run("Should return the identity of the credentials and cache it", func(t *testing.T) {
+		fakeSTSClient := &mocks.FakeSTSClient{}
+		fakeSTSClient.On("GetCallerIdentity", &sts.GetCallerIdentityInput{}).Return(output, nil)
+		service := NewCallerIdentityService(fakeSTSClient, "1/0/us-east-1")
+
+		for i := 0; i < 2; i++ {
+			resp, err := service.GetCallerIdentity()
+			require.NoError(t, err)
+			assert.Equal(t, identity, resp)
+		}
+		fakeSTSClient.AssertNumberOfCalls(t, "GetCallerIdentity", 1)
+	})
+
+	t.Run("Should cache the identity of each cache key separately", func(t *testing.T) {
+		fakeSTSClient := &mocks.FakeSTSClient{}
+		fakeSTSClient.On("GetCallerIdentity", &sts.GetCallerIdentityInput{}).Return(output, nil)
+
+		_, err := NewCallerIdentityService(fakeSTSClient, "2/0/us-east-1").GetCallerIdentity()
+		require.NoError(t, err)
+		_, err = NewCallerIdentityService(fakeSTSClient, "2/1/us-east-1").GetCallerIdentity()
+		require.NoError(t, err)
+		fakeSTSClient.AssertNumberOfCalls(t, "GetCallerIdentity", 2)
+	})
+
+	t.Run("Should not cache the identity if it can't be resolved", func(t *testing.T) {
+		fakeSTSClient := &mocks.FakeSTSClient{}
+		fakeSTSClient.On("GetCallerIdentity", &sts.GetCallerIdentityInput{}).Return(&sts.GetCallerIdentityOutput{}, awserr.New("ExpiredToken", "The security token included in the request is expired", nil)).Once()
+		fakeSTSClient.On("GetCallerIdentity", &sts.GetCallerIdentityInput{}).Return(output, nil)
+		service := NewCallerIdentityService(fakeSTSClient, "3/0/us-east-1")
+
+		_, err := service.GetCallerIdentity()
+		require.Error(t, err)
+		var awsErr awserr.Error
+		require.ErrorAs(t, err, &awsErr)
+		assert.Equal(t, "ExpiredToken", awsErr.Code())
+
+		resp, err := service.GetCallerIdentity()
+		require.NoError(t, err)
+		assert.Equal(t, identity, resp)
+	})
+}
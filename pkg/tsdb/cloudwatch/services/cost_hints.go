@@ -0,0 +1,71 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+const (
+	// CostHintDetailedMonitoring is the hint of metrics that are free at a 5 minute period, but are only reported every
+	// minute with detailed monitoring, which is charged for
+	CostHintDetailedMonitoring = "detailedMonitoring"
+	// CostHintCustomMetric is the hint of metrics that are charged for as custom metrics, since they're only reported
+	// once an opt-in feature or an agent is enabled
+	CostHintCustomMetric = "customMetric"
+)
+
+// namespaceCostHints holds the cost hint of well-known namespaces whose metrics are all charged for
+var namespaceCostHints = map[string]string{
+	"ContainerInsights":     CostHintCustomMetric,
+	"ECS/ContainerInsights": CostHintCustomMetric,
+}
+
+// metricCostHints holds the cost hint of well-known metrics that are charged for, in namespaces whose other metrics
+// are free
+var metricCostHints = map[string]map[string]string{
+	"AWS/EC2": {
+		"CPUUtilization":    CostHintDetailedMonitoring,
+		"DiskReadBytes":     CostHintDetailedMonitoring,
+		"DiskReadOps":       CostHintDetailedMonitoring,
+		"DiskWriteBytes":    CostHintDetailedMonitoring,
+		"DiskWriteOps":      CostHintDetailedMonitoring,
+		"NetworkIn":         CostHintDetailedMonitoring,
+		"NetworkOut":        CostHintDetailedMonitoring,
+		"NetworkPacketsIn":  CostHintDetailedMonitoring,
+		"NetworkPacketsOut": CostHintDetailedMonitoring,
+	},
+	// the request metrics of S3 have to be enabled per bucket, unlike its daily storage metrics
+	"AWS/S3": {
+		"4xxErrors":           CostHintCustomMetric,
+		"5xxErrors":           CostHintCustomMetric,
+		"AllRequests":         CostHintCustomMetric,
+		"BytesDownloaded":     CostHintCustomMetric,
+		"BytesUploaded":       CostHintCustomMetric,
+		"DeleteRequests":      CostHintCustomMetric,
+		"FirstByteLatency":    CostHintCustomMetric,
+		"GetRequests":         CostHintCustomMetric,
+		"HeadRequests":        CostHintCustomMetric,
+		"ListRequests":        CostHintCustomMetric,
+		"PostRequests":        CostHintCustomMetric,
+		"PutRequests":         CostHintCustomMetric,
+		"SelectRequests":      CostHintCustomMetric,
+		"SelectReturnedBytes": CostHintCustomMetric,
+		"SelectScannedBytes":  CostHintCustomMetric,
+		"TotalRequestLatency": CostHintCustomMetric,
+	},
+}
+
+// GetCostHint returns the cost hint of the metric, which is the hint of the metric if it has one and otherwise the
+// hint of its namespace, or an empty string if the metric isn't known to be charged for
+func GetCostHint(namespace string, metricName string) string {
+	if costHint, ok := metricCostHints[namespace][metricName]; ok {
+		return costHint
+	}
+	return namespaceCostHints[namespace]
+}
+
+// AddCostHints sets the CostHint of the metrics known to be charged for.
+// Other metrics are left without a hint, which doesn't mean they're free, e.g. the metrics of custom namespaces.
+func AddCostHints(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		metrics[i].CostHint = GetCostHint(metrics[i].Namespace, metrics[i].Name)
+	}
+	return metrics
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostHints_GetCostHint(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		expected   string
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", expected: CostHintDetailedMonitoring},
+		{namespace: "AWS/EC2", metricName: "StatusCheckFailed", expected: ""},
+		{namespace: "AWS/S3", metricName: "AllRequests", expected: CostHintCustomMetric},
+		{namespace: "AWS/S3", metricName: "BucketSizeBytes", expected: ""},
+		{namespace: "ECS/ContainerInsights", metricName: "CpuUtilized", expected: CostHintCustomMetric},
+		{namespace: "AWS/Lambda", metricName: "Invocations", expected: ""},
+		{namespace: "customNamespace", metricName: "Requests", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetCostHint(tc.namespace, tc.metricName))
+		})
+	}
+}
+
+func TestCostHints_AddCostHints(t *testing.T) {
+	metrics := AddCostHints([]resources.Metric{
+		{Namespace: "AWS/EC2", Name: "NetworkIn"},
+		{Namespace: "ContainerInsights", Name: "node_cpu_utilization"},
+		{Namespace: "AWS/EC2", Name: "CPUCreditBalance"},
+		{Namespace: "customNamespace", Name: "Requests"},
+	})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "NetworkIn", CostHint: CostHintDetailedMonitoring},
+		{Namespace: "ContainerInsights", Name: "node_cpu_utilization", CostHint: CostHintCustomMetric},
+		{Namespace: "AWS/EC2", Name: "CPUCreditBalance"},
+		{Namespace: "customNamespace", Name: "Requests"},
+	}, metrics)
+}
+
+func TestCostHints_CuratedNamespacesAndMetricsExist(t *testing.T) {
+	for namespace := range namespaceCostHints {
+		assert.Contains(t, constants.NamespaceMetricsMap, namespace)
+	}
+	for namespace, costHints := range metricCostHints {
+		for metricName := range costHints {
+			assert.Contains(t, constants.NamespaceMetricsMap[namespace], metricName)
+		}
+	}
+}
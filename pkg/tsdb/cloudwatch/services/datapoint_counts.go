@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// maxMinDatapointsMetrics caps the number of metrics whose data points are counted, which is the most a single
+// GetMetricData call accepts
+const maxMinDatapointsMetrics = 500
+
+// datapointCountWindow is how far back the data points of a metric are counted, which is also the period of the query
+// so that the count is a single sample count per metric
+const datapointCountWindow = time.Hour
+
+type DatapointCountsService struct {
+	models.MetricsClientProvider
+}
+
+func NewDatapointCountsService(metricsClient models.MetricsClientProvider) models.DatapointCountsProvider {
+	return &DatapointCountsService{metricsClient}
+}
+
+// FilterByMinDatapoints returns the metrics with at least minDatapoints data points in the last hour, counted with a
+// single GetMetricData call. Only the first metrics up to the cap are counted, the metrics after them are returned
+// without being counted rather than left out.
+func (d *DatapointCountsService) FilterByMinDatapoints(ctx context.Context, metrics []resources.TaggedMetric, minDatapoints int) ([]resources.TaggedMetric, error) {
+	counted := metrics
+	if len(counted) > maxMinDatapointsMetrics {
+		counted = counted[:maxMinDatapointsMetrics]
+	}
+	if len(counted) == 0 {
+		return metrics, nil
+	}
+
+	endTime := time.Now()
+	results, err := d.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(endTime.Add(-datapointCountWindow)),
+		EndTime:           aws.Time(endTime),
+		MetricDataQueries: metricStatQueries(counted, int64(datapointCountWindow/time.Second), cloudwatch.StatisticSampleCount),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	// the window may span two periods, and the results of a query are returned once per page
+	counts := make([]float64, len(counted))
+	for _, result := range results {
+		i, err := strconv.Atoi(strings.TrimPrefix(aws.StringValue(result.Id), "m"))
+		if err != nil || i < 0 || i >= len(counted) {
+			continue
+		}
+		for _, value := range result.Values {
+			counts[i] += aws.Float64Value(value)
+		}
+	}
+
+	filtered := make([]resources.TaggedMetric, 0, len(metrics))
+	for i, metric := range counted {
+		if counts[i] >= float64(minDatapoints) {
+			filtered = append(filtered, metric)
+		}
+	}
+	return append(filtered, metrics[len(counted):]...), nil
+}
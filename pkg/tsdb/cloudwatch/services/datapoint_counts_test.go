@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatapointCountsService_FilterByMinDatapoints(t *testing.T) {
+	metrics := []resources.TaggedMetric{
+		{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "busy"}},
+		{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "sparse"}},
+		{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "split"}},
+		{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "idle"}},
+	}
+
+	t.Run("Should leave out the metrics with fewer data points than the minimum", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("m0"), Values: []*float64{aws.Float64(60)}},
+			{Id: aws.String("m1"), Values: []*float64{aws.Float64(2)}},
+			// the window spans two periods
+			{Id: aws.String("m2"), Values: []*float64{aws.Float64(5), aws.Float64(4)}},
+			{Id: aws.String("m3"), Values: []*float64{}},
+		}, nil)
+
+		filtered, err := NewDatapointCountsService(fakeMetricsClient).FilterByMinDatapoints(context.Background(), metrics, 8)
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.TaggedMetric{metrics[0], metrics[2]}, filtered)
+
+		fakeMetricsClient.AssertNumberOfCalls(t, "GetMetricData", 1)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 4)
+		assert.Equal(t, int64(3600), *input.MetricDataQueries[0].MetricStat.Period)
+		assert.Equal(t, cloudwatch.StatisticSampleCount, *input.MetricDataQueries[0].MetricStat.Stat)
+	})
+
+	t.Run("Should keep every metric that reaches the minimum", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("m0"), Values: []*float64{aws.Float64(1)}},
+			{Id: aws.String("m1"), Values: []*float64{aws.Float64(1)}},
+			{Id: aws.String("m2"), Values: []*float64{aws.Float64(1)}},
+			{Id: aws.String("m3"), Values: []*float64{aws.Float64(1)}},
+		}, nil)
+
+		filtered, err := NewDatapointCountsService(fakeMetricsClient).FilterByMinDatapoints(context.Background(), metrics, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, metrics, filtered)
+	})
+
+	t.Run("Should keep the metrics after the cap without counting them", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, nil)
+		many := make([]resources.TaggedMetric, maxMinDatapointsMetrics+2)
+		for i := range many {
+			many[i] = resources.TaggedMetric{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": fmt.Sprint(i)}}
+		}
+
+		filtered, err := NewDatapointCountsService(fakeMetricsClient).FilterByMinDatapoints(context.Background(), many, 1)
+
+		require.NoError(t, err)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Len(t, input.MetricDataQueries, maxMinDatapointsMetrics)
+		assert.Equal(t, many[maxMinDatapointsMetrics:], filtered)
+	})
+
+	t.Run("Should return an error if the data points can't be counted", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, fmt.Errorf("throttled"))
+
+		_, err := NewDatapointCountsService(fakeMetricsClient).FilterByMinDatapoints(context.Background(), metrics, 1)
+
+		assert.EqualError(t, err, "unable to call AWS API: throttled")
+	})
+}
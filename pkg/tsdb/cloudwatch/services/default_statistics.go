@@ -0,0 +1,68 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+const DefaultStatistic = "Average"
+
+// metricDefaultStatistics holds the statistic that makes most sense for well-known metrics,
+// e.g. Sum for counters and Maximum for error and backlog indicators
+var metricDefaultStatistics = map[string]map[string]string{
+	"AWS/ApplicationELB": {
+		"HTTPCode_ELB_5XX_Count":    "Sum",
+		"HTTPCode_Target_5XX_Count": "Sum",
+		"HealthyHostCount":          "Minimum",
+		"RequestCount":              "Sum",
+		"TargetResponseTime":        "Average",
+		"UnHealthyHostCount":        "Maximum",
+	},
+	"AWS/EC2": {
+		"CPUUtilization":    "Average",
+		"DiskReadOps":       "Sum",
+		"DiskWriteOps":      "Sum",
+		"NetworkIn":         "Sum",
+		"NetworkOut":        "Sum",
+		"StatusCheckFailed": "Maximum",
+	},
+	"AWS/Lambda": {
+		"ConcurrentExecutions": "Maximum",
+		"Duration":             "Average",
+		"Errors":               "Sum",
+		"Invocations":          "Sum",
+		"Throttles":            "Sum",
+	},
+	"AWS/RDS": {
+		"CPUUtilization":      "Average",
+		"DatabaseConnections": "Maximum",
+		"FreeStorageSpace":    "Minimum",
+	},
+	"AWS/S3": {
+		"4xxErrors":       "Sum",
+		"5xxErrors":       "Sum",
+		"BucketSizeBytes": "Average",
+		"NumberOfObjects": "Average",
+	},
+	"AWS/SQS": {
+		"ApproximateAgeOfOldestMessage":      "Maximum",
+		"ApproximateNumberOfMessagesVisible": "Maximum",
+		"NumberOfMessagesSent":               "Sum",
+	},
+}
+
+// GetDefaultStatistic returns the curated default statistic for a metric, or Average if there is none
+func GetDefaultStatistic(namespace string, metricName string) string {
+	if statistic, ok := metricDefaultStatistics[namespace][metricName]; ok {
+		return statistic
+	}
+	return DefaultStatistic
+}
+
+// AddDefaultStatistics sets the DefaultStatistic of metrics that have curated metadata.
+// Metrics without curated metadata are left empty so that clients can apply their own default.
+func AddDefaultStatistics(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		if statistic, ok := metricDefaultStatistics[metrics[i].Namespace][metrics[i].Name]; ok {
+			metrics[i].DefaultStatistic = statistic
+		}
+	}
+	return metrics
+}
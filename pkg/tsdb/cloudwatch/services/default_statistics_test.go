@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultStatistics_GetDefaultStatistic(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		expected   string
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", expected: "Average"},
+		{namespace: "AWS/EC2", metricName: "StatusCheckFailed", expected: "Maximum"},
+		{namespace: "AWS/Lambda", metricName: "Invocations", expected: "Sum"},
+		{namespace: "AWS/Lambda", metricName: "ConcurrentExecutions", expected: "Maximum"},
+		{namespace: "AWS/ApplicationELB", metricName: "HealthyHostCount", expected: "Minimum"},
+		{namespace: "AWS/SQS", metricName: "ApproximateAgeOfOldestMessage", expected: "Maximum"},
+		{namespace: "AWS/EC2", metricName: "unknownMetric", expected: "Average"},
+		{namespace: "customNamespace", metricName: "Invocations", expected: "Average"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetDefaultStatistic(tc.namespace, tc.metricName))
+		})
+	}
+}
+
+func TestDefaultStatistics_CuratedMetricsExist(t *testing.T) {
+	for namespace, statistics := range metricDefaultStatistics {
+		for metricName := range statistics {
+			assert.Contains(t, constants.NamespaceMetricsMap[namespace], metricName)
+		}
+	}
+}
+
+func TestDefaultStatistics_AddDefaultStatistics(t *testing.T) {
+	metrics := AddDefaultStatistics([]resources.Metric{{Namespace: "AWS/Lambda", Name: "Errors"}, {Namespace: "customNamespace", Name: "Errors"}})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/Lambda", Name: "Errors", DefaultStatistic: "Sum"},
+		{Namespace: "customNamespace", Name: "Errors"},
+	}, metrics)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// maxDimensionAutocompleteValues caps the number of values returned per dimension key
+const maxDimensionAutocompleteValues = 100
+
+// dimensionAutocompleteCacheTTL is how long the dimension values of a namespace are cached
+const dimensionAutocompleteCacheTTL = 5 * time.Minute
+
+type cachedDimensionAutocomplete struct {
+	values  map[string][]string
+	expires time.Time
+}
+
+// dimensionAutocompleteCache caches the dimension values of a namespace by cache key and namespace
+var dimensionAutocompleteCache = struct {
+	sync.Mutex
+	entries map[string]cachedDimensionAutocomplete
+}{entries: make(map[string]cachedDimensionAutocomplete)}
+
+type DimensionAutocompleteService struct {
+	models.ListMetricsProvider
+	cacheKey string
+}
+
+// NewDimensionAutocompleteService returns a service listing the dimension values of a namespace. The values are cached
+// under the cache key, which has to identify the account and region of the list metrics service.
+func NewDimensionAutocompleteService(listMetricsService models.ListMetricsProvider, cacheKey string) models.DimensionAutocompleteProvider {
+	return &DimensionAutocompleteService{listMetricsService, cacheKey}
+}
+
+// GetDimensionAutocomplete returns every dimension key of the metrics in the namespace with its distinct values, so
+// that a single picker can offer them all at once. The values of the keys are listed concurrently, and only the first
+// values of each key up to the cap are returned.
+func (d *DimensionAutocompleteService) GetDimensionAutocomplete(ctx context.Context, namespace string) (map[string][]string, error) {
+	cacheKey := d.cacheKey + "/" + namespace
+	dimensionAutocompleteCache.Lock()
+	cached, exists := dimensionAutocompleteCache.entries[cacheKey]
+	dimensionAutocompleteCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.values, nil
+	}
+
+	keys, err := d.GetDimensionKeysByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string][]string{}
+	if len(keys) > 0 {
+		values, err = d.GetDimensionValuesByDimensionKeys(ctx, resources.BulkDimensionValuesRequest{
+			ResourceRequest: &resources.ResourceRequest{},
+			Namespace:       namespace,
+			DimensionKeys:   keys,
+			DimensionFilter: []*resources.Dimension{},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, keyValues := range values {
+		if len(keyValues) > maxDimensionAutocompleteValues {
+			values[key] = keyValues[:maxDimensionAutocompleteValues]
+		}
+	}
+
+	dimensionAutocompleteCache.Lock()
+	dimensionAutocompleteCache.entries[cacheKey] = cachedDimensionAutocomplete{values: values, expires: time.Now().Add(dimensionAutocompleteCacheTTL)}
+	dimensionAutocompleteCache.Unlock()
+
+	return values, nil
+}
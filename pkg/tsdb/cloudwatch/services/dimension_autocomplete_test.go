@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDimensionAutocompleteService_GetDimensionAutocomplete(t *testing.T) {
+	t.Cleanup(func() {
+		dimensionAutocompleteCache.entries = make(map[string]cachedDimensionAutocomplete)
+	})
+
+	t.Run("Should return every dimension key of the namespace with its values", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
+
+		values, err := NewDimensionAutocompleteService(NewListMetricsService(fakeMetricsClient), "test/us-east-1").GetDimensionAutocomplete(context.Background(), "AWS/EC2")
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"InstanceId":           {"i-1234567890abcdef0", "i-5234567890abcdef0", "i-64234567890abcdef0"},
+			"InstanceType":         {"t2.micro", "t3.micro"},
+			"AutoScalingGroupName": {"my-asg", "my-asg2"},
+		}, values)
+		// the keys of the namespace are listed once, then the values of each key
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsWithPageLimit", 4)
+		// the values are listed across all metrics of the namespace rather than for an empty metric name
+		fakeMetricsClient.AssertCalled(t, "ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")})
+	})
+
+	t.Run("Should cap the values of each key", func(t *testing.T) {
+		manyValues := make([]string, maxDimensionAutocompleteValues+50)
+		for i := range manyValues {
+			manyValues[i] = fmt.Sprintf("i-%03d", i)
+		}
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByNamespace", mock.Anything, "MyApp").Return([]string{"InstanceId", "Service"}, nil)
+		mockListMetricsService.On("GetDimensionValuesByDimensionKeys", mock.Anything, mock.MatchedBy(func(r resources.BulkDimensionValuesRequest) bool {
+			return r.Namespace == "MyApp" && r.MetricName == "" && assert.Equal(t, []string{"InstanceId", "Service"}, r.DimensionKeys)
+		})).Return(map[string][]string{"InstanceId": manyValues, "Service": {"api"}}, nil)
+
+		values, err := NewDimensionAutocompleteService(mockListMetricsService, "capped/us-east-1").GetDimensionAutocomplete(context.Background(), "MyApp")
+
+		require.NoError(t, err)
+		assert.Equal(t, manyValues[:maxDimensionAutocompleteValues], values["InstanceId"])
+		assert.Equal(t, []string{"api"}, values["Service"])
+	})
+
+	t.Run("Should return no keys for a namespace without dimensions", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByNamespace", mock.Anything, "MyApp").Return([]string{}, nil)
+
+		values, err := NewDimensionAutocompleteService(mockListMetricsService, "empty/us-east-1").GetDimensionAutocomplete(context.Background(), "MyApp")
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{}, values)
+		mockListMetricsService.AssertNotCalled(t, "GetDimensionValuesByDimensionKeys", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should cache the values of a namespace", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByNamespace", mock.Anything, "MyApp").Return([]string{"Service"}, nil)
+		mockListMetricsService.On("GetDimensionValuesByDimensionKeys", mock.Anything, mock.Anything).Return(map[string][]string{"Service": {"api"}}, nil)
+		autocompleteService := NewDimensionAutocompleteService(mockListMetricsService, "cached/us-east-1")
+
+		for i := 0; i < 2; i++ {
+			values, err := autocompleteService.GetDimensionAutocomplete(context.Background(), "MyApp")
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]string{"Service": {"api"}}, values)
+		}
+		mockListMetricsService.AssertNumberOfCalls(t, "GetDimensionKeysByNamespace", 1)
+		mockListMetricsService.AssertNumberOfCalls(t, "GetDimensionValuesByDimensionKeys", 1)
+	})
+
+	t.Run("Should return the error of listing the values", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByNamespace", mock.Anything, "MyApp").Return([]string{"Service"}, nil)
+		mockListMetricsService.On("GetDimensionValuesByDimensionKeys", mock.Anything, mock.Anything).Return(map[string][]string(nil), fmt.Errorf("access denied"))
+
+		_, err := NewDimensionAutocompleteService(mockListMetricsService, "failing/us-east-1").GetDimensionAutocomplete(context.Background(), "MyApp")
+
+		require.Error(t, err)
+	})
+}
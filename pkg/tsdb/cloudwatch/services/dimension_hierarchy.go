@@ -0,0 +1,111 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// dimensionHierarchies holds the order of the dimension keys of namespaces whose dimensions are nested, from the
+// resource that contains the others to the most specific one, e.g. the target groups of a load balancer
+var dimensionHierarchies = map[string][]string{
+	"AWS/ApiGateway":        {"ApiName", "ApiId", "Stage", "Resource", "Method"},
+	"AWS/ApplicationELB":    {"LoadBalancer", "TargetGroup", "AvailabilityZone"},
+	"AWS/DynamoDB":          {"TableName", "GlobalSecondaryIndexName", "Operation"},
+	"AWS/ECS":               {"ClusterName", "ServiceName"},
+	"AWS/ELB":               {"LoadBalancerName", "AvailabilityZone"},
+	"AWS/Lambda":            {"FunctionName", "Resource", "ExecutedVersion"},
+	"AWS/NetworkELB":        {"LoadBalancer", "TargetGroup", "AvailabilityZone"},
+	"AWS/RDS":               {"DBClusterIdentifier", "Role", "DBInstanceIdentifier"},
+	"ContainerInsights":     {"ClusterName", "Namespace", "Service", "PodName"},
+	"ECS/ContainerInsights": {"ClusterName", "ServiceName", "TaskDefinitionFamily"},
+}
+
+// GetDimensionHierarchy returns the curated order of the dimension keys of the namespace, or nil if it has none
+func GetDimensionHierarchy(namespace string) []string {
+	return dimensionHierarchies[namespace]
+}
+
+type dimensionNodeKey struct {
+	parent    *resources.DimensionNode
+	dimension string
+	value     string
+}
+
+// BuildDimensionHierarchy arranges the metrics of a namespace in a tree of their dimension combinations. The path of a
+// metric follows its dimension keys in the curated order of the namespace, followed by the keys that aren't curated in
+// alphabetical order, which is the only order of a namespace without a curated one. A metric reported without some of
+// the curated keys skips their level, e.g. a metric of a load balancer by availability zone sits below the load
+// balancer rather than below a target group. Values of the same key are sorted.
+func BuildDimensionHierarchy(namespace string, metrics []resources.TaggedMetric) resources.DimensionHierarchy {
+	curated := GetDimensionHierarchy(namespace)
+	rank := make(map[string]int, len(curated))
+	for i, key := range curated {
+		rank[key] = i
+	}
+	uncurated := map[string]bool{}
+	for _, metric := range metrics {
+		for key := range metric.Dimensions {
+			if _, ok := rank[key]; !ok {
+				uncurated[key] = true
+			}
+		}
+	}
+	order := append([]string{}, curated...)
+	extra := make([]string, 0, len(uncurated))
+	for key := range uncurated {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		rank[key] = len(order)
+		order = append(order, key)
+	}
+
+	hierarchy := resources.DimensionHierarchy{Order: order, Children: []*resources.DimensionNode{}}
+	nodes := map[dimensionNodeKey]*resources.DimensionNode{}
+	for _, metric := range metrics {
+		keys := make([]string, 0, len(metric.Dimensions))
+		for key := range metric.Dimensions {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return rank[keys[i]] < rank[keys[j]] })
+
+		var parent *resources.DimensionNode
+		for _, key := range keys {
+			nodeKey := dimensionNodeKey{parent: parent, dimension: key, value: metric.Dimensions[key]}
+			node, exists := nodes[nodeKey]
+			if !exists {
+				node = &resources.DimensionNode{Dimension: key, Value: metric.Dimensions[key]}
+				nodes[nodeKey] = node
+				if parent == nil {
+					hierarchy.Children = append(hierarchy.Children, node)
+				} else {
+					parent.Children = append(parent.Children, node)
+				}
+			}
+			parent = node
+		}
+		if parent == nil {
+			hierarchy.Metrics = append(hierarchy.Metrics, metric)
+		} else {
+			parent.Metrics = append(parent.Metrics, metric)
+		}
+	}
+
+	sortDimensionNodes(hierarchy.Children, rank)
+	return hierarchy
+}
+
+// sortDimensionNodes sorts sibling nodes by the rank of their key and then by their value
+func sortDimensionNodes(nodes []*resources.DimensionNode, rank map[string]int) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Dimension != nodes[j].Dimension {
+			return rank[nodes[i].Dimension] < rank[nodes[j].Dimension]
+		}
+		return nodes[i].Value < nodes[j].Value
+	})
+	for _, node := range nodes {
+		sortDimensionNodes(node.Children, rank)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDimensionHierarchy(t *testing.T) {
+	metric := func(name string, dimensions map[string]string) resources.TaggedMetric {
+		return resources.TaggedMetric{Metric: resources.Metric{Namespace: "AWS/ApplicationELB", Name: name}, Dimensions: dimensions}
+	}
+
+	t.Run("Should nest the dimensions of a namespace in their curated order", func(t *testing.T) {
+		requestCount := metric("RequestCount", map[string]string{"LoadBalancer": "app/web"})
+		zoneRequestCount := metric("RequestCount", map[string]string{"LoadBalancer": "app/web", "AvailabilityZone": "us-east-1a"})
+		healthyHosts := metric("HealthyHostCount", map[string]string{"LoadBalancer": "app/web", "TargetGroup": "targetgroup/api", "AvailabilityZone": "us-east-1a"})
+		otherHealthyHosts := metric("HealthyHostCount", map[string]string{"LoadBalancer": "app/web", "TargetGroup": "targetgroup/api", "AvailabilityZone": "us-east-1b"})
+		targetResponseTime := metric("TargetResponseTime", map[string]string{"LoadBalancer": "app/web", "TargetGroup": "targetgroup/api"})
+		otherLoadBalancer := metric("RequestCount", map[string]string{"LoadBalancer": "app/admin"})
+
+		hierarchy := BuildDimensionHierarchy("AWS/ApplicationELB", []resources.TaggedMetric{otherHealthyHosts, healthyHosts, requestCount, zoneRequestCount, targetResponseTime, otherLoadBalancer})
+
+		assert.Equal(t, resources.DimensionHierarchy{
+			Order: []string{"LoadBalancer", "TargetGroup", "AvailabilityZone"},
+			Children: []*resources.DimensionNode{
+				{Dimension: "LoadBalancer", Value: "app/admin", Metrics: []resources.TaggedMetric{otherLoadBalancer}},
+				{
+					Dimension: "LoadBalancer", Value: "app/web", Metrics: []resources.TaggedMetric{requestCount},
+					Children: []*resources.DimensionNode{
+						{
+							Dimension: "TargetGroup", Value: "targetgroup/api", Metrics: []resources.TaggedMetric{targetResponseTime},
+							Children: []*resources.DimensionNode{
+								{Dimension: "AvailabilityZone", Value: "us-east-1a", Metrics: []resources.TaggedMetric{healthyHosts}},
+								{Dimension: "AvailabilityZone", Value: "us-east-1b", Metrics: []resources.TaggedMetric{otherHealthyHosts}},
+							},
+						},
+						{Dimension: "AvailabilityZone", Value: "us-east-1a", Metrics: []resources.TaggedMetric{zoneRequestCount}},
+					},
+				},
+			},
+		}, hierarchy)
+	})
+
+	t.Run("Should keep metrics without dimensions at the root", func(t *testing.T) {
+		total := metric("RequestCount", map[string]string{})
+
+		hierarchy := BuildDimensionHierarchy("AWS/ApplicationELB", []resources.TaggedMetric{total})
+
+		assert.Equal(t, []resources.TaggedMetric{total}, hierarchy.Metrics)
+		assert.Empty(t, hierarchy.Children)
+	})
+
+	t.Run("Should put keys that aren't curated after the curated ones in alphabetical order", func(t *testing.T) {
+		hierarchy := BuildDimensionHierarchy("AWS/ApplicationELB", []resources.TaggedMetric{
+			metric("RequestCount", map[string]string{"LoadBalancer": "app/web", "Zeta": "z", "Alpha": "a"}),
+		})
+
+		assert.Equal(t, []string{"LoadBalancer", "TargetGroup", "AvailabilityZone", "Alpha", "Zeta"}, hierarchy.Order)
+		require.Len(t, hierarchy.Children, 1)
+		require.Len(t, hierarchy.Children[0].Children, 1)
+		assert.Equal(t, "Alpha", hierarchy.Children[0].Children[0].Dimension)
+		require.Len(t, hierarchy.Children[0].Children[0].Children, 1)
+		assert.Equal(t, "Zeta", hierarchy.Children[0].Children[0].Children[0].Dimension)
+	})
+
+	t.Run("Should order the keys of a namespace without a curated order alphabetically", func(t *testing.T) {
+		hierarchy := BuildDimensionHierarchy("MyApp", []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api", "Environment": "prod"}},
+		})
+
+		assert.Equal(t, []string{"Environment", "Service"}, hierarchy.Order)
+		require.Len(t, hierarchy.Children, 1)
+		assert.Equal(t, "Environment", hierarchy.Children[0].Dimension)
+		assert.Equal(t, "Service", hierarchy.Children[0].Children[0].Dimension)
+	})
+}
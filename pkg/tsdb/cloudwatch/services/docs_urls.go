@@ -0,0 +1,48 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+// namespaceDocsURLs holds the AWS documentation page listing the metrics of well-known namespaces
+var namespaceDocsURLs = map[string]string{
+	"AWS/ApiGateway":     "https://docs.aws.amazon.com/apigateway/latest/developerguide/api-gateway-metrics-and-dimensions.html",
+	"AWS/ApplicationELB": "https://docs.aws.amazon.com/elasticloadbalancing/latest/application/load-balancer-cloudwatch-metrics.html",
+	"AWS/DynamoDB":       "https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/metrics-dimensions.html",
+	"AWS/EBS":            "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/using_cloudwatch_ebs.html",
+	"AWS/EC2":            "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/viewing_metrics_with_cloudwatch.html",
+	"AWS/ECS":            "https://docs.aws.amazon.com/AmazonECS/latest/developerguide/cloudwatch-metrics.html",
+	"AWS/ELB":            "https://docs.aws.amazon.com/elasticloadbalancing/latest/classic/elb-cloudwatch-metrics.html",
+	"AWS/Lambda":         "https://docs.aws.amazon.com/lambda/latest/dg/monitoring-metrics.html",
+	"AWS/NetworkELB":     "https://docs.aws.amazon.com/elasticloadbalancing/latest/network/load-balancer-cloudwatch-metrics.html",
+	"AWS/RDS":            "https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/rds-metrics.html",
+	"AWS/S3":             "https://docs.aws.amazon.com/AmazonS3/latest/userguide/metrics-dimensions.html",
+	"AWS/SNS":            "https://docs.aws.amazon.com/sns/latest/dg/sns-monitoring-using-cloudwatch.html",
+	"AWS/SQS":            "https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-available-cloudwatch-metrics.html",
+}
+
+// metricDocsURLs holds the AWS documentation page of well-known metrics that are documented on a page of their own
+// rather than on the page of their namespace
+var metricDocsURLs = map[string]map[string]string{
+	"AWS/EC2": {
+		"StatusCheckFailed":          "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html",
+		"StatusCheckFailed_Instance": "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html",
+		"StatusCheckFailed_System":   "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html",
+	},
+}
+
+// GetDocsURL returns the URL of the AWS documentation of the metric, which is the page of the metric if it has one
+// and otherwise the page of its namespace, or an empty string if the namespace isn't known
+func GetDocsURL(namespace string, metricName string) string {
+	if docsURL, ok := metricDocsURLs[namespace][metricName]; ok {
+		return docsURL
+	}
+	return namespaceDocsURLs[namespace]
+}
+
+// AddDocsURLs sets the DocsURL of metrics of known namespaces.
+// Metrics of other namespaces are left without a URL, since custom namespaces aren't documented by AWS.
+func AddDocsURLs(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		metrics[i].DocsURL = GetDocsURL(metrics[i].Namespace, metrics[i].Name)
+	}
+	return metrics
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsURLs_GetDocsURL(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		expected   string
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", expected: "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/viewing_metrics_with_cloudwatch.html"},
+		{namespace: "AWS/EC2", metricName: "StatusCheckFailed", expected: "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html"},
+		{namespace: "AWS/Lambda", metricName: "Invocations", expected: "https://docs.aws.amazon.com/lambda/latest/dg/monitoring-metrics.html"},
+		{namespace: "AWS/Lambda", metricName: "unknownMetric", expected: "https://docs.aws.amazon.com/lambda/latest/dg/monitoring-metrics.html"},
+		{namespace: "customNamespace", metricName: "Invocations", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetDocsURL(tc.namespace, tc.metricName))
+		})
+	}
+}
+
+func TestDocsURLs_AddDocsURLs(t *testing.T) {
+	metrics := AddDocsURLs([]resources.Metric{
+		{Namespace: "AWS/SQS", Name: "NumberOfMessagesSent"},
+		{Namespace: "AWS/EC2", Name: "StatusCheckFailed_System"},
+		{Namespace: "customNamespace", Name: "Requests"},
+	})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/SQS", Name: "NumberOfMessagesSent", DocsURL: "https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-available-cloudwatch-metrics.html"},
+		{Namespace: "AWS/EC2", Name: "StatusCheckFailed_System", DocsURL: "https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/monitoring-system-instance-status-check.html"},
+		{Namespace: "customNamespace", Name: "Requests"},
+	}, metrics)
+}
+
+func TestDocsURLs_CuratedNamespacesAndMetricsExist(t *testing.T) {
+	for namespace := range namespaceDocsURLs {
+		assert.Contains(t, constants.NamespaceMetricsMap, namespace)
+	}
+	for namespace, docsURLs := range metricDocsURLs {
+		for metricName := range docsURLs {
+			assert.Contains(t, constants.NamespaceMetricsMap[namespace], metricName)
+		}
+	}
+}
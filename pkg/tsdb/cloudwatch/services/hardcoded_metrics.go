@@ -8,6 +8,7 @@ import (
 )
 
 var GetHardCodedDimensionKeysByNamespace = func(namespace string) ([]string, error) {
+	namespace = ResolveNamespaceAlias(namespace)
 	var dimensionKeys []string
 	exists := false
 	if dimensionKeys, exists = constants.NamespaceDimensionKeysMap[namespace]; !exists {
@@ -17,6 +18,7 @@ var GetHardCodedDimensionKeysByNamespace = func(namespace string) ([]string, err
 }
 
 var GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Metric, error) {
+	namespace = ResolveNamespaceAlias(namespace)
 	response := []resources.Metric{}
 	exists := false
 	var metrics []string
@@ -42,6 +44,25 @@ var GetAllHardCodedMetrics = func() []resources.Metric {
 	return response
 }
 
+// IsHardCodedNamespace returns whether the metrics of the namespace are hardcoded, which those of custom namespaces
+// aren't
+func IsHardCodedNamespace(namespace string) bool {
+	_, exists := constants.NamespaceMetricsMap[namespace]
+	return exists
+}
+
+// FilterHardCodedMetricsWithoutDimensions leaves out the hardcoded metrics of namespaces that don't have any
+// dimensions. The dimensions of the individual hardcoded metrics aren't known, so those of their namespace are used.
+func FilterHardCodedMetricsWithoutDimensions(metrics []resources.Metric) []resources.Metric {
+	filtered := []resources.Metric{}
+	for _, metric := range metrics {
+		if len(constants.NamespaceDimensionKeysMap[metric.Namespace]) > 0 {
+			filtered = append(filtered, metric)
+		}
+	}
+	return filtered
+}
+
 var GetHardCodedNamespaces = func() []string {
 	var namespaces []string
 	for key := range constants.NamespaceMetricsMap {
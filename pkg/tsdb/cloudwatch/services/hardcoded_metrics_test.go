@@ -21,6 +21,14 @@ func TestHardcodedMetrics_GetHardCodedDimensionKeysByNamespace(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, []string{"AutoScalingGroupName", "ImageId", "InstanceId", "InstanceType"}, resp)
 	})
+
+	t.Run("Should resolve a namespace alias", func(t *testing.T) {
+		resp, err := GetHardCodedDimensionKeysByNamespace("ALB")
+		require.NoError(t, err)
+		expected, err := GetHardCodedDimensionKeysByNamespace("AWS/ApplicationELB")
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp)
+	})
 }
 
 func TestHardcodedMetrics_GetHardCodedMetricsByNamespace(t *testing.T) {
@@ -36,4 +44,30 @@ func TestHardcodedMetrics_GetHardCodedMetricsByNamespace(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, []resources.Metric{{Name: "ActionExecution", Namespace: "AWS/IoTAnalytics"}, {Name: "ActivityExecutionError", Namespace: "AWS/IoTAnalytics"}, {Name: "IncomingMessages", Namespace: "AWS/IoTAnalytics"}}, resp)
 	})
+
+	t.Run("Should resolve a namespace alias and return the metrics with the canonical namespace", func(t *testing.T) {
+		resp, err := GetHardCodedMetricsByNamespace("alb")
+		require.NoError(t, err)
+		require.NotEmpty(t, resp)
+		for _, metric := range resp {
+			assert.Equal(t, "AWS/ApplicationELB", metric.Namespace)
+		}
+	})
+}
+
+func TestHardcodedMetrics_FilterHardCodedMetricsWithoutDimensions(t *testing.T) {
+	t.Run("Should leave out the metrics of namespaces without dimensions", func(t *testing.T) {
+		resp := FilterHardCodedMetricsWithoutDimensions([]resources.Metric{
+			{Name: "CPUUtilization", Namespace: "AWS/EC2"},
+			{Name: "CallCount", Namespace: "AWS/EC2/API"},
+			{Name: "Bounce", Namespace: "AWS/SES"},
+		})
+		assert.Equal(t, []resources.Metric{{Name: "CPUUtilization", Namespace: "AWS/EC2"}}, resp)
+	})
+}
+
+func TestHardcodedMetrics_IsHardCodedNamespace(t *testing.T) {
+	assert.True(t, IsHardCodedNamespace("AWS/EC2"))
+	assert.False(t, IsHardCodedNamespace("MyApp"))
+	assert.False(t, IsHardCodedNamespace("EC2"))
 }
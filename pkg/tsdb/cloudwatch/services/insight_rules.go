@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// insightRulesCacheTTL is how long the Contributor Insights rules of an account and region are cached
+const insightRulesCacheTTL = 5 * time.Minute
+
+// maxInsightRulesPages caps the number of DescribeInsightRules pages that are described
+const maxInsightRulesPages = 10
+
+// dynamoDBInsightRulePrefix is the prefix of the rules DynamoDB manages for the Contributor Insights of a table, which
+// are named DynamoDBContributorInsights-<type>-<table>-<timestamp>
+const dynamoDBInsightRulePrefix = "DynamoDBContributorInsights-"
+
+// insightRuleTarget is what a Contributor Insights rule covers, which are the metrics of the namespace whose dimension
+// matches one of the patterns, or all metrics of the namespace if there's no dimension. Patterns ending with * match by
+// prefix.
+type insightRuleTarget struct {
+	namespace string
+	dimension string
+	patterns  []string
+}
+
+type cachedInsightRules struct {
+	targets []insightRuleTarget
+	expires time.Time
+}
+
+// insightRulesCache caches the targets of the enabled Contributor Insights rules by cache key
+var insightRulesCache = struct {
+	sync.Mutex
+	entries map[string]cachedInsightRules
+}{entries: make(map[string]cachedInsightRules)}
+
+type InsightRulesService struct {
+	models.InsightRulesAPIProvider
+	cacheKey string
+}
+
+// NewInsightRulesService returns a service marking metrics with whether Contributor Insights rules cover them. The
+// rules are cached under the cache key, which has to identify the account and region of the client.
+func NewInsightRulesService(insightRulesClient models.InsightRulesAPIProvider, cacheKey string) models.InsightRulesProvider {
+	return &InsightRulesService{insightRulesClient, cacheKey}
+}
+
+// AddInsightRuleFlags sets HasInsightRules of each metric to whether an enabled Contributor Insights rule covers it, so
+// that its top contributors can be drilled down into. Rules on log groups cover the AWS/Logs metrics of their log
+// groups, and the rules DynamoDB manages cover the AWS/DynamoDB metrics of their table. If describing the rules isn't
+// allowed, the metrics aren't marked at all and no error is returned, so that listing metrics doesn't depend on the
+// permission.
+func (s *InsightRulesService) AddInsightRuleFlags(metrics []resources.TaggedMetric) error {
+	targets, err := s.getInsightRuleTargets()
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && (awsErr.Code() == "AccessDenied" || awsErr.Code() == "AccessDeniedException") {
+			return nil
+		}
+		return err
+	}
+
+	for i, metric := range metrics {
+		covered := false
+		for _, target := range targets {
+			if target.covers(metric) {
+				covered = true
+				break
+			}
+		}
+		metrics[i].HasInsightRules = aws.Bool(covered)
+	}
+
+	return nil
+}
+
+// getInsightRuleTargets returns the targets of the enabled rules, of which only the first pages up to the cap are
+// described
+func (s *InsightRulesService) getInsightRuleTargets() ([]insightRuleTarget, error) {
+	insightRulesCache.Lock()
+	cached, exists := insightRulesCache.entries[s.cacheKey]
+	insightRulesCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.targets, nil
+	}
+
+	targets := []insightRuleTarget{}
+	input := &cloudwatch.DescribeInsightRulesInput{}
+	for page := 0; page < maxInsightRulesPages; page++ {
+		output, err := s.DescribeInsightRules(input)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+		}
+		for _, rule := range output.InsightRules {
+			if target, ok := getInsightRuleTarget(rule); ok {
+				targets = append(targets, target)
+			}
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		input = &cloudwatch.DescribeInsightRulesInput{NextToken: output.NextToken}
+	}
+
+	insightRulesCache.Lock()
+	insightRulesCache.entries[s.cacheKey] = cachedInsightRules{targets: targets, expires: time.Now().Add(insightRulesCacheTTL)}
+	insightRulesCache.Unlock()
+
+	return targets, nil
+}
+
+// getInsightRuleTarget returns what the rule covers, unless it's disabled or it's not known what it covers
+func getInsightRuleTarget(rule *cloudwatch.InsightRule) (insightRuleTarget, bool) {
+	if aws.StringValue(rule.State) != "ENABLED" {
+		return insightRuleTarget{}, false
+	}
+
+	if aws.BoolValue(rule.ManagedRule) {
+		name := aws.StringValue(rule.Name)
+		if !strings.HasPrefix(name, dynamoDBInsightRulePrefix) {
+			return insightRuleTarget{}, false
+		}
+		target := insightRuleTarget{namespace: "AWS/DynamoDB"}
+		if table := getDynamoDBInsightRuleTable(name); table != "" {
+			target.dimension = "TableName"
+			target.patterns = []string{table}
+		}
+		return target, true
+	}
+
+	var definition struct {
+		LogGroupNames []string
+	}
+	if err := json.Unmarshal([]byte(aws.StringValue(rule.Definition)), &definition); err != nil || len(definition.LogGroupNames) == 0 {
+		return insightRuleTarget{}, false
+	}
+	return insightRuleTarget{namespace: "AWS/Logs", dimension: "LogGroupName", patterns: definition.LogGroupNames}, true
+}
+
+// getDynamoDBInsightRuleTable returns the table of a rule DynamoDB manages, or an empty string if the name doesn't
+// have one
+func getDynamoDBInsightRuleTable(name string) string {
+	_, table, found := strings.Cut(strings.TrimPrefix(name, dynamoDBInsightRulePrefix), "-")
+	if !found {
+		return ""
+	}
+	if i := strings.LastIndex(table, "-"); i > 0 {
+		if _, err := strconv.ParseInt(table[i+1:], 10, 64); err == nil {
+			table = table[:i]
+		}
+	}
+	// table names can't contain a /, so whatever follows one, e.g. an index, isn't part of the table
+	table, _, _ = strings.Cut(table, "/")
+	return table
+}
+
+func (t insightRuleTarget) covers(metric resources.TaggedMetric) bool {
+	if metric.Namespace != t.namespace {
+		return false
+	}
+	if t.dimension == "" {
+		return true
+	}
+
+	value, exists := metric.Dimensions[t.dimension]
+	if !exists {
+		return false
+	}
+	for _, pattern := range t.patterns {
+		if value == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
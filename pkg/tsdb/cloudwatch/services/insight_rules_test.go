@@ -0,0 +1,129 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func insightRule(name string, state string, managed bool, definition string) *cloudwatch.InsightRule {
+	return &cloudwatch.InsightRule{Name: aws.String(name), State: aws.String(state), ManagedRule: aws.Bool(managed), Definition: aws.String(definition)}
+}
+
+func TestInsightRulesService_AddInsightRuleFlags(t *testing.T) {
+	t.Cleanup(func() {
+		insightRulesCache.entries = make(map[string]cachedInsightRules)
+	})
+
+	hasInsightRules := func(metrics []resources.TaggedMetric) []bool {
+		flags := []bool{}
+		for _, metric := range metrics {
+			require.NotNil(t, metric.HasInsightRules)
+			flags = append(flags, *metric.HasInsightRules)
+		}
+		return flags
+	}
+
+	t.Run("Should mark the metrics of the tables and log groups with enabled rules", func(t *testing.T) {
+		fakeInsightRulesClient := &mocks.FakeInsightRulesClient{}
+		fakeInsightRulesClient.On("DescribeInsightRules", &cloudwatch.DescribeInsightRulesInput{}).Return(&cloudwatch.DescribeInsightRulesOutput{
+			InsightRules: []*cloudwatch.InsightRule{
+				insightRule("DynamoDBContributorInsights-PKC-orders-1664437290456", "ENABLED", true, ""),
+				insightRule("DynamoDBContributorInsights-PKC-users-1664437290456", "DISABLED", true, ""),
+				insightRule("api-errors", "ENABLED", false, `{"Schema":{"Name":"CloudWatchLogRule","Version":1},"LogGroupNames":["/aws/api-gateway/*"]}`),
+			},
+			NextToken: aws.String("next"),
+		}, nil)
+		fakeInsightRulesClient.On("DescribeInsightRules", &cloudwatch.DescribeInsightRulesInput{NextToken: aws.String("next")}).Return(&cloudwatch.DescribeInsightRulesOutput{
+			InsightRules: []*cloudwatch.InsightRule{
+				insightRule("lambda-invocations", "ENABLED", false, `{"Schema":{"Name":"CloudWatchLogRule","Version":1},"LogGroupNames":["/aws/lambda/my-function"]}`),
+			},
+		}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "orders"}},
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "users"}},
+			{Metric: resources.Metric{Namespace: "AWS/Logs", Name: "IncomingBytes"}, Dimensions: map[string]string{"LogGroupName": "/aws/api-gateway/prod"}},
+			{Metric: resources.Metric{Namespace: "AWS/Logs", Name: "IncomingBytes"}, Dimensions: map[string]string{"LogGroupName": "/aws/lambda/my-function"}},
+			{Metric: resources.Metric{Namespace: "AWS/Logs", Name: "IncomingBytes"}, Dimensions: map[string]string{"LogGroupName": "/aws/lambda/other-function"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}
+
+		err := NewInsightRulesService(fakeInsightRulesClient, "test/us-east-1").AddInsightRuleFlags(metrics)
+
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false, true, true, false, false}, hasInsightRules(metrics))
+		fakeInsightRulesClient.AssertNumberOfCalls(t, "DescribeInsightRules", 2)
+	})
+
+	t.Run("Should mark all metrics of the namespace if the table of a managed rule is unknown", func(t *testing.T) {
+		fakeInsightRulesClient := &mocks.FakeInsightRulesClient{}
+		fakeInsightRulesClient.On("DescribeInsightRules", mock.Anything).Return(&cloudwatch.DescribeInsightRulesOutput{
+			InsightRules: []*cloudwatch.InsightRule{insightRule("DynamoDBContributorInsights-PKC", "ENABLED", true, "")},
+		}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "orders"}},
+			{Metric: resources.Metric{Namespace: "AWS/Logs", Name: "IncomingBytes"}, Dimensions: map[string]string{"LogGroupName": "/aws/lambda/my-function"}},
+		}
+
+		err := NewInsightRulesService(fakeInsightRulesClient, "unknown-table/us-east-1").AddInsightRuleFlags(metrics)
+
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false}, hasInsightRules(metrics))
+	})
+
+	t.Run("Should cache the rules", func(t *testing.T) {
+		fakeInsightRulesClient := &mocks.FakeInsightRulesClient{}
+		fakeInsightRulesClient.On("DescribeInsightRules", mock.Anything).Return(&cloudwatch.DescribeInsightRulesOutput{
+			InsightRules: []*cloudwatch.InsightRule{insightRule("DynamoDBContributorInsights-SKT-orders-1664437290456", "ENABLED", true, "")},
+		}, nil)
+		insightRulesService := NewInsightRulesService(fakeInsightRulesClient, "cached/us-east-1")
+
+		for i := 0; i < 2; i++ {
+			metrics := []resources.TaggedMetric{
+				{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ThrottledRequests"}, Dimensions: map[string]string{"TableName": "orders"}},
+			}
+			require.NoError(t, insightRulesService.AddInsightRuleFlags(metrics))
+			assert.Equal(t, []bool{true}, hasInsightRules(metrics))
+		}
+		fakeInsightRulesClient.AssertNumberOfCalls(t, "DescribeInsightRules", 1)
+	})
+
+	t.Run("Should leave the metrics unmarked if describing the rules is denied", func(t *testing.T) {
+		fakeInsightRulesClient := &mocks.FakeInsightRulesClient{}
+		fakeInsightRulesClient.On("DescribeInsightRules", mock.Anything).Return(&cloudwatch.DescribeInsightRulesOutput{}, awserr.New("AccessDenied", "access denied", nil))
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "orders"}},
+		}
+
+		err := NewInsightRulesService(fakeInsightRulesClient, "denied/us-east-1").AddInsightRuleFlags(metrics)
+
+		require.NoError(t, err)
+		assert.Nil(t, metrics[0].HasInsightRules)
+	})
+
+	t.Run("Should return other errors of the API", func(t *testing.T) {
+		fakeInsightRulesClient := &mocks.FakeInsightRulesClient{}
+		fakeInsightRulesClient.On("DescribeInsightRules", mock.Anything).Return(&cloudwatch.DescribeInsightRulesOutput{}, awserr.New("InternalFailure", "internal failure", nil))
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/DynamoDB", Name: "ConsumedReadCapacityUnits"}, Dimensions: map[string]string{"TableName": "orders"}},
+		}
+
+		err := NewInsightRulesService(fakeInsightRulesClient, "failing/us-east-1").AddInsightRuleFlags(metrics)
+
+		require.Error(t, err)
+	})
+}
+
+func TestGetDynamoDBInsightRuleTable(t *testing.T) {
+	assert.Equal(t, "orders", getDynamoDBInsightRuleTable("DynamoDBContributorInsights-PKC-orders-1664437290456"))
+	assert.Equal(t, "my-orders", getDynamoDBInsightRuleTable("DynamoDBContributorInsights-SKT-my-orders-1664437290456"))
+	assert.Equal(t, "orders", getDynamoDBInsightRuleTable("DynamoDBContributorInsights-PKC-orders/index/by-date-1664437290456"))
+	assert.Equal(t, "", getDynamoDBInsightRuleTable("DynamoDBContributorInsights-PKC"))
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"golang.org/x/sync/errgroup"
+)
+
+// latestDataPointsBatchSize is the number of metrics queried per GetMetricData call, which is the most it accepts
+const latestDataPointsBatchSize = 500
+
+// maxLatestDataPointsMetrics caps the number of metrics whose latest data point is queried
+const maxLatestDataPointsMetrics = 4 * latestDataPointsBatchSize
+
+// maxConcurrentLatestDataPointsRequests limits the number of GetMetricData calls made concurrently
+const maxConcurrentLatestDataPointsRequests = 4
+
+// latestDataPointWindow is how far back the latest data point of a metric is looked for
+const latestDataPointWindow = 3 * time.Hour
+
+// latestDataPointPeriod is the period of the latest data point, which is the resolution of basic monitoring
+const latestDataPointPeriod = 300
+
+type LatestDataPointsService struct {
+	models.MetricsClientProvider
+}
+
+func NewLatestDataPointsService(metricsClient models.MetricsClientProvider) models.LatestDataPointsProvider {
+	return &LatestDataPointsService{metricsClient}
+}
+
+// AddLatestDataPoints sets the latest data point of each metric, aggregated by the default statistic of the metric.
+// Metrics without data points in the last three hours don't get a data point, and only the first metrics up to the
+// cap are queried.
+func (l *LatestDataPointsService) AddLatestDataPoints(ctx context.Context, metrics []resources.TaggedMetric) error {
+	if len(metrics) > maxLatestDataPointsMetrics {
+		metrics = metrics[:maxLatestDataPointsMetrics]
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-latestDataPointWindow)
+
+	var mu sync.Mutex
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentLatestDataPointsRequests)
+	for start := 0; start < len(metrics); start += latestDataPointsBatchSize {
+		batch := metrics[start:minInt(start+latestDataPointsBatchSize, len(metrics))]
+		eg.Go(func() error {
+			results, err := l.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+				StartTime:         aws.Time(startTime),
+				EndTime:           aws.Time(endTime),
+				ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+				MetricDataQueries: metricStatQueries(batch, latestDataPointPeriod, ""),
+			})
+			if err != nil {
+				return fmt.Errorf("%v: %w", "unable to call AWS API", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range results {
+				i, err := strconv.Atoi(strings.TrimPrefix(aws.StringValue(result.Id), "m"))
+				if err != nil || i < 0 || i >= len(batch) || len(result.Values) == 0 || len(result.Timestamps) == 0 {
+					continue
+				}
+				// the results of a query are returned once per page, the first data point of the first page is the latest
+				if batch[i].Latest == nil {
+					batch[i].Latest = &resources.DataPoint{Value: aws.Float64Value(result.Values[0]), Timestamp: aws.TimeValue(result.Timestamps[0])}
+				}
+			}
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+// metricStatQueries returns a query for each of the metrics aggregated over the period by the statistic, or by the
+// default statistic of each metric if it's empty. The id of a query is m followed by the index of the metric.
+func metricStatQueries(metrics []resources.TaggedMetric, period int64, statistic string) []*cloudwatch.MetricDataQuery {
+	queries := make([]*cloudwatch.MetricDataQuery, 0, len(metrics))
+	for i, metric := range metrics {
+		dimensionKeys := make([]string, 0, len(metric.Dimensions))
+		for key := range metric.Dimensions {
+			dimensionKeys = append(dimensionKeys, key)
+		}
+		sort.Strings(dimensionKeys)
+
+		dimensions := make([]*cloudwatch.Dimension, 0, len(dimensionKeys))
+		for _, key := range dimensionKeys {
+			dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(key), Value: aws.String(metric.Dimensions[key])})
+		}
+
+		stat := statistic
+		if stat == "" {
+			stat = GetDefaultStatistic(metric.Namespace, metric.Name)
+		}
+
+		queries = append(queries, &cloudwatch.MetricDataQuery{
+			Id: aws.String("m" + strconv.Itoa(i)),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String(metric.Namespace),
+					MetricName: aws.String(metric.Name),
+					Dimensions: dimensions,
+				},
+				Period: aws.Int64(period),
+				Stat:   aws.String(stat),
+			},
+		})
+	}
+
+	return queries
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
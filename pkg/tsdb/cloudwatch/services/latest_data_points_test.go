@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestDataPointsService_AddLatestDataPoints(t *testing.T) {
+	latest := time.Date(2022, 11, 1, 10, 5, 0, 0, time.UTC)
+
+	t.Run("Should attach the latest data point to the metrics that have data", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("m0"), Values: []*float64{aws.Float64(42), aws.Float64(40)}, Timestamps: []*time.Time{aws.Time(latest), aws.Time(latest.Add(-5 * time.Minute))}},
+			{Id: aws.String("m1"), Values: []*float64{}, Timestamps: []*time.Time{}},
+			{Id: aws.String("m2"), Values: []*float64{aws.Float64(7)}, Timestamps: []*time.Time{aws.Time(latest)}},
+		}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-1", "AutoScalingGroupName": "asg"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+			{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Errors"}, Dimensions: map[string]string{"FunctionName": "fn"}},
+		}
+
+		err := NewLatestDataPointsService(fakeMetricsClient).AddLatestDataPoints(context.Background(), metrics)
+
+		require.NoError(t, err)
+		assert.Equal(t, &resources.DataPoint{Value: 42, Timestamp: latest}, metrics[0].Latest)
+		assert.Nil(t, metrics[1].Latest)
+		assert.Equal(t, &resources.DataPoint{Value: 7, Timestamp: latest}, metrics[2].Latest)
+
+		fakeMetricsClient.AssertNumberOfCalls(t, "GetMetricData", 1)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, cloudwatch.ScanByTimestampDescending, *input.ScanBy)
+		require.Len(t, input.MetricDataQueries, 3)
+		assert.Equal(t, &cloudwatch.MetricDataQuery{
+			Id: aws.String("m0"),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String("AWS/EC2"),
+					MetricName: aws.String("CPUUtilization"),
+					Dimensions: []*cloudwatch.Dimension{
+						{Name: aws.String("AutoScalingGroupName"), Value: aws.String("asg")},
+						{Name: aws.String("InstanceId"), Value: aws.String("i-1")},
+					},
+				},
+				Period: aws.Int64(latestDataPointPeriod),
+				Stat:   aws.String("Average"),
+			},
+		}, input.MetricDataQueries[0])
+		assert.Equal(t, "Sum", *input.MetricDataQueries[2].MetricStat.Stat)
+	})
+
+	t.Run("Should query the metrics in batches up to the cap", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, nil)
+		metrics := make([]resources.TaggedMetric, maxLatestDataPointsMetrics+10)
+		for i := range metrics {
+			metrics[i] = resources.TaggedMetric{Metric: resources.Metric{Namespace: "MyApp", Name: fmt.Sprintf("metric-%d", i)}}
+		}
+
+		err := NewLatestDataPointsService(fakeMetricsClient).AddLatestDataPoints(context.Background(), metrics)
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertNumberOfCalls(t, "GetMetricData", maxLatestDataPointsMetrics/latestDataPointsBatchSize)
+		for _, call := range fakeMetricsClient.Calls {
+			assert.Len(t, call.Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries, latestDataPointsBatchSize)
+		}
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, fmt.Errorf("access denied"))
+		metrics := []resources.TaggedMetric{{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}}}
+
+		err := NewLatestDataPointsService(fakeMetricsClient).AddLatestDataPoints(context.Background(), metrics)
+
+		require.Error(t, err)
+	})
+}
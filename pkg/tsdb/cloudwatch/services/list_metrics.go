@@ -1,15 +1,33 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"golang.org/x/sync/errgroup"
 )
 
+var ErrMetricStreamNotFound = errors.New("metric stream not found")
+
+// ErrInvalidNextToken is returned if the token of a page of metrics wasn't returned by GetMetricsByNamespacePage
+var ErrInvalidNextToken = errors.New("invalid nextToken")
+
+// maxConcurrentDimensionValuesRequests limits the number of ListMetrics calls made concurrently for a bulk dimension values request
+const maxConcurrentDimensionValuesRequests = 5
+
+// metricCountPageLimit caps the number of ListMetrics pages listed to count the metrics of a namespace
+const metricCountPageLimit = 2
+
 type ListMetricsService struct {
 	models.MetricsClientProvider
 }
@@ -18,7 +36,7 @@ func NewListMetricsService(metricsClient models.MetricsClientProvider) models.Li
 	return &ListMetricsService{metricsClient}
 }
 
-func (l *ListMetricsService) GetDimensionKeysByDimensionFilter(r resources.DimensionKeysRequest) ([]string, error) {
+func (l *ListMetricsService) GetDimensionKeysByDimensionFilter(ctx context.Context, r resources.DimensionKeysRequest) ([]string, error) {
 	input := &cloudwatch.ListMetricsInput{}
 	if r.Namespace != "" {
 		input.Namespace = aws.String(r.Namespace)
@@ -26,9 +44,8 @@ func (l *ListMetricsService) GetDimensionKeysByDimensionFilter(r resources.Dimen
 	if r.MetricName != "" {
 		input.MetricName = aws.String(r.MetricName)
 	}
-	setDimensionFilter(input, r.DimensionFilter)
 
-	metrics, err := l.ListMetricsWithPageLimit(input)
+	metrics, err := l.listMetricsByDimensionFilter(ctx, input, r.DimensionFilter, r.ExpandWildcards)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
 	}
@@ -63,14 +80,15 @@ func (l *ListMetricsService) GetDimensionKeysByDimensionFilter(r resources.Dimen
 	return dimensionKeys, nil
 }
 
-func (l *ListMetricsService) GetDimensionValuesByDimensionFilter(r resources.DimensionValuesRequest) ([]string, error) {
+func (l *ListMetricsService) GetDimensionValuesByDimensionFilter(ctx context.Context, r resources.DimensionValuesRequest) ([]string, error) {
 	input := &cloudwatch.ListMetricsInput{
-		Namespace:  aws.String(r.Namespace),
-		MetricName: aws.String(r.MetricName),
+		Namespace: aws.String(r.Namespace),
+	}
+	if r.MetricName != "" {
+		input.MetricName = aws.String(r.MetricName)
 	}
-	setDimensionFilter(input, r.DimensionFilter)
 
-	metrics, err := l.ListMetricsWithPageLimit(input)
+	metrics, err := l.listMetricsByDimensionFilter(ctx, input, r.DimensionFilter, r.ExpandWildcards)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
 	}
@@ -94,8 +112,47 @@ func (l *ListMetricsService) GetDimensionValuesByDimensionFilter(r resources.Dim
 	return dimensionValues, nil
 }
 
-func (l *ListMetricsService) GetDimensionKeysByNamespace(namespace string) ([]string, error) {
-	metrics, err := l.ListMetricsWithPageLimit(&cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
+// GetDimensionValuesByDimensionKeys returns the distinct values of each of the requested dimension keys.
+// The values of every key are listed separately, so the page limit applies per key.
+func (l *ListMetricsService) GetDimensionValuesByDimensionKeys(ctx context.Context, r resources.BulkDimensionValuesRequest) (map[string][]string, error) {
+	var mu sync.Mutex
+	response := make(map[string][]string, len(r.DimensionKeys))
+
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentDimensionValuesRequests)
+	for _, key := range r.DimensionKeys {
+		key := key
+		eg.Go(func() error {
+			values, err := l.GetDimensionValuesByDimensionFilter(ctx, resources.DimensionValuesRequest{
+				ResourceRequest: r.ResourceRequest,
+				Namespace:       r.Namespace,
+				MetricName:      r.MetricName,
+				DimensionKey:    key,
+				DimensionFilter: r.DimensionFilter,
+			})
+			if err != nil {
+				return err
+			}
+			if values == nil {
+				values = []string{}
+			}
+
+			mu.Lock()
+			response[key] = values
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (l *ListMetricsService) GetDimensionKeysByNamespace(ctx context.Context, namespace string) ([]string, error) {
+	metrics, err := l.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
 	if err != nil {
 		return []string{}, err
 	}
@@ -116,25 +173,403 @@ func (l *ListMetricsService) GetDimensionKeysByNamespace(namespace string) ([]st
 	return dimensionKeys, nil
 }
 
-func (l *ListMetricsService) GetMetricsByNamespace(namespace string) ([]resources.Metric, error) {
-	metrics, err := l.ListMetricsWithPageLimit(&cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
+func (l *ListMetricsService) GetMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	return l.getMetricsByNamespace(ctx, namespace, false)
+}
+
+// RefreshMetricsByNamespace returns the metrics in the namespace like GetMetricsByNamespace. Nothing is cached by the
+// service itself, so they're always listed.
+func (l *ListMetricsService) RefreshMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	return l.getMetricsByNamespace(ctx, namespace, false)
+}
+
+// GetDimensionedMetricsByNamespace returns the metrics in the namespace like GetMetricsByNamespace, but leaves out
+// the metrics that are only reported without dimensions.
+func (l *ListMetricsService) GetDimensionedMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	return l.getMetricsByNamespace(ctx, namespace, true)
+}
+
+func (l *ListMetricsService) getMetricsByNamespace(ctx context.Context, namespace string, requireDimensions bool) ([]resources.Metric, error) {
+	metrics, err := l.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
 	if err != nil {
 		return nil, err
 	}
 
 	response := []resources.Metric{}
-	dupCheck := make(map[string]struct{})
+	dupCheck := make(map[resources.Metric]struct{})
+	for _, metric := range metrics {
+		if requireDimensions && len(metric.Dimensions) == 0 {
+			continue
+		}
+
+		m := toMetric(metric)
+		if _, exists := dupCheck[m]; exists {
+			continue
+		}
+		dupCheck[m] = struct{}{}
+		response = append(response, m)
+	}
+
+	return response, nil
+}
+
+// GetMetricsByNamespacePage returns a page of at most limit metrics in the namespace, like GetMetricsByNamespace or,
+// with requireDimensions, GetDimensionedMetricsByNamespace, starting at nextToken, or at the first page if it's empty.
+// ListMetrics pages are listed until the page is full or there are none left, and the token of the next page is
+// returned, which is empty if it's the last page. Since a page may end in the middle of a ListMetrics page, the token
+// is the token of AWS prefixed with the number of metrics of that page already returned. Metrics are only deduplicated
+// within a page, so a metric reported with several sets of dimensions may be returned on more than one page.
+func (l *ListMetricsService) GetMetricsByNamespacePage(ctx context.Context, namespace string, requireDimensions bool, limit int, nextToken string) ([]resources.Metric, string, error) {
+	skip, awsToken := 0, ""
+	if nextToken != "" {
+		offset, token, found := strings.Cut(nextToken, ":")
+		var err error
+		if skip, err = strconv.Atoi(offset); !found || err != nil || skip < 0 {
+			return nil, "", ErrInvalidNextToken
+		}
+		awsToken = token
+	}
+
+	response := []resources.Metric{}
+	dupCheck := make(map[resources.Metric]struct{})
+	for {
+		input := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+		if awsToken != "" {
+			input.NextToken = aws.String(awsToken)
+		}
+		metrics, next, err := l.ListMetricsPage(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("%v: %w", "unable to call AWS API", err)
+		}
+
+		for i := skip; i < len(metrics); i++ {
+			if len(response) == limit {
+				return response, fmt.Sprintf("%d:%s", i, awsToken), nil
+			}
+			if requireDimensions && len(metrics[i].Dimensions) == 0 {
+				continue
+			}
+			m := toMetric(metrics[i])
+			if _, exists := dupCheck[m]; exists {
+				continue
+			}
+			dupCheck[m] = struct{}{}
+			response = append(response, m)
+		}
+
+		if next == "" {
+			return response, "", nil
+		}
+		awsToken, skip = next, 0
+		if len(response) == limit {
+			return response, fmt.Sprintf("%d:%s", 0, awsToken), nil
+		}
+	}
+}
+
+func toMetric(metric *cloudwatch.Metric) resources.Metric {
+	dimensionKeys := make([]string, 0, len(metric.Dimensions))
+	for _, dim := range metric.Dimensions {
+		dimensionKeys = append(dimensionKeys, *dim.Name)
+	}
+
+	return resources.Metric{Name: *metric.MetricName, Namespace: *metric.Namespace, ResourceType: GetResourceType(*metric.Namespace, dimensionKeys)}
+}
+
+// GetMetricCountByNamespace returns the number of distinct metric names in the namespace. Only the first few pages of
+// metrics are listed, so the returned bool is true if there were metrics left and the count is a lower bound.
+func (l *ListMetricsService) GetMetricCountByNamespace(ctx context.Context, namespace string) (int, bool, error) {
+	metrics, truncated, err := l.ListMetricsWithMaxPages(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}, metricCountPageLimit)
+	if err != nil {
+		return 0, false, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	metricNames := make(map[string]struct{})
+	for _, metric := range metrics {
+		metricNames[aws.StringValue(metric.MetricName)] = struct{}{}
+	}
+
+	return len(metricNames), truncated, nil
+}
+
+// GetMetricsWithDimensionsByNamespace returns the metrics in the namespace with the values of their dimensions.
+// Only a single page of metrics is listed, since every combination of dimension values is a metric of its own. The
+// page starts at nextToken, or at the first page if it's empty, and the token of the next page is returned.
+func (l *ListMetricsService) GetMetricsWithDimensionsByNamespace(ctx context.Context, namespace string, nextToken string) ([]resources.TaggedMetric, string, error) {
+	input := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+	if nextToken != "" {
+		input.NextToken = aws.String(nextToken)
+	}
+
+	metrics, nextToken, err := l.ListMetricsPage(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	return toTaggedMetrics(metrics), nextToken, nil
+}
+
+// GetMetricsWithAllDimensionsByNamespace returns every combination of metric name and dimension values in the
+// namespace as a metric of its own, up to the page limit.
+func (l *ListMetricsService) GetMetricsWithAllDimensionsByNamespace(ctx context.Context, namespace string) ([]resources.TaggedMetric, error) {
+	metrics, err := l.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	return toTaggedMetrics(metrics), nil
+}
+
+// GetMetricsWithDimensionsByNamespaceUpTo returns the metrics in the namespace with the values of their dimensions,
+// listing page after page until there are none left or maxResults metrics were listed. The returned bool is true if
+// the listing stopped at maxResults, i.e. the metrics are incomplete.
+func (l *ListMetricsService) GetMetricsWithDimensionsByNamespaceUpTo(ctx context.Context, namespace string, maxResults int) ([]resources.TaggedMetric, bool, error) {
+	input := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+	var metrics []*cloudwatch.Metric
+	for {
+		page, nextToken, err := l.ListMetricsPage(ctx, input)
+		if err != nil {
+			return nil, false, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+		}
+		metrics = append(metrics, page...)
+
+		if len(metrics) >= maxResults {
+			truncated := len(metrics) > maxResults || nextToken != ""
+			return toTaggedMetrics(metrics[:maxResults]), truncated, nil
+		}
+		if nextToken == "" {
+			return toTaggedMetrics(metrics), false, nil
+		}
+		input.NextToken = aws.String(nextToken)
+	}
+}
+
+func toTaggedMetrics(metrics []*cloudwatch.Metric) []resources.TaggedMetric {
+	response := make([]resources.TaggedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		dimensions := make(map[string]string, len(metric.Dimensions))
+		dimensionKeys := make([]string, 0, len(metric.Dimensions))
+		for _, dim := range metric.Dimensions {
+			dimensions[*dim.Name] = aws.StringValue(dim.Value)
+			dimensionKeys = append(dimensionKeys, *dim.Name)
+		}
+
+		response = append(response, resources.TaggedMetric{
+			Metric:     resources.Metric{Name: *metric.MetricName, Namespace: *metric.Namespace, ResourceType: GetResourceType(*metric.Namespace, dimensionKeys)},
+			Dimensions: dimensions,
+		})
+	}
+
+	return response
+}
+
+// GetMetricsByNamespaceGroupedByAccount lists the metrics in the namespace across the monitoring account and its
+// linked source accounts, and returns them by the id of the account that owns them.
+func (l *ListMetricsService) GetMetricsByNamespaceGroupedByAccount(ctx context.Context, namespace string) (map[string][]resources.Metric, error) {
+	metrics, err := l.ListMetricsWithAccounts(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:             aws.String(namespace),
+		IncludeLinkedAccounts: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(map[string][]resources.Metric)
+	dupCheck := make(map[string]map[resources.Metric]struct{})
+	for _, metric := range metrics {
+		dimensionKeys := make([]string, 0, len(metric.Dimensions))
+		for _, dim := range metric.Dimensions {
+			dimensionKeys = append(dimensionKeys, *dim.Name)
+		}
+
+		accountId := aws.StringValue(metric.AccountId)
+		if _, exists := dupCheck[accountId]; !exists {
+			dupCheck[accountId] = make(map[resources.Metric]struct{})
+		}
+
+		m := resources.Metric{Name: *metric.MetricName, Namespace: *metric.Namespace, ResourceType: GetResourceType(*metric.Namespace, dimensionKeys)}
+		if _, exists := dupCheck[accountId][m]; exists {
+			continue
+		}
+		dupCheck[accountId][m] = struct{}{}
+		response[accountId] = append(response[accountId], m)
+	}
+
+	return response, nil
+}
+
+// GetMetricsByNamespaceOfAccount lists the metrics in the namespace that are owned by the account, which is either the
+// monitoring account itself or one of its linked source accounts, and sets the account on each of them.
+func (l *ListMetricsService) GetMetricsByNamespaceOfAccount(ctx context.Context, namespace string, accountId string) ([]resources.Metric, error) {
+	metrics, err := l.ListMetricsWithAccounts(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:             aws.String(namespace),
+		IncludeLinkedAccounts: aws.Bool(true),
+		OwningAccount:         aws.String(accountId),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := []resources.Metric{}
+	dupCheck := make(map[resources.Metric]struct{})
 	for _, metric := range metrics {
-		if _, exists := dupCheck[*metric.MetricName]; exists {
+		m := toMetric(metric.Metric)
+		m.AccountId = accountId
+		if metric.AccountId != nil {
+			m.AccountId = *metric.AccountId
+		}
+		if _, exists := dupCheck[m]; exists {
 			continue
 		}
-		dupCheck[*metric.MetricName] = struct{}{}
-		response = append(response, resources.Metric{Name: *metric.MetricName, Namespace: *metric.Namespace})
+		dupCheck[m] = struct{}{}
+		response = append(response, m)
+	}
+
+	return response, nil
+}
+
+// GetMetricsByMetricStream returns the metrics that are included in the given metric stream.
+// A metric is included if its namespace matches one of the stream's include filters (or the stream has none)
+// and doesn't match any of the stream's exclude filters.
+func (l *ListMetricsService) GetMetricsByMetricStream(ctx context.Context, streamName string) ([]resources.Metric, error) {
+	stream, err := l.GetMetricStream(&cloudwatch.GetMetricStreamInput{Name: aws.String(streamName)})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == cloudwatch.ErrCodeResourceNotFoundException {
+			return nil, fmt.Errorf("%w: %q", ErrMetricStreamNotFound, streamName)
+		}
+		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	inputs := []*cloudwatch.ListMetricsInput{{}}
+	if len(stream.IncludeFilters) > 0 {
+		inputs = []*cloudwatch.ListMetricsInput{}
+		for _, filter := range stream.IncludeFilters {
+			inputs = append(inputs, &cloudwatch.ListMetricsInput{Namespace: filter.Namespace})
+		}
+	}
+
+	excludedNamespaces := make(map[string]struct{})
+	for _, filter := range stream.ExcludeFilters {
+		excludedNamespaces[aws.StringValue(filter.Namespace)] = struct{}{}
+	}
+
+	response := []resources.Metric{}
+	dupCheck := make(map[resources.Metric]struct{})
+	for _, input := range inputs {
+		metrics, err := l.ListMetricsWithPageLimit(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+		}
+
+		for _, metric := range metrics {
+			if _, excluded := excludedNamespaces[*metric.Namespace]; excluded {
+				continue
+			}
+			m := resources.Metric{Name: *metric.MetricName, Namespace: *metric.Namespace}
+			if _, exists := dupCheck[m]; exists {
+				continue
+			}
+			dupCheck[m] = struct{}{}
+			response = append(response, m)
+		}
 	}
 
 	return response, nil
 }
 
+// listMetricsByDimensionFilter lists the metrics matching the dimension filter.
+// The ListMetrics API doesn't support wildcards, so if expandWildcards is set, dimensions with a wildcard value
+// (e.g. i-0ab*) are listed by name only and the listed metrics are matched against the wildcard values instead.
+func (l *ListMetricsService) listMetricsByDimensionFilter(ctx context.Context, input *cloudwatch.ListMetricsInput, dimensionFilter []*resources.Dimension, expandWildcards bool) ([]*cloudwatch.Metric, error) {
+	var wildcards map[string][]string
+	if expandWildcards {
+		dimensionFilter, wildcards = splitWildcardDimensionFilter(dimensionFilter)
+	}
+	setDimensionFilter(input, dimensionFilter)
+
+	metrics, err := l.ListMetricsWithPageLimit(ctx, input)
+	if err != nil || len(wildcards) == 0 {
+		return metrics, err
+	}
+
+	var matching []*cloudwatch.Metric
+	for _, metric := range metrics {
+		if matchesWildcardDimensions(metric, wildcards) {
+			matching = append(matching, metric)
+		}
+	}
+
+	return matching, nil
+}
+
+// splitWildcardDimensionFilter replaces the dimensions with a wildcard value by a filter on the dimension name
+// and returns the wildcard values by dimension name.
+func splitWildcardDimensionFilter(dimensionFilter []*resources.Dimension) ([]*resources.Dimension, map[string][]string) {
+	filter := []*resources.Dimension{}
+	wildcards := make(map[string][]string)
+	for _, dimension := range dimensionFilter {
+		if !strings.Contains(dimension.Value, "*") {
+			filter = append(filter, dimension)
+			continue
+		}
+
+		if _, exists := wildcards[dimension.Name]; !exists {
+			filter = append(filter, &resources.Dimension{Name: dimension.Name})
+		}
+		wildcards[dimension.Name] = append(wildcards[dimension.Name], dimension.Value)
+	}
+
+	return filter, wildcards
+}
+
+// matchesWildcardDimensions returns true if, for every dimension name, the metric has that dimension with a value
+// matching one of the wildcard values
+func matchesWildcardDimensions(metric *cloudwatch.Metric, wildcards map[string][]string) bool {
+	for name, patterns := range wildcards {
+		matched := false
+		for _, dim := range metric.Dimensions {
+			if aws.StringValue(dim.Name) != name {
+				continue
+			}
+			for _, pattern := range patterns {
+				if matchesWildcard(pattern, aws.StringValue(dim.Value)) {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesWildcard returns true if the value matches the pattern, where * matches any sequence of characters
+func matchesWildcard(pattern string, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
 func setDimensionFilter(input *cloudwatch.ListMetricsInput, dimensionFilter []*resources.Dimension) {
 	for _, dimension := range dimensionFilter {
 		df := &cloudwatch.DimensionFilter{
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+type cachedNamespaceMetrics struct {
+	metrics []resources.Metric
+	expires time.Time
+}
+
+// maxListMetricsCacheEntries caps the number of namespaces whose metrics are cached across all data sources
+const maxListMetricsCacheEntries = 1000
+
+// listMetricsCache caches the metrics of custom namespaces by cache key and namespace
+var listMetricsCache = struct {
+	sync.Mutex
+	entries map[string]cachedNamespaceMetrics
+}{entries: make(map[string]cachedNamespaceMetrics)}
+
+// CachedListMetricsService lists metrics like the list metrics service it wraps, but caches the metrics of namespaces
+// listed with GetMetricsByNamespace, so that opening the metric picker again doesn't list them again
+type CachedListMetricsService struct {
+	models.ListMetricsProvider
+	cacheKey string
+	ttl      time.Duration
+}
+
+// NewCachedListMetricsService returns the list metrics service with the metrics of namespaces cached for the ttl, or
+// the service itself if the ttl is 0. The metrics are cached under the cache key, which has to identify the account
+// and region of the service, and the credentials or role it lists them with, so that the metrics of another account or
+// region are never returned.
+func NewCachedListMetricsService(service models.ListMetricsProvider, cacheKey string, ttl time.Duration) models.ListMetricsProvider {
+	if ttl <= 0 {
+		return service
+	}
+	return &CachedListMetricsService{service, cacheKey, ttl}
+}
+
+// GetMetricsByNamespace returns the cached metrics of the namespace, or lists and caches them if they aren't cached or
+// have expired. Metrics that can't be listed aren't cached. The metrics are copied, since callers decorate them.
+func (s *CachedListMetricsService) GetMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	key := s.cacheKey + "/" + namespace
+	listMetricsCache.Lock()
+	cached, exists := listMetricsCache.entries[key]
+	if exists && !time.Now().Before(cached.expires) {
+		delete(listMetricsCache.entries, key)
+		exists = false
+	}
+	listMetricsCache.Unlock()
+	if exists {
+		return append([]resources.Metric{}, cached.metrics...), nil
+	}
+
+	return s.RefreshMetricsByNamespace(ctx, namespace)
+}
+
+// RefreshMetricsByNamespace lists the metrics of the namespace regardless of whether they're cached, and caches them
+func (s *CachedListMetricsService) RefreshMetricsByNamespace(ctx context.Context, namespace string) ([]resources.Metric, error) {
+	metrics, err := s.ListMetricsProvider.GetMetricsByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	key := s.cacheKey + "/" + namespace
+	listMetricsCache.Lock()
+	if _, exists := listMetricsCache.entries[key]; !exists && len(listMetricsCache.entries) >= maxListMetricsCacheEntries {
+		evictListMetricsCacheEntries(time.Now())
+	}
+	listMetricsCache.entries[key] = cachedNamespaceMetrics{metrics: append([]resources.Metric{}, metrics...), expires: time.Now().Add(s.ttl)}
+	listMetricsCache.Unlock()
+
+	return metrics, nil
+}
+
+// evictListMetricsCacheEntries deletes the expired entries of the cache, or the entry that expires first if none has
+// expired, so that there's room for another one. The cache has to be locked.
+func evictListMetricsCacheEntries(now time.Time) {
+	var firstKey string
+	var first time.Time
+	for key, cached := range listMetricsCache.entries {
+		if !now.Before(cached.expires) {
+			delete(listMetricsCache.entries, key)
+			continue
+		}
+		if firstKey == "" || cached.expires.Before(first) {
+			firstKey, first = key, cached.expires
+		}
+	}
+	if len(listMetricsCache.entries) >= maxListMetricsCacheEntries {
+		delete(listMetricsCache.entries, firstKey)
+	}
+}
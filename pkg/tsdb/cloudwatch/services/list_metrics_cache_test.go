@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedListMetricsService_GetMetricsByNamespace(t *testing.T) {
+	t.Cleanup(func() {
+		listMetricsCache.entries = make(map[string]cachedNamespaceMetrics)
+	})
+	metrics := []resources.Metric{{Namespace: "MyApp", Name: "Latency"}, {Namespace: "MyApp", Name: "Errors"}}
+
+	t.Run("Should list the metrics of a namespace once until they expire", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "1/us-east-1", time.Minute)
+
+		for i := 0; i < 3; i++ {
+			resp, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+			require.NoError(t, err)
+			assert.Equal(t, metrics, resp)
+		}
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 1)
+
+		listMetricsCache.Lock()
+		entry := listMetricsCache.entries["1/us-east-1/MyApp"]
+		entry.expires = time.Now().Add(-time.Second)
+		listMetricsCache.entries["1/us-east-1/MyApp"] = entry
+		listMetricsCache.Unlock()
+
+		_, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 2)
+	})
+
+	t.Run("Should cache the metrics of each region and namespace separately", func(t *testing.T) {
+		eastService := &mocks.ListMetricsServiceMock{}
+		eastService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics[:1], nil)
+		eastService.On("GetMetricsByNamespace", mock.Anything, "OtherApp").Return([]resources.Metric{{Namespace: "OtherApp", Name: "Latency"}}, nil)
+		westService := &mocks.ListMetricsServiceMock{}
+		westService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics[1:], nil)
+
+		east := NewCachedListMetricsService(eastService, "2/us-east-1", time.Minute)
+		west := NewCachedListMetricsService(westService, "2/us-west-2", time.Minute)
+
+		resp, err := east.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics[:1], resp)
+		resp, err = west.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics[1:], resp)
+		resp, err = east.GetMetricsByNamespace(context.Background(), "OtherApp")
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Namespace: "OtherApp", Name: "Latency"}}, resp)
+	})
+
+	t.Run("Should not cache the metrics if they can't be listed", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{}, fmt.Errorf("throttled")).Once()
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "3/us-east-1", time.Minute)
+
+		_, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.Error(t, err)
+
+		resp, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics, resp)
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 2)
+	})
+
+	t.Run("Should list the metrics again and cache them on a refresh", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics[:1], nil).Once()
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "4/us-east-1", time.Minute)
+
+		resp, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics[:1], resp)
+
+		resp, err = service.RefreshMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics, resp)
+
+		resp, err = service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, metrics, resp)
+		mockListMetricsService.AssertNumberOfCalls(t, "GetMetricsByNamespace", 2)
+	})
+
+	t.Run("Should not let callers change the cached metrics", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{{Namespace: "MyApp", Name: "Latency"}}, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "5/us-east-1", time.Minute)
+
+		resp, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		resp[0].PrometheusName = "aws_myapp_latency"
+
+		resp, err = service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		resp[0].DefaultStatistic = "Average"
+
+		resp, err = service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Namespace: "MyApp", Name: "Latency"}}, resp)
+	})
+
+	t.Run("Should be safe for concurrent requests", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "6/us-east-1", time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+				assert.NoError(t, err)
+				assert.Equal(t, metrics, resp)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Should delete the metrics of a namespace once they have expired", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return(metrics, nil).Once()
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, "MyApp").Return([]resources.Metric{}, fmt.Errorf("throttled"))
+		service := NewCachedListMetricsService(mockListMetricsService, "8/us-east-1", time.Minute)
+
+		_, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		listMetricsCache.Lock()
+		entry := listMetricsCache.entries["8/us-east-1/MyApp"]
+		entry.expires = time.Now().Add(-time.Second)
+		listMetricsCache.entries["8/us-east-1/MyApp"] = entry
+		listMetricsCache.Unlock()
+
+		_, err = service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.Error(t, err)
+		listMetricsCache.Lock()
+		_, exists := listMetricsCache.entries["8/us-east-1/MyApp"]
+		listMetricsCache.Unlock()
+		assert.False(t, exists)
+	})
+
+	t.Run("Should cache at most the max number of namespaces", func(t *testing.T) {
+		listMetricsCache.Lock()
+		listMetricsCache.entries = make(map[string]cachedNamespaceMetrics)
+		for i := 0; i < maxListMetricsCacheEntries; i++ {
+			expires := time.Now().Add(time.Duration(i+1) * time.Minute)
+			if i%2 == 0 {
+				expires = time.Now().Add(-time.Second)
+			}
+			listMetricsCache.entries[fmt.Sprintf("9/us-east-1/App%d", i)] = cachedNamespaceMetrics{expires: expires}
+		}
+		listMetricsCache.Unlock()
+
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetMetricsByNamespace", mock.Anything, mock.Anything).Return(metrics, nil)
+		service := NewCachedListMetricsService(mockListMetricsService, "10/us-east-1", time.Hour)
+
+		// the expired entries are swept to make room
+		_, err := service.GetMetricsByNamespace(context.Background(), "MyApp")
+		require.NoError(t, err)
+		listMetricsCache.Lock()
+		assert.Len(t, listMetricsCache.entries, maxListMetricsCacheEntries/2+1)
+		listMetricsCache.Unlock()
+
+		for i := 0; i < maxListMetricsCacheEntries; i++ {
+			_, err := service.GetMetricsByNamespace(context.Background(), fmt.Sprintf("OtherApp%d", i))
+			require.NoError(t, err)
+		}
+		listMetricsCache.Lock()
+		assert.Len(t, listMetricsCache.entries, maxListMetricsCacheEntries)
+		// the entries that expire first are evicted once none has expired
+		_, exists := listMetricsCache.entries["9/us-east-1/App1"]
+		assert.False(t, exists)
+		_, exists = listMetricsCache.entries["10/us-east-1/OtherApp999"]
+		assert.True(t, exists)
+		listMetricsCache.Unlock()
+	})
+
+	t.Run("Should not cache anything with a ttl of 0", func(t *testing.T) {
+		mockListMetricsService := &mocks.ListMetricsServiceMock{}
+		service := NewCachedListMetricsService(mockListMetricsService, "7/us-east-1", 0)
+		assert.Same(t, mockListMetricsService, service)
+	})
+}
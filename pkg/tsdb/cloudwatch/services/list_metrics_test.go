@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
@@ -44,10 +47,10 @@ var metricResponse = []*cloudwatch.Metric{
 func TestListMetricsService_GetDimensionKeysByDimensionFilter(t *testing.T) {
 	t.Run("Should filter out duplicates and keys matching dimension filter keys", func(t *testing.T) {
 		fakeMetricsClient := &mocks.FakeMetricsClient{}
-		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return(metricResponse, nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
 		listMetricsService := NewListMetricsService(fakeMetricsClient)
 
-		resp, err := listMetricsService.GetDimensionKeysByDimensionFilter(resources.DimensionKeysRequest{
+		resp, err := listMetricsService.GetDimensionKeysByDimensionFilter(context.Background(), resources.DimensionKeysRequest{
 			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
 			Namespace:       "AWS/EC2",
 			MetricName:      "CPUUtilization",
@@ -64,10 +67,10 @@ func TestListMetricsService_GetDimensionKeysByDimensionFilter(t *testing.T) {
 func TestListMetricsService_GetDimensionKeysByNamespace(t *testing.T) {
 	t.Run("Should filter out duplicates and keys matching dimension filter keys", func(t *testing.T) {
 		fakeMetricsClient := &mocks.FakeMetricsClient{}
-		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return(metricResponse, nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
 		listMetricsService := NewListMetricsService(fakeMetricsClient)
 
-		resp, err := listMetricsService.GetDimensionKeysByNamespace("AWS/EC2")
+		resp, err := listMetricsService.GetDimensionKeysByNamespace(context.Background(), "AWS/EC2")
 
 		require.NoError(t, err)
 		assert.Equal(t, []string{"InstanceId", "InstanceType", "AutoScalingGroupName"}, resp)
@@ -77,10 +80,10 @@ func TestListMetricsService_GetDimensionKeysByNamespace(t *testing.T) {
 func TestListMetricsService_GetDimensionValuesByDimensionFilter(t *testing.T) {
 	t.Run("Should filter out duplicates and keys matching dimension filter keys", func(t *testing.T) {
 		fakeMetricsClient := &mocks.FakeMetricsClient{}
-		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return(metricResponse, nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
 		listMetricsService := NewListMetricsService(fakeMetricsClient)
 
-		resp, err := listMetricsService.GetDimensionValuesByDimensionFilter(resources.DimensionValuesRequest{
+		resp, err := listMetricsService.GetDimensionValuesByDimensionFilter(context.Background(), resources.DimensionValuesRequest{
 			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
 			Namespace:       "AWS/EC2",
 			MetricName:      "CPUUtilization",
@@ -93,4 +96,514 @@ func TestListMetricsService_GetDimensionValuesByDimensionFilter(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, []string{"i-1234567890abcdef0", "i-5234567890abcdef0", "i-64234567890abcdef0"}, resp)
 	})
+
+	t.Run("Should expand wildcard dimension values server-side", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{
+			Namespace:  aws.String("AWS/EC2"),
+			MetricName: aws.String("CPUUtilization"),
+			Dimensions: []*cloudwatch.DimensionFilter{
+				{Name: aws.String("InstanceId")},
+				{Name: aws.String("InstanceType"), Value: aws.String("t2.micro")},
+			},
+		}).Return(metricResponse, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetDimensionValuesByDimensionFilter(context.Background(), resources.DimensionValuesRequest{
+			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+			Namespace:       "AWS/EC2",
+			MetricName:      "CPUUtilization",
+			DimensionKey:    "InstanceId",
+			DimensionFilter: []*resources.Dimension{
+				{Name: "InstanceId", Value: "i-*4567890abcdef0"},
+				{Name: "InstanceId", Value: "i-6*"},
+				{Name: "InstanceType", Value: "t2.micro"},
+			},
+			ExpandWildcards: true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-1234567890abcdef0", "i-5234567890abcdef0", "i-64234567890abcdef0"}, resp)
+	})
+
+	t.Run("Should only return dimension values of metrics matching the wildcard", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetDimensionValuesByDimensionFilter(context.Background(), resources.DimensionValuesRequest{
+			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+			Namespace:       "AWS/EC2",
+			MetricName:      "CPUUtilization",
+			DimensionKey:    "InstanceId",
+			DimensionFilter: []*resources.Dimension{
+				{Name: "AutoScalingGroupName", Value: "my-asg*"},
+			},
+			ExpandWildcards: true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-5234567890abcdef0", "i-64234567890abcdef0"}, resp)
+	})
+}
+
+func TestListMetricsService_GetDimensionKeysByDimensionFilter_ExpandWildcards(t *testing.T) {
+	fakeMetricsClient := &mocks.FakeMetricsClient{}
+	fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String("AWS/EC2"),
+		MetricName: aws.String("CPUUtilization"),
+		Dimensions: []*cloudwatch.DimensionFilter{{Name: aws.String("InstanceType")}},
+	}).Return(metricResponse, nil)
+	listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+	resp, err := listMetricsService.GetDimensionKeysByDimensionFilter(context.Background(), resources.DimensionKeysRequest{
+		ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+		Namespace:       "AWS/EC2",
+		MetricName:      "CPUUtilization",
+		DimensionFilter: []*resources.Dimension{
+			{Name: "InstanceType", Value: "t3.*"},
+		},
+		ExpandWildcards: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"InstanceId", "AutoScalingGroupName"}, resp)
+}
+
+func TestMatchesWildcard(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{"*", "anything", true},
+		{"i-*", "i-1234", true},
+		{"i-*", "x-1234", false},
+		{"*-prod", "api-prod", true},
+		{"*-prod", "api-prod-2", false},
+		{"api-*-prod", "api-eu-prod", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+		{"no-wildcard", "no-wildcard", true},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, matchesWildcard(tc.pattern, tc.value), "%q should match %q: %v", tc.pattern, tc.value, tc.expected)
+	}
+}
+
+func TestListMetricsService_GetDimensionValuesByDimensionKeys(t *testing.T) {
+	t.Run("Should return the distinct values of each dimension key", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetDimensionValuesByDimensionKeys(context.Background(), resources.BulkDimensionValuesRequest{
+			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+			Namespace:       "AWS/EC2",
+			MetricName:      "CPUUtilization",
+			DimensionKeys:   []string{"InstanceId", "InstanceType", "AutoScalingGroupName", "ImageId"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"InstanceId":           {"i-1234567890abcdef0", "i-5234567890abcdef0", "i-64234567890abcdef0"},
+			"InstanceType":         {"t2.micro", "t3.micro"},
+			"AutoScalingGroupName": {"my-asg", "my-asg2"},
+			"ImageId":              {},
+		}, resp)
+		// every key is listed separately so that the page limit applies per key
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsWithPageLimit", 4)
+	})
+
+	t.Run("Should return an error if listing the values of a key fails", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, awserr.New("AccessDenied", "denied", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetDimensionValuesByDimensionKeys(context.Background(), resources.BulkDimensionValuesRequest{
+			ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+			Namespace:       "AWS/EC2",
+			MetricName:      "CPUUtilization",
+			DimensionKeys:   []string{"InstanceId", "InstanceType"},
+		})
+
+		require.Error(t, err)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestListMetricsService_GetMetricsByNamespace(t *testing.T) {
+	t.Run("Should return a metric per resource type", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByNamespace(context.Background(), "AWS/EC2")
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "CPUUtilization", Namespace: "AWS/EC2", ResourceType: "ec2:instance"}}, resp)
+	})
+
+	t.Run("Should leave the resource type empty for unknown dimensions", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{
+			{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp"), Dimensions: []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}}},
+			{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp"), Dimensions: []*cloudwatch.Dimension{{Name: aws.String("Service"), Value: aws.String("api")}}},
+		}, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByNamespace(context.Background(), "MyApp")
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "Latency", Namespace: "MyApp"}}, resp)
+	})
+}
+
+func TestListMetricsService_GetDimensionedMetricsByNamespace(t *testing.T) {
+	t.Run("Should leave out metrics that are only reported without dimensions", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{
+			{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp"), Dimensions: []*cloudwatch.Dimension{{Name: aws.String("Service"), Value: aws.String("api")}}},
+			{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")},
+			{MetricName: aws.String("Requests"), Namespace: aws.String("MyApp")},
+		}, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetDimensionedMetricsByNamespace(context.Background(), "MyApp")
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "Latency", Namespace: "MyApp"}}, resp)
+	})
+}
+
+func TestListMetricsService_GetMetricsByNamespacePage(t *testing.T) {
+	customMetric := func(name string, dimensions ...string) *cloudwatch.Metric {
+		metric := &cloudwatch.Metric{MetricName: aws.String(name), Namespace: aws.String("MyApp")}
+		for _, dimension := range dimensions {
+			metric.Dimensions = append(metric.Dimensions, &cloudwatch.Dimension{Name: aws.String(dimension), Value: aws.String("value")})
+		}
+		return metric
+	}
+	firstPage := []*cloudwatch.Metric{customMetric("A", "Host"), customMetric("A", "Host"), customMetric("B"), customMetric("C", "Host")}
+	secondPage := []*cloudwatch.Metric{customMetric("D", "Host")}
+	newFakeMetricsClient := func() *mocks.FakeMetricsClient {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("MyApp")}).Return(firstPage, "token-2", nil)
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("MyApp"), NextToken: aws.String("token-2")}).Return(secondPage, "", nil)
+		return fakeMetricsClient
+	}
+	names := func(metrics []resources.Metric) []string {
+		names := make([]string, 0, len(metrics))
+		for _, metric := range metrics {
+			names = append(names, metric.Name)
+		}
+		return names
+	}
+
+	t.Run("Should continue a page ending in the middle of a ListMetrics page where it ended", func(t *testing.T) {
+		fakeMetricsClient := newFakeMetricsClient()
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, nextToken, err := listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "B"}, names(resp))
+		assert.Equal(t, "3:", nextToken)
+
+		resp, nextToken, err = listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 2, nextToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"C", "D"}, names(resp))
+		assert.Empty(t, nextToken)
+	})
+
+	t.Run("Should list ListMetrics pages until the page is full", func(t *testing.T) {
+		fakeMetricsClient := newFakeMetricsClient()
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, nextToken, err := listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 3, "")
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "A", Namespace: "MyApp"}, {Name: "B", Namespace: "MyApp"}, {Name: "C", Namespace: "MyApp"}}, resp)
+		assert.Equal(t, "0:token-2", nextToken)
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsPage", 1)
+
+		resp, nextToken, err = listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 3, nextToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"D"}, names(resp))
+		assert.Empty(t, nextToken)
+	})
+
+	t.Run("Should leave out metrics without dimensions if requireDimensions is true", func(t *testing.T) {
+		listMetricsService := NewListMetricsService(newFakeMetricsClient())
+
+		resp, nextToken, err := listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", true, 10, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "C", "D"}, names(resp))
+		assert.Empty(t, nextToken)
+	})
+
+	t.Run("Should return ErrInvalidNextToken for a token it didn't return", func(t *testing.T) {
+		listMetricsService := NewListMetricsService(newFakeMetricsClient())
+
+		for _, nextToken := range []string{"token-2", "-1:token-2", "x:token-2"} {
+			_, _, err := listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 10, nextToken)
+			assert.ErrorIs(t, err, ErrInvalidNextToken)
+		}
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, "", fmt.Errorf("access denied"))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, _, err := listMetricsService.GetMetricsByNamespacePage(context.Background(), "MyApp", false, 10, "")
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricsByNamespaceGroupedByAccount(t *testing.T) {
+	t.Run("Should include linked accounts and group the metrics by owning account", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithAccounts", mock.Anything, mock.Anything).Return([]resources.MetricResponse{
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")}, AccountId: aws.String("111111111111")},
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")}, AccountId: aws.String("111111111111")},
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Errors"), Namespace: aws.String("MyApp")}, AccountId: aws.String("111111111111")},
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")}, AccountId: aws.String("222222222222")},
+		}, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByNamespaceGroupedByAccount(context.Background(), "MyApp")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsWithAccounts", mock.Anything, &cloudwatch.ListMetricsInput{
+			Namespace:             aws.String("MyApp"),
+			IncludeLinkedAccounts: aws.Bool(true),
+		})
+		assert.Equal(t, map[string][]resources.Metric{
+			"111111111111": {{Name: "Latency", Namespace: "MyApp"}, {Name: "Errors", Namespace: "MyApp"}},
+			"222222222222": {{Name: "Latency", Namespace: "MyApp"}},
+		}, resp)
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithAccounts", mock.Anything, mock.Anything).Return([]resources.MetricResponse{}, awserr.New("AccessDenied", "access denied", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, err := listMetricsService.GetMetricsByNamespaceGroupedByAccount(context.Background(), "MyApp")
+
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricsByNamespaceOfAccount(t *testing.T) {
+	t.Run("Should list the metrics owned by the account and set the account on them", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithAccounts", mock.Anything, mock.Anything).Return([]resources.MetricResponse{
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")}, AccountId: aws.String("111111111111")},
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Latency"), Namespace: aws.String("MyApp")}, AccountId: aws.String("111111111111")},
+			{Metric: &cloudwatch.Metric{MetricName: aws.String("Errors"), Namespace: aws.String("MyApp")}},
+		}, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByNamespaceOfAccount(context.Background(), "MyApp", "111111111111")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsWithAccounts", mock.Anything, &cloudwatch.ListMetricsInput{
+			Namespace:             aws.String("MyApp"),
+			IncludeLinkedAccounts: aws.Bool(true),
+			OwningAccount:         aws.String("111111111111"),
+		})
+		assert.Equal(t, []resources.Metric{
+			{Name: "Latency", Namespace: "MyApp", AccountId: "111111111111"},
+			{Name: "Errors", Namespace: "MyApp", AccountId: "111111111111"},
+		}, resp)
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithAccounts", mock.Anything, mock.Anything).Return([]resources.MetricResponse{}, awserr.New("AccessDenied", "access denied", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, err := listMetricsService.GetMetricsByNamespaceOfAccount(context.Background(), "MyApp", "111111111111")
+
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricCountByNamespace(t *testing.T) {
+	t.Run("Should count the distinct metric names of a capped listing", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithMaxPages", mock.Anything, mock.Anything, mock.Anything).Return(metricResponse, true, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		count, approximate, err := listMetricsService.GetMetricCountByNamespace(context.Background(), "AWS/EC2")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsWithMaxPages", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")}, metricCountPageLimit)
+		assert.Equal(t, 1, count)
+		assert.True(t, approximate)
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithMaxPages", mock.Anything, mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, false, awserr.New("AccessDenied", "access denied", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, _, err := listMetricsService.GetMetricCountByNamespace(context.Background(), "MyApp")
+
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricsWithDimensionsByNamespaceUpTo(t *testing.T) {
+	t.Run("Should list all pages if there are fewer metrics than the cap", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")}).Return(metricResponse[:2], "token-2", nil)
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2"), NextToken: aws.String("token-2")}).Return(metricResponse[2:], "", nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, truncated, err := listMetricsService.GetMetricsWithDimensionsByNamespaceUpTo(context.Background(), "AWS/EC2", 100)
+
+		require.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Len(t, resp, len(metricResponse))
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsPage", 2)
+	})
+
+	t.Run("Should stop listing at the cap and report that the metrics are truncated", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return(metricResponse[:2], "token-2", nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, truncated, err := listMetricsService.GetMetricsWithDimensionsByNamespaceUpTo(context.Background(), "AWS/EC2", 3)
+
+		require.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Len(t, resp, 3)
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsPage", 2)
+	})
+
+	t.Run("Should return an error if a page can't be listed", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, "", fmt.Errorf("access denied"))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, _, err := listMetricsService.GetMetricsWithDimensionsByNamespaceUpTo(context.Background(), "AWS/EC2", 3)
+
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricsWithDimensionsByNamespace(t *testing.T) {
+	t.Run("Should return each metric of the first page with its dimensions and resource type", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return(metricResponse[:2], "token-2", nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, nextToken, err := listMetricsService.GetMetricsWithDimensionsByNamespace(context.Background(), "AWS/EC2", "")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")})
+		assert.Equal(t, "token-2", nextToken)
+		assert.Equal(t, []resources.TaggedMetric{
+			{
+				Metric:     resources.Metric{Name: "CPUUtilization", Namespace: "AWS/EC2", ResourceType: "ec2:instance"},
+				Dimensions: map[string]string{"InstanceId": "i-1234567890abcdef0", "InstanceType": "t2.micro"},
+			},
+			{
+				Metric:     resources.Metric{Name: "CPUUtilization", Namespace: "AWS/EC2", ResourceType: "ec2:instance"},
+				Dimensions: map[string]string{"InstanceId": "i-5234567890abcdef0", "InstanceType": "t2.micro", "AutoScalingGroupName": "my-asg"},
+			},
+		}, resp)
+	})
+
+	t.Run("Should continue listing at the next token", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, mock.Anything).Return(metricResponse[2:], "", nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, nextToken, err := listMetricsService.GetMetricsWithDimensionsByNamespace(context.Background(), "AWS/EC2", "token-2")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2"), NextToken: aws.String("token-2")})
+		assert.Len(t, resp, len(metricResponse)-2)
+		assert.Empty(t, nextToken)
+	})
+}
+
+func TestListMetricsService_GetMetricsWithAllDimensionsByNamespace(t *testing.T) {
+	t.Run("Should return every dimension combination of a metric without collapsing", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return(metricResponse, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsWithAllDimensionsByNamespace(context.Background(), "AWS/EC2")
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertCalled(t, "ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")})
+		require.Len(t, resp, 3)
+		for i, metric := range resp {
+			assert.Equal(t, "CPUUtilization", metric.Name)
+			assert.Equal(t, *metricResponse[i].Dimensions[0].Value, metric.Dimensions["InstanceId"])
+			assert.Len(t, metric.Dimensions, len(metricResponse[i].Dimensions))
+		}
+	})
+
+	t.Run("Should return the error of the AWS API", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, mock.Anything).Return([]*cloudwatch.Metric{}, awserr.New("AccessDenied", "access denied", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, err := listMetricsService.GetMetricsWithAllDimensionsByNamespace(context.Background(), "AWS/EC2")
+
+		require.Error(t, err)
+	})
+}
+
+func TestListMetricsService_GetMetricsByMetricStream(t *testing.T) {
+	streamMetrics := []*cloudwatch.Metric{
+		{MetricName: aws.String("CPUUtilization"), Namespace: aws.String("AWS/EC2")},
+		{MetricName: aws.String("CPUUtilization"), Namespace: aws.String("AWS/EC2")},
+		{MetricName: aws.String("Invocations"), Namespace: aws.String("AWS/Lambda")},
+		{MetricName: aws.String("NumberOfMessagesSent"), Namespace: aws.String("AWS/SQS")},
+	}
+
+	t.Run("Should list metrics of included namespaces only", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricStream", &cloudwatch.GetMetricStreamInput{Name: aws.String("my-stream")}).Return(&cloudwatch.GetMetricStreamOutput{
+			IncludeFilters: []*cloudwatch.MetricStreamFilter{{Namespace: aws.String("AWS/EC2")}, {Namespace: aws.String("AWS/Lambda")}},
+		}, nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")}).Return(streamMetrics[:2], nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/Lambda")}).Return(streamMetrics[2:3], nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByMetricStream(context.Background(), "my-stream")
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "CPUUtilization", Namespace: "AWS/EC2"}, {Name: "Invocations", Namespace: "AWS/Lambda"}}, resp)
+	})
+
+	t.Run("Should list all metrics except excluded namespaces", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricStream", mock.Anything).Return(&cloudwatch.GetMetricStreamOutput{
+			ExcludeFilters: []*cloudwatch.MetricStreamFilter{{Namespace: aws.String("AWS/Lambda")}},
+		}, nil)
+		fakeMetricsClient.On("ListMetricsWithPageLimit", mock.Anything, &cloudwatch.ListMetricsInput{}).Return(streamMetrics, nil)
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		resp, err := listMetricsService.GetMetricsByMetricStream(context.Background(), "my-stream")
+
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Metric{{Name: "CPUUtilization", Namespace: "AWS/EC2"}, {Name: "NumberOfMessagesSent", Namespace: "AWS/SQS"}}, resp)
+	})
+
+	t.Run("Should return ErrMetricStreamNotFound if the stream doesn't exist", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricStream", mock.Anything).Return((*cloudwatch.GetMetricStreamOutput)(nil), awserr.New(cloudwatch.ErrCodeResourceNotFoundException, "not found", nil))
+		listMetricsService := NewListMetricsService(fakeMetricsClient)
+
+		_, err := listMetricsService.GetMetricsByMetricStream(context.Background(), "unknown-stream")
+
+		require.ErrorIs(t, err, ErrMetricStreamNotFound)
+		fakeMetricsClient.AssertNotCalled(t, "ListMetricsWithPageLimit", mock.Anything, mock.Anything)
+	})
 }
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// AddHardCodedDimensionKeys sets the Details of hardcoded metrics to the dimension keys of their namespace
+func AddHardCodedDimensionKeys(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		keys := append([]string{}, constants.NamespaceDimensionKeysMap[metrics[i].Namespace]...)
+		metrics[i].Details = &resources.MetricDetails{DimensionKeys: keys}
+	}
+	return metrics
+}
+
+// MetricsWithDimensionKeys collapses the dimension combinations of metrics listed with their dimensions into a metric
+// each, like the metrics of a namespace are listed without them, and sets their Details to the sorted union of the
+// dimension keys of their combinations. The metrics are returned in the order they were first listed in.
+func MetricsWithDimensionKeys(tagged []resources.TaggedMetric) []resources.Metric {
+	metrics := []resources.Metric{}
+	keysByMetric := map[resources.Metric]map[string]bool{}
+	for _, metric := range tagged {
+		keys, exists := keysByMetric[metric.Metric]
+		if !exists {
+			keys = map[string]bool{}
+			keysByMetric[metric.Metric] = keys
+			metrics = append(metrics, metric.Metric)
+		}
+		for key := range metric.Dimensions {
+			keys[key] = true
+		}
+	}
+
+	for i := range metrics {
+		keys := make([]string, 0, len(keysByMetric[metrics[i]]))
+		for key := range keysByMetric[metrics[i]] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		metrics[i].Details = &resources.MetricDetails{DimensionKeys: keys}
+	}
+	return metrics
+}
+
+// AddMetricOrigin sets the account and region of the Details of the metrics, which have to be set already
+func AddMetricOrigin(metrics []resources.Metric, accountId string, region string) []resources.Metric {
+	for i := range metrics {
+		if metrics[i].Details == nil {
+			continue
+		}
+		metrics[i].Details.AccountId = accountId
+		metrics[i].Details.Region = region
+	}
+	return metrics
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddHardCodedDimensionKeys(t *testing.T) {
+	t.Run("Should set the dimension keys of the namespace of each metric", func(t *testing.T) {
+		metrics := AddHardCodedDimensionKeys([]resources.Metric{{Namespace: "AWS/DAX", Name: "CPUUtilization"}, {Namespace: "Unknown", Name: "Latency"}})
+
+		assert.Equal(t, []resources.Metric{
+			{Namespace: "AWS/DAX", Name: "CPUUtilization", Details: &resources.MetricDetails{DimensionKeys: []string{"Account", "ClusterId", "NodeId"}}},
+			{Namespace: "Unknown", Name: "Latency", Details: &resources.MetricDetails{DimensionKeys: []string{}}},
+		}, metrics)
+	})
+}
+
+func TestMetricsWithDimensionKeys(t *testing.T) {
+	t.Run("Should collapse the dimension combinations of a metric and union their keys", func(t *testing.T) {
+		metrics := MetricsWithDimensionKeys([]resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Errors"}, Dimensions: map[string]string{}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "web", "Environment": "prod"}},
+		})
+
+		assert.Equal(t, []resources.Metric{
+			{Namespace: "MyApp", Name: "Latency", Details: &resources.MetricDetails{DimensionKeys: []string{"Environment", "Service"}}},
+			{Namespace: "MyApp", Name: "Errors", Details: &resources.MetricDetails{DimensionKeys: []string{}}},
+		}, metrics)
+	})
+}
+
+func TestAddMetricOrigin(t *testing.T) {
+	t.Run("Should set the account and region of metrics with details", func(t *testing.T) {
+		metrics := AddMetricOrigin([]resources.Metric{
+			{Namespace: "MyApp", Name: "Latency", Details: &resources.MetricDetails{DimensionKeys: []string{"Service"}}},
+			{Namespace: "MyApp", Name: "Errors"},
+		}, "123456789012", "us-east-1")
+
+		assert.Equal(t, []resources.Metric{
+			{Namespace: "MyApp", Name: "Latency", Details: &resources.MetricDetails{DimensionKeys: []string{"Service"}, AccountId: "123456789012", Region: "us-east-1"}},
+			{Namespace: "MyApp", Name: "Errors"},
+		}, metrics)
+	})
+}
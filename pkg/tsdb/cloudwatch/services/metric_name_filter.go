@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// MetricNameMatchesQuery returns whether the name of a metric matches the query of the metric picker. The name matches
+// if it starts with the query, or contains it if contains is set, ignoring case. Every name matches an empty query.
+func MetricNameMatchesQuery(name string, query string, contains bool) bool {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	if contains {
+		return strings.Contains(name, query)
+	}
+	return strings.HasPrefix(name, query)
+}
+
+// FilterMetricsByName returns the metrics whose name matches the query, as MetricNameMatchesQuery matches it
+func FilterMetricsByName(metrics []resources.Metric, query string, contains bool) []resources.Metric {
+	if query == "" {
+		return metrics
+	}
+	filtered := []resources.Metric{}
+	for _, metric := range metrics {
+		if MetricNameMatchesQuery(metric.Name, query, contains) {
+			filtered = append(filtered, metric)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricNameMatchesQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		contains bool
+		expected bool
+	}{
+		{name: "CPUUtilization", query: "", expected: true},
+		{name: "CPUUtilization", query: "", contains: true, expected: true},
+		{name: "CPUUtilization", query: "CPU", expected: true},
+		{name: "CPUUtilization", query: "cpuutil", expected: true},
+		{name: "CPUUtilization", query: "Utilization", expected: false},
+		{name: "CPUUtilization", query: "utilization", contains: true, expected: true},
+		{name: "CPUUtilization", query: "Memory", contains: true, expected: false},
+		{name: "CPU", query: "CPUUtilization", expected: false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, MetricNameMatchesQuery(tc.name, tc.query, tc.contains), "%q matching %q, contains: %v", tc.name, tc.query, tc.contains)
+	}
+}
+
+func TestFilterMetricsByName(t *testing.T) {
+	metrics := []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization"},
+		{Namespace: "AWS/EC2", Name: "CPUCreditBalance"},
+		{Namespace: "AWS/RDS", Name: "CPUUtilization"},
+		{Namespace: "AWS/EC2", Name: "NetworkIn"},
+	}
+
+	t.Run("returns all metrics for an empty query", func(t *testing.T) {
+		assert.Equal(t, metrics, FilterMetricsByName(metrics, "", false))
+	})
+
+	t.Run("returns the metrics whose name starts with the query", func(t *testing.T) {
+		assert.Equal(t, []resources.Metric{
+			{Namespace: "AWS/EC2", Name: "CPUUtilization"},
+			{Namespace: "AWS/RDS", Name: "CPUUtilization"},
+		}, FilterMetricsByName(metrics, "cpuu", false))
+	})
+
+	t.Run("returns the metrics whose name contains the query", func(t *testing.T) {
+		assert.Equal(t, []resources.Metric{
+			{Namespace: "AWS/EC2", Name: "CPUCreditBalance"},
+		}, FilterMetricsByName(metrics, "credit", true))
+	})
+
+	t.Run("returns no metrics if no name matches", func(t *testing.T) {
+		assert.Equal(t, []resources.Metric{}, FilterMetricsByName(metrics, "credit", false))
+	})
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+)
+
+type MetricsHealthService struct {
+	models.MetricsClientProvider
+}
+
+func NewMetricsHealthService(metricsClient models.MetricsClientProvider) models.MetricsHealthProvider {
+	return &MetricsHealthService{metricsClient}
+}
+
+// CheckListMetrics lists a single page of metrics, which fails if the credentials of the data source are invalid or
+// aren't allowed to list metrics. ListMetrics can't be limited to fewer metrics than a page.
+func (s *MetricsHealthService) CheckListMetrics(ctx context.Context) error {
+	_, _, err := s.ListMetricsPage(ctx, &cloudwatch.ListMetricsInput{})
+	return err
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHealthService_CheckListMetrics(t *testing.T) {
+	t.Run("Should list a single page of metrics", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{}).Return([]*cloudwatch.Metric{}, "next", nil)
+
+		err := NewMetricsHealthService(fakeMetricsClient).CheckListMetrics(context.Background())
+
+		require.NoError(t, err)
+		fakeMetricsClient.AssertNumberOfCalls(t, "ListMetricsPage", 1)
+	})
+
+	t.Run("Should return the error of ListMetrics", func(t *testing.T) {
+		awsErr := awserr.New("AccessDenied", "not authorized to perform: cloudwatch:ListMetrics", nil)
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("ListMetricsPage", mock.Anything, &cloudwatch.ListMetricsInput{}).Return([]*cloudwatch.Metric{}, "", awsErr)
+
+		err := NewMetricsHealthService(fakeMetricsClient).CheckListMetrics(context.Background())
+
+		assert.ErrorIs(t, err, awsErr)
+	})
+}
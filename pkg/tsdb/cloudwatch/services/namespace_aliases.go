@@ -0,0 +1,43 @@
+package services
+
+import "strings"
+
+// namespaceAliases holds friendly names of namespaces whose names are cryptic or refer to an outdated service name
+var namespaceAliases = map[string]string{
+	"AWS/ApplicationELB":   "ALB",
+	"AWS/Cassandra":        "Keyspaces",
+	"AWS/DDoSProtection":   "Shield",
+	"AWS/DX":               "DirectConnect",
+	"AWS/DocDB":            "DocumentDB",
+	"AWS/ELB":              "CLB",
+	"AWS/ES":               "OpenSearch",
+	"AWS/ElasticMapReduce": "EMR",
+	"AWS/Events":           "EventBridge",
+	"AWS/GatewayELB":       "GWLB",
+	"AWS/Kafka":            "MSK",
+	"AWS/NetworkELB":       "NLB",
+	"AWS/States":           "StepFunctions",
+}
+
+// namespacesByAlias maps the lower case aliases to their namespace
+var namespacesByAlias = func() map[string]string {
+	namespaces := make(map[string]string, len(namespaceAliases))
+	for namespace, alias := range namespaceAliases {
+		namespaces[strings.ToLower(alias)] = namespace
+	}
+	return namespaces
+}()
+
+// GetNamespaceAlias returns the friendly name of the namespace, or an empty string if it has none
+func GetNamespaceAlias(namespace string) string {
+	return namespaceAliases[namespace]
+}
+
+// ResolveNamespaceAlias returns the namespace the alias, which is matched case-insensitively, refers to. Anything that
+// isn't an alias, e.g. the namespace itself, is returned as is.
+func ResolveNamespaceAlias(alias string) string {
+	if namespace, ok := namespacesByAlias[strings.ToLower(alias)]; ok {
+		return namespace
+	}
+	return alias
+}
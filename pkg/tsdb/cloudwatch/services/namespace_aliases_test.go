@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceAliases(t *testing.T) {
+	t.Run("Should return the alias of a namespace", func(t *testing.T) {
+		assert.Equal(t, "ALB", GetNamespaceAlias("AWS/ApplicationELB"))
+		assert.Empty(t, GetNamespaceAlias("AWS/EC2"))
+	})
+
+	t.Run("Should resolve aliases case-insensitively", func(t *testing.T) {
+		assert.Equal(t, "AWS/ApplicationELB", ResolveNamespaceAlias("ALB"))
+		assert.Equal(t, "AWS/ApplicationELB", ResolveNamespaceAlias("alb"))
+	})
+
+	t.Run("Should return anything that isn't an alias as is", func(t *testing.T) {
+		assert.Equal(t, "AWS/ApplicationELB", ResolveNamespaceAlias("AWS/ApplicationELB"))
+		assert.Equal(t, "MyApp", ResolveNamespaceAlias("MyApp"))
+	})
+
+	t.Run("Should only have aliases of known namespaces, which don't collide with one another", func(t *testing.T) {
+		aliases := make(map[string]struct{})
+		for namespace, alias := range namespaceAliases {
+			_, exists := constants.NamespaceMetricsMap[namespace]
+			assert.True(t, exists, namespace)
+			_, exists = constants.NamespaceMetricsMap[alias]
+			assert.False(t, exists, alias)
+			_, exists = aliases[alias]
+			assert.False(t, exists, alias)
+			aliases[alias] = struct{}{}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// namespacePeriods holds the period in seconds that the metrics of well-known namespaces are reported at by default,
+// e.g. 300 for EC2 since detailed monitoring is opt-in
+var namespacePeriods = map[string]int64{
+	"AWS/ApiGateway":     60,
+	"AWS/ApplicationELB": 60,
+	"AWS/DynamoDB":       60,
+	"AWS/EBS":            300,
+	"AWS/EC2":            300,
+	"AWS/ECS":            60,
+	"AWS/ELB":            60,
+	"AWS/Lambda":         60,
+	"AWS/NetworkELB":     60,
+	"AWS/RDS":            60,
+	"AWS/S3":             60,
+	"AWS/SNS":            300,
+	"AWS/SQS":            60,
+}
+
+// metricPeriods holds the period in seconds of the well-known metrics that are reported at a different period than
+// the rest of their namespace
+var metricPeriods = map[string]map[string]int64{
+	"AWS/S3": {
+		// the storage metrics are reported once a day, unlike the request metrics
+		"BucketSizeBytes": 86400,
+		"NumberOfObjects": 86400,
+	},
+}
+
+// maxInferPeriodMetrics caps the number of metrics whose period is inferred from their data points
+const maxInferPeriodMetrics = 100
+
+// inferPeriodWindow is how far back the data points a period is inferred from are queried
+const inferPeriodWindow = time.Hour
+
+// inferPeriodResolution is the period the data points a period is inferred from are queried at, which is the
+// resolution of detailed monitoring and the finest period that can be inferred
+const inferPeriodResolution = 60
+
+// GetCuratedPeriod returns the period in seconds that the metric is reported at, or 0 if it isn't known. Only the
+// periods of hard-coded metrics are known, since other metrics in a namespace may be reported by something else.
+func GetCuratedPeriod(namespace string, metricName string) int64 {
+	if period, ok := metricPeriods[namespace][metricName]; ok {
+		return period
+	}
+	for _, name := range constants.NamespaceMetricsMap[namespace] {
+		if name == metricName {
+			return namespacePeriods[namespace]
+		}
+	}
+	return 0
+}
+
+// AddPeriods sets the Period of metrics whose period is known.
+// Other metrics are left without a period, since custom metrics can be reported at any period.
+func AddPeriods(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		metrics[i].Period = GetCuratedPeriod(metrics[i].Namespace, metrics[i].Name)
+	}
+	return metrics
+}
+
+type PeriodsService struct {
+	models.MetricsClientProvider
+}
+
+func NewPeriodsService(metricsClient models.MetricsClientProvider) models.PeriodsProvider {
+	return &PeriodsService{metricsClient}
+}
+
+// InferPeriods sets the Period of each metric to the spacing of its data points in the last hour, at a resolution of a
+// minute. Metrics with fewer than two data points in that hour get their curated period, if any, and only the first
+// metrics up to the cap are queried.
+func (p *PeriodsService) InferPeriods(ctx context.Context, metrics []resources.TaggedMetric) error {
+	if len(metrics) > maxInferPeriodMetrics {
+		metrics = metrics[:maxInferPeriodMetrics]
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	endTime := time.Now()
+	results, err := p.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(endTime.Add(-inferPeriodWindow)),
+		EndTime:           aws.Time(endTime),
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampAscending),
+		MetricDataQueries: metricStatQueries(metrics, inferPeriodResolution, cloudwatch.StatisticSampleCount),
+	})
+	if err != nil {
+		return fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	// the results of a query are returned once per page
+	timestamps := make([][]time.Time, len(metrics))
+	for _, result := range results {
+		i, err := strconv.Atoi(strings.TrimPrefix(aws.StringValue(result.Id), "m"))
+		if err != nil || i < 0 || i >= len(metrics) {
+			continue
+		}
+		for _, timestamp := range result.Timestamps {
+			timestamps[i] = append(timestamps[i], aws.TimeValue(timestamp))
+		}
+	}
+
+	for i := range metrics {
+		if period := InferPeriod(timestamps[i]); period > 0 {
+			metrics[i].Period = period
+		} else {
+			metrics[i].Period = GetCuratedPeriod(metrics[i].Namespace, metrics[i].Name)
+		}
+	}
+
+	return nil
+}
+
+// InferPeriod returns the period in seconds that data points at the timestamps were reported at, which is the smallest
+// spacing between two of them, since a metric may not have been reported in every period. It returns 0 if there are
+// fewer than two timestamps.
+func InferPeriod(timestamps []time.Time) int64 {
+	sorted := make([]time.Time, len(timestamps))
+	copy(sorted, timestamps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+
+	var period time.Duration
+	for i := 1; i < len(sorted); i++ {
+		if spacing := sorted[i].Sub(sorted[i-1]); spacing > 0 && (period == 0 || spacing < period) {
+			period = spacing
+		}
+	}
+	return int64(period / time.Second)
+}
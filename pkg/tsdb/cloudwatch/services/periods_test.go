@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriods_GetCuratedPeriod(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		expected   int64
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", expected: 300},
+		{namespace: "AWS/Lambda", metricName: "Invocations", expected: 60},
+		{namespace: "AWS/S3", metricName: "4xxErrors", expected: 60},
+		{namespace: "AWS/S3", metricName: "BucketSizeBytes", expected: 86400},
+		{namespace: "AWS/EC2", metricName: "unknownMetric", expected: 0},
+		{namespace: "AWS/Redshift", metricName: "CPUUtilization", expected: 0},
+		{namespace: "customNamespace", metricName: "Invocations", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetCuratedPeriod(tc.namespace, tc.metricName))
+		})
+	}
+}
+
+func TestPeriods_CuratedMetricsExist(t *testing.T) {
+	for namespace := range namespacePeriods {
+		assert.Contains(t, constants.NamespaceMetricsMap, namespace)
+	}
+	for namespace, periods := range metricPeriods {
+		for metricName := range periods {
+			assert.Contains(t, constants.NamespaceMetricsMap[namespace], metricName)
+		}
+	}
+}
+
+func TestPeriods_AddPeriods(t *testing.T) {
+	metrics := AddPeriods([]resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "customNamespace", Name: "Errors"}})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", Period: 300},
+		{Namespace: "customNamespace", Name: "Errors"},
+	}, metrics)
+}
+
+func TestPeriods_InferPeriod(t *testing.T) {
+	start := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should infer the spacing of the data points", func(t *testing.T) {
+		assert.Equal(t, int64(60), InferPeriod([]time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}))
+		assert.Equal(t, int64(300), InferPeriod([]time.Time{start, start.Add(5 * time.Minute), start.Add(10 * time.Minute)}))
+	})
+
+	t.Run("Should infer the smallest spacing if data points are missing", func(t *testing.T) {
+		assert.Equal(t, int64(300), InferPeriod([]time.Time{start.Add(20 * time.Minute), start, start.Add(5 * time.Minute)}))
+	})
+
+	t.Run("Should not infer a period from fewer than two data points", func(t *testing.T) {
+		assert.Equal(t, int64(0), InferPeriod(nil))
+		assert.Equal(t, int64(0), InferPeriod([]time.Time{start}))
+	})
+}
+
+func TestPeriodsService_InferPeriods(t *testing.T) {
+	start := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should set the period inferred from the sample spacing of each metric", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("m0"), Timestamps: []*time.Time{aws.Time(start), aws.Time(start.Add(time.Minute))}},
+			{Id: aws.String("m1"), Timestamps: []*time.Time{aws.Time(start), aws.Time(start.Add(5 * time.Minute))}},
+			// the second page of the results of m1
+			{Id: aws.String("m1"), Timestamps: []*time.Time{aws.Time(start.Add(10 * time.Minute))}},
+			{Id: aws.String("m2"), Timestamps: []*time.Time{aws.Time(start)}},
+			{Id: aws.String("m3"), Timestamps: []*time.Time{}},
+		}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-detailed"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}, Dimensions: map[string]string{"InstanceId": "i-idle"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "idle"}},
+		}
+
+		err := NewPeriodsService(fakeMetricsClient).InferPeriods(context.Background(), metrics)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), metrics[0].Period)
+		assert.Equal(t, int64(300), metrics[1].Period)
+		// metrics without enough data points fall back to their curated period
+		assert.Equal(t, int64(300), metrics[2].Period)
+		assert.Equal(t, int64(0), metrics[3].Period)
+
+		fakeMetricsClient.AssertNumberOfCalls(t, "GetMetricData", 1)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 4)
+		assert.Equal(t, int64(inferPeriodResolution), *input.MetricDataQueries[0].MetricStat.Period)
+		assert.Equal(t, cloudwatch.StatisticSampleCount, *input.MetricDataQueries[0].MetricStat.Stat)
+	})
+
+	t.Run("Should only query the metrics up to the cap", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, nil)
+		metrics := make([]resources.TaggedMetric, maxInferPeriodMetrics+1)
+		for i := range metrics {
+			metrics[i] = resources.TaggedMetric{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization"}}
+		}
+
+		err := NewPeriodsService(fakeMetricsClient).InferPeriods(context.Background(), metrics)
+
+		require.NoError(t, err)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Len(t, input.MetricDataQueries, maxInferPeriodMetrics)
+		assert.Equal(t, int64(0), metrics[maxInferPeriodMetrics].Period)
+	})
+}
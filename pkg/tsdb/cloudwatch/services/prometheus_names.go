@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// unitsWithoutSuffix are CloudWatch units that don't add any information to a Prometheus metric name
+var unitsWithoutSuffix = map[string]bool{
+	"":      true,
+	"None":  true,
+	"Count": true,
+}
+
+// GetPrometheusMetricName returns a Prometheus-compatible name in the form namespace_metric_unit.
+// CamelCase is converted to snake_case and characters not allowed by Prometheus are replaced with underscores,
+// so that the result matches [a-zA-Z_][a-zA-Z0-9_]*. The unit is omitted if it's unknown or dimensionless.
+func GetPrometheusMetricName(namespace string, metricName string, unit string) string {
+	parts := []string{toPrometheusSnakeCase(namespace), toPrometheusSnakeCase(metricName)}
+	if !unitsWithoutSuffix[unit] {
+		parts = append(parts, toPrometheusSnakeCase(strings.ReplaceAll(unit, "/", "Per")))
+	}
+
+	name := strings.Join(parts, "_")
+	name = strings.Trim(collapseUnderscores(name), "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// AddPrometheusNames sets the PrometheusName of each metric
+func AddPrometheusNames(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		metrics[i].PrometheusName = GetPrometheusMetricName(metrics[i].Namespace, metrics[i].Name, "")
+	}
+	return metrics
+}
+
+func toPrometheusSnakeCase(s string) string {
+	s = strings.ReplaceAll(s, "%", "Percent")
+	runes := []rune(s)
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if !isPrometheusNameRune(r) {
+			sb.WriteRune('_')
+			continue
+		}
+
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// split "NetworkIn" into network_in and "CPUUtilization" into cpu_utilization
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
+
+func isPrometheusNameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+func collapseUnderscores(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && i > 0 && s[i-1] == '_' {
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
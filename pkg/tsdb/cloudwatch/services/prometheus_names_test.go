@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusNames_GetPrometheusMetricName(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		unit       string
+		expected   string
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", unit: "Percent", expected: "aws_ec2_cpu_utilization_percent"},
+		{namespace: "AWS/EC2", metricName: "NetworkIn", unit: "Bytes", expected: "aws_ec2_network_in_bytes"},
+		{namespace: "AWS/EC2", metricName: "EBSByteBalance%", expected: "aws_ec2_ebs_byte_balance_percent"},
+		{namespace: "AWS/EC2", metricName: "StatusCheckFailed_Instance", unit: "Count", expected: "aws_ec2_status_check_failed_instance"},
+		{namespace: "AWS/ApplicationELB", metricName: "HTTPCode_ELB_5XX_Count", unit: "None", expected: "aws_application_elb_http_code_elb_5xx_count"},
+		{namespace: "AWS/Kinesis", metricName: "PutRecords.Bytes", unit: "Bytes/Second", expected: "aws_kinesis_put_records_bytes_bytes_per_second"},
+		{namespace: "Custom App (prod)", metricName: "my-metric.v2", expected: "custom_app_prod_my_metric_v2"},
+		{namespace: "123app", metricName: "latency", unit: "Milliseconds", expected: "_123app_latency_milliseconds"},
+		{namespace: "--", metricName: "??", expected: "_"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetPrometheusMetricName(tc.namespace, tc.metricName, tc.unit))
+		})
+	}
+}
+
+func TestPrometheusNames_AddPrometheusNames(t *testing.T) {
+	metrics := AddPrometheusNames([]resources.Metric{{Namespace: "AWS/EC2", Name: "CPUUtilization"}, {Namespace: "Custom/Namespace", Name: "Errors 4xx"}})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", PrometheusName: "aws_ec2_cpu_utilization"},
+		{Namespace: "Custom/Namespace", Name: "Errors 4xx", PrometheusName: "custom_namespace_errors_4xx"},
+	}, metrics)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentResourceTagsRequests limits the number of resource types whose tags are listed concurrently
+const maxConcurrentResourceTagsRequests = 5
+
+// resourceTagsPageLimit caps the number of GetResources pages listed per resource type
+const resourceTagsPageLimit = 10
+
+// resourceTagsCacheTTL is how long the tags of the resources of a type are cached
+const resourceTagsCacheTTL = 5 * time.Minute
+
+type cachedResourceTags struct {
+	tags    map[string]map[string]string
+	expires time.Time
+}
+
+// resourceTagsCache caches the tags of the resources of a type by cache key and resource type
+var resourceTagsCache = struct {
+	sync.Mutex
+	entries map[string]cachedResourceTags
+}{entries: make(map[string]cachedResourceTags)}
+
+type ResourceTagsService struct {
+	models.ResourceTaggingAPIProvider
+	cacheKey string
+}
+
+// NewResourceTagsService returns a service attaching resource tags to metrics. The listed tags are cached under the
+// cache key, which has to identify the account and region of the tagging client.
+func NewResourceTagsService(taggingClient models.ResourceTaggingAPIProvider, cacheKey string) models.ResourceTagsProvider {
+	return &ResourceTagsService{taggingClient, cacheKey}
+}
+
+// AddResourceTags sets the tags of each metric to the tags of the resource it belongs to. The resource is identified
+// by the dimension of the metric that determines its resource type, so metrics of an unknown resource type, or of a
+// resource that isn't tagged, don't get any tags.
+func (r *ResourceTagsService) AddResourceTags(metrics []resources.TaggedMetric) error {
+	var mu sync.Mutex
+	tagsByResourceType := make(map[string]map[string]map[string]string)
+	listed := make(map[string]struct{})
+
+	eg := errgroup.Group{}
+	eg.SetLimit(maxConcurrentResourceTagsRequests)
+	for _, metric := range metrics {
+		resourceType := metric.ResourceType
+		if resourceType == "" {
+			continue
+		}
+		if _, exists := listed[resourceType]; exists {
+			continue
+		}
+		listed[resourceType] = struct{}{}
+
+		eg.Go(func() error {
+			tags, err := r.getTagsByResourceType(resourceType)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			tagsByResourceType[resourceType] = tags
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	for i, metric := range metrics {
+		value, ok := metric.Dimensions[getResourceDimensionKey(metric.Namespace, metric.ResourceType)]
+		if !ok {
+			continue
+		}
+		if tags, exists := tagsByResourceType[metric.ResourceType][value]; exists {
+			metrics[i].Tags = tags
+		}
+	}
+
+	return nil
+}
+
+// getTagsByResourceType returns the tags of the resources of the type, by each of the ids the resource could be
+// referred to by in a dimension value.
+func (r *ResourceTagsService) getTagsByResourceType(resourceType string) (map[string]map[string]string, error) {
+	cacheKey := r.cacheKey + "/" + resourceType
+	resourceTagsCache.Lock()
+	cached, exists := resourceTagsCache.entries[cacheKey]
+	resourceTagsCache.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.tags, nil
+	}
+
+	tags := make(map[string]map[string]string)
+	var paginationToken *string
+	for page := 0; page < resourceTagsPageLimit; page++ {
+		output, err := r.GetResources(&resourcegroupstaggingapi.GetResourcesInput{
+			ResourceTypeFilters: []*string{aws.String(resourceType)},
+			PaginationToken:     paginationToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "unable to call tagging API", err)
+		}
+
+		for _, mapping := range output.ResourceTagMappingList {
+			resourceArn, err := arn.Parse(aws.StringValue(mapping.ResourceARN))
+			if err != nil {
+				continue
+			}
+			resourceTags := make(map[string]string, len(mapping.Tags))
+			for _, tag := range mapping.Tags {
+				resourceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			for _, id := range resourceIds(resourceArn.Resource) {
+				tags[id] = resourceTags
+			}
+		}
+
+		if aws.StringValue(output.PaginationToken) == "" {
+			break
+		}
+		paginationToken = output.PaginationToken
+	}
+
+	resourceTagsCache.Lock()
+	resourceTagsCache.entries[cacheKey] = cachedResourceTags{tags: tags, expires: time.Now().Add(resourceTagsCacheTTL)}
+	resourceTagsCache.Unlock()
+
+	return tags, nil
+}
+
+// resourceIds returns the ids a resource could be referred to by in a dimension value. Dimension values are usually
+// the last part of the resource in the ARN (e.g. i-0ab for instance/i-0ab), but some are the whole resource
+// (e.g. targetgroup/my-tg/0ab) or everything but the resource type (e.g. app/my-lb/0ab for loadbalancer/app/my-lb/0ab).
+func resourceIds(resource string) []string {
+	ids := []string{resource}
+	if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		ids = append(ids, resource[i+1:])
+	}
+	if i := strings.LastIndexAny(resource, "/:"); i >= 0 {
+		ids = append(ids, resource[i+1:])
+	}
+	return ids
+}
+
+// getResourceDimensionKey returns the dimension key identifying resources of the type in the namespace
+func getResourceDimensionKey(namespace string, resourceType string) string {
+	for _, pattern := range namespaceResourceTypes[namespace] {
+		if pattern.resourceType == resourceType {
+			return pattern.dimensionKey
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func tagMapping(resourceArn string, tags map[string]string) *resourcegroupstaggingapi.ResourceTagMapping {
+	mapping := &resourcegroupstaggingapi.ResourceTagMapping{ResourceARN: aws.String(resourceArn)}
+	for key, value := range tags {
+		mapping.Tags = append(mapping.Tags, &resourcegroupstaggingapi.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return mapping
+}
+
+func TestResourceTagsService_AddResourceTags(t *testing.T) {
+	t.Cleanup(func() {
+		resourceTagsCache.entries = make(map[string]cachedResourceTags)
+	})
+
+	t.Run("Should attach the tags of the resource to matching metrics", func(t *testing.T) {
+		fakeTaggingClient := &mocks.FakeResourceTaggingClient{}
+		fakeTaggingClient.On("GetResources", &resourcegroupstaggingapi.GetResourcesInput{
+			ResourceTypeFilters: []*string{aws.String("ec2:instance")},
+		}).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				tagMapping("arn:aws:ec2:us-east-1:123456789012:instance/i-1", map[string]string{"team": "a"}),
+			},
+			PaginationToken: aws.String("next"),
+		}, nil)
+		fakeTaggingClient.On("GetResources", &resourcegroupstaggingapi.GetResourcesInput{
+			ResourceTypeFilters: []*string{aws.String("ec2:instance")},
+			PaginationToken:     aws.String("next"),
+		}).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				tagMapping("arn:aws:ec2:us-east-1:123456789012:instance/i-2", map[string]string{"team": "b"}),
+			},
+		}, nil)
+		fakeTaggingClient.On("GetResources", &resourcegroupstaggingapi.GetResourcesInput{
+			ResourceTypeFilters: []*string{aws.String("elasticloadbalancing:loadbalancer")},
+		}).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				tagMapping("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188", map[string]string{"env": "prod"}),
+			},
+		}, nil)
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-2"}},
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-untagged"}},
+			{Metric: resources.Metric{Namespace: "AWS/ApplicationELB", Name: "RequestCount", ResourceType: "elasticloadbalancing:loadbalancer"}, Dimensions: map[string]string{"LoadBalancer": "app/my-lb/50dc6c495c0c9188"}},
+			{Metric: resources.Metric{Namespace: "MyApp", Name: "Latency"}, Dimensions: map[string]string{"Service": "api"}},
+		}
+
+		err := NewResourceTagsService(fakeTaggingClient, "test/us-east-1").AddResourceTags(metrics)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "a"}, metrics[0].Tags)
+		assert.Equal(t, map[string]string{"team": "b"}, metrics[1].Tags)
+		assert.Nil(t, metrics[2].Tags)
+		assert.Equal(t, map[string]string{"env": "prod"}, metrics[3].Tags)
+		assert.Nil(t, metrics[4].Tags)
+		fakeTaggingClient.AssertNumberOfCalls(t, "GetResources", 3)
+	})
+
+	t.Run("Should cache the tags of a resource type", func(t *testing.T) {
+		fakeTaggingClient := &mocks.FakeResourceTaggingClient{}
+		fakeTaggingClient.On("GetResources", mock.Anything).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				tagMapping("arn:aws:lambda:us-east-1:123456789012:function:my-function", map[string]string{"team": "a"}),
+			},
+		}, nil)
+		tagsService := NewResourceTagsService(fakeTaggingClient, "cached/us-east-1")
+
+		for i := 0; i < 2; i++ {
+			metrics := []resources.TaggedMetric{
+				{Metric: resources.Metric{Namespace: "AWS/Lambda", Name: "Invocations", ResourceType: "lambda:function"}, Dimensions: map[string]string{"FunctionName": "my-function"}},
+			}
+			require.NoError(t, tagsService.AddResourceTags(metrics))
+			assert.Equal(t, map[string]string{"team": "a"}, metrics[0].Tags)
+		}
+		fakeTaggingClient.AssertNumberOfCalls(t, "GetResources", 1)
+	})
+
+	t.Run("Should return the error of the tagging API", func(t *testing.T) {
+		fakeTaggingClient := &mocks.FakeResourceTaggingClient{}
+		fakeTaggingClient.On("GetResources", mock.Anything).Return(&resourcegroupstaggingapi.GetResourcesOutput{}, awserr.New("AccessDenied", "access denied", nil))
+		metrics := []resources.TaggedMetric{
+			{Metric: resources.Metric{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"}, Dimensions: map[string]string{"InstanceId": "i-1"}},
+		}
+
+		err := NewResourceTagsService(fakeTaggingClient, "failing/us-east-1").AddResourceTags(metrics)
+
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+type resourceTypePattern struct {
+	dimensionKey string
+	resourceType string
+}
+
+// namespaceResourceTypes maps the dimension keys identifying a resource to the resource type, using the same
+// format as the Resource Groups Tagging API. The first pattern of a namespace is its primary resource type.
+var namespaceResourceTypes = map[string][]resourceTypePattern{
+	"AWS/ApplicationELB": {
+		{dimensionKey: "LoadBalancer", resourceType: "elasticloadbalancing:loadbalancer"},
+		{dimensionKey: "TargetGroup", resourceType: "elasticloadbalancing:targetgroup"},
+	},
+	"AWS/DynamoDB": {
+		{dimensionKey: "TableName", resourceType: "dynamodb:table"},
+	},
+	"AWS/EBS": {
+		{dimensionKey: "VolumeId", resourceType: "ec2:volume"},
+	},
+	"AWS/EC2": {
+		{dimensionKey: "InstanceId", resourceType: "ec2:instance"},
+		{dimensionKey: "AutoScalingGroupName", resourceType: "autoscaling:autoScalingGroup"},
+		{dimensionKey: "ImageId", resourceType: "ec2:image"},
+	},
+	"AWS/ELB": {
+		{dimensionKey: "LoadBalancerName", resourceType: "elasticloadbalancing:loadbalancer"},
+	},
+	"AWS/Lambda": {
+		{dimensionKey: "FunctionName", resourceType: "lambda:function"},
+	},
+	"AWS/NetworkELB": {
+		{dimensionKey: "LoadBalancer", resourceType: "elasticloadbalancing:loadbalancer"},
+		{dimensionKey: "TargetGroup", resourceType: "elasticloadbalancing:targetgroup"},
+	},
+	"AWS/RDS": {
+		{dimensionKey: "DBInstanceIdentifier", resourceType: "rds:db"},
+		{dimensionKey: "DBClusterIdentifier", resourceType: "rds:cluster"},
+	},
+	"AWS/S3": {
+		{dimensionKey: "BucketName", resourceType: "s3"},
+	},
+	"AWS/SQS": {
+		{dimensionKey: "QueueName", resourceType: "sqs"},
+	},
+}
+
+// GetResourceType returns the type of the resource a metric with the given dimension keys belongs to.
+// An empty string is returned if the namespace or the dimension keys aren't known.
+func GetResourceType(namespace string, dimensionKeys []string) string {
+	for _, pattern := range namespaceResourceTypes[namespace] {
+		for _, key := range dimensionKeys {
+			if key == pattern.dimensionKey {
+				return pattern.resourceType
+			}
+		}
+	}
+	return ""
+}
+
+// AddResourceTypes sets the ResourceType of each metric that doesn't have one yet to the primary resource type of its
+// namespace, as the dimensions of hardcoded metrics aren't known
+func AddResourceTypes(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		if metrics[i].ResourceType != "" {
+			continue
+		}
+		if patterns := namespaceResourceTypes[metrics[i].Namespace]; len(patterns) > 0 {
+			metrics[i].ResourceType = patterns[0].resourceType
+		}
+	}
+	return metrics
+}
+
+// FilterMetricsByResourceType returns the metrics of the given resource type
+func FilterMetricsByResourceType(metrics []resources.Metric, resourceType string) []resources.Metric {
+	filtered := []resources.Metric{}
+	for _, metric := range metrics {
+		if metric.ResourceType == resourceType {
+			filtered = append(filtered, metric)
+		}
+	}
+	return filtered
+}
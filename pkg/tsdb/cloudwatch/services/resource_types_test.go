@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResourceType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		namespace     string
+		dimensionKeys []string
+		expected      string
+	}{
+		{"EC2 instance", "AWS/EC2", []string{"InstanceId"}, "ec2:instance"},
+		{"EC2 auto scaling group", "AWS/EC2", []string{"AutoScalingGroupName"}, "autoscaling:autoScalingGroup"},
+		{"primary resource type takes precedence", "AWS/EC2", []string{"AutoScalingGroupName", "InstanceId"}, "ec2:instance"},
+		{"RDS instance", "AWS/RDS", []string{"DBInstanceIdentifier"}, "rds:db"},
+		{"RDS cluster", "AWS/RDS", []string{"DBClusterIdentifier", "Role"}, "rds:cluster"},
+		{"classic ELB", "AWS/ELB", []string{"LoadBalancerName", "AvailabilityZone"}, "elasticloadbalancing:loadbalancer"},
+		{"ALB target group", "AWS/ApplicationELB", []string{"TargetGroup"}, "elasticloadbalancing:targetgroup"},
+		{"unknown dimension keys", "AWS/EC2", []string{"InstanceType"}, ""},
+		{"no dimension keys", "AWS/RDS", nil, ""},
+		{"unknown namespace", "MyApp", []string{"InstanceId"}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetResourceType(tc.namespace, tc.dimensionKeys))
+		})
+	}
+}
+
+func TestAddResourceTypes(t *testing.T) {
+	metrics := AddResourceTypes([]resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization"},
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "autoscaling:autoScalingGroup"},
+		{Namespace: "AWS/RDS", Name: "CPUUtilization"},
+		{Namespace: "MyApp", Name: "Latency"},
+	})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"},
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "autoscaling:autoScalingGroup"},
+		{Namespace: "AWS/RDS", Name: "CPUUtilization", ResourceType: "rds:db"},
+		{Namespace: "MyApp", Name: "Latency"},
+	}, metrics)
+}
+
+func TestFilterMetricsByResourceType(t *testing.T) {
+	metrics := []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "ec2:instance"},
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", ResourceType: "autoscaling:autoScalingGroup"},
+		{Namespace: "AWS/RDS", Name: "CPUUtilization", ResourceType: "rds:db"},
+		{Namespace: "MyApp", Name: "Latency"},
+	}
+
+	assert.Equal(t, []resources.Metric{{Namespace: "AWS/RDS", Name: "CPUUtilization", ResourceType: "rds:db"}}, FilterMetricsByResourceType(metrics, "rds:db"))
+	assert.Equal(t, []resources.Metric{}, FilterMetricsByResourceType(metrics, "lambda:function"))
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// SortAndPageMetrics sorts the metrics by the given field and returns the page of at most pageSize metrics that
+// follows the metric with the sort key after, or the first page if after is empty. The sort key of the last metric of
+// the page is returned to request the next page with, and is empty if it's the last page.
+// Metrics are ordered by the field, then by name and then by their dimensions, so the order is total and a page picks
+// up where the previous one ended even if metrics were added or removed in between.
+func SortAndPageMetrics(metrics []resources.TaggedMetric, sortBy string, after string, pageSize int) ([]resources.TaggedMetric, string) {
+	keys := make([]string, len(metrics))
+	sorted := make([]int, len(metrics))
+	for i, metric := range metrics {
+		keys[i] = metricSortKey(metric, sortBy)
+		sorted[i] = i
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return keys[sorted[i]] < keys[sorted[j]]
+	})
+
+	start := 0
+	if after != "" {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return keys[sorted[i]] > after
+		})
+	}
+	end := start + pageSize
+	if end > len(sorted) {
+		end = len(sorted)
+	}
+
+	page := make([]resources.TaggedMetric, 0, end-start)
+	for _, i := range sorted[start:end] {
+		page = append(page, metrics[i])
+	}
+
+	next := ""
+	if end < len(sorted) {
+		next = keys[sorted[end-1]]
+	}
+	return page, next
+}
+
+// metricSortKey joins the fields a metric is sorted by with NUL bytes, which sort before any other character, so
+// that comparing keys compares the fields one after the other
+func metricSortKey(metric resources.TaggedMetric, sortBy string) string {
+	fields := make([]string, 0, len(metric.Dimensions)+2)
+	if sortBy == resources.MetricsSortByResourceType {
+		fields = append(fields, metric.ResourceType)
+	}
+	fields = append(fields, metric.Name)
+
+	dimensions := make([]string, 0, len(metric.Dimensions))
+	for key, value := range metric.Dimensions {
+		dimensions = append(dimensions, key+"="+value)
+	}
+	sort.Strings(dimensions)
+
+	return strings.Join(append(fields, dimensions...), "\x00")
+}
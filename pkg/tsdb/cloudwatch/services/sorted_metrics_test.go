@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortAndPageMetrics(t *testing.T) {
+	metric := func(name string, resourceType string, instanceId string) resources.TaggedMetric {
+		return resources.TaggedMetric{Metric: resources.Metric{Name: name, Namespace: "MyApp", ResourceType: resourceType}, Dimensions: map[string]string{"InstanceId": instanceId}}
+	}
+
+	t.Run("Should return pages in a globally consistent order", func(t *testing.T) {
+		metrics := make([]resources.TaggedMetric, 0, 95)
+		for i := 0; i < 95; i++ {
+			metrics = append(metrics, metric(fmt.Sprintf("Metric%02d", i%10), "", fmt.Sprintf("i-%02d", i)))
+		}
+		rand.New(rand.NewSource(1)).Shuffle(len(metrics), func(i, j int) { metrics[i], metrics[j] = metrics[j], metrics[i] })
+
+		var listed []string
+		after := ""
+		for pages := 1; ; pages++ {
+			page, next := SortAndPageMetrics(metrics, resources.MetricsSortByName, after, 10)
+			require.LessOrEqual(t, len(page), 10)
+			for _, m := range page {
+				listed = append(listed, m.Name+"/"+m.Dimensions["InstanceId"])
+			}
+			if next == "" {
+				require.Equal(t, 10, pages)
+				break
+			}
+			after = next
+		}
+
+		require.Len(t, listed, 95)
+		assert.True(t, sort.StringsAreSorted(listed))
+	})
+
+	t.Run("Should sort by resource type, then by name and dimensions", func(t *testing.T) {
+		metrics := []resources.TaggedMetric{
+			metric("Latency", "ec2:instance", "i-2"),
+			metric("Errors", "rds:db", "i-1"),
+			metric("Latency", "ec2:instance", "i-1"),
+			metric("Errors", "ec2:instance", "i-3"),
+		}
+
+		page, next := SortAndPageMetrics(metrics, resources.MetricsSortByResourceType, "", 10)
+
+		assert.Empty(t, next)
+		assert.Equal(t, []resources.TaggedMetric{
+			metric("Errors", "ec2:instance", "i-3"),
+			metric("Latency", "ec2:instance", "i-1"),
+			metric("Latency", "ec2:instance", "i-2"),
+			metric("Errors", "rds:db", "i-1"),
+		}, page)
+	})
+
+	t.Run("Should continue after the last metric of the previous page if metrics were removed in between", func(t *testing.T) {
+		metrics := []resources.TaggedMetric{metric("A", "", "i-1"), metric("B", "", "i-1"), metric("C", "", "i-1"), metric("D", "", "i-1")}
+
+		page, next := SortAndPageMetrics(metrics, resources.MetricsSortByName, "", 2)
+		require.Equal(t, metrics[:2], page)
+		require.NotEmpty(t, next)
+
+		page, next = SortAndPageMetrics([]resources.TaggedMetric{metrics[3], metrics[2], metrics[0]}, resources.MetricsSortByName, next, 2)
+		assert.Equal(t, []resources.TaggedMetric{metrics[2], metrics[3]}, page)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Should order a name before the longer names it's a prefix of", func(t *testing.T) {
+		metrics := []resources.TaggedMetric{metric("Latency2", "", "i-1"), metric("Latency", "", "i-2")}
+
+		page, _ := SortAndPageMetrics(metrics, resources.MetricsSortByName, "", 10)
+
+		assert.Equal(t, []resources.TaggedMetric{metrics[1], metrics[0]}, page)
+	})
+}
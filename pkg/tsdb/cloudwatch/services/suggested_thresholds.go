@@ -0,0 +1,53 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+// metricSuggestedThresholds holds a reasonable default alarm threshold of well-known metrics whose healthy range
+// doesn't depend on the resource, e.g. a utilization in percent or a count of errors. Metrics like free storage or
+// connection counts depend on the size of the resource, so they aren't curated.
+var metricSuggestedThresholds = map[string]map[string]resources.SuggestedThreshold{
+	"AWS/ApplicationELB": {
+		"HTTPCode_ELB_5XX_Count": {Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+		"UnHealthyHostCount":     {Statistic: "Maximum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+	},
+	"AWS/DynamoDB": {
+		"SystemErrors":      {Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+		"ThrottledRequests": {Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+	},
+	"AWS/EBS": {
+		"BurstBalance": {Statistic: "Average", ComparisonOperator: "LessThanThreshold", Threshold: 20},
+	},
+	"AWS/EC2": {
+		"CPUUtilization":    {Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80},
+		"StatusCheckFailed": {Statistic: "Maximum", ComparisonOperator: "GreaterThanOrEqualToThreshold", Threshold: 1},
+	},
+	"AWS/ECS": {
+		"CPUUtilization":    {Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80},
+		"MemoryUtilization": {Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80},
+	},
+	"AWS/Lambda": {
+		"Errors":    {Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+		"Throttles": {Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0},
+	},
+	"AWS/RDS": {
+		"CPUUtilization": {Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80},
+	},
+}
+
+// GetSuggestedThreshold returns the suggested alarm threshold of the metric, or nil if there's no curated threshold
+func GetSuggestedThreshold(namespace string, metricName string) *resources.SuggestedThreshold {
+	threshold, ok := metricSuggestedThresholds[namespace][metricName]
+	if !ok {
+		return nil
+	}
+	return &threshold
+}
+
+// AddSuggestedThresholds sets the SuggestedThreshold of the metrics that have a curated threshold.
+// Other metrics are left without one, since a threshold that fits every resource can't be suggested for them.
+func AddSuggestedThresholds(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		metrics[i].SuggestedThreshold = GetSuggestedThreshold(metrics[i].Namespace, metrics[i].Name)
+	}
+	return metrics
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/constants"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestedThresholds_GetSuggestedThreshold(t *testing.T) {
+	testCases := []struct {
+		namespace  string
+		metricName string
+		expected   *resources.SuggestedThreshold
+	}{
+		{namespace: "AWS/EC2", metricName: "CPUUtilization", expected: &resources.SuggestedThreshold{Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80}},
+		{namespace: "AWS/EC2", metricName: "StatusCheckFailed", expected: &resources.SuggestedThreshold{Statistic: "Maximum", ComparisonOperator: "GreaterThanOrEqualToThreshold", Threshold: 1}},
+		{namespace: "AWS/EBS", metricName: "BurstBalance", expected: &resources.SuggestedThreshold{Statistic: "Average", ComparisonOperator: "LessThanThreshold", Threshold: 20}},
+		{namespace: "AWS/EC2", metricName: "NetworkIn", expected: nil},
+		{namespace: "AWS/RDS", metricName: "FreeStorageSpace", expected: nil},
+		{namespace: "customNamespace", metricName: "CPUUtilization", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.namespace+" "+tc.metricName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetSuggestedThreshold(tc.namespace, tc.metricName))
+		})
+	}
+}
+
+func TestSuggestedThresholds_AddSuggestedThresholds(t *testing.T) {
+	metrics := AddSuggestedThresholds([]resources.Metric{
+		{Namespace: "AWS/Lambda", Name: "Throttles"},
+		{Namespace: "AWS/Lambda", Name: "Invocations"},
+		{Namespace: "AWS/ECS", Name: "MemoryUtilization"},
+		{Namespace: "customNamespace", Name: "Errors"},
+	})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/Lambda", Name: "Throttles", SuggestedThreshold: &resources.SuggestedThreshold{Statistic: "Sum", ComparisonOperator: "GreaterThanThreshold", Threshold: 0}},
+		{Namespace: "AWS/Lambda", Name: "Invocations"},
+		{Namespace: "AWS/ECS", Name: "MemoryUtilization", SuggestedThreshold: &resources.SuggestedThreshold{Statistic: "Average", ComparisonOperator: "GreaterThanThreshold", Threshold: 80}},
+		{Namespace: "customNamespace", Name: "Errors"},
+	}, metrics)
+}
+
+func TestSuggestedThresholds_AreNotShared(t *testing.T) {
+	threshold := GetSuggestedThreshold("AWS/EC2", "CPUUtilization")
+	threshold.Threshold = 95
+
+	assert.Equal(t, float64(80), GetSuggestedThreshold("AWS/EC2", "CPUUtilization").Threshold)
+}
+
+func TestSuggestedThresholds_CuratedMetricsExist(t *testing.T) {
+	for namespace, thresholds := range metricSuggestedThresholds {
+		for metricName, threshold := range thresholds {
+			assert.Contains(t, constants.NamespaceMetricsMap[namespace], metricName)
+			assert.Contains(t, cloudwatch.Statistic_Values(), threshold.Statistic)
+			assert.Contains(t, cloudwatch.ComparisonOperator_Values(), threshold.ComparisonOperator)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+)
+
+// testQueryPeriods is the number of periods a test query is run over
+const testQueryPeriods = 10
+
+type TestQueryService struct {
+	models.MetricsClientProvider
+}
+
+func NewTestQueryService(metricsClient models.MetricsClientProvider) models.TestQueryProvider {
+	return &TestQueryService{metricsClient}
+}
+
+// RunTestQuery runs the query over the last ten periods and returns the data points in ascending order, together with
+// the messages AWS returned for the query. The result isn't OK if the query ran but failed to complete or returned no
+// data points, e.g. because the dimensions don't match a metric. Errors of the AWS API are wrapped, so they can be
+// told apart by their code.
+func (s *TestQueryService) RunTestQuery(ctx context.Context, r resources.TestQueryRequest) (resources.TestQueryResult, error) {
+	statistic := r.Statistic
+	if statistic == "" {
+		statistic = GetDefaultStatistic(r.Namespace, r.MetricName)
+	}
+
+	dimensions := make([]*cloudwatch.Dimension, 0, len(r.Dimensions))
+	for _, dimension := range r.Dimensions {
+		dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(dimension.Name), Value: aws.String(dimension.Value)})
+	}
+	sort.Slice(dimensions, func(i, j int) bool {
+		return *dimensions[i].Name < *dimensions[j].Name
+	})
+
+	window := time.Duration(r.Period*testQueryPeriods) * time.Second
+	endTime := time.Now()
+	results, err := s.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(endTime.Add(-window)),
+		EndTime:   aws.Time(endTime),
+		ScanBy:    aws.String(cloudwatch.ScanByTimestampAscending),
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{{
+			Id: aws.String("test"),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String(r.Namespace),
+					MetricName: aws.String(r.MetricName),
+					Dimensions: dimensions,
+				},
+				Period: aws.Int64(r.Period),
+				Stat:   aws.String(statistic),
+			},
+		}},
+	})
+	if err != nil {
+		return resources.TestQueryResult{}, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	result := resources.TestQueryResult{DataPoints: []resources.DataPoint{}}
+	var messages []string
+	failed := false
+	for _, page := range results {
+		// the data points of the query are split across a result per page
+		for i := range page.Values {
+			if i < len(page.Timestamps) {
+				result.DataPoints = append(result.DataPoints, resources.DataPoint{Value: aws.Float64Value(page.Values[i]), Timestamp: aws.TimeValue(page.Timestamps[i])})
+			}
+		}
+		if aws.StringValue(page.StatusCode) == cloudwatch.StatusCodeInternalError {
+			failed = true
+		}
+		for _, message := range page.Messages {
+			messages = append(messages, aws.StringValue(message.Value))
+		}
+	}
+
+	result.Message = strings.Join(messages, "; ")
+	switch {
+	case failed:
+		if result.Message == "" {
+			result.Message = "the query failed to complete"
+		}
+	case len(result.DataPoints) == 0:
+		if result.Message == "" {
+			result.Message = fmt.Sprintf("no data points in the last %s, check that the dimensions match an existing metric", window)
+		}
+	default:
+		result.OK = true
+	}
+
+	return result, nil
+}
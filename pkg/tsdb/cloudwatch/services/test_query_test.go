@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestQueryService_RunTestQuery(t *testing.T) {
+	request := resources.TestQueryRequest{
+		ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+		Namespace:       "AWS/EC2",
+		MetricName:      "CPUUtilization",
+		Period:          60,
+		Dimensions:      []*resources.Dimension{{Name: "InstanceId", Value: "i-1"}, {Name: "AutoScalingGroupName", Value: "asg"}},
+	}
+	first := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should return the data points of the query", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("test"), Values: []*float64{aws.Float64(1), aws.Float64(2)}, Timestamps: []*time.Time{aws.Time(first), aws.Time(first.Add(time.Minute))}},
+			{Id: aws.String("test"), Values: []*float64{aws.Float64(3)}, Timestamps: []*time.Time{aws.Time(first.Add(2 * time.Minute))}},
+		}, nil)
+
+		result, err := NewTestQueryService(fakeMetricsClient).RunTestQuery(context.Background(), request)
+
+		require.NoError(t, err)
+		assert.Equal(t, resources.TestQueryResult{OK: true, DataPoints: []resources.DataPoint{
+			{Value: 1, Timestamp: first},
+			{Value: 2, Timestamp: first.Add(time.Minute)},
+			{Value: 3, Timestamp: first.Add(2 * time.Minute)},
+		}}, result)
+
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, 10*time.Minute, input.EndTime.Sub(*input.StartTime))
+		assert.Equal(t, &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String("AWS/EC2"),
+				MetricName: aws.String("CPUUtilization"),
+				Dimensions: []*cloudwatch.Dimension{
+					{Name: aws.String("AutoScalingGroupName"), Value: aws.String("asg")},
+					{Name: aws.String("InstanceId"), Value: aws.String("i-1")},
+				},
+			},
+			Period: aws.Int64(60),
+			Stat:   aws.String("Average"),
+		}, input.MetricDataQueries[0].MetricStat)
+	})
+
+	t.Run("Should not be ok without data points", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("test"), Values: []*float64{}, Timestamps: []*time.Time{}, StatusCode: aws.String(cloudwatch.StatusCodeComplete)},
+		}, nil)
+
+		result, err := NewTestQueryService(fakeMetricsClient).RunTestQuery(context.Background(), request)
+
+		require.NoError(t, err)
+		assert.False(t, result.OK)
+		assert.Empty(t, result.DataPoints)
+		assert.Contains(t, result.Message, "no data points in the last 10m0s")
+	})
+
+	t.Run("Should not be ok if the query failed to complete", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{
+			{Id: aws.String("test"), Values: []*float64{aws.Float64(1)}, Timestamps: []*time.Time{aws.Time(first)}, StatusCode: aws.String(cloudwatch.StatusCodeInternalError),
+				Messages: []*cloudwatch.MessageData{{Code: aws.String("InternalError"), Value: aws.String("something went wrong")}}},
+		}, nil)
+
+		result, err := NewTestQueryService(fakeMetricsClient).RunTestQuery(context.Background(), request)
+
+		require.NoError(t, err)
+		assert.False(t, result.OK)
+		assert.Equal(t, "something went wrong", result.Message)
+	})
+
+	t.Run("Should use the given statistic", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, nil)
+		r := request
+		r.Statistic = "p99"
+
+		_, err := NewTestQueryService(fakeMetricsClient).RunTestQuery(context.Background(), r)
+
+		require.NoError(t, err)
+		input := fakeMetricsClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, "p99", *input.MetricDataQueries[0].MetricStat.Stat)
+	})
+
+	t.Run("Should return an error if the query can't be run", func(t *testing.T) {
+		fakeMetricsClient := &mocks.FakeMetricsClient{}
+		fakeMetricsClient.On("GetMetricData", mock.Anything, mock.Anything).Return([]*cloudwatch.MetricDataResult{}, fmt.Errorf("access denied"))
+
+		_, err := NewTestQueryService(fakeMetricsClient).RunTestQuery(context.Background(), request)
+
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+package services
+
+import "github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+
+// metricUnits holds the CloudWatch unit of well-known metrics, since ListMetrics doesn't return the unit of a metric.
+// The unit of a metric is the one AWS documents it's published with.
+var metricUnits = map[string]map[string]string{
+	"AWS/ApplicationELB": {
+		"ActiveConnectionCount":  "Count",
+		"HTTPCode_ELB_5XX_Count": "Count",
+		"ProcessedBytes":         "Bytes",
+		"RequestCount":           "Count",
+		"TargetResponseTime":     "Seconds",
+		"UnHealthyHostCount":     "Count",
+	},
+	"AWS/DynamoDB": {
+		"ConsumedReadCapacityUnits":  "Count",
+		"ConsumedWriteCapacityUnits": "Count",
+		"SuccessfulRequestLatency":   "Milliseconds",
+		"SystemErrors":               "Count",
+		"ThrottledRequests":          "Count",
+	},
+	"AWS/EBS": {
+		"BurstBalance":      "Percent",
+		"VolumeReadBytes":   "Bytes",
+		"VolumeReadOps":     "Count",
+		"VolumeWriteBytes":  "Bytes",
+		"VolumeWriteOps":    "Count",
+		"VolumeQueueLength": "Count",
+	},
+	"AWS/EC2": {
+		"CPUCreditBalance":  "Count",
+		"CPUUtilization":    "Percent",
+		"DiskReadBytes":     "Bytes",
+		"DiskWriteBytes":    "Bytes",
+		"NetworkIn":         "Bytes",
+		"NetworkOut":        "Bytes",
+		"NetworkPacketsIn":  "Count",
+		"NetworkPacketsOut": "Count",
+		"StatusCheckFailed": "Count",
+	},
+	"AWS/ECS": {
+		"CPUUtilization":    "Percent",
+		"MemoryUtilization": "Percent",
+	},
+	"AWS/Lambda": {
+		"ConcurrentExecutions": "Count",
+		"Duration":             "Milliseconds",
+		"Errors":               "Count",
+		"Invocations":          "Count",
+		"IteratorAge":          "Milliseconds",
+		"Throttles":            "Count",
+	},
+	"AWS/RDS": {
+		"CPUUtilization":      "Percent",
+		"DatabaseConnections": "Count",
+		"FreeStorageSpace":    "Bytes",
+		"FreeableMemory":      "Bytes",
+		"ReadIOPS":            "Count/Second",
+		"ReadLatency":         "Seconds",
+		"ReadThroughput":      "Bytes/Second",
+		"WriteIOPS":           "Count/Second",
+		"WriteLatency":        "Seconds",
+		"WriteThroughput":     "Bytes/Second",
+	},
+	"AWS/SQS": {
+		"ApproximateAgeOfOldestMessage":      "Seconds",
+		"ApproximateNumberOfMessagesVisible": "Count",
+		"NumberOfMessagesSent":               "Count",
+		"SentMessageSize":                    "Bytes",
+	},
+}
+
+// unitLabels holds the display label of each unit CloudWatch metrics can be published with
+var unitLabels = map[string]string{
+	"Seconds":          "seconds",
+	"Microseconds":     "microseconds",
+	"Milliseconds":     "milliseconds",
+	"Bytes":            "bytes",
+	"Kilobytes":        "kilobytes",
+	"Megabytes":        "megabytes",
+	"Gigabytes":        "gigabytes",
+	"Terabytes":        "terabytes",
+	"Bits":             "bits",
+	"Kilobits":         "kilobits",
+	"Megabits":         "megabits",
+	"Gigabits":         "gigabits",
+	"Terabits":         "terabits",
+	"Percent":          "percent",
+	"Count":            "count",
+	"Bytes/Second":     "bytes per second",
+	"Kilobytes/Second": "kilobytes per second",
+	"Megabytes/Second": "megabytes per second",
+	"Gigabytes/Second": "gigabytes per second",
+	"Terabytes/Second": "terabytes per second",
+	"Bits/Second":      "bits per second",
+	"Kilobits/Second":  "kilobits per second",
+	"Megabits/Second":  "megabits per second",
+	"Gigabits/Second":  "gigabits per second",
+	"Terabits/Second":  "terabits per second",
+	"Count/Second":     "count per second",
+	"None":             "none",
+}
+
+// GetMetricUnit returns the CloudWatch unit of the metric, or an empty string if its unit isn't curated
+func GetMetricUnit(namespace string, metricName string) string {
+	return metricUnits[namespace][metricName]
+}
+
+// GetUnitLabel returns the display label of a CloudWatch unit, or the unit itself if it has no label
+func GetUnitLabel(unit string) string {
+	if label, ok := unitLabels[unit]; ok {
+		return label
+	}
+	return unit
+}
+
+// AddUnitLabels sets the Unit of the metrics whose unit is curated, unless it's already set, and the UnitLabel of the
+// metrics with a unit. Other metrics are left without either, since their unit isn't known.
+func AddUnitLabels(metrics []resources.Metric) []resources.Metric {
+	for i := range metrics {
+		if metrics[i].Unit == "" {
+			metrics[i].Unit = GetMetricUnit(metrics[i].Namespace, metrics[i].Name)
+		}
+		if metrics[i].Unit != "" {
+			metrics[i].UnitLabel = GetUnitLabel(metrics[i].Unit)
+		}
+	}
+	return metrics
+}
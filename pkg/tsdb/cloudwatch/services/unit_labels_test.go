@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUnitLabel(t *testing.T) {
+	testCases := map[string]string{
+		"Seconds":      "seconds",
+		"Milliseconds": "milliseconds",
+		"Bytes":        "bytes",
+		"Bytes/Second": "bytes per second",
+		"Count/Second": "count per second",
+		"Percent":      "percent",
+		"Count":        "count",
+		"None":         "none",
+	}
+	for unit, label := range testCases {
+		assert.Equal(t, label, GetUnitLabel(unit), unit)
+	}
+
+	t.Run("falls back to the unit if it has no label", func(t *testing.T) {
+		assert.Equal(t, "Requests/Minute", GetUnitLabel("Requests/Minute"))
+		assert.Equal(t, "", GetUnitLabel(""))
+	})
+}
+
+func TestAddUnitLabels(t *testing.T) {
+	metrics := AddUnitLabels([]resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization"},
+		{Namespace: "AWS/RDS", Name: "ReadThroughput"},
+		{Namespace: "AWS/Lambda", Name: "Duration"},
+		{Namespace: "AWS/EC2", Name: "MetadataNoToken"},
+		{Namespace: "Custom", Name: "CPUUtilization"},
+		{Namespace: "AWS/EC2", Name: "NetworkIn", Unit: "Count"},
+		{Namespace: "Custom", Name: "QueueDepth", Unit: "Count"},
+	})
+
+	assert.Equal(t, []resources.Metric{
+		{Namespace: "AWS/EC2", Name: "CPUUtilization", Unit: "Percent", UnitLabel: "percent"},
+		{Namespace: "AWS/RDS", Name: "ReadThroughput", Unit: "Bytes/Second", UnitLabel: "bytes per second"},
+		{Namespace: "AWS/Lambda", Name: "Duration", Unit: "Milliseconds", UnitLabel: "milliseconds"},
+		{Namespace: "AWS/EC2", Name: "MetadataNoToken"},
+		{Namespace: "Custom", Name: "CPUUtilization"},
+		// a unit that's already set is kept
+		{Namespace: "AWS/EC2", Name: "NetworkIn", Unit: "Count", UnitLabel: "count"},
+		{Namespace: "Custom", Name: "QueueDepth", Unit: "Count", UnitLabel: "count"},
+	}, metrics)
+}
+
+func TestMetricUnitsHaveLabels(t *testing.T) {
+	for namespace, units := range metricUnits {
+		for metricName, unit := range units {
+			assert.Contains(t, unitLabels, unit, "%s %s", namespace, metricName)
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -36,6 +37,7 @@ type logsQueryCalls struct {
 	startQueryWithContext []*cloudwatchlogs.StartQueryInput
 	getEventsWithContext  []*cloudwatchlogs.GetLogEventsInput
 	describeLogGroups     []*cloudwatchlogs.DescribeLogGroupsInput
+	contexts              []context.Context
 }
 
 func (m *fakeCWLogsClient) GetQueryResultsWithContext(ctx context.Context, input *cloudwatchlogs.GetQueryResultsInput, option ...request.Option) (*cloudwatchlogs.GetQueryResultsOutput, error) {
@@ -75,6 +77,7 @@ func (m *fakeCWLogsClient) GetLogGroupFieldsWithContext(ctx context.Context, inp
 
 func (m *fakeCWLogsClient) GetLogEventsWithContext(ctx context.Context, input *cloudwatchlogs.GetLogEventsInput, option ...request.Option) (*cloudwatchlogs.GetLogEventsOutput, error) {
 	m.calls.getEventsWithContext = append(m.calls.getEventsWithContext, input)
+	m.calls.contexts = append(m.calls.contexts, ctx)
 
 	return &cloudwatchlogs.GetLogEventsOutput{
 		Events: []*cloudwatchlogs.OutputLogEvent{},
@@ -92,16 +95,19 @@ type fakeCWAnnotationsClient struct {
 type annontationsQueryCalls struct {
 	describeAlarmsForMetric []*cloudwatch.DescribeAlarmsForMetricInput
 	describeAlarms          []*cloudwatch.DescribeAlarmsInput
+	contexts                []aws.Context
 }
 
-func (c *fakeCWAnnotationsClient) DescribeAlarmsForMetric(params *cloudwatch.DescribeAlarmsForMetricInput) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
+func (c *fakeCWAnnotationsClient) DescribeAlarmsForMetricWithContext(ctx aws.Context, params *cloudwatch.DescribeAlarmsForMetricInput, opts ...request.Option) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
 	c.calls.describeAlarmsForMetric = append(c.calls.describeAlarmsForMetric, params)
+	c.calls.contexts = append(c.calls.contexts, ctx)
 
 	return c.describeAlarmsForMetricOutput, nil
 }
 
-func (c *fakeCWAnnotationsClient) DescribeAlarms(params *cloudwatch.DescribeAlarmsInput) (*cloudwatch.DescribeAlarmsOutput, error) {
+func (c *fakeCWAnnotationsClient) DescribeAlarmsWithContext(ctx aws.Context, params *cloudwatch.DescribeAlarmsInput, opts ...request.Option) (*cloudwatch.DescribeAlarmsOutput, error) {
 	c.calls.describeAlarms = append(c.calls.describeAlarms, params)
+	c.calls.contexts = append(c.calls.contexts, ctx)
 
 	return c.describeAlarmsOutput, nil
 }
@@ -166,14 +172,14 @@ func (c fakeRGTAClient) GetResourcesPages(in *resourcegroupstaggingapi.GetResour
 }
 
 type fakeCheckHealthClient struct {
-	cloudwatchiface.CloudWatchAPI
+	models.CloudWatchMetricsAPIProvider
 	cloudwatchlogsiface.CloudWatchLogsAPI
 
 	listMetricsPages  func(input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error
 	describeLogGroups func(input *cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
 }
 
-func (c fakeCheckHealthClient) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+func (c fakeCheckHealthClient) ListMetricsPagesWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
 	if c.listMetricsPages != nil {
 		return c.listMetricsPages(input, fn)
 	}